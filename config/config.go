@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -14,51 +20,291 @@ type DbConfig struct {
 	Pass     string
 	DbName   string
 	DbDriver string
+	// AutoMigrate applies pending migrations when the server starts
+	AutoMigrate bool
 }
 
 type ApiConfig struct {
 	Host string
 	Port string
+	// DevMode lets the server try the next ports when Port is taken
+	DevMode         bool
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// Location is where the shop is, dates in requests and reports are days
+	// of this time zone
+	Location *time.Location
+	// OwnerUsername is made an owner on start, it grants the first owner of
+	// a database where nobody is one yet
+	OwnerUsername string
 }
 
 type TokenConfig struct {
-	ApplicationName     string
-	JwtSignatureKey     string
-	JwtSigningMethod    *jwt.SigningMethodHMAC
-	AccessTokenLifetime time.Duration
+	ApplicationName      string
+	JwtSignatureKey      string
+	JwtSigningMethod     *jwt.SigningMethodHMAC
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+}
+
+type UploadConfig struct {
+	ImageDir     string
+	MenuImageDir string
+	UserImageDir string
+}
+
+type CorsConfig struct {
+	AllowedOrigins []string
+}
+
+// PricingConfig has the charges added to every sale, in basis points of
+// the amount they are charged on, 1000 is 10%.
+type PricingConfig struct {
+	// ServiceRate is charged on the subtotal after discounts
+	ServiceRate int
+	// TaxRate is the PB1 restaurant tax, charged on the subtotal after
+	// discounts plus the service charge
+	TaxRate int
+}
+
+const (
+	AlertLog     = "log"
+	AlertWebhook = "webhook"
+	AlertSMTP    = "smtp"
+)
+
+// AlertConfig is where low stock alerts are sent, Notifier is log, webhook
+// or smtp.
+type AlertConfig struct {
+	Notifier   string
+	WebhookURL string
+	SMTPHost   string
+	SMTPPort   string
+	SMTPUser   string
+	SMTPPass   string
+	SMTPFrom   string
+	SMTPTo     []string
 }
 
 type Config struct {
 	DbConfig
 	ApiConfig
 	TokenConfig
+	UploadConfig
+	CorsConfig
+	PricingConfig
+	AlertConfig
 }
 
-func (c *Config) readConfig() {
+func (c *Config) readConfig(src *source) error {
+	var errs []string
+
+	autoMigrate, err := strconv.ParseBool(src.get("DB_AUTO_MIGRATE", "true"))
+	if err != nil {
+		errs = append(errs, "DB_AUTO_MIGRATE: "+err.Error())
+	}
+
 	c.DbConfig = DbConfig{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Pass:     os.Getenv("DB_PASS"),
-		DbName:   os.Getenv("DB_NAME"),
-		DbDriver: os.Getenv("DB_DRIVER"),
+		Host:        src.get("DB_HOST", "localhost"),
+		Port:        src.get("DB_PORT", "5432"),
+		User:        src.get("DB_USER", ""),
+		Pass:        src.get("DB_PASS", ""),
+		DbName:      src.get("DB_NAME", ""),
+		DbDriver:    src.get("DB_DRIVER", "postgres"),
+		AutoMigrate: autoMigrate,
+	}
+
+	devMode, err := strconv.ParseBool(src.get("API_DEV_MODE", "false"))
+	if err != nil {
+		errs = append(errs, "API_DEV_MODE: "+err.Error())
+	}
+	readTimeout, err := src.duration("API_READ_TIMEOUT", time.Second*15)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	writeTimeout, err := src.duration("API_WRITE_TIMEOUT", time.Second*30)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	idleTimeout, err := src.duration("API_IDLE_TIMEOUT", time.Second*60)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	shutdownTimeout, err := src.duration("API_SHUTDOWN_TIMEOUT", time.Second*15)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	location, err := time.LoadLocation(src.get("APP_TIMEZONE", "Asia/Jakarta"))
+	if err != nil {
+		errs = append(errs, "APP_TIMEZONE: "+err.Error())
 	}
 
 	c.ApiConfig = ApiConfig{
-		Host: os.Getenv("API_HOST"),
-		Port: os.Getenv("API_PORT"),
+		Host:            src.get("API_HOST", ""),
+		Port:            src.get("API_PORT", "8000"),
+		DevMode:         devMode,
+		ReadTimeout:     readTimeout,
+		WriteTimeout:    writeTimeout,
+		IdleTimeout:     idleTimeout,
+		ShutdownTimeout: shutdownTimeout,
+		Location:        location,
+		OwnerUsername:   src.get("APP_OWNER", ""),
+	}
+
+	accessLifetime, err := src.duration("ACCESS_TOKEN_LIFETIME", time.Minute*15)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	refreshLifetime, err := src.duration("REFRESH_TOKEN_LIFETIME", time.Hour*24*7)
+	if err != nil {
+		errs = append(errs, err.Error())
 	}
 
 	c.TokenConfig = TokenConfig{
-		ApplicationName:     os.Getenv("APP_NAME"),
-		JwtSignatureKey:     "asdf",
-		JwtSigningMethod:    jwt.SigningMethodHS256,
-		AccessTokenLifetime: time.Hour * 24,
+		ApplicationName:      src.get("APP_NAME", "warung_makan"),
+		JwtSignatureKey:      src.get("JWT_SECRET", ""),
+		JwtSigningMethod:     jwt.SigningMethodHS256,
+		AccessTokenLifetime:  accessLifetime,
+		RefreshTokenLifetime: refreshLifetime,
+	}
+
+	imageDir := src.get("UPLOAD_DIR", "./images")
+	c.UploadConfig = UploadConfig{
+		ImageDir:     imageDir,
+		MenuImageDir: src.get("UPLOAD_MENU_DIR", imageDir+"/menu"),
+		UserImageDir: src.get("UPLOAD_USER_DIR", imageDir+"/user"),
+	}
+
+	c.CorsConfig = CorsConfig{
+		AllowedOrigins: splitList(src.get("CORS_ALLOWED_ORIGINS", "")),
+	}
+
+	serviceRate, err := src.percent("PRICING_SERVICE_PERCENT")
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	taxRate, err := src.percent("PRICING_TAX_PERCENT")
+	if err != nil {
+		errs = append(errs, err.Error())
 	}
+
+	c.PricingConfig = PricingConfig{
+		ServiceRate: serviceRate,
+		TaxRate:     taxRate,
+	}
+
+	c.AlertConfig = AlertConfig{
+		Notifier:   src.get("ALERT_NOTIFIER", AlertLog),
+		WebhookURL: src.get("ALERT_WEBHOOK_URL", ""),
+		SMTPHost:   src.get("ALERT_SMTP_HOST", ""),
+		SMTPPort:   src.get("ALERT_SMTP_PORT", "587"),
+		SMTPUser:   src.get("ALERT_SMTP_USER", ""),
+		SMTPPass:   src.get("ALERT_SMTP_PASS", ""),
+		SMTPFrom:   src.get("ALERT_SMTP_FROM", ""),
+		SMTPTo:     splitList(src.get("ALERT_SMTP_TO", "")),
+	}
+
+	errs = append(errs, c.validate()...)
+	if len(errs) > 0 {
+		return errors.New("invalid config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *Config) validate() []string {
+	var errs []string
+	required := map[string]string{
+		"DB_HOST":    c.DbConfig.Host,
+		"DB_USER":    c.DbConfig.User,
+		"DB_NAME":    c.DbConfig.DbName,
+		"DB_DRIVER":  c.DbConfig.DbDriver,
+		"API_PORT":   c.ApiConfig.Port,
+		"APP_NAME":   c.TokenConfig.ApplicationName,
+		"JWT_SECRET": c.TokenConfig.JwtSignatureKey,
+	}
+	for _, key := range sourceKeys {
+		if value, ok := required[key]; ok && value == "" {
+			errs = append(errs, key+" is required")
+		}
+	}
+
+	if c.TokenConfig.AccessTokenLifetime <= 0 {
+		errs = append(errs, "ACCESS_TOKEN_LIFETIME must be positive")
+	}
+	if c.TokenConfig.RefreshTokenLifetime <= c.TokenConfig.AccessTokenLifetime {
+		errs = append(errs, "REFRESH_TOKEN_LIFETIME must be longer than ACCESS_TOKEN_LIFETIME")
+	}
+
+	alert := c.AlertConfig
+	switch alert.Notifier {
+	case AlertLog:
+	case AlertWebhook:
+		if alert.WebhookURL == "" {
+			errs = append(errs, "ALERT_WEBHOOK_URL is required for the webhook notifier")
+		}
+	case AlertSMTP:
+		if alert.SMTPHost == "" || alert.SMTPFrom == "" || len(alert.SMTPTo) == 0 {
+			errs = append(errs, "ALERT_SMTP_HOST, ALERT_SMTP_FROM and ALERT_SMTP_TO are required for the smtp notifier")
+		}
+	default:
+		errs = append(errs, "ALERT_NOTIFIER must be log, webhook or smtp")
+	}
+	return errs
 }
 
-func NewConfig() Config {
+// NewConfig reads the config from the environment, an optional .env file
+// (ENV_FILE, default .env) and an optional yaml file (CONFIG_FILE, default
+// config.yaml). environment variables win over .env, .env wins over yaml.
+func NewConfig() (Config, error) {
+	src, err := newSource(os.Getenv("ENV_FILE"), os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return Config{}, err
+	}
+
 	conf := new(Config)
-	conf.readConfig()
-	return *conf
+	if err := conf.readConfig(src); err != nil {
+		return Config{}, err
+	}
+	return *conf, nil
+}
+
+// percent reads a percentage like 10 or 5.5 as basis points, 0 when the
+// key is not set.
+func (s *source) percent(key string) (int, error) {
+	value := s.get(key, "")
+	if value == "" {
+		return 0, nil
+	}
+
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%s must be a percentage between 0 and 100", key)
+	}
+	return int(math.Round(percent * 100)), nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func (s *source) duration(key string, fallback time.Duration) (time.Duration, error) {
+	value := s.get(key, "")
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return d, nil
 }
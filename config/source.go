@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultEnvFile    = ".env"
+	defaultConfigFile = "config.yaml"
+)
+
+var sourceKeys = []string{
+	"DB_HOST", "DB_PORT", "DB_USER", "DB_PASS", "DB_NAME", "DB_DRIVER", "DB_AUTO_MIGRATE",
+	"API_HOST", "API_PORT", "API_DEV_MODE",
+	"API_READ_TIMEOUT", "API_WRITE_TIMEOUT", "API_IDLE_TIMEOUT", "API_SHUTDOWN_TIMEOUT",
+	"APP_TIMEZONE", "APP_OWNER",
+	"APP_NAME", "JWT_SECRET", "ACCESS_TOKEN_LIFETIME", "REFRESH_TOKEN_LIFETIME",
+	"UPLOAD_DIR", "UPLOAD_MENU_DIR", "UPLOAD_USER_DIR",
+	"CORS_ALLOWED_ORIGINS",
+	"PRICING_SERVICE_PERCENT", "PRICING_TAX_PERCENT",
+	"ALERT_NOTIFIER", "ALERT_WEBHOOK_URL",
+	"ALERT_SMTP_HOST", "ALERT_SMTP_PORT", "ALERT_SMTP_USER", "ALERT_SMTP_PASS", "ALERT_SMTP_FROM", "ALERT_SMTP_TO",
+}
+
+type yamlFile struct {
+	AppName  string `yaml:"app_name"`
+	Timezone string `yaml:"timezone"`
+	Owner    string `yaml:"owner"`
+	Db       struct {
+		Host        string `yaml:"host"`
+		Port        string `yaml:"port"`
+		User        string `yaml:"user"`
+		Pass        string `yaml:"pass"`
+		Name        string `yaml:"name"`
+		Driver      string `yaml:"driver"`
+		AutoMigrate string `yaml:"auto_migrate"`
+	} `yaml:"db"`
+	Api struct {
+		Host            string `yaml:"host"`
+		Port            string `yaml:"port"`
+		DevMode         string `yaml:"dev_mode"`
+		ReadTimeout     string `yaml:"read_timeout"`
+		WriteTimeout    string `yaml:"write_timeout"`
+		IdleTimeout     string `yaml:"idle_timeout"`
+		ShutdownTimeout string `yaml:"shutdown_timeout"`
+	} `yaml:"api"`
+	Token struct {
+		JwtSecret       string `yaml:"jwt_secret"`
+		AccessLifetime  string `yaml:"access_lifetime"`
+		RefreshLifetime string `yaml:"refresh_lifetime"`
+	} `yaml:"token"`
+	Upload struct {
+		Dir     string `yaml:"dir"`
+		MenuDir string `yaml:"menu_dir"`
+		UserDir string `yaml:"user_dir"`
+	} `yaml:"upload"`
+	Cors struct {
+		AllowedOrigins []string `yaml:"allowed_origins"`
+	} `yaml:"cors"`
+	Pricing struct {
+		ServicePercent string `yaml:"service_percent"`
+		TaxPercent     string `yaml:"tax_percent"`
+	} `yaml:"pricing"`
+	Alert struct {
+		Notifier   string `yaml:"notifier"`
+		WebhookURL string `yaml:"webhook_url"`
+		SMTP       struct {
+			Host string   `yaml:"host"`
+			Port string   `yaml:"port"`
+			User string   `yaml:"user"`
+			Pass string   `yaml:"pass"`
+			From string   `yaml:"from"`
+			To   []string `yaml:"to"`
+		} `yaml:"smtp"`
+	} `yaml:"alert"`
+}
+
+func (y *yamlFile) values() map[string]string {
+	return map[string]string{
+		"DB_HOST":                 y.Db.Host,
+		"DB_PORT":                 y.Db.Port,
+		"DB_USER":                 y.Db.User,
+		"DB_PASS":                 y.Db.Pass,
+		"DB_NAME":                 y.Db.Name,
+		"DB_DRIVER":               y.Db.Driver,
+		"DB_AUTO_MIGRATE":         y.Db.AutoMigrate,
+		"API_HOST":                y.Api.Host,
+		"API_PORT":                y.Api.Port,
+		"API_DEV_MODE":            y.Api.DevMode,
+		"API_READ_TIMEOUT":        y.Api.ReadTimeout,
+		"API_WRITE_TIMEOUT":       y.Api.WriteTimeout,
+		"API_IDLE_TIMEOUT":        y.Api.IdleTimeout,
+		"API_SHUTDOWN_TIMEOUT":    y.Api.ShutdownTimeout,
+		"APP_NAME":                y.AppName,
+		"APP_TIMEZONE":            y.Timezone,
+		"APP_OWNER":               y.Owner,
+		"JWT_SECRET":              y.Token.JwtSecret,
+		"ACCESS_TOKEN_LIFETIME":   y.Token.AccessLifetime,
+		"REFRESH_TOKEN_LIFETIME":  y.Token.RefreshLifetime,
+		"UPLOAD_DIR":              y.Upload.Dir,
+		"UPLOAD_MENU_DIR":         y.Upload.MenuDir,
+		"UPLOAD_USER_DIR":         y.Upload.UserDir,
+		"CORS_ALLOWED_ORIGINS":    strings.Join(y.Cors.AllowedOrigins, ","),
+		"PRICING_SERVICE_PERCENT": y.Pricing.ServicePercent,
+		"PRICING_TAX_PERCENT":     y.Pricing.TaxPercent,
+		"ALERT_NOTIFIER":          y.Alert.Notifier,
+		"ALERT_WEBHOOK_URL":       y.Alert.WebhookURL,
+		"ALERT_SMTP_HOST":         y.Alert.SMTP.Host,
+		"ALERT_SMTP_PORT":         y.Alert.SMTP.Port,
+		"ALERT_SMTP_USER":         y.Alert.SMTP.User,
+		"ALERT_SMTP_PASS":         y.Alert.SMTP.Pass,
+		"ALERT_SMTP_FROM":         y.Alert.SMTP.From,
+		"ALERT_SMTP_TO":           strings.Join(y.Alert.SMTP.To, ","),
+	}
+}
+
+// source resolves a config key from, in order, the process environment,
+// the .env file and the yaml file.
+type source struct {
+	dotEnv map[string]string
+	yaml   map[string]string
+}
+
+func (s *source) get(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value := s.dotEnv[key]; value != "" {
+		return value
+	}
+	if value := s.yaml[key]; value != "" {
+		return value
+	}
+	return fallback
+}
+
+// newSource loads the given files. an empty path falls back to the default
+// file name, which is skipped when it does not exist. explicitly given files
+// must exist.
+func newSource(envFile, configFile string) (*source, error) {
+	src := new(source)
+
+	dotEnv, err := readOptional(envFile, defaultEnvFile, readDotEnv)
+	if err != nil {
+		return nil, err
+	}
+	src.dotEnv = dotEnv
+
+	yamlValues, err := readOptional(configFile, defaultConfigFile, readYaml)
+	if err != nil {
+		return nil, err
+	}
+	src.yaml = yamlValues
+
+	return src, nil
+}
+
+func readOptional(path, defaultPath string, read func([]byte) (map[string]string, error)) (map[string]string, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultPath
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	values, err := read(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return values, nil
+}
+
+func readYaml(content []byte) (map[string]string, error) {
+	var file yamlFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	return file.values(), nil
+}
+
+func readDotEnv(content []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
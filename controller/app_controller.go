@@ -1,12 +1,12 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"path/filepath"
 	"strings"
-	"warung-makan/config"
 	"warung-makan/manager"
 	"warung-makan/middleware"
-	"warung-makan/model"
 	"warung-makan/utils"
 	"warung-makan/utils/authenticator"
 
@@ -18,13 +18,11 @@ type Controller struct {
 	router *gin.Engine
 }
 
-func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *Controller {
+func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine, accessToken authenticator.AccessToken, tokenMdw middleware.AuthTokenMiddleware, imageDir string) *Controller {
 	controller := Controller{
 		ucMan:  usecaseManager,
 		router: router,
 	}
-	accessToken := authenticator.NewAccessToken(config.NewConfig().TokenConfig)
-	tokenMdw := middleware.NewAuthTokenMiddleware(accessToken)
 
 	router.GET("/", func(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "hello world")
@@ -32,23 +30,6 @@ func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *C
 
 	test := router.Group("/test")
 
-	// ======= GENERATE TOKEN
-	test.POST("/generate_token", func(ctx *gin.Context) {
-		var user model.User
-		err := ctx.ShouldBindJSON(&user)
-		if err != nil {
-			utils.JsonErrorBadRequest(ctx, err, "cannot bind struct")
-		}
-
-		token, err := accessToken.GenerateAccessToken(&user)
-		if err != nil {
-			utils.JsonErrorInternalServerError(ctx, err, "cannot generate token")
-			return
-		}
-
-		utils.JsonNamedDataMessageResponse(ctx, "token", token, "token generated")
-	})
-
 	// ======= REQUIRE TOKEN
 	test.GET("/require_token", func(ctx *gin.Context) {
 		var h middleware.FakeAuthHeader
@@ -72,7 +53,7 @@ func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *C
 
 		tokenString := strings.Replace(h.Authorization, "Bearer ", "", -1)
 		if tokenString == "" {
-			utils.JsonErrorInternalServerError(ctx, nil, "token string became empty")
+			utils.JsonErrorBadRequest(ctx, errors.New("empty token"), "token string became empty")
 			ctx.Abort()
 			return
 		}
@@ -107,7 +88,7 @@ func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *C
 			return
 		}
 
-		filePath := "./images/" + file.Filename
+		filePath := filepath.Join(imageDir, filepath.Base(file.Filename))
 
 		err = ctx.SaveUploadedFile(file, filePath)
 		if err != nil {
@@ -125,7 +106,7 @@ func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *C
 	test.GET("/get_image", func(ctx *gin.Context) {
 		q := ctx.Query("q")
 		if q == "" {
-			ctx.File("./images/vueko.jpg")
+			ctx.File(filepath.Join(imageDir, "vueko.jpg"))
 			return
 		}
 		// else if q == "nyan" {
@@ -134,7 +115,7 @@ func NewController(usecaseManager manager.UsecaseManager, router *gin.Engine) *C
 		// 	ctx.File("./images/ninja.gif")
 		// }
 
-		ctx.File("./images/" + q)
+		ctx.File(filepath.Join(imageDir, filepath.Base(q)))
 	})
 
 	protectedRoute := router.Group("/test/protected", tokenMdw.RequireToken())
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryController struct {
+	usecase usecase.CategoryUsecase
+	router  *gin.Engine
+}
+
+func (c *CategoryController) ListCategory(ctx *gin.Context) {
+	list, err := c.usecase.GetAll()
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get category list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *CategoryController) GetById(ctx *gin.Context) {
+	category, err := c.usecase.GetById(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get category")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, category)
+}
+
+func (c *CategoryController) CreateNewCategory(ctx *gin.Context) {
+	var category model.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	newCategory, err := c.usecase.Insert(&category)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newCategory, "category created")
+}
+
+func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
+	var category model.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	category.Id = ctx.Param("id")
+	updated, err := c.usecase.Update(&category)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "category updated")
+}
+
+func (c *CategoryController) DeleteCategory(ctx *gin.Context) {
+	if err := c.usecase.Delete(ctx.Param("id")); err != nil {
+		utils.JsonAppError(ctx, err, "cannot delete category")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "category deleted")
+}
+
+func NewCategoryController(usecase usecase.CategoryUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *CategoryController {
+	controller := CategoryController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	router.GET("/category", controller.ListCategory)
+	router.GET("/category/:id", controller.GetById)
+
+	protectedRoute := router.Group("/category", authMiddleware.RequireToken(), authMiddleware.RequireRole(model.RoleOwner))
+	protectedRoute.POST("", controller.CreateNewCategory)
+	protectedRoute.PUT("/:id", controller.UpdateCategory)
+	protectedRoute.DELETE("/:id", controller.DeleteCategory)
+
+	return &controller
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"warung-makan/utils"
+	"warung-makan/utils/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFile streams a table to the client as a csv (the default) or xlsx
+// attachment, the format is read from the format query parameter. errors
+// before the first bytes are sent are answered as usual, after that the
+// response can only be cut short.
+func exportFile(ctx *gin.Context, name string, header []interface{}, rows func(export.Writer) error) {
+	format := ctx.DefaultQuery("format", export.FormatCSV)
+	writer, err := export.NewWriter(format, ctx.Writer)
+	if err == nil {
+		ctx.Header("Content-Type", export.ContentType(format))
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+		err = writer.Write(header...)
+	}
+	if err == nil {
+		err = rows(writer)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if ctx.Writer.Written() {
+		log.Printf("export of %s stopped: %v\n", name, err)
+		ctx.Abort()
+		return
+	}
+	ctx.Writer.Header().Del("Content-Disposition")
+	utils.JsonAppError(ctx, err, "cannot export "+name)
+}
+
+// exportTime is a time read from the database as text, written as a time
+// when it can be parsed.
+func exportTime(value string) interface{} {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t
+	}
+	return value
+}
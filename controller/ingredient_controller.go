@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IngredientController struct {
+	usecase usecase.IngredientUsecase
+	router  *gin.Engine
+}
+
+func (c *IngredientController) ListIngredient(ctx *gin.Context) {
+	list, err := c.usecase.GetAll()
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get ingredient list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *IngredientController) GetById(ctx *gin.Context) {
+	ingredient, err := c.usecase.GetById(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get ingredient")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, ingredient)
+}
+
+func (c *IngredientController) CreateNewIngredient(ctx *gin.Context) {
+	var ingredient model.Ingredient
+	if err := ctx.ShouldBindJSON(&ingredient); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	newIngredient, err := c.usecase.Insert(&ingredient)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newIngredient, "ingredient created")
+}
+
+func (c *IngredientController) UpdateIngredient(ctx *gin.Context) {
+	var ingredient model.Ingredient
+	if err := ctx.ShouldBindJSON(&ingredient); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	ingredient.Id = ctx.Param("id")
+	updated, err := c.usecase.Update(&ingredient)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "ingredient updated")
+}
+
+func (c *IngredientController) DeleteIngredient(ctx *gin.Context) {
+	if err := c.usecase.Delete(ctx.Param("id")); err != nil {
+		utils.JsonAppError(ctx, err, "cannot delete ingredient")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "ingredient deleted")
+}
+
+func (c *IngredientController) GetRecipe(ctx *gin.Context) {
+	recipe, err := c.usecase.GetRecipe(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get recipe")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, recipe)
+}
+
+func (c *IngredientController) SetRecipe(ctx *gin.Context) {
+	var recipe model.Recipe
+	if err := ctx.ShouldBindJSON(&recipe); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	lines, err := c.usecase.SetRecipe(ctx.Param("id"), recipe)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "recipe not saved")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, lines, "recipe saved")
+}
+
+func NewIngredientController(usecase usecase.IngredientUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *IngredientController {
+	controller := IngredientController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/ingredient", authMiddleware.RequireToken())
+	protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.ListIngredient)
+	protectedRoute.GET("/:id", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.GetById)
+	protectedRoute.POST("", authMiddleware.RequireRole(model.RoleOwner), controller.CreateNewIngredient)
+	protectedRoute.PUT("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.UpdateIngredient)
+	protectedRoute.DELETE("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.DeleteIngredient)
+
+	recipeRoute := router.Group("/menu/:id/recipe", authMiddleware.RequireToken())
+	recipeRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.GetRecipe)
+	recipeRoute.PUT("", authMiddleware.RequireRole(model.RoleOwner), controller.SetRecipe)
+
+	return &controller
+}
@@ -1,24 +1,24 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
-	"warung-makan/config"
+	"warung-makan/middleware"
 	"warung-makan/model"
 	"warung-makan/usecase"
 	"warung-makan/utils"
-	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
 )
 
 type LoginController struct {
-	usecase usecase.UserUsecase
-	router  *gin.Engine
+	usecase      usecase.UserUsecase
+	tokenUsecase usecase.TokenUsecase
+	router       *gin.Engine
 }
 
 func (lc *LoginController) Login(ctx *gin.Context) {
 	var credential model.Credential
-	accessToken := authenticator.NewAccessToken(config.NewConfig().TokenConfig)
 
 	err := ctx.ShouldBindJSON(&credential)
 	if err != nil {
@@ -27,26 +27,31 @@ func (lc *LoginController) Login(ctx *gin.Context) {
 	}
 
 	user, err := lc.usecase.GetByCredentials(credential.Username, credential.Password)
-	if err != nil {
+	if errors.Is(err, usecase.ErrInvalidCredentials) {
 		utils.JsonErrorBadRequest(ctx, err, "invalid credentials")
 		return
 	}
+	if err != nil {
+		utils.JsonErrorInternalServerError(ctx, err, "cannot log in")
+		return
+	}
 
-	token, err := accessToken.GenerateAccessToken(&user)
+	tokenPair, err := lc.tokenUsecase.Issue(&user)
 	if err != nil {
 		utils.JsonErrorInternalServerError(ctx, err, "cannot generate token")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "you are logged in",
-		"token":   token,
+		"message":       "you are logged in",
+		"token":         tokenPair.AccessToken,
+		"refresh_token": tokenPair.RefreshToken,
+		"expires_in":    tokenPair.ExpiresIn,
 	})
 }
 
 func (lc *LoginController) LoginTest(ctx *gin.Context) {
 	var credential model.Credential
-	accessToken := authenticator.NewAccessToken(config.NewConfig().TokenConfig)
 
 	err := ctx.ShouldBindJSON(&credential)
 
@@ -56,28 +61,88 @@ func (lc *LoginController) LoginTest(ctx *gin.Context) {
 	}
 
 	user, err := lc.usecase.GetByCredentials(credential.Username, credential.Password)
-	if err != nil {
+	if errors.Is(err, usecase.ErrInvalidCredentials) {
 		utils.JsonErrorBadRequest(ctx, err, "invalid credentials")
 		return
 	}
-
-	_, err = accessToken.GenerateAccessToken(&user)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot generate token")
+		utils.JsonErrorInternalServerError(ctx, err, "cannot log in")
 		return
 	}
 
 	ctx.JSON(http.StatusOK, user)
 }
 
-func NewLoginController(usecase usecase.UserUsecase, router *gin.Engine) *LoginController {
+func (lc *LoginController) RefreshToken(ctx *gin.Context) {
+	var refreshRequest model.RefreshRequest
+
+	err := ctx.ShouldBindJSON(&refreshRequest)
+	if err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	tokenPair, err := lc.tokenUsecase.Refresh(refreshRequest.RefreshToken)
+	if errors.Is(err, usecase.ErrInvalidRefreshToken) {
+		utils.JsonErrorUnauthorized(ctx, err, "cannot refresh token")
+		return
+	}
+	if err != nil {
+		utils.JsonErrorInternalServerError(ctx, err, "cannot refresh token")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "token refreshed",
+		"token":         tokenPair.AccessToken,
+		"refresh_token": tokenPair.RefreshToken,
+		"expires_in":    tokenPair.ExpiresIn,
+	})
+}
+
+func (lc *LoginController) Logout(ctx *gin.Context) {
+	jti := ctx.GetString(middleware.ContextTokenId)
+	expiresAt := ctx.GetTime(middleware.ContextTokenExpiry)
+
+	err := lc.tokenUsecase.Logout(jti, expiresAt)
+	if err != nil {
+		utils.JsonErrorInternalServerError(ctx, err, "cannot logout")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "you are logged out")
+}
+
+func (lc *LoginController) RevokeUserSessions(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id != ctx.GetString(middleware.ContextUserId) && ctx.GetString(middleware.ContextRole) != model.RoleOwner {
+		utils.JsonErrorForbidden(ctx, errors.New("not your account"), "you can only revoke your own sessions")
+		return
+	}
+
+	err := lc.tokenUsecase.RevokeUserSessions(id)
+	if err != nil {
+		utils.JsonErrorInternalServerError(ctx, err, "cannot revoke sessions")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "all sessions of the user are revoked")
+}
+
+func NewLoginController(usecase usecase.UserUsecase, tokenUsecase usecase.TokenUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *LoginController {
 	controller := LoginController{
-		usecase: usecase,
-		router:  router,
+		usecase:      usecase,
+		tokenUsecase: tokenUsecase,
+		router:       router,
 	}
 
 	router.POST("/login", controller.Login)
 	router.POST("/test/login", controller.LoginTest)
+	router.POST("/token/refresh", controller.RefreshToken)
+
+	protectedRoute := router.Group("", authMiddleware.RequireToken())
+	protectedRoute.POST("/logout", controller.Logout)
+	protectedRoute.DELETE("/user/:id/sessions", controller.RevokeUserSessions)
 
 	return &controller
 }
@@ -1,55 +1,50 @@
 package controller
 
 import (
-	"log"
-	"net/http"
 	"os"
-	"warung-makan/config"
+	"path/filepath"
 	"warung-makan/middleware"
 	"warung-makan/model"
 	"warung-makan/usecase"
 	"warung-makan/utils"
-	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MenuController struct {
-	usecase usecase.MenuUsecase
-	router  *gin.Engine
+	usecase  usecase.MenuUsecase
+	router   *gin.Engine
+	imageDir string
 }
 
 func (c *MenuController) ListMenu(ctx *gin.Context) {
-	if name := ctx.Query("name"); name != "" {
-		menu, err := c.usecase.GetByName(ctx.Query("name"))
-
-		if err != nil {
-			utils.JsonErrorNotFound(ctx, err, "cannot get list")
-			return
-		}
-
-		if len(menu) == 0 {
-			ctx.String(http.StatusBadRequest, "no menu with name like "+name)
-			return
-		}
-
-		utils.JsonDataResponse(ctx, menu)
+	params := newQueryParams(ctx)
+	filter := params.menuFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get menu list")
 		return
 	}
 
-	list, err := c.usecase.GetAll()
+	list, page, err := c.usecase.List(filter)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot get menu list")
+		utils.JsonAppError(ctx, err, "cannot get menu list")
 		return
 	}
 
-	utils.JsonDataResponse(ctx, list)
+	utils.JsonPageResponse(ctx, list, page)
 }
 
 func (c *MenuController) GetById(ctx *gin.Context) {
-	menu, err := c.usecase.GetById(ctx.Param("id"))
+	params := newQueryParams(ctx)
+	includeDeleted := params.includeDeleted()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get menu")
+		return
+	}
+
+	menu, err := c.usecase.GetById(ctx.Param("id"), includeDeleted)
 	if err != nil {
-		utils.JsonErrorNotFound(ctx, err, "cannot get menu")
+		utils.JsonAppError(ctx, err, "cannot get menu")
 		return
 	}
 
@@ -73,7 +68,7 @@ func (c *MenuController) CreateNewMenu(ctx *gin.Context) {
 	}
 
 	menu.Id = utils.GenerateId()
-	imagePath := "./images/menu/" + menu.Id + ".jpg"
+	imagePath := filepath.Join(c.imageDir, menu.Id+".jpg")
 	err = ctx.SaveUploadedFile(imageFile, imagePath)
 	if err != nil {
 		utils.JsonErrorInternalServerError(ctx, err, "cannot save image")
@@ -83,7 +78,7 @@ func (c *MenuController) CreateNewMenu(ctx *gin.Context) {
 	menu.Image = menu.Id + ".jpg"
 	newMenu, err := c.usecase.Insert(&menu)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "insert failed")
+		utils.JsonAppError(ctx, err, "insert failed")
 		return
 	}
 
@@ -102,7 +97,7 @@ func (c *MenuController) CreateNewMenuNoImage(ctx *gin.Context) {
 	// menu.Image = menu.Id + ".jpg"
 	newMenu, err := c.usecase.Insert(&menu)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "insert failed")
+		utils.JsonAppError(ctx, err, "insert failed")
 		return
 	}
 
@@ -121,7 +116,7 @@ func (c *MenuController) UpdateMenu(ctx *gin.Context) {
 	menu.Id = ctx.Param("id")
 	updatedMenu, err := c.usecase.Update(&menu)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "update failed")
+		utils.JsonAppError(ctx, err, "update failed")
 		return
 	}
 
@@ -130,30 +125,30 @@ func (c *MenuController) UpdateMenu(ctx *gin.Context) {
 
 }
 
+// DeleteMenu archives a menu, its image is kept for a restore.
 func (c *MenuController) DeleteMenu(ctx *gin.Context) {
-	menu, err := c.usecase.GetById(ctx.Param("id"))
+	err := c.usecase.Delete(ctx.Param("id"))
 	if err != nil {
-		utils.JsonErrorBadRequest(ctx, err, "menu not found")
+		utils.JsonAppError(ctx, err, "cannot delete menu")
 		return
 	}
 
-	err = c.usecase.Delete(menu.Id)
-	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot delete menu")
-		return
-	}
+	utils.JsonSuccessMessage(ctx, "Menu deleted")
+}
 
-	err = os.Remove("./images/menu/" + menu.Id + ".jpg")
+func (c *MenuController) RestoreMenu(ctx *gin.Context) {
+	menu, err := c.usecase.Restore(ctx.Param("id"))
 	if err != nil {
-		log.Println(err)
+		utils.JsonAppError(ctx, err, "cannot restore menu")
+		return
 	}
 
-	utils.JsonSuccessMessage(ctx, "Menu deleted")
+	utils.JsonDataMessageResponse(ctx, menu, "menu restored")
 }
 
 func (c *MenuController) GetMenuImage(ctx *gin.Context) {
 	id := ctx.Param("id")
-	imagePath := "./images/menu/" + id + ".jpg"
+	imagePath := filepath.Join(c.imageDir, id+".jpg")
 	if _, err := os.Stat(imagePath); err != nil {
 		// ctx.File("./images/menu/default.jpg")
 		utils.JsonErrorNotFound(ctx, err, imagePath)
@@ -163,22 +158,40 @@ func (c *MenuController) GetMenuImage(ctx *gin.Context) {
 	ctx.File(imagePath)
 }
 
-func NewMenuController(usecase usecase.MenuUsecase, router *gin.Engine) *MenuController {
+// archivedForOwner lets only an owner ask for archived menus with
+// include_deleted, the menu is public otherwise.
+func archivedForOwner(authMiddleware middleware.AuthTokenMiddleware) gin.HandlerFunc {
+	requireToken := authMiddleware.RequireToken()
+	requireOwner := authMiddleware.RequireRole(model.RoleOwner)
+	return func(ctx *gin.Context) {
+		if !newQueryParams(ctx).includeDeleted() {
+			return
+		}
+		if requireToken(ctx); ctx.IsAborted() {
+			return
+		}
+		requireOwner(ctx)
+	}
+}
+
+func NewMenuController(usecase usecase.MenuUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware, imageDir string) *MenuController {
 	controller := MenuController{
-		usecase: usecase,
-		router:  router,
+		usecase:  usecase,
+		router:   router,
+		imageDir: imageDir,
 	}
-	authMiddleware := middleware.NewAuthTokenMiddleware(authenticator.NewAccessToken(config.NewConfig().TokenConfig))
 
-	router.GET("/menu", controller.ListMenu)
-	router.GET("/menu/:id", controller.GetById)
+	ownerForArchived := archivedForOwner(authMiddleware)
+	router.GET("/menu", ownerForArchived, controller.ListMenu)
+	router.GET("/menu/:id", ownerForArchived, controller.GetById)
 	router.GET("/menu/:id/image", controller.GetMenuImage)
 
-	protectedRoute := router.Group("/menu", authMiddleware.RequireToken())
+	protectedRoute := router.Group("/menu", authMiddleware.RequireToken(), authMiddleware.RequireRole(model.RoleOwner))
 	protectedRoute.POST("/", controller.CreateNewMenu)
 	protectedRoute.POST("/no_image", controller.CreateNewMenuNoImage)
 	protectedRoute.PUT("/:id", controller.UpdateMenu)
 	protectedRoute.DELETE("/:id", controller.DeleteMenu)
+	protectedRoute.POST("/:id/restore", controller.RestoreMenu)
 
 	return &controller
 }
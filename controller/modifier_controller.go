@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"strconv"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModifierController struct {
+	usecase usecase.ModifierUsecase
+	router  *gin.Engine
+}
+
+func (c *ModifierController) ListModifiers(ctx *gin.Context) {
+	groups, err := c.usecase.GetByMenu(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get modifiers")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, groups)
+}
+
+func (c *ModifierController) CreateNewGroup(ctx *gin.Context) {
+	var group model.ModifierGroup
+	if err := ctx.ShouldBindJSON(&group); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	group.MenuId = ctx.Param("id")
+	newGroup, err := c.usecase.Insert(&group)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newGroup, "modifier group created")
+}
+
+func (c *ModifierController) UpdateGroup(ctx *gin.Context) {
+	groupId, err := strconv.ParseInt(ctx.Param("groupId"), 10, 64)
+	if err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "group id must be a number")
+		return
+	}
+
+	var group model.ModifierGroup
+	if err := ctx.ShouldBindJSON(&group); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	group.Id = groupId
+	group.MenuId = ctx.Param("id")
+	updated, err := c.usecase.Update(&group)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "modifier group updated")
+}
+
+func (c *ModifierController) DeleteGroup(ctx *gin.Context) {
+	groupId, err := strconv.ParseInt(ctx.Param("groupId"), 10, 64)
+	if err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "group id must be a number")
+		return
+	}
+
+	if err := c.usecase.Delete(ctx.Param("id"), groupId); err != nil {
+		utils.JsonAppError(ctx, err, "cannot delete modifier group")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "modifier group deleted")
+}
+
+func NewModifierController(usecase usecase.ModifierUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *ModifierController {
+	controller := ModifierController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	router.GET("/menu/:id/modifiers", controller.ListModifiers)
+
+	protectedRoute := router.Group("/menu/:id/modifiers", authMiddleware.RequireToken(), authMiddleware.RequireRole(model.RoleOwner))
+	protectedRoute.POST("", controller.CreateNewGroup)
+	protectedRoute.PUT("/:groupId", controller.UpdateGroup)
+	protectedRoute.DELETE("/:groupId", controller.DeleteGroup)
+
+	return &controller
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromoController struct {
+	usecase usecase.PromoUsecase
+	router  *gin.Engine
+}
+
+func (c *PromoController) ListPromo(ctx *gin.Context) {
+	list, err := c.usecase.GetAll()
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get promo list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *PromoController) GetByCode(ctx *gin.Context) {
+	promo, err := c.usecase.GetByCode(ctx.Param("code"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get promo")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, promo)
+}
+
+func (c *PromoController) CreateNewPromo(ctx *gin.Context) {
+	var promo model.Promo
+	if err := ctx.ShouldBindJSON(&promo); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	newPromo, err := c.usecase.Insert(&promo)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newPromo, "promo created")
+}
+
+func (c *PromoController) UpdatePromo(ctx *gin.Context) {
+	var promo model.Promo
+	if err := ctx.ShouldBindJSON(&promo); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	promo.Code = ctx.Param("code")
+	updated, err := c.usecase.Update(&promo)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "promo updated")
+}
+
+func NewPromoController(usecase usecase.PromoUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *PromoController {
+	controller := PromoController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/promo", authMiddleware.RequireToken(), authMiddleware.RequireRole(model.RoleOwner))
+	protectedRoute.GET("", controller.ListPromo)
+	protectedRoute.GET("/:code", controller.GetByCode)
+	protectedRoute.POST("", controller.CreateNewPromo)
+	protectedRoute.PUT("/:code", controller.UpdatePromo)
+
+	return &controller
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseOrderController struct {
+	usecase usecase.PurchaseOrderUsecase
+	router  *gin.Engine
+}
+
+func (c *PurchaseOrderController) ListPurchaseOrder(ctx *gin.Context) {
+	list, err := c.usecase.List(model.PurchaseFilter{
+		SupplierId: ctx.Query("supplier_id"),
+		Status:     ctx.Query("status"),
+	})
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get purchase order list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *PurchaseOrderController) GetById(ctx *gin.Context) {
+	order, err := c.usecase.GetById(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get purchase order")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, order)
+}
+
+func (c *PurchaseOrderController) CreateNewPurchaseOrder(ctx *gin.Context) {
+	var order model.PurchaseOrder
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	newOrder, err := c.usecase.Insert(&order, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newOrder, "purchase order created")
+}
+
+func (c *PurchaseOrderController) UpdatePurchaseOrder(ctx *gin.Context) {
+	var order model.PurchaseOrder
+	if err := ctx.ShouldBindJSON(&order); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	order.Id = ctx.Param("id")
+	updated, err := c.usecase.Update(&order)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "purchase order updated")
+}
+
+func (c *PurchaseOrderController) DeletePurchaseOrder(ctx *gin.Context) {
+	if err := c.usecase.Delete(ctx.Param("id")); err != nil {
+		utils.JsonAppError(ctx, err, "cannot delete purchase order")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "purchase order deleted")
+}
+
+func (c *PurchaseOrderController) OrderPurchaseOrder(ctx *gin.Context) {
+	order, err := c.usecase.Order(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "purchase order not ordered")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, order, "purchase order ordered")
+}
+
+func (c *PurchaseOrderController) ReceivePurchaseOrder(ctx *gin.Context) {
+	var request model.ReceiveRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	order, err := c.usecase.Receive(ctx.Param("id"), request, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "purchase order not received")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, order, "purchase order received")
+}
+
+func NewPurchaseOrderController(usecase usecase.PurchaseOrderUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *PurchaseOrderController {
+	controller := PurchaseOrderController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/purchase-order", authMiddleware.RequireToken())
+	protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.ListPurchaseOrder)
+	protectedRoute.GET("/:id", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.GetById)
+	protectedRoute.POST("", authMiddleware.RequireRole(model.RoleOwner), controller.CreateNewPurchaseOrder)
+	protectedRoute.PUT("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.UpdatePurchaseOrder)
+	protectedRoute.DELETE("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.DeletePurchaseOrder)
+	protectedRoute.POST("/:id/order", authMiddleware.RequireRole(model.RoleOwner), controller.OrderPurchaseOrder)
+	protectedRoute.POST("/:id/receive", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen), controller.ReceivePurchaseOrder)
+
+	return &controller
+}
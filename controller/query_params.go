@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dateLayout = "2006-01-02"
+
+// queryParams reads typed query parameters and collects what is wrong with
+// them, so one response tells about every bad parameter.
+type queryParams struct {
+	ctx     *gin.Context
+	invalid map[string]string
+}
+
+func newQueryParams(ctx *gin.Context) *queryParams {
+	return &queryParams{ctx: ctx, invalid: map[string]string{}}
+}
+
+func (q *queryParams) intParam(key string) *int {
+	value := q.ctx.Query(key)
+	if value == "" {
+		return nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		q.invalid[key] = "must be a whole number"
+		return nil
+	}
+	return &number
+}
+
+func (q *queryParams) boolParam(key string) *bool {
+	value := q.ctx.Query(key)
+	if value == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		q.invalid[key] = "must be true or false"
+		return nil
+	}
+	return &b
+}
+
+// timeParam reads a date or an RFC 3339 time. with endOfDay a date means
+// the start of the day after, so a range up to a date includes that day.
+func (q *queryParams) timeParam(key string, endOfDay bool) *time.Time {
+	value := q.ctx.Query(key)
+	if value == "" {
+		return nil
+	}
+
+	if t, err := time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		q.invalid[key] = "must be a date (2006-01-02) or an RFC 3339 time"
+		return nil
+	}
+	return &t
+}
+
+func (q *queryParams) listQuery() model.ListQuery {
+	query := model.ListQuery{
+		Cursor: q.ctx.Query("cursor"),
+		Sort:   q.ctx.Query("sort"),
+		Order:  q.ctx.Query("order"),
+	}
+	if page := q.intParam("page"); page != nil {
+		query.Page = *page
+		if *page < 1 {
+			q.invalid["page"] = "must be at least 1"
+		}
+	}
+	if limit := q.intParam("limit"); limit != nil {
+		query.Limit = *limit
+		if *limit < 1 {
+			q.invalid["limit"] = "must be at least 1"
+		}
+	}
+	return query
+}
+
+func (q *queryParams) err() error {
+	if len(q.invalid) == 0 {
+		return nil
+	}
+	return apperror.Validation("invalid query parameters", q.invalid)
+}
+
+func (q *queryParams) menuFilter() model.MenuFilter {
+	filter := model.MenuFilter{
+		ListQuery: q.listQuery(),
+		Name:      q.ctx.Query("name"),
+		MinPrice:  q.intParam("min_price"),
+		MaxPrice:  q.intParam("max_price"),
+		InStock:   q.boolParam("in_stock"),
+		Category:  q.ctx.Query("category"),
+		Tag:       q.ctx.Query("tag"),
+	}
+	if availableNow := q.boolParam("available_now"); availableNow != nil {
+		filter.AvailableNow = *availableNow
+	}
+	filter.IncludeDeleted = q.includeDeleted()
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		q.invalid["min_price"] = "must not be more than max_price"
+	}
+	return filter
+}
+
+func (q *queryParams) userFilter() model.UserFilter {
+	return model.UserFilter{
+		ListQuery:      q.listQuery(),
+		Name:           q.ctx.Query("name"),
+		Role:           q.ctx.Query("role"),
+		IncludeDeleted: q.includeDeleted(),
+	}
+}
+
+// includeDeleted asks for archived rows too, they are left out by default.
+func (q *queryParams) includeDeleted() bool {
+	include := q.boolParam("include_deleted")
+	return include != nil && *include
+}
+
+func (q *queryParams) transactionFilter() model.TransactionFilter {
+	filter := model.TransactionFilter{
+		ListQuery: q.listQuery(),
+		From:      q.timeParam("from", false),
+		To:        q.timeParam("to", true),
+		MinTotal:  q.intParam("min_total"),
+		Status:    q.ctx.Query("status"),
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		q.invalid["from"] = "must be before to"
+	}
+	return filter
+}
+
+func (q *queryParams) reportFilter() model.ReportFilter {
+	filter := model.ReportFilter{
+		From:   q.timeParam("from", false),
+		To:     q.timeParam("to", true),
+		Sort:   q.ctx.Query("sort"),
+		Period: q.ctx.Query("period"),
+	}
+	if limit := q.intParam("limit"); limit != nil {
+		filter.Limit = *limit
+		if *limit < 1 {
+			q.invalid["limit"] = "must be at least 1"
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		q.invalid["from"] = "must be before to"
+	}
+	return filter
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+	"warung-makan/utils/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	usecase usecase.ReportUsecase
+	router  *gin.Engine
+}
+
+// report answers with the report as json, or as a file of the given table
+// when a format is asked for.
+func (c *ReportController) report(ctx *gin.Context, name string, build func(model.ReportFilter) (model.SalesReport, error), table func(model.SalesReport) ([]interface{}, [][]interface{})) {
+	params := newQueryParams(ctx)
+	filter := params.reportFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	report, err := build(filter)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	if ctx.Query("format") == "" {
+		utils.JsonDataResponse(ctx, report)
+		return
+	}
+
+	header, rows := table(report)
+	exportFile(ctx, name, header, func(w export.Writer) error {
+		for _, row := range rows {
+			if err := w.Write(row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func periodTable(report model.SalesReport) ([]interface{}, [][]interface{}) {
+	rows := make([][]interface{}, len(report.Periods))
+	for i, each := range report.Periods {
+		rows[i] = []interface{}{each.Period, each.Transactions, each.Subtotal, each.DiscountAmount, each.ServiceCharge, each.Tax, each.GrossRevenue, each.Refunded, each.NetRevenue, each.AverageTicket}
+	}
+	return []interface{}{"period", "transactions", "subtotal", "discount_amount", "service_charge", "tax", "gross_revenue", "refunded", "net_revenue", "average_ticket"}, rows
+}
+
+func menuTable(report model.SalesReport) ([]interface{}, [][]interface{}) {
+	rows := make([][]interface{}, len(report.Menus))
+	for i, each := range report.Menus {
+		rows[i] = []interface{}{each.MenuId, each.Name, each.Qty, each.RefundedQty, each.Revenue, each.NetRevenue, each.Transactions}
+	}
+	return []interface{}{"menu_id", "name", "qty", "refunded_qty", "revenue", "net_revenue", "transactions"}, rows
+}
+
+func (c *ReportController) Daily(ctx *gin.Context) {
+	c.report(ctx, "daily-report", c.usecase.Daily, periodTable)
+}
+
+func (c *ReportController) Monthly(ctx *gin.Context) {
+	c.report(ctx, "monthly-report", c.usecase.Monthly, periodTable)
+}
+
+func (c *ReportController) Menu(ctx *gin.Context) {
+	c.report(ctx, "menu-report", c.usecase.Menu, menuTable)
+}
+
+func (c *ReportController) Suppliers(ctx *gin.Context) {
+	params := newQueryParams(ctx)
+	filter := params.reportFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	report, err := c.usecase.Suppliers(filter)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	if ctx.Query("format") == "" {
+		utils.JsonDataResponse(ctx, report)
+		return
+	}
+
+	header := []interface{}{"supplier_id", "name", "purchase_orders", "spent"}
+	exportFile(ctx, "supplier-report", header, func(w export.Writer) error {
+		for _, each := range report.Suppliers {
+			if err := w.Write(each.SupplierId, each.Name, each.PurchaseOrders, each.Spent); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *ReportController) Shrinkage(ctx *gin.Context) {
+	params := newQueryParams(ctx)
+	filter := params.reportFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	report, err := c.usecase.Shrinkage(filter)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get report")
+		return
+	}
+
+	if ctx.Query("format") == "" {
+		utils.JsonDataResponse(ctx, report)
+		return
+	}
+
+	header := []interface{}{"kind", "id", "name", "unit", "variance", "stock_takes", "lost", "found", "unvalued"}
+	exportFile(ctx, "shrinkage-report", header, func(w export.Writer) error {
+		for _, each := range report.Items {
+			if err := w.Write(each.Kind, each.Id, each.Name, each.Unit, each.Variance, each.StockTakes, each.Lost, each.Found, each.Unvalued); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func NewReportController(usecase usecase.ReportUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *ReportController {
+	controller := ReportController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/report", authMiddleware.RequireToken(), authMiddleware.RequireRole(model.RoleOwner, model.RoleViewer))
+	protectedRoute.GET("/daily", controller.Daily)
+	protectedRoute.GET("/monthly", controller.Monthly)
+	protectedRoute.GET("/menu", controller.Menu)
+	protectedRoute.GET("/suppliers", controller.Suppliers)
+	protectedRoute.GET("/shrinkage", controller.Shrinkage)
+
+	return &controller
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockController struct {
+	usecase usecase.StockUsecase
+	router  *gin.Engine
+}
+
+// listMovements lists the movements of the menu or the ingredient of kind
+// in the path.
+func (c *StockController) listMovements(kind string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		movements, err := c.usecase.GetByItem(model.StockItem{Kind: kind, Id: ctx.Param("id")})
+		if err != nil {
+			utils.JsonAppError(ctx, err, "cannot get stock movements")
+			return
+		}
+
+		utils.JsonDataResponse(ctx, movements)
+	}
+}
+
+func (c *StockController) ListAlerts(ctx *gin.Context) {
+	alerts, err := c.usecase.Alerts()
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get stock alerts")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, alerts)
+}
+
+// stockStep binds a stock request and moves the stock of the menu or the
+// ingredient of kind in the path with move.
+func stockStep(kind string, move func(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error), done string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var request model.StockRequest
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+			return
+		}
+
+		item := model.StockItem{Kind: kind, Id: ctx.Param("id")}
+		movement, err := move(item, request, ctx.GetString(middleware.ContextUserId))
+		if err != nil {
+			utils.JsonAppError(ctx, err, "stock not changed")
+			return
+		}
+
+		utils.JsonDataMessageResponse(ctx, movement, done)
+	}
+}
+
+func NewStockController(usecase usecase.StockUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *StockController {
+	controller := StockController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	alertRoute := router.Group("/stock/alerts", authMiddleware.RequireToken())
+	alertRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen, model.RoleViewer), controller.ListAlerts)
+
+	for _, kind := range []string{model.StockItemMenu, model.StockItemIngredient} {
+		protectedRoute := router.Group("/"+kind+"/:id/stock", authMiddleware.RequireToken())
+		protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleViewer), controller.listMovements(kind))
+		protectedRoute.POST("/restock", authMiddleware.RequireRole(model.RoleOwner), stockStep(kind, usecase.Restock, kind+" restocked"))
+		protectedRoute.POST("/waste", authMiddleware.RequireRole(model.RoleOwner, model.RoleKitchen), stockStep(kind, usecase.Waste, "waste recorded"))
+		protectedRoute.POST("/adjust", authMiddleware.RequireRole(model.RoleOwner), stockStep(kind, usecase.Adjust, "stock adjusted"))
+	}
+
+	return &controller
+}
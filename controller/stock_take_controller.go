@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockTakeController struct {
+	usecase usecase.StockTakeUsecase
+	router  *gin.Engine
+}
+
+func (c *StockTakeController) ListStockTake(ctx *gin.Context) {
+	list, err := c.usecase.List(ctx.Query("status"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get stock take list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *StockTakeController) GetById(ctx *gin.Context) {
+	stockTake, err := c.usecase.GetById(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get stock take")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, stockTake)
+}
+
+func (c *StockTakeController) CreateNewStockTake(ctx *gin.Context) {
+	// the note is optional, a stock take may be started with an empty body
+	var stockTake model.StockTake
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&stockTake); err != nil && !errors.Is(err, io.EOF) {
+			utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+			return
+		}
+	}
+
+	newStockTake, err := c.usecase.Insert(&stockTake, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newStockTake, "stock take started")
+}
+
+func (c *StockTakeController) CountStock(ctx *gin.Context) {
+	var request model.StockCountRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	stockTake, err := c.usecase.Count(ctx.Param("id"), request, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "counts not saved")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, stockTake, "counts saved")
+}
+
+func (c *StockTakeController) ApproveStockTake(ctx *gin.Context) {
+	stockTake, err := c.usecase.Approve(ctx.Param("id"), ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "stock take not approved")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, stockTake, "stock take approved")
+}
+
+func (c *StockTakeController) CancelStockTake(ctx *gin.Context) {
+	stockTake, err := c.usecase.Cancel(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "stock take not cancelled")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, stockTake, "stock take cancelled")
+}
+
+func NewStockTakeController(usecase usecase.StockTakeUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *StockTakeController {
+	controller := StockTakeController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/stock-take", authMiddleware.RequireToken())
+	protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen, model.RoleViewer), controller.ListStockTake)
+	protectedRoute.GET("/:id", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen, model.RoleViewer), controller.GetById)
+	protectedRoute.POST("", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen), controller.CreateNewStockTake)
+	protectedRoute.PUT("/:id/counts", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen), controller.CountStock)
+	protectedRoute.POST("/:id/approve", authMiddleware.RequireRole(model.RoleOwner), controller.ApproveStockTake)
+	protectedRoute.POST("/:id/cancel", authMiddleware.RequireRole(model.RoleOwner), controller.CancelStockTake)
+
+	return &controller
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupplierController struct {
+	usecase usecase.SupplierUsecase
+	router  *gin.Engine
+}
+
+func (c *SupplierController) ListSupplier(ctx *gin.Context) {
+	list, err := c.usecase.GetAll()
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get supplier list")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, list)
+}
+
+func (c *SupplierController) GetById(ctx *gin.Context) {
+	supplier, err := c.usecase.GetById(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot get supplier")
+		return
+	}
+
+	utils.JsonDataResponse(ctx, supplier)
+}
+
+func (c *SupplierController) CreateNewSupplier(ctx *gin.Context) {
+	var supplier model.Supplier
+	if err := ctx.ShouldBindJSON(&supplier); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	newSupplier, err := c.usecase.Insert(&supplier)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "insert failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newSupplier, "supplier created")
+}
+
+func (c *SupplierController) UpdateSupplier(ctx *gin.Context) {
+	var supplier model.Supplier
+	if err := ctx.ShouldBindJSON(&supplier); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	supplier.Id = ctx.Param("id")
+	updated, err := c.usecase.Update(&supplier)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "update failed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, updated, "supplier updated")
+}
+
+func (c *SupplierController) DeleteSupplier(ctx *gin.Context) {
+	if err := c.usecase.Delete(ctx.Param("id")); err != nil {
+		utils.JsonAppError(ctx, err, "cannot delete supplier")
+		return
+	}
+
+	utils.JsonSuccessMessage(ctx, "supplier deleted")
+}
+
+func NewSupplierController(usecase usecase.SupplierUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *SupplierController {
+	controller := SupplierController{
+		usecase: usecase,
+		router:  router,
+	}
+
+	protectedRoute := router.Group("/supplier", authMiddleware.RequireToken())
+	protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleViewer), controller.ListSupplier)
+	protectedRoute.GET("/:id", authMiddleware.RequireRole(model.RoleOwner, model.RoleViewer), controller.GetById)
+	protectedRoute.POST("", authMiddleware.RequireRole(model.RoleOwner), controller.CreateNewSupplier)
+	protectedRoute.PUT("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.UpdateSupplier)
+	protectedRoute.DELETE("/:id", authMiddleware.RequireRole(model.RoleOwner), controller.DeleteSupplier)
+
+	return &controller
+}
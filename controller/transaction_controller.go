@@ -1,38 +1,67 @@
 package controller
 
 import (
-	"fmt"
-	"net/http"
-	"warung-makan/config"
+	"errors"
+	"io"
+	"strconv"
 	"warung-makan/middleware"
 	"warung-makan/model"
 	"warung-makan/usecase"
 	"warung-makan/utils"
-	"warung-makan/utils/authenticator"
+	"warung-makan/utils/export"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TransactionController struct {
-	usecase     usecase.TransactionUsecase
-	menuUsecase usecase.MenuUsecase
-	router      *gin.Engine
+	usecase usecase.TransactionUsecase
+	router  *gin.Engine
 }
 
 func (c *TransactionController) ListTransaction(ctx *gin.Context) {
-	list, err := c.usecase.GetAll()
+	params := newQueryParams(ctx)
+	filter := params.transactionFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get transaction list")
+		return
+	}
+
+	list, page, err := c.usecase.List(filter)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot get transaction list")
+		utils.JsonAppError(ctx, err, "cannot get transaction list")
 		return
 	}
 
-	utils.JsonDataResponse(ctx, list)
+	utils.JsonPageResponse(ctx, list, page)
+}
+
+// ExportTransactions writes one row per item of the filtered transactions.
+func (c *TransactionController) ExportTransactions(ctx *gin.Context) {
+	params := newQueryParams(ctx)
+	filter := params.transactionFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot export transactions")
+		return
+	}
+
+	header := []interface{}{"transaction_id", "created_at", "status", "transaction_subtotal", "transaction_discount", "service_charge", "tax", "total", "menu_id", "menu_name", "modifiers", "unit_price", "qty", "subtotal", "discount"}
+	exportFile(ctx, "transactions", header, func(w export.Writer) error {
+		return c.usecase.Export(filter, func(transaction model.Transaction) error {
+			for _, item := range transaction.Items {
+				err := w.Write(transaction.Id, exportTime(transaction.Created_at), transaction.Status, transaction.Subtotal, transaction.DiscountAmount, transaction.ServiceCharge, transaction.Tax, transaction.TotalPrice, item.MenuId, item.MenuName, item.Modifiers.String(), item.UnitPrice, item.Qty, item.Subtotal, item.DiscountAmount)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
 }
 
 func (c *TransactionController) GetById(ctx *gin.Context) {
 	transaction, err := c.usecase.GetById(ctx.Param("id"))
 	if err != nil {
-		utils.JsonErrorNotFound(ctx, err, "cannot get transaction")
+		utils.JsonAppError(ctx, err, "cannot get transaction")
 		return
 	}
 
@@ -48,36 +77,134 @@ func (c *TransactionController) CreateNewTransaction(ctx *gin.Context) {
 		return
 	}
 
-	transaction.Id = utils.GenerateId()
-	// fill transaction details
-	for i, each := range transaction.Items {
-		menu, err := c.menuUsecase.GetById(each.MenuId)
+	partial := false
+	if value := ctx.Query("partial"); value != "" {
+		partial, err = strconv.ParseBool(value)
 		if err != nil {
-			fmt.Println("cant find the menu")
-			continue
+			utils.JsonErrorBadRequest(ctx, err, "partial must be true or false")
+			return
 		}
+	}
 
-		if each.Qty > menu.Stock || menu.Stock < 1 || each.Qty < 1 {
-			fmt.Println("qty is invalid")
-			continue
+	newTransaction, err := c.usecase.Insert(&transaction, partial)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "transaction not created")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, newTransaction, "transaction created")
+}
+
+func (c *TransactionController) OpenOrder(ctx *gin.Context) {
+	// the items are optional, an order may be opened with an empty body
+	var lines model.OrderLines
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&lines); err != nil && !errors.Is(err, io.EOF) {
+			utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+			return
 		}
-		transaction.Items[i].TransactionId = transaction.Id
-		transaction.Items[i].Subtotal = menu.Price * each.Qty
-		transaction.TotalPrice += transaction.Items[i].Subtotal
 	}
 
-	if len(transaction.Items) == 0 {
-		ctx.String(http.StatusBadRequest, "Transaction has 0 valid item. Transaction not created.")
+	order, err := c.usecase.Open(lines.Items)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "order not opened")
 		return
 	}
 
-	newTransaction, err := c.usecase.Insert(&transaction)
+	utils.JsonDataMessageResponse(ctx, order, "order opened")
+}
+
+func (c *TransactionController) AddOrderItems(ctx *gin.Context) {
+	var lines model.OrderLines
+	if err := ctx.ShouldBindJSON(&lines); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	order, err := c.usecase.AddItems(ctx.Param("id"), lines.Items)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "insert failed")
+		utils.JsonAppError(ctx, err, "items not added")
 		return
 	}
 
-	utils.JsonDataMessageResponse(ctx, newTransaction, "transaction created")
+	utils.JsonDataMessageResponse(ctx, order, "items added")
+}
+
+func (c *TransactionController) RemoveOrderItem(ctx *gin.Context) {
+	itemId, err := strconv.ParseInt(ctx.Param("itemId"), 10, 64)
+	if err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "item id must be a number")
+		return
+	}
+
+	order, err := c.usecase.RemoveItem(ctx.Param("id"), itemId)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "item not removed")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, order, "item removed")
+}
+
+func (c *TransactionController) PayOrder(ctx *gin.Context) {
+	var request model.PaymentRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	order, err := c.usecase.Pay(ctx.Param("id"), request)
+	if err != nil {
+		utils.JsonAppError(ctx, err, "order not paid")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, order, "order paid")
+}
+
+// orderStep answers a request that moves an order on with step.
+func orderStep(step func(id string) (model.Transaction, error), done string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		order, err := step(ctx.Param("id"))
+		if err != nil {
+			utils.JsonAppError(ctx, err, "order not "+done)
+			return
+		}
+
+		utils.JsonDataMessageResponse(ctx, order, "order "+done)
+	}
+}
+
+func (c *TransactionController) VoidTransaction(ctx *gin.Context) {
+	var request model.RefundRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	refund, err := c.usecase.Void(ctx.Param("id"), request, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "transaction not voided")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, refund, "transaction voided")
+}
+
+func (c *TransactionController) RefundTransaction(ctx *gin.Context) {
+	var request model.RefundRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
+		return
+	}
+
+	refund, err := c.usecase.Refund(ctx.Param("id"), request, ctx.GetString(middleware.ContextUserId))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "transaction not refunded")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, refund, "transaction refunded")
 }
 
 // func (c *TransactionController) UpdateTransaction(ctx *gin.Context) {
@@ -114,18 +241,26 @@ func (c *TransactionController) CreateNewTransaction(ctx *gin.Context) {
 // 	utils.JsonSuccessMessage(ctx, "Transaction deleted")
 // }
 
-func NewTransactionController(usecase usecase.TransactionUsecase, menuUsecase usecase.MenuUsecase, router *gin.Engine) *TransactionController {
+func NewTransactionController(usecase usecase.TransactionUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware) *TransactionController {
 	controller := TransactionController{
-		usecase:     usecase,
-		menuUsecase: menuUsecase,
-		router:      router,
+		usecase: usecase,
+		router:  router,
 	}
-	authMiddleware := middleware.NewAuthTokenMiddleware(authenticator.NewAccessToken(config.NewConfig().TokenConfig))
 
 	protectedRoute := router.Group("/transaction", authMiddleware.RequireToken())
-	protectedRoute.GET("", controller.ListTransaction)
-	protectedRoute.GET("/:id", controller.GetById)
-	protectedRoute.POST("", controller.CreateNewTransaction)
+	protectedRoute.GET("", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen, model.RoleViewer), controller.ListTransaction)
+	protectedRoute.GET("/export", authMiddleware.RequireRole(model.RoleOwner, model.RoleViewer), controller.ExportTransactions)
+	protectedRoute.GET("/:id", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen, model.RoleViewer), controller.GetById)
+	protectedRoute.POST("", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), controller.CreateNewTransaction)
+	protectedRoute.POST("/open", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), controller.OpenOrder)
+	protectedRoute.POST("/:id/items", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), controller.AddOrderItems)
+	protectedRoute.DELETE("/:id/items/:itemId", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), controller.RemoveOrderItem)
+	protectedRoute.POST("/:id/send", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), orderStep(usecase.Send, "sent to kitchen"))
+	protectedRoute.POST("/:id/serve", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier, model.RoleKitchen), orderStep(usecase.Serve, "served"))
+	protectedRoute.POST("/:id/pay", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), controller.PayOrder)
+	protectedRoute.POST("/:id/cancel", authMiddleware.RequireRole(model.RoleOwner, model.RoleCashier), orderStep(usecase.Cancel, "cancelled"))
+	protectedRoute.POST("/:id/void", authMiddleware.RequireRole(model.RoleOwner), controller.VoidTransaction)
+	protectedRoute.POST("/:id/refund", authMiddleware.RequireRole(model.RoleOwner), controller.RefundTransaction)
 
 	return &controller
 }
@@ -1,55 +1,52 @@
 package controller
 
 import (
-	"log"
+	"errors"
 	"net/http"
 	"os"
-	"warung-makan/config"
+	"path/filepath"
 	"warung-makan/middleware"
 	"warung-makan/model"
 	"warung-makan/usecase"
 	"warung-makan/utils"
-	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserController struct {
-	usecase usecase.UserUsecase
-	router  *gin.Engine
+	usecase  usecase.UserUsecase
+	router   *gin.Engine
+	imageDir string
 }
 
 func (c *UserController) ListUser(ctx *gin.Context) {
-	if name := ctx.Query("name"); name != "" {
-		user, err := c.usecase.GetByName(ctx.Query("name"))
-
-		if err != nil {
-			utils.JsonErrorNotFound(ctx, err, "cannot get list")
-			return
-		}
-
-		if len(user) == 0 {
-			ctx.String(http.StatusBadRequest, "no user with name like "+name)
-			return
-		}
-
-		utils.JsonDataResponse(ctx, user)
+	params := newQueryParams(ctx)
+	filter := params.userFilter()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get user list")
 		return
 	}
 
-	list, err := c.usecase.GetAll()
+	list, page, err := c.usecase.List(filter)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot get user list")
+		utils.JsonAppError(ctx, err, "cannot get user list")
 		return
 	}
 
-	utils.JsonDataResponse(ctx, list)
+	utils.JsonPageResponse(ctx, list, page)
 }
 
 func (c *UserController) GetById(ctx *gin.Context) {
-	user, err := c.usecase.GetById(ctx.Param("id"))
+	params := newQueryParams(ctx)
+	includeDeleted := params.includeDeleted()
+	if err := params.err(); err != nil {
+		utils.JsonAppError(ctx, err, "cannot get user")
+		return
+	}
+
+	user, err := c.usecase.GetById(ctx.Param("id"), includeDeleted)
 	if err != nil {
-		utils.JsonErrorNotFound(ctx, err, "cannot get user")
+		utils.JsonAppError(ctx, err, "cannot get user")
 		return
 	}
 
@@ -77,7 +74,7 @@ func (c *UserController) CreateNewUser(ctx *gin.Context) {
 
 	id := utils.GenerateId()
 
-	imagePath := "./images/user/" + id + ".jpg"
+	imagePath := filepath.Join(c.imageDir, id+".jpg")
 	err = ctx.SaveUploadedFile(imageFile, imagePath)
 	if err != nil {
 		utils.JsonErrorInternalServerError(ctx, err, "cannot save image")
@@ -88,7 +85,7 @@ func (c *UserController) CreateNewUser(ctx *gin.Context) {
 	user.Image = id + ".jpg"
 	user, err = c.usecase.Insert(&user)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "insert failed")
+		utils.JsonAppError(ctx, err, "insert failed")
 		return
 	}
 
@@ -112,7 +109,7 @@ func (c *UserController) CreateNewUserNoImage(ctx *gin.Context) {
 	// user.Image = id + ".jpg"
 	user, err = c.usecase.Insert(&user)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "insert failed")
+		utils.JsonAppError(ctx, err, "insert failed")
 		return
 	}
 
@@ -132,37 +129,61 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 	user.Id = ctx.Param("id")
 	updatedUser, err := c.usecase.Update(&user)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "update failed")
+		utils.JsonAppError(ctx, err, "update failed")
 		return
 	}
 
 	utils.JsonDataResponse(ctx, updatedUser)
 }
 
-func (c *UserController) DeleteUser(ctx *gin.Context) {
-	user, err := c.usecase.GetById(ctx.Param("id"))
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var passwordChange model.PasswordChange
+
+	id := ctx.Param("id")
+	if id != ctx.GetString(middleware.ContextUserId) {
+		utils.JsonErrorForbidden(ctx, errors.New("not your account"), "you can only change your own password")
+		return
+	}
+
+	err := ctx.ShouldBindJSON(&passwordChange)
 	if err != nil {
-		utils.JsonErrorNotFound(ctx, err, "user not found")
+		utils.JsonErrorBadRequest(ctx, err, "cant bind struct")
 		return
 	}
 
-	err = c.usecase.Delete(user.Id)
+	err = c.usecase.ChangePassword(id, &passwordChange)
 	if err != nil {
-		utils.JsonErrorInternalServerError(ctx, err, "cannot delete user")
+		utils.JsonAppError(ctx, err, "cannot change password")
 		return
 	}
 
-	err = os.Remove("./images/user/" + user.Id + ".jpg")
+	utils.JsonSuccessMessage(ctx, "Password changed")
+}
+
+// DeleteUser archives a user, their image is kept for a restore.
+func (c *UserController) DeleteUser(ctx *gin.Context) {
+	err := c.usecase.Delete(ctx.Param("id"))
 	if err != nil {
-		log.Println(err)
+		utils.JsonAppError(ctx, err, "cannot delete user")
+		return
 	}
 
 	utils.JsonSuccessMessage(ctx, "User deleted")
 }
 
+func (c *UserController) RestoreUser(ctx *gin.Context) {
+	user, err := c.usecase.Restore(ctx.Param("id"))
+	if err != nil {
+		utils.JsonAppError(ctx, err, "cannot restore user")
+		return
+	}
+
+	utils.JsonDataMessageResponse(ctx, user, "user restored")
+}
+
 func (c *UserController) GetUserImage(ctx *gin.Context) {
 	id := ctx.Param("id")
-	imagePath := "./images/user/" + id + ".jpg"
+	imagePath := filepath.Join(c.imageDir, id+".jpg")
 	if _, err := os.Stat(imagePath); err != nil {
 		utils.JsonErrorNotFound(ctx, err, imagePath)
 		return
@@ -171,22 +192,26 @@ func (c *UserController) GetUserImage(ctx *gin.Context) {
 	ctx.File(imagePath)
 }
 
-func NewUserController(usecase usecase.UserUsecase, router *gin.Engine) *UserController {
+func NewUserController(usecase usecase.UserUsecase, router *gin.Engine, authMiddleware middleware.AuthTokenMiddleware, imageDir string) *UserController {
 	controller := UserController{
-		usecase: usecase,
-		router:  router,
+		usecase:  usecase,
+		router:   router,
+		imageDir: imageDir,
 	}
-	authMiddleware := middleware.NewAuthTokenMiddleware(authenticator.NewAccessToken(config.NewConfig().TokenConfig))
 
-	router.GET("/user", controller.ListUser)
-	router.GET("/user/:id", controller.GetById)
 	router.GET("/user/:id/image", controller.GetUserImage)
 
 	protectedRoute := router.Group("/user", authMiddleware.RequireToken())
-	protectedRoute.POST("/", controller.CreateNewUser)
-	protectedRoute.POST("/no_image", controller.CreateNewUserNoImage)
-	protectedRoute.PUT("/:id", controller.UpdateUser)
-	protectedRoute.DELETE("/:id", controller.DeleteUser)
+	protectedRoute.PUT("/:id/password", controller.ChangePassword)
+
+	ownerRoute := protectedRoute.Group("", authMiddleware.RequireRole(model.RoleOwner))
+	ownerRoute.GET("", controller.ListUser)
+	ownerRoute.GET("/:id", controller.GetById)
+	ownerRoute.POST("/", controller.CreateNewUser)
+	ownerRoute.POST("/no_image", controller.CreateNewUserNoImage)
+	ownerRoute.PUT("/:id", controller.UpdateUser)
+	ownerRoute.DELETE("/:id", controller.DeleteUser)
+	ownerRoute.POST("/:id/restore", controller.RestoreUser)
 
 	return &controller
 }
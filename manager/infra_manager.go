@@ -14,12 +14,17 @@ type infraManager struct {
 
 type InfraManager interface {
 	GetSqlDb() *sqlx.DB
+	Close() error
 }
 
 func (i *infraManager) GetSqlDb() *sqlx.DB {
 	return i.DB
 }
 
+func (i *infraManager) Close() error {
+	return i.DB.Close()
+}
+
 func (i *infraManager) initDb() {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", i.DbConfig.Host, i.DbConfig.User, i.DbConfig.Pass, i.DbConfig.DbName, i.DbConfig.Port)
 
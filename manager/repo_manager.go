@@ -11,6 +11,16 @@ type RepoManager interface {
 	MenuRepo() repository.MenuRepository
 	TransactionRepo() repository.TransactionRepository
 	TransactionDetailRepo() repository.TransactionDetailRepository
+	TokenRepo() repository.TokenRepository
+	ReportRepo() repository.ReportRepository
+	PromoRepo() repository.PromoRepository
+	CategoryRepo() repository.CategoryRepository
+	ModifierRepo() repository.ModifierRepository
+	StockRepo() repository.StockRepository
+	IngredientRepo() repository.IngredientRepository
+	SupplierRepo() repository.SupplierRepository
+	PurchaseOrderRepo() repository.PurchaseOrderRepository
+	StockTakeRepo() repository.StockTakeRepository
 }
 
 func (rm *repoManager) UserRepo() repository.UserRepository {
@@ -29,6 +39,46 @@ func (rm *repoManager) TransactionDetailRepo() repository.TransactionDetailRepos
 	return repository.NewTransactionDetailRepository(rm.infra.GetSqlDb())
 }
 
+func (rm *repoManager) TokenRepo() repository.TokenRepository {
+	return repository.NewTokenRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) ReportRepo() repository.ReportRepository {
+	return repository.NewReportRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) PromoRepo() repository.PromoRepository {
+	return repository.NewPromoRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) CategoryRepo() repository.CategoryRepository {
+	return repository.NewCategoryRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) ModifierRepo() repository.ModifierRepository {
+	return repository.NewModifierRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) StockRepo() repository.StockRepository {
+	return repository.NewStockRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) IngredientRepo() repository.IngredientRepository {
+	return repository.NewIngredientRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) SupplierRepo() repository.SupplierRepository {
+	return repository.NewSupplierRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) PurchaseOrderRepo() repository.PurchaseOrderRepository {
+	return repository.NewPurchaseOrderRepository(rm.infra.GetSqlDb())
+}
+
+func (rm *repoManager) StockTakeRepo() repository.StockTakeRepository {
+	return repository.NewStockTakeRepository(rm.infra.GetSqlDb())
+}
+
 func NewRepoManager(infra InfraManager) RepoManager {
 	return &repoManager{
 		infra: infra,
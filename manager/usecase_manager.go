@@ -1,36 +1,100 @@
 package manager
 
-import "warung-makan/usecase"
+import (
+	"time"
+	"warung-makan/config"
+	"warung-makan/usecase"
+	"warung-makan/utils/authenticator"
+	"warung-makan/utils/notifier"
+)
 
 type usecaseManager struct {
-	repo RepoManager
+	repo        RepoManager
+	tokenConfig config.TokenConfig
+	location    *time.Location
+	pricing     config.PricingConfig
+	notifier    notifier.Notifier
 }
 
 type UsecaseManager interface {
 	UserUsecase() usecase.UserUsecase
 	MenuUsecase() usecase.MenuUsecase
 	TransactionUsecase() usecase.TransactionUsecase
+	TokenUsecase() usecase.TokenUsecase
+	ReportUsecase() usecase.ReportUsecase
+	PromoUsecase() usecase.PromoUsecase
+	CategoryUsecase() usecase.CategoryUsecase
+	ModifierUsecase() usecase.ModifierUsecase
+	StockUsecase() usecase.StockUsecase
+	IngredientUsecase() usecase.IngredientUsecase
+	SupplierUsecase() usecase.SupplierUsecase
+	PurchaseOrderUsecase() usecase.PurchaseOrderUsecase
+	StockTakeUsecase() usecase.StockTakeUsecase
 	// TransactionDetailUsecase() usecase.TransactionDetailUsecase
 }
 
 func (um *usecaseManager) UserUsecase() usecase.UserUsecase {
-	return usecase.NewUserUsecase(um.repo.UserRepo())
+	return usecase.NewUserUsecase(um.repo.UserRepo(), um.TokenUsecase())
 }
 
 func (um *usecaseManager) MenuUsecase() usecase.MenuUsecase {
-	return usecase.NewMenuUsecase(um.repo.MenuRepo())
+	return usecase.NewMenuUsecase(um.repo.MenuRepo(), um.location)
 }
 
 func (um *usecaseManager) TransactionUsecase() usecase.TransactionUsecase {
-	return usecase.NewTransactionUsecase(um.repo.TransactionRepo())
+	return usecase.NewTransactionUsecase(um.repo.TransactionRepo(), um.pricing, um.notifier)
+}
+
+func (um *usecaseManager) TokenUsecase() usecase.TokenUsecase {
+	return usecase.NewTokenUsecase(um.repo.TokenRepo(), um.repo.UserRepo(), authenticator.NewAccessToken(um.tokenConfig), um.tokenConfig)
+}
+
+func (um *usecaseManager) ReportUsecase() usecase.ReportUsecase {
+	return usecase.NewReportUsecase(um.repo.ReportRepo(), um.location)
+}
+
+func (um *usecaseManager) PromoUsecase() usecase.PromoUsecase {
+	return usecase.NewPromoUsecase(um.repo.PromoRepo())
+}
+
+func (um *usecaseManager) CategoryUsecase() usecase.CategoryUsecase {
+	return usecase.NewCategoryUsecase(um.repo.CategoryRepo())
+}
+
+func (um *usecaseManager) ModifierUsecase() usecase.ModifierUsecase {
+	return usecase.NewModifierUsecase(um.repo.ModifierRepo(), um.repo.MenuRepo())
+}
+
+func (um *usecaseManager) StockUsecase() usecase.StockUsecase {
+	return usecase.NewStockUsecase(um.repo.StockRepo(), um.repo.MenuRepo(), um.repo.IngredientRepo())
+}
+
+func (um *usecaseManager) IngredientUsecase() usecase.IngredientUsecase {
+	return usecase.NewIngredientUsecase(um.repo.IngredientRepo(), um.repo.MenuRepo())
+}
+
+func (um *usecaseManager) SupplierUsecase() usecase.SupplierUsecase {
+	return usecase.NewSupplierUsecase(um.repo.SupplierRepo())
+}
+
+func (um *usecaseManager) PurchaseOrderUsecase() usecase.PurchaseOrderUsecase {
+	return usecase.NewPurchaseOrderUsecase(um.repo.PurchaseOrderRepo(), um.repo.SupplierRepo(), um.repo.MenuRepo(), um.repo.IngredientRepo())
+}
+
+func (um *usecaseManager) StockTakeUsecase() usecase.StockTakeUsecase {
+	return usecase.NewStockTakeUsecase(um.repo.StockTakeRepo(), um.repo.MenuRepo(), um.repo.IngredientRepo())
 }
 
 // func (um *usecaseManager) TransactionDetailUsecase() usecase.TransactionDetailUsecase {
 // 	return usecase.NewTransactionDetailUsecase(um.repo.TransactionDetailRepo())
 // }
 
-func NewUsecaseManager(repo RepoManager) UsecaseManager {
+func NewUsecaseManager(repo RepoManager, tokenConfig config.TokenConfig, location *time.Location, pricing config.PricingConfig, notifier notifier.Notifier) UsecaseManager {
 	return &usecaseManager{
-		repo: repo,
+		repo:        repo,
+		tokenConfig: tokenConfig,
+		location:    location,
+		pricing:     pricing,
+		notifier:    notifier,
 	}
 }
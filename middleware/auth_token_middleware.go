@@ -1,13 +1,24 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
+	"time"
 	"warung-makan/utils"
 	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
 )
 
+// keys set on gin.Context by RequireToken
+const (
+	ContextUserId      = "user_id"
+	ContextUsername    = "username"
+	ContextRole        = "role"
+	ContextTokenId     = "token_id"
+	ContextTokenExpiry = "token_expiry"
+)
+
 type FakeAuthHeader struct {
 	Authorization string `header:"Authorization" binding:"required"`
 }
@@ -16,12 +27,18 @@ type authHeader struct {
 	Authorization string `header:"Authorization" binding:"required"`
 }
 
+type TokenRevocationChecker interface {
+	IsRevoked(jti string) (bool, error)
+}
+
 type authTokenMiddleware struct {
 	accessToken authenticator.AccessToken
+	revocation  TokenRevocationChecker
 }
 
 type AuthTokenMiddleware interface {
 	RequireToken() gin.HandlerFunc
+	RequireRole(roles ...string) gin.HandlerFunc
 }
 
 func (atm *authTokenMiddleware) RequireToken() gin.HandlerFunc {
@@ -36,22 +53,57 @@ func (atm *authTokenMiddleware) RequireToken() gin.HandlerFunc {
 
 		tokenString := strings.Replace(headerAuth.Authorization, "Bearer ", "", -1)
 		if tokenString == "" {
-			utils.JsonErrorInternalServerError(ctx, nil, "token string became empty")
-			ctx.Abort()
+			utils.JsonErrorUnauthorized(ctx, errors.New("empty token"), "token string became empty")
 			return
 		}
 
-		_, err = atm.accessToken.VerifyToken(tokenString)
+		claims, err := atm.accessToken.VerifyToken(tokenString)
 		if err != nil {
 			utils.JsonErrorUnauthorized(ctx, err, "cannot verify token")
 			ctx.Abort()
 			return
 		}
+
+		jti, _ := claims["jti"].(string)
+		revoked, err := atm.revocation.IsRevoked(jti)
+		if err != nil {
+			utils.JsonErrorInternalServerError(ctx, err, "cannot check token revocation")
+			return
+		}
+		if revoked {
+			utils.JsonErrorUnauthorized(ctx, errors.New("token revoked"), "token has been revoked")
+			return
+		}
+
+		userId, _ := claims["user_id"].(string)
+		username, _ := claims["username"].(string)
+		role, _ := claims["role"].(string)
+		exp, _ := claims["exp"].(float64)
+		ctx.Set(ContextUserId, userId)
+		ctx.Set(ContextUsername, username)
+		ctx.Set(ContextRole, role)
+		ctx.Set(ContextTokenId, jti)
+		ctx.Set(ContextTokenExpiry, time.Unix(int64(exp), 0))
+	}
+}
+
+// must be used after RequireToken
+func (atm *authTokenMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString(ContextRole)
+		for _, allowed := range roles {
+			if role == allowed {
+				return
+			}
+		}
+
+		utils.JsonErrorForbidden(ctx, errors.New("role "+role+" is not allowed"), "you are not allowed to access this resource")
 	}
 }
 
-func NewAuthTokenMiddleware(accessToken authenticator.AccessToken) AuthTokenMiddleware {
+func NewAuthTokenMiddleware(accessToken authenticator.AccessToken, revocation TokenRevocationChecker) AuthTokenMiddleware {
 	return &authTokenMiddleware{
 		accessToken: accessToken,
+		revocation:  revocation,
 	}
 }
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	corsAllowMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders = "Authorization, Content-Type"
+)
+
+// Cors allows cross origin requests from the given origins, "*" allows any
+// origin. with no origins it does nothing.
+func Cors(allowedOrigins []string) gin.HandlerFunc {
+	allowAll := false
+	allowed := map[string]bool{}
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" || (!allowAll && !allowed[origin]) {
+			ctx.Next()
+			return
+		}
+
+		header := ctx.Writer.Header()
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+		header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+
+		if ctx.Request.Method == http.MethodOptions {
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,249 @@
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lock id for pg_advisory_xact_lock, so two app instances starting at the
+// same time do not apply the same migration twice
+const lockId = 7290347
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	AppliedAt sql.NullTime
+}
+
+type appliedMigration struct {
+	Version   int          `db:"version"`
+	Name      string       `db:"name"`
+	AppliedAt sql.NullTime `db:"applied_at"`
+}
+
+type migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+type Migrator interface {
+	Up() ([]Migration, error)
+	Down(steps int) ([]Migration, error)
+	Status() ([]Status, error)
+}
+
+// Embedded returns the migrations shipped in the sql directory.
+func Embedded() ([]Migration, error) {
+	return Load(embedded, "sql")
+}
+
+// Load reads <version>_<name>.up.sql and .down.sql pairs from dir, sorted by
+// version. every version needs both files.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *migrator) applied() (map[int]appliedMigration, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(utils.MIGRATION_LOCK, lockId); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(utils.MIGRATION_CREATE_TABLE); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var rows []appliedMigration
+	if err := m.db.Select(&rows, utils.MIGRATION_GET_ALL); err != nil {
+		return nil, err
+	}
+
+	applied := map[int]appliedMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// run executes one step in its own transaction while holding the migration
+// lock. it is skipped when another instance already did the same step.
+func (m *migrator) run(migration Migration, up bool) (bool, error) {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(utils.MIGRATION_LOCK, lockId); err != nil {
+		return false, err
+	}
+
+	var isApplied bool
+	if err := tx.Get(&isApplied, utils.MIGRATION_IS_APPLIED, migration.Version); err != nil {
+		return false, err
+	}
+	if isApplied == up {
+		return false, nil
+	}
+
+	script, record, args := migration.Up, utils.MIGRATION_INSERT, []interface{}{migration.Version, migration.Name}
+	if !up {
+		script, record, args = migration.Down, utils.MIGRATION_DELETE, []interface{}{migration.Version}
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return false, fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied.
+func (m *migrator) Up() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		ran, err := m.run(migration, true)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			log.Printf("migration %d_%s applied\n", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		ran, err := m.run(migration, false)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			log.Printf("migration %d_%s reverted\n", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+	}
+	return done, nil
+}
+
+func (m *migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status = append(status, Status{
+			Migration: migration,
+			AppliedAt: applied[migration.Version].AppliedAt,
+		})
+	}
+	return status, nil
+}
+
+func NewMigrator(db *sqlx.DB, migrations []Migration) Migrator {
+	return &migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
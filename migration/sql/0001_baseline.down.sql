@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS revoked_token;
+DROP TABLE IF EXISTS refresh_token;
+DROP TABLE IF EXISTS transaction_detail;
+DROP TABLE IF EXISTS transaction;
+DROP TABLE IF EXISTS menu;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,79 @@
+-- schema as it was before migrations were introduced. every statement is
+-- guarded so databases created from the old warung_makan.sql dump can adopt it.
+
+CREATE TABLE IF NOT EXISTS menu (
+    id character varying(60) NOT NULL,
+    name character varying(100) NOT NULL,
+    price integer,
+    stock integer,
+    image text
+);
+
+CREATE TABLE IF NOT EXISTS transaction (
+    id character varying(60) NOT NULL,
+    total_price integer,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone
+);
+
+CREATE TABLE IF NOT EXISTS transaction_detail (
+    transaction_id character varying(60),
+    menu_id character varying(60),
+    qty integer,
+    subtotal integer,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id character varying(255) NOT NULL,
+    name character varying(255) NOT NULL,
+    username character varying(32) NOT NULL,
+    password text NOT NULL,
+    role character varying(16) DEFAULT 'viewer' NOT NULL,
+    image text
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role character varying(16) DEFAULT 'viewer' NOT NULL;
+
+CREATE TABLE IF NOT EXISTS refresh_token (
+    id character varying(60) NOT NULL,
+    user_id character varying(255) NOT NULL,
+    token_hash text NOT NULL,
+    access_jti character varying(60) NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS revoked_token (
+    jti character varying(60) NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'transaction_pkey') THEN
+        ALTER TABLE transaction ADD CONSTRAINT transaction_pkey PRIMARY KEY (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_pkey') THEN
+        ALTER TABLE users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'unique_username') THEN
+        ALTER TABLE users ADD CONSTRAINT unique_username UNIQUE (username);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'refresh_token_pkey') THEN
+        ALTER TABLE refresh_token ADD CONSTRAINT refresh_token_pkey PRIMARY KEY (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'unique_token_hash') THEN
+        ALTER TABLE refresh_token ADD CONSTRAINT unique_token_hash UNIQUE (token_hash);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'refresh_token_user_id_fkey') THEN
+        ALTER TABLE refresh_token ADD CONSTRAINT refresh_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'revoked_token_pkey') THEN
+        ALTER TABLE revoked_token ADD CONSTRAINT revoked_token_pkey PRIMARY KEY (jti);
+    END IF;
+END
+$$;
@@ -0,0 +1,31 @@
+DROP INDEX IF EXISTS revoked_token_expires_at_idx;
+DROP INDEX IF EXISTS refresh_token_user_id_idx;
+DROP INDEX IF EXISTS transaction_created_at_idx;
+DROP INDEX IF EXISTS transaction_detail_menu_id_idx;
+DROP INDEX IF EXISTS transaction_detail_transaction_id_idx;
+
+ALTER TABLE transaction_detail
+    DROP CONSTRAINT IF EXISTS transaction_detail_menu_id_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_detail_transaction_id_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_detail_qty_check,
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN subtotal DROP NOT NULL,
+    ALTER COLUMN subtotal DROP DEFAULT,
+    ALTER COLUMN qty DROP NOT NULL,
+    ALTER COLUMN menu_id DROP NOT NULL,
+    ALTER COLUMN transaction_id DROP NOT NULL,
+    DROP COLUMN IF EXISTS id;
+
+ALTER TABLE transaction
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN total_price DROP NOT NULL,
+    ALTER COLUMN total_price DROP DEFAULT;
+
+ALTER TABLE menu
+    DROP CONSTRAINT IF EXISTS menu_stock_check,
+    DROP CONSTRAINT IF EXISTS menu_price_check,
+    ALTER COLUMN stock DROP NOT NULL,
+    ALTER COLUMN stock DROP DEFAULT,
+    ALTER COLUMN price DROP NOT NULL,
+    ALTER COLUMN price DROP DEFAULT,
+    DROP CONSTRAINT IF EXISTS menu_pkey;
@@ -0,0 +1,43 @@
+-- foreign keys and checks are NOT VALID so rows written before this
+-- migration do not block it, new rows are checked.
+
+ALTER TABLE menu ADD CONSTRAINT menu_pkey PRIMARY KEY (id);
+
+UPDATE menu SET price = 0 WHERE price IS NULL;
+UPDATE menu SET stock = 0 WHERE stock IS NULL;
+ALTER TABLE menu
+    ALTER COLUMN price SET DEFAULT 0,
+    ALTER COLUMN price SET NOT NULL,
+    ALTER COLUMN stock SET DEFAULT 0,
+    ALTER COLUMN stock SET NOT NULL,
+    ADD CONSTRAINT menu_price_check CHECK (price >= 0) NOT VALID,
+    ADD CONSTRAINT menu_stock_check CHECK (stock >= 0) NOT VALID;
+
+UPDATE transaction SET total_price = 0 WHERE total_price IS NULL;
+UPDATE transaction SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE transaction
+    ALTER COLUMN total_price SET DEFAULT 0,
+    ALTER COLUMN total_price SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL;
+
+ALTER TABLE transaction_detail ADD COLUMN id bigserial;
+ALTER TABLE transaction_detail ADD CONSTRAINT transaction_detail_pkey PRIMARY KEY (id);
+
+UPDATE transaction_detail SET subtotal = 0 WHERE subtotal IS NULL;
+UPDATE transaction_detail SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE transaction_detail
+    ALTER COLUMN transaction_id SET NOT NULL,
+    ALTER COLUMN menu_id SET NOT NULL,
+    ALTER COLUMN qty SET NOT NULL,
+    ALTER COLUMN subtotal SET DEFAULT 0,
+    ALTER COLUMN subtotal SET NOT NULL,
+    ALTER COLUMN created_at SET NOT NULL,
+    ADD CONSTRAINT transaction_detail_qty_check CHECK (qty > 0) NOT VALID,
+    ADD CONSTRAINT transaction_detail_transaction_id_fkey FOREIGN KEY (transaction_id) REFERENCES transaction(id) ON DELETE CASCADE NOT VALID,
+    ADD CONSTRAINT transaction_detail_menu_id_fkey FOREIGN KEY (menu_id) REFERENCES menu(id) NOT VALID;
+
+CREATE INDEX transaction_detail_transaction_id_idx ON transaction_detail (transaction_id);
+CREATE INDEX transaction_detail_menu_id_idx ON transaction_detail (menu_id);
+CREATE INDEX transaction_created_at_idx ON transaction (created_at);
+CREATE INDEX refresh_token_user_id_idx ON refresh_token (user_id);
+CREATE INDEX revoked_token_expires_at_idx ON revoked_token (expires_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS transaction_refund_item;
+DROP TABLE IF EXISTS transaction_refund;
+
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_status_check,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,28 @@
+-- a transaction row is never changed after it is paid except for its
+-- status, voids and refunds are recorded next to it.
+
+ALTER TABLE transaction
+    ADD COLUMN status character varying(20) NOT NULL DEFAULT 'paid',
+    ADD CONSTRAINT transaction_status_check CHECK (status IN ('paid', 'voided', 'partially_refunded', 'refunded'));
+
+CREATE TABLE transaction_refund (
+    id character varying(60) PRIMARY KEY,
+    transaction_id character varying(60) NOT NULL REFERENCES transaction(id),
+    kind character varying(10) NOT NULL CHECK (kind IN ('void', 'refund')),
+    reason text NOT NULL,
+    user_id character varying(255) NOT NULL REFERENCES users(id),
+    amount integer NOT NULL CHECK (amount >= 0),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE transaction_refund_item (
+    id bigserial PRIMARY KEY,
+    refund_id character varying(60) NOT NULL REFERENCES transaction_refund(id) ON DELETE CASCADE,
+    detail_id bigint NOT NULL REFERENCES transaction_detail(id),
+    menu_id character varying(60) NOT NULL,
+    qty integer NOT NULL CHECK (qty > 0),
+    amount integer NOT NULL CHECK (amount >= 0)
+);
+
+CREATE INDEX transaction_refund_transaction_id_idx ON transaction_refund (transaction_id);
+CREATE INDEX transaction_refund_item_detail_id_idx ON transaction_refund_item (detail_id);
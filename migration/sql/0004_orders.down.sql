@@ -0,0 +1,11 @@
+ALTER TABLE menu
+    DROP CONSTRAINT IF EXISTS menu_reserved_check,
+    DROP COLUMN IF EXISTS reserved;
+
+DROP INDEX IF EXISTS transaction_status_idx;
+
+-- orders that were never paid have no status before this migration
+UPDATE transaction SET status = 'voided' WHERE status IN ('open', 'sent_to_kitchen', 'served', 'cancelled');
+ALTER TABLE transaction DROP CONSTRAINT IF EXISTS transaction_status_check;
+ALTER TABLE transaction ADD CONSTRAINT transaction_status_check
+    CHECK (status IN ('paid', 'voided', 'partially_refunded', 'refunded'));
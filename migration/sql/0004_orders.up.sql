@@ -0,0 +1,12 @@
+-- orders can be opened before they are paid. reserved is the stock held by
+-- orders sent to the kitchen, it is taken from stock when they are paid.
+
+ALTER TABLE transaction DROP CONSTRAINT IF EXISTS transaction_status_check;
+ALTER TABLE transaction ADD CONSTRAINT transaction_status_check
+    CHECK (status IN ('open', 'sent_to_kitchen', 'served', 'paid', 'cancelled', 'voided', 'partially_refunded', 'refunded'));
+
+CREATE INDEX transaction_status_idx ON transaction (status);
+
+ALTER TABLE menu
+    ADD COLUMN reserved integer NOT NULL DEFAULT 0,
+    ADD CONSTRAINT menu_reserved_check CHECK (reserved >= 0 AND reserved <= stock) NOT VALID;
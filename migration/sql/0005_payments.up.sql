@@ -0,0 +1,15 @@
+-- how each sale was paid, a split payment has a row per method. tendered
+-- and change are only kept for cash. sales made before this migration have
+-- no payments.
+
+CREATE TABLE payment (
+    id bigserial PRIMARY KEY,
+    transaction_id character varying(60) NOT NULL REFERENCES transaction(id),
+    method character varying(20) NOT NULL CHECK (method IN ('cash', 'qris', 'bank_transfer', 'e_wallet')),
+    amount integer NOT NULL CHECK (amount > 0),
+    tendered integer CHECK (tendered >= amount),
+    change integer CHECK (change >= 0),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_transaction_id_idx ON payment (transaction_id);
@@ -0,0 +1,11 @@
+ALTER TABLE transaction_detail DROP COLUMN IF EXISTS discount_amount;
+
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_total_check,
+    DROP COLUMN IF EXISTS promo_code,
+    DROP COLUMN IF EXISTS tax,
+    DROP COLUMN IF EXISTS service_charge,
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS promo;
@@ -0,0 +1,33 @@
+-- the price breakdown of a transaction: total_price is subtotal minus
+-- discount_amount plus service_charge and tax. discount_amount is the line
+-- discounts plus the order discount or promo. older sales had no discounts
+-- or charges, their subtotal is their total.
+
+CREATE TABLE promo (
+    code character varying(40) PRIMARY KEY,
+    discount_type character varying(10) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value integer NOT NULL CHECK (discount_value > 0),
+    starts_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at timestamp with time zone,
+    usage_limit integer CHECK (usage_limit > 0),
+    used integer NOT NULL DEFAULT 0 CHECK (used >= 0),
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (discount_type <> 'percent' OR discount_value <= 100),
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+ALTER TABLE transaction
+    ADD COLUMN subtotal integer NOT NULL DEFAULT 0,
+    ADD COLUMN discount_amount integer NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    ADD COLUMN service_charge integer NOT NULL DEFAULT 0 CHECK (service_charge >= 0),
+    ADD COLUMN tax integer NOT NULL DEFAULT 0 CHECK (tax >= 0),
+    ADD COLUMN promo_code character varying(40) REFERENCES promo(code);
+
+UPDATE transaction SET subtotal = total_price;
+
+ALTER TABLE transaction ADD CONSTRAINT transaction_total_check
+    CHECK (total_price = subtotal - discount_amount + service_charge + tax);
+
+ALTER TABLE transaction_detail
+    ADD COLUMN discount_amount integer NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
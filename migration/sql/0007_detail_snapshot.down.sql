@@ -0,0 +1,3 @@
+ALTER TABLE transaction_detail
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS menu_name;
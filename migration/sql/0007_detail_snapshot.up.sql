@@ -0,0 +1,11 @@
+-- every line keeps the name and price of its menu at the time of the sale,
+-- so renaming, repricing or deleting a menu does not change the history.
+-- older lines get the current name of their menu and the price they were
+-- sold at.
+
+ALTER TABLE transaction_detail
+    ADD COLUMN menu_name character varying(255) NOT NULL DEFAULT '',
+    ADD COLUMN unit_price integer NOT NULL DEFAULT 0 CHECK (unit_price >= 0);
+
+UPDATE transaction_detail d SET menu_name = m.name FROM menu m WHERE m.id = d.menu_id;
+UPDATE transaction_detail SET unit_price = subtotal / qty WHERE qty > 0;
@@ -0,0 +1,3 @@
+-- archived menus and users become live again
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE menu DROP COLUMN IF EXISTS deleted_at;
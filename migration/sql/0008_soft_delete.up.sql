@@ -0,0 +1,5 @@
+-- menus and users are archived instead of deleted, so the transactions and
+-- refunds that point at them keep working. an archived row can be restored.
+
+ALTER TABLE menu ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE users ADD COLUMN deleted_at timestamp with time zone;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS menu_tags_idx;
+DROP INDEX IF EXISTS menu_category_idx;
+
+ALTER TABLE menu
+    DROP CONSTRAINT IF EXISTS menu_available_check,
+    DROP COLUMN IF EXISTS available_until,
+    DROP COLUMN IF EXISTS available_from,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS category;
@@ -0,0 +1,22 @@
+-- menus are grouped by category, shown in display_order, and can carry
+-- free-form tags and a time of day they are served. a window whose end is
+-- before its start runs past midnight. deleting a category leaves its menus
+-- without one.
+
+CREATE TABLE category (
+    id character varying(60) PRIMARY KEY,
+    name character varying(100) NOT NULL UNIQUE,
+    display_order integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE menu
+    ADD COLUMN category_id character varying(60) REFERENCES category(id) ON DELETE SET NULL,
+    ADD COLUMN tags text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN available_from time,
+    ADD COLUMN available_until time,
+    ADD CONSTRAINT menu_available_check
+        CHECK ((available_from IS NULL) = (available_until IS NULL) AND (available_from IS NULL OR available_from <> available_until));
+
+CREATE INDEX menu_category_idx ON menu (category_id);
+CREATE INDEX menu_tags_idx ON menu USING gin (tags);
@@ -0,0 +1,4 @@
+ALTER TABLE transaction_detail DROP COLUMN IF EXISTS modifiers;
+
+DROP TABLE IF EXISTS modifier_option;
+DROP TABLE IF EXISTS modifier_group;
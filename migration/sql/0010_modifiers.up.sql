@@ -0,0 +1,25 @@
+-- a menu can have modifier groups, like the portion size or the add-ons,
+-- each with options that change the price. a line keeps the options it was
+-- sold with as a snapshot in modifiers, so changing or removing an option
+-- does not change old sales.
+
+CREATE TABLE modifier_group (
+    id bigserial PRIMARY KEY,
+    menu_id character varying(60) NOT NULL REFERENCES menu(id) ON DELETE CASCADE,
+    name character varying(100) NOT NULL,
+    min_select integer NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select integer NOT NULL DEFAULT 1 CHECK (max_select >= 1 AND max_select >= min_select),
+    display_order integer NOT NULL DEFAULT 0,
+    UNIQUE (menu_id, name)
+);
+
+CREATE TABLE modifier_option (
+    id bigserial PRIMARY KEY,
+    group_id bigint NOT NULL REFERENCES modifier_group(id) ON DELETE CASCADE,
+    name character varying(100) NOT NULL,
+    price_delta integer NOT NULL DEFAULT 0,
+    display_order integer NOT NULL DEFAULT 0,
+    UNIQUE (group_id, name)
+);
+
+ALTER TABLE transaction_detail ADD COLUMN modifiers jsonb NOT NULL DEFAULT '[]';
@@ -0,0 +1,23 @@
+-- every change of menu stock is a movement, qty is positive when stock
+-- comes in and negative when it goes out. the stock of a menu is the sum of
+-- its movements, the stock menus had before the ledger is their opening
+-- movement. sales, refunds and voids point at their transaction, the
+-- movements made by hand at the user who made them.
+
+CREATE TABLE stock_movement (
+    id bigserial PRIMARY KEY,
+    menu_id character varying(60) NOT NULL REFERENCES menu(id),
+    qty integer NOT NULL CHECK (qty <> 0),
+    reason character varying(20) NOT NULL
+        CHECK (reason IN ('opening', 'restock', 'waste', 'adjustment', 'sale', 'refund', 'void')),
+    note text,
+    transaction_id character varying(60) REFERENCES transaction(id),
+    user_id character varying(255) REFERENCES users(id),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX stock_movement_menu_id_idx ON stock_movement (menu_id, created_at);
+CREATE INDEX stock_movement_transaction_id_idx ON stock_movement (transaction_id);
+
+INSERT INTO stock_movement (menu_id, qty, reason, note)
+SELECT id, stock, 'opening', 'stock before the ledger' FROM menu WHERE stock <> 0;
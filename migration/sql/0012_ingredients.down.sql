@@ -0,0 +1,11 @@
+ALTER TABLE transaction_detail DROP COLUMN IF EXISTS ingredients;
+
+DROP INDEX IF EXISTS stock_movement_ingredient_id_idx;
+DELETE FROM stock_movement WHERE menu_id IS NULL;
+ALTER TABLE stock_movement
+    DROP CONSTRAINT IF EXISTS stock_movement_item_check,
+    DROP COLUMN IF EXISTS ingredient_id,
+    ALTER COLUMN menu_id SET NOT NULL;
+
+DROP TABLE IF EXISTS recipe;
+DROP TABLE IF EXISTS ingredient;
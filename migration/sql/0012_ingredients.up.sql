@@ -0,0 +1,35 @@
+-- menus can be made of ingredients. a recipe is how much of each ingredient
+-- one portion uses, counted in the unit of the ingredient. a menu with a
+-- recipe is sold from the stock of its ingredients instead of its own. a
+-- line keeps the recipe it was sold with in ingredients, so the stock it
+-- reserved is the stock it takes or gives back later.
+
+CREATE TABLE ingredient (
+    id character varying(60) PRIMARY KEY,
+    name character varying(100) NOT NULL UNIQUE,
+    unit character varying(10) NOT NULL CHECK (unit IN ('g', 'ml', 'pcs')),
+    stock integer NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    reserved integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT ingredient_reserved_check CHECK (reserved >= 0 AND reserved <= stock)
+);
+
+CREATE TABLE recipe (
+    menu_id character varying(60) NOT NULL REFERENCES menu(id) ON DELETE CASCADE,
+    ingredient_id character varying(60) NOT NULL REFERENCES ingredient(id),
+    qty integer NOT NULL CHECK (qty > 0),
+    PRIMARY KEY (menu_id, ingredient_id)
+);
+
+CREATE INDEX recipe_ingredient_id_idx ON recipe (ingredient_id);
+
+-- a movement is of a menu or of an ingredient, an ingredient with movements
+-- cannot be deleted so its stock can always be rebuilt from them
+ALTER TABLE stock_movement
+    ALTER COLUMN menu_id DROP NOT NULL,
+    ADD COLUMN ingredient_id character varying(60) REFERENCES ingredient(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT stock_movement_item_check CHECK ((menu_id IS NULL) <> (ingredient_id IS NULL));
+
+CREATE INDEX stock_movement_ingredient_id_idx ON stock_movement (ingredient_id, created_at);
+
+ALTER TABLE transaction_detail ADD COLUMN ingredients jsonb NOT NULL DEFAULT '[]';
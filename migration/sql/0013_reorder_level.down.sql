@@ -0,0 +1,2 @@
+ALTER TABLE ingredient DROP COLUMN IF EXISTS reorder_level;
+ALTER TABLE menu DROP COLUMN IF EXISTS reorder_level;
@@ -0,0 +1,6 @@
+-- the reorder level of a menu or an ingredient is the stock under which it
+-- shows in the stock alerts, NULL for no alerts. a menu with a recipe is
+-- alerted through its ingredients.
+
+ALTER TABLE menu ADD COLUMN reorder_level integer CHECK (reorder_level >= 0);
+ALTER TABLE ingredient ADD COLUMN reorder_level integer CHECK (reorder_level >= 0);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS stock_movement_purchase_order_id_idx;
+-- purchases stay in the ledger as restocks
+UPDATE stock_movement SET reason = 'restock', note = COALESCE(note, 'purchase order ' || purchase_order_id)
+WHERE reason = 'purchase';
+ALTER TABLE stock_movement
+    DROP COLUMN IF EXISTS unit_cost,
+    DROP COLUMN IF EXISTS purchase_order_id,
+    DROP CONSTRAINT IF EXISTS stock_movement_reason_check,
+    ADD CONSTRAINT stock_movement_reason_check
+        CHECK (reason IN ('opening', 'restock', 'waste', 'adjustment', 'sale', 'refund', 'void'));
+
+DROP TABLE IF EXISTS purchase_order_line;
+DROP TABLE IF EXISTS purchase_order;
+DROP TABLE IF EXISTS supplier;
@@ -0,0 +1,51 @@
+-- stock is bought from suppliers with purchase orders. a line is of a menu
+-- or of an ingredient, with the qty ordered at an expected unit cost.
+-- receiving a line is a purchase movement that records what one unit
+-- actually cost, received_qty and received_cost add them up per line.
+
+CREATE TABLE supplier (
+    id character varying(60) PRIMARY KEY,
+    name character varying(100) NOT NULL UNIQUE,
+    phone character varying(30),
+    email character varying(100),
+    address text,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE purchase_order (
+    id character varying(60) PRIMARY KEY,
+    supplier_id character varying(60) NOT NULL REFERENCES supplier(id),
+    status character varying(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'ordered', 'partially_received', 'received')),
+    note text,
+    user_id character varying(255) REFERENCES users(id),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ordered_at timestamp with time zone,
+    received_at timestamp with time zone
+);
+
+CREATE INDEX purchase_order_supplier_id_idx ON purchase_order (supplier_id, created_at);
+
+CREATE TABLE purchase_order_line (
+    id bigserial PRIMARY KEY,
+    purchase_order_id character varying(60) NOT NULL REFERENCES purchase_order(id) ON DELETE CASCADE,
+    menu_id character varying(60) REFERENCES menu(id),
+    ingredient_id character varying(60) REFERENCES ingredient(id),
+    qty integer NOT NULL CHECK (qty > 0),
+    unit_cost integer NOT NULL CHECK (unit_cost >= 0),
+    received_qty integer NOT NULL DEFAULT 0,
+    received_cost bigint NOT NULL DEFAULT 0,
+    CONSTRAINT purchase_order_line_item_check CHECK ((menu_id IS NULL) <> (ingredient_id IS NULL)),
+    CONSTRAINT purchase_order_line_received_check CHECK (received_qty >= 0 AND received_qty <= qty)
+);
+
+CREATE INDEX purchase_order_line_purchase_order_id_idx ON purchase_order_line (purchase_order_id);
+
+ALTER TABLE stock_movement
+    DROP CONSTRAINT stock_movement_reason_check,
+    ADD CONSTRAINT stock_movement_reason_check
+        CHECK (reason IN ('opening', 'restock', 'waste', 'adjustment', 'sale', 'refund', 'void', 'purchase')),
+    ADD COLUMN purchase_order_id character varying(60) REFERENCES purchase_order(id),
+    ADD COLUMN unit_cost integer CHECK (unit_cost >= 0);
+
+CREATE INDEX stock_movement_purchase_order_id_idx ON stock_movement (purchase_order_id);
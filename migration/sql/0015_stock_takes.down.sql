@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS stock_movement_stock_take_id_idx;
+-- the adjustments of approved stock takes stay in the ledger
+UPDATE stock_movement SET note = COALESCE(note, 'stock take ' || stock_take_id)
+WHERE stock_take_id IS NOT NULL;
+ALTER TABLE stock_movement DROP COLUMN IF EXISTS stock_take_id;
+
+DROP TABLE IF EXISTS stock_take_line;
+DROP TABLE IF EXISTS stock_take;
@@ -0,0 +1,43 @@
+-- a stock take is a physical count of the stock. every line is what was
+-- counted of a menu or an ingredient against the stock the ledger expected
+-- at the time, the variance valued at the unit cost of the last purchase of
+-- the item. approving a stock take adjusts the stock by the variances. a
+-- counted ingredient cannot be deleted, so past shrinkage stays as it was.
+
+CREATE TABLE stock_take (
+    id character varying(60) PRIMARY KEY,
+    status character varying(20) NOT NULL DEFAULT 'counting'
+        CHECK (status IN ('counting', 'approved', 'cancelled')),
+    note text,
+    user_id character varying(255) REFERENCES users(id),
+    approved_by character varying(255) REFERENCES users(id),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    approved_at timestamp with time zone
+);
+
+CREATE INDEX stock_take_approved_at_idx ON stock_take (approved_at) WHERE status = 'approved';
+
+CREATE TABLE stock_take_line (
+    id bigserial PRIMARY KEY,
+    stock_take_id character varying(60) NOT NULL REFERENCES stock_take(id) ON DELETE CASCADE,
+    menu_id character varying(60) REFERENCES menu(id),
+    ingredient_id character varying(60) REFERENCES ingredient(id) ON DELETE RESTRICT,
+    expected integer NOT NULL,
+    counted integer NOT NULL CHECK (counted >= 0),
+    variance integer NOT NULL,
+    unit_cost integer CHECK (unit_cost >= 0),
+    variance_value bigint,
+    user_id character varying(255) REFERENCES users(id),
+    counted_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT stock_take_line_item_check CHECK ((menu_id IS NULL) <> (ingredient_id IS NULL)),
+    CONSTRAINT stock_take_line_variance_check CHECK (variance = counted - expected)
+);
+
+-- an item is counted once per stock take, a recount replaces it
+CREATE UNIQUE INDEX stock_take_line_menu_idx ON stock_take_line (stock_take_id, menu_id);
+CREATE UNIQUE INDEX stock_take_line_ingredient_idx ON stock_take_line (stock_take_id, ingredient_id);
+
+ALTER TABLE stock_movement
+    ADD COLUMN stock_take_id character varying(60) REFERENCES stock_take(id);
+
+CREATE INDEX stock_movement_stock_take_id_idx ON stock_movement (stock_take_id);
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Category groups menus, categories are listed by DisplayOrder and then by
+// name.
+type Category struct {
+	Id           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name" binding:"required"`
+	DisplayOrder int       `json:"display_order" db:"display_order"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// the units ingredients are counted in, recipes use the unit of their
+// ingredient
+var IngredientUnits = []string{"g", "ml", "pcs"}
+
+// Ingredient is what menus with a recipe are made of. Stock is set when an
+// ingredient is created, after that it only changes with stock movements.
+type Ingredient struct {
+	Id    string `json:"id" db:"id"`
+	Name  string `json:"name" db:"name" binding:"required"`
+	Unit  string `json:"unit" db:"unit" binding:"required"`
+	Stock int    `json:"stock" db:"stock"`
+	// Reserved is the part of Stock held by orders sent to the kitchen
+	Reserved int `json:"reserved" db:"reserved"`
+	// ReorderLevel is the available stock under which the ingredient shows
+	// in the stock alerts, nil for no alerts
+	ReorderLevel *int      `json:"reorder_level" db:"reorder_level"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Available is the stock that can still be used.
+func (i Ingredient) Available() int {
+	return i.Stock - i.Reserved
+}
+
+// IngredientUsage counts what refers to an ingredient in its unit, it
+// cannot be deleted or change its unit while any of it is there.
+type IngredientUsage struct {
+	Movements      int `db:"movements"`
+	Recipes        int `db:"recipes"`
+	PurchaseLines  int `db:"purchase_lines"`
+	StockTakeLines int `db:"stock_take_lines"`
+}
+
+// Uses names what refers to the ingredient, nothing when it is unused.
+func (u IngredientUsage) Uses() []string {
+	uses := []string{}
+	for _, use := range []struct {
+		name  string
+		count int
+	}{
+		{"stock movements", u.Movements},
+		{"recipes", u.Recipes},
+		{"purchase orders", u.PurchaseLines},
+		{"stock takes", u.StockTakeLines},
+	} {
+		if use.count > 0 {
+			uses = append(uses, use.name)
+		}
+	}
+	return uses
+}
+
+// RecipeLine is how much of an ingredient one portion of a menu uses. Name
+// and Unit of the ingredient are only read.
+type RecipeLine struct {
+	MenuId       string `json:"-" db:"menu_id"`
+	IngredientId string `json:"ingredient_id" db:"ingredient_id" binding:"required"`
+	Qty          int    `json:"qty" db:"qty" binding:"required"`
+	Name         string `json:"name" db:"name"`
+	Unit         string `json:"unit" db:"unit"`
+}
+
+// Recipe is the body that replaces the recipe of a menu, no lines removes
+// it.
+type Recipe struct {
+	Ingredients []RecipeLine `json:"ingredients"`
+}
+
+// LineIngredient is how much of an ingredient one portion of an order line
+// uses, as the recipe was when the line was added.
+type LineIngredient struct {
+	IngredientId string `json:"ingredient_id"`
+	Qty          int    `json:"qty"`
+}
+
+// LineIngredients are kept as json on the line.
+type LineIngredients []LineIngredient
+
+func (m LineIngredients) Value() (driver.Value, error) {
+	if m == nil {
+		m = LineIngredients{}
+	}
+	return json.Marshal(m)
+}
+
+func (m *LineIngredients) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	}
+	return fmt.Errorf("cannot scan %T into ingredients", src)
+}
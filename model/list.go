@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// ListQuery is the paging and sorting of a list request. Page is 1 based
+// and ignored when Cursor, taken from the previous page, is set.
+type ListQuery struct {
+	Page   int
+	Limit  int
+	Cursor string
+	Sort   string
+	Order  string
+}
+
+type MenuFilter struct {
+	ListQuery
+	Name     string
+	MinPrice *int
+	MaxPrice *int
+	InStock  *bool
+	Category string
+	Tag      string
+	// AvailableNow keeps the menus served at the current time of day, the
+	// usecase turns it into AvailableAt (15:04)
+	AvailableNow bool
+	AvailableAt  string
+	// IncludeDeleted lists the archived menus too
+	IncludeDeleted bool
+}
+
+type UserFilter struct {
+	ListQuery
+	Name string
+	Role string
+	// IncludeDeleted lists the archived users too
+	IncludeDeleted bool
+}
+
+// TransactionFilter keeps transactions created from From up to, not
+// including, To.
+type TransactionFilter struct {
+	ListQuery
+	From     *time.Time
+	To       *time.Time
+	MinTotal *int
+	Status   string
+}
+
+// PageInfo is sent along with every list. NextCursor is empty on the last
+// page, Page is empty when the list was read by cursor.
+type PageInfo struct {
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -1,9 +1,41 @@
 package model
 
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
 type Menu struct {
 	Id    string `json:"id" form:"id" db:"id"`
 	Name  string `json:"name" form:"name" db:"name" binding:"required"`
 	Price int    `json:"price" form:"price" db:"price" binding:"required"`
-	Stock int    `json:"stock" form:"stock" db:"stock" binding:"required"`
+	// Stock is set when a menu is created, after that it only changes with
+	// stock movements
+	Stock int    `json:"stock" form:"stock" db:"stock"`
 	Image string `json:"image" form:"image" db:"image"`
+	// Reserved is the part of Stock held by orders sent to the kitchen
+	Reserved   int            `json:"reserved" form:"-" db:"reserved"`
+	CategoryId *string        `json:"category_id" form:"category_id" db:"category_id"`
+	Tags       pq.StringArray `json:"tags" form:"tags" db:"tags"`
+	// AvailableFrom and AvailableUntil are the time of day (15:04) the menu
+	// is served, every day. both are empty for a menu served all day.
+	AvailableFrom  *string `json:"available_from" form:"available_from" db:"available_from"`
+	AvailableUntil *string `json:"available_until" form:"available_until" db:"available_until"`
+	// Portions is how many can be made from the ingredients of a menu with
+	// a recipe, its own Stock is not used then
+	Portions *int `json:"portions,omitempty" form:"-" db:"portions"`
+	// ReorderLevel is the available stock under which the menu shows in the
+	// stock alerts, nil for no alerts
+	ReorderLevel *int `json:"reorder_level" form:"reorder_level" db:"reorder_level"`
+	// DeletedAt is set while the menu is archived, it cannot be sold then
+	DeletedAt *time.Time `json:"deleted_at,omitempty" form:"-" db:"deleted_at"`
+}
+
+// Available is the stock that can still be ordered.
+func (m Menu) Available() int {
+	if m.Portions != nil {
+		return *m.Portions
+	}
+	return m.Stock - m.Reserved
 }
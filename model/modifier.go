@@ -0,0 +1,81 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ModifierGroup is a choice on a menu, like the portion size or the
+// add-ons. an order line picks from MinSelect up to MaxSelect of its
+// options, a group with MinSelect 0 can be skipped.
+type ModifierGroup struct {
+	Id           int64            `json:"id" db:"id"`
+	MenuId       string           `json:"menu_id" db:"menu_id"`
+	Name         string           `json:"name" db:"name" binding:"required"`
+	MinSelect    int              `json:"min_select" db:"min_select"`
+	MaxSelect    int              `json:"max_select" db:"max_select"`
+	DisplayOrder int              `json:"display_order" db:"display_order"`
+	Options      []ModifierOption `json:"options" db:"-"`
+}
+
+// ModifierOption adds PriceDelta to the unit price of a line, it can be
+// negative for a smaller portion.
+type ModifierOption struct {
+	Id           int64  `json:"id" db:"id"`
+	GroupId      int64  `json:"group_id" db:"group_id"`
+	Name         string `json:"name" db:"name"`
+	PriceDelta   int    `json:"price_delta" db:"price_delta"`
+	DisplayOrder int    `json:"display_order" db:"display_order"`
+}
+
+// LineModifier is an option chosen on an order line. only OptionId is read
+// from a request, the rest is the option as it was sold.
+type LineModifier struct {
+	OptionId   int64  `json:"option_id"`
+	Group      string `json:"group"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// LineModifiers are kept as json on the line.
+type LineModifiers []LineModifier
+
+func (m LineModifiers) Value() (driver.Value, error) {
+	if m == nil {
+		m = LineModifiers{}
+	}
+	return json.Marshal(m)
+}
+
+func (m *LineModifiers) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	}
+	return fmt.Errorf("cannot scan %T into modifiers", src)
+}
+
+// String lists the chosen options, like "large, extra bakso".
+func (m LineModifiers) String() string {
+	names := make([]string, len(m))
+	for i, each := range m {
+		names[i] = each.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// PriceDelta is what the modifiers add to the unit price.
+func (m LineModifiers) PriceDelta() int {
+	delta := 0
+	for _, each := range m {
+		delta += each.PriceDelta
+	}
+	return delta
+}
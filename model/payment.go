@@ -0,0 +1,42 @@
+package model
+
+const (
+	PaymentCash         = "cash"
+	PaymentQRIS         = "qris"
+	PaymentBankTransfer = "bank_transfer"
+	PaymentEWallet      = "e_wallet"
+
+	// the method reports use for sales made before payments were recorded
+	PaymentUnrecorded = "unrecorded"
+)
+
+var PaymentMethods = []string{PaymentCash, PaymentQRIS, PaymentBankTransfer, PaymentEWallet}
+
+// Payment is the part of the total paid with one method. Tendered, the
+// money handed over, and Change are only set for cash. a cash payment may
+// leave out Amount, then it pays what the other payments leave.
+type Payment struct {
+	Id            int64  `json:"id,omitempty" db:"id"`
+	TransactionId string `json:"transaction_id,omitempty" db:"transaction_id"`
+	Method        string `json:"method" db:"method"`
+	Amount        int    `json:"amount" db:"amount"`
+	Tendered      *int   `json:"tendered,omitempty" db:"tendered"`
+	Change        *int   `json:"change,omitempty" db:"change"`
+}
+
+// PaymentRequest is the body that pays an order, with its order discount
+// or promo code.
+type PaymentRequest struct {
+	Payments  []Payment `json:"payments"`
+	Discount  *Discount `json:"discount"`
+	PromoCode string    `json:"promo_code"`
+}
+
+// PaymentSales is the revenue of one payment method.
+type PaymentSales struct {
+	Method       string `json:"method" db:"method"`
+	Transactions int    `json:"transactions" db:"transactions"`
+	Revenue      int    `json:"revenue" db:"revenue"`
+	Refunded     int    `json:"refunded" db:"refunded"`
+	NetRevenue   int    `json:"net_revenue" db:"net_revenue"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// Discount takes a percent of, or a fixed amount off, an order line or a
+// whole order. it is never more than what it is taken off.
+type Discount struct {
+	Type  string `json:"type" db:"discount_type"`
+	Value int    `json:"value" db:"discount_value"`
+}
+
+// Promo is a code that gives an order discount while it is active, from
+// StartsAt up to EndsAt, and used less than UsageLimit times. a promo
+// without EndsAt or UsageLimit has no end or limit.
+type Promo struct {
+	Code       string `json:"code" db:"code"`
+	Discount   `json:"discount"`
+	StartsAt   time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	UsageLimit *int       `json:"usage_limit,omitempty" db:"usage_limit"`
+	Used       int        `json:"used" db:"used"`
+	Active     bool       `json:"active" db:"active"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
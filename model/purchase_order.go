@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// a purchase order is a draft until it is sent to the supplier as ordered,
+// then it is partially received until every line came in.
+const (
+	PurchaseDraft             = "draft"
+	PurchaseOrdered           = "ordered"
+	PurchasePartiallyReceived = "partially_received"
+	PurchaseReceived          = "received"
+)
+
+// PurchaseOrder is stock ordered from a supplier. ExpectedCost is what the
+// lines cost at their unit cost, ReceivedCost what came in so far cost.
+// Lines are only loaded with one purchase order.
+type PurchaseOrder struct {
+	Id           string              `json:"id" db:"id"`
+	SupplierId   string              `json:"supplier_id" db:"supplier_id" binding:"required"`
+	SupplierName string              `json:"supplier_name" db:"supplier_name"`
+	Status       string              `json:"status" db:"status"`
+	Note         *string             `json:"note" db:"note"`
+	UserId       *string             `json:"user_id,omitempty" db:"user_id"`
+	ExpectedCost int                 `json:"expected_cost" db:"expected_cost"`
+	ReceivedCost int                 `json:"received_cost" db:"received_cost"`
+	CreatedAt    time.Time           `json:"created_at" db:"created_at"`
+	OrderedAt    *time.Time          `json:"ordered_at" db:"ordered_at"`
+	ReceivedAt   *time.Time          `json:"received_at" db:"received_at"`
+	Lines        []PurchaseOrderLine `json:"lines,omitempty" db:"-"`
+}
+
+// PurchaseOrderLine is a qty of a menu or of an ingredient, UnitCost is
+// what one unit is expected to cost. Name is only read.
+type PurchaseOrderLine struct {
+	Id              int64   `json:"id" db:"id"`
+	PurchaseOrderId string  `json:"-" db:"purchase_order_id"`
+	MenuId          *string `json:"menu_id,omitempty" db:"menu_id"`
+	IngredientId    *string `json:"ingredient_id,omitempty" db:"ingredient_id"`
+	Name            string  `json:"name" db:"name"`
+	Qty             int     `json:"qty" db:"qty"`
+	UnitCost        int     `json:"unit_cost" db:"unit_cost"`
+	ReceivedQty     int     `json:"received_qty" db:"received_qty"`
+	ReceivedCost    int     `json:"received_cost" db:"received_cost"`
+}
+
+// Item is the menu or the ingredient the line is of.
+func (l PurchaseOrderLine) Item() StockItem {
+	if l.IngredientId != nil {
+		return StockItem{Kind: StockItemIngredient, Id: *l.IngredientId}
+	}
+	if l.MenuId != nil {
+		return StockItem{Kind: StockItemMenu, Id: *l.MenuId}
+	}
+	return StockItem{}
+}
+
+// PurchaseFilter narrows the purchase order list, empty fields match all.
+type PurchaseFilter struct {
+	SupplierId string
+	Status     string
+}
+
+// ReceiveRequest is what came in for a purchase order, a line received
+// without a UnitCost cost what was expected.
+type ReceiveRequest struct {
+	Lines []ReceiveLine `json:"lines" binding:"required"`
+	Note  string        `json:"note"`
+}
+
+type ReceiveLine struct {
+	LineId   int64 `json:"line_id" binding:"required"`
+	Qty      int   `json:"qty" binding:"required"`
+	UnitCost *int  `json:"unit_cost"`
+}
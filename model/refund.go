@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+const (
+	RefundKindVoid   = "void"
+	RefundKindRefund = "refund"
+)
+
+// Refund gives back all (a void) or some of the items of a transaction.
+// Amount is the money given back, UserId who did it.
+type Refund struct {
+	Id            string       `json:"id" db:"id"`
+	TransactionId string       `json:"transaction_id" db:"transaction_id"`
+	Kind          string       `json:"kind" db:"kind"`
+	Reason        string       `json:"reason" db:"reason"`
+	UserId        string       `json:"user_id" db:"user_id"`
+	Amount        int          `json:"amount" db:"amount"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	Items         []RefundItem `json:"items" db:"-"`
+}
+
+// RefundItem is the part of a transaction item that is given back.
+type RefundItem struct {
+	RefundId string `json:"-" db:"refund_id"`
+	DetailId int64  `json:"detail_id" db:"detail_id"`
+	MenuId   string `json:"menu_id" db:"menu_id"`
+	Qty      int    `json:"qty" db:"qty"`
+	Amount   int    `json:"amount" db:"amount"`
+}
+
+// RefundRequest is the body of a void or a refund. a refund without items
+// gives back everything that is not refunded yet, a void has no items.
+type RefundRequest struct {
+	Reason string       `json:"reason" binding:"required"`
+	Items  []RefundLine `json:"items"`
+}
+
+type RefundLine struct {
+	DetailId int64 `json:"detail_id" binding:"required"`
+	Qty      int   `json:"qty" binding:"required"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+
+	MenuSalesByQty     = "qty"
+	MenuSalesByRevenue = "revenue"
+
+	// how many menus the daily and monthly reports list
+	TopMenuCount = 5
+)
+
+// ReportFilter is the range of a report, from From up to, not including,
+// To. Limit and Sort are only used by the menu report, Period by the
+// shrinkage report.
+type ReportFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Sort   string
+	Period string
+}
+
+// SalesSummary breaks the gross revenue down the way receipts do, subtotal
+// less discounts plus service charge and tax. Refunded is what was given
+// back of these sales, NetRevenue the gross revenue less it.
+type SalesSummary struct {
+	Transactions   int `json:"transactions" db:"transactions"`
+	Subtotal       int `json:"subtotal" db:"subtotal"`
+	DiscountAmount int `json:"discount_amount" db:"discount_amount"`
+	ServiceCharge  int `json:"service_charge" db:"service_charge"`
+	Tax            int `json:"tax" db:"tax"`
+	GrossRevenue   int `json:"gross_revenue" db:"gross_revenue"`
+	Refunded       int `json:"refunded" db:"refunded"`
+	NetRevenue     int `json:"net_revenue" db:"net_revenue"`
+	AverageTicket  int `json:"average_ticket" db:"average_ticket"`
+}
+
+// SalesPeriod is the sales of one day (2006-01-02) or month (2006-01).
+type SalesPeriod struct {
+	Period string `json:"period" db:"period"`
+	SalesSummary
+}
+
+// MenuSales is what was sold of a menu, Qty and Revenue include what was
+// refunded later.
+type MenuSales struct {
+	MenuId       string `json:"menu_id" db:"menu_id"`
+	Name         string `json:"name" db:"name"`
+	Qty          int    `json:"qty" db:"qty"`
+	RefundedQty  int    `json:"refunded_qty" db:"refunded_qty"`
+	Revenue      int    `json:"revenue" db:"revenue"`
+	NetRevenue   int    `json:"net_revenue" db:"net_revenue"`
+	Transactions int    `json:"transactions" db:"transactions"`
+}
+
+type SalesReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Summary SalesSummary  `json:"summary"`
+	Periods []SalesPeriod `json:"periods,omitempty"`
+	Menus   []MenuSales   `json:"menus"`
+	// Payments break the revenue down by payment method
+	Payments []PaymentSales `json:"payments"`
+}
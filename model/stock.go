@@ -0,0 +1,91 @@
+package model
+
+import "time"
+
+const (
+	StockOpening    = "opening"
+	StockRestock    = "restock"
+	StockWaste      = "waste"
+	StockAdjustment = "adjustment"
+	StockSale       = "sale"
+	StockRefund     = "refund"
+	StockVoid       = "void"
+	StockPurchase   = "purchase"
+)
+
+const (
+	StockItemMenu       = "menu"
+	StockItemIngredient = "ingredient"
+)
+
+// StockItem is a menu or an ingredient, the things that have stock.
+type StockItem struct {
+	Kind string
+	Id   string
+}
+
+func (i StockItem) String() string {
+	return i.Kind + " " + i.Id
+}
+
+// Movement is a change of qty of the stock of the item.
+func (i StockItem) Movement(qty int, reason string) StockMovement {
+	id := i.Id
+	movement := StockMovement{Qty: qty, Reason: reason}
+	if i.Kind == StockItemIngredient {
+		movement.IngredientId = &id
+	} else {
+		movement.MenuId = &id
+	}
+	return movement
+}
+
+// StockMovement is one change of the stock of a menu or an ingredient, Qty
+// is positive when stock comes in and negative when it goes out. sales,
+// refunds and voids have a TransactionId, the movements made by hand a
+// UserId. purchases have the PurchaseOrderId and what one unit cost, the
+// adjustments of an approved stock take its StockTakeId.
+type StockMovement struct {
+	Id              int64     `json:"id" db:"id"`
+	MenuId          *string   `json:"menu_id,omitempty" db:"menu_id"`
+	IngredientId    *string   `json:"ingredient_id,omitempty" db:"ingredient_id"`
+	Qty             int       `json:"qty" db:"qty"`
+	Reason          string    `json:"reason" db:"reason"`
+	Note            *string   `json:"note,omitempty" db:"note"`
+	TransactionId   *string   `json:"transaction_id,omitempty" db:"transaction_id"`
+	UserId          *string   `json:"user_id,omitempty" db:"user_id"`
+	PurchaseOrderId *string   `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+	UnitCost        *int      `json:"unit_cost,omitempty" db:"unit_cost"`
+	StockTakeId     *string   `json:"stock_take_id,omitempty" db:"stock_take_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Item is the menu or the ingredient the movement is of.
+func (m StockMovement) Item() StockItem {
+	if m.IngredientId != nil {
+		return StockItem{Kind: StockItemIngredient, Id: *m.IngredientId}
+	}
+	if m.MenuId != nil {
+		return StockItem{Kind: StockItemMenu, Id: *m.MenuId}
+	}
+	return StockItem{}
+}
+
+// StockRequest is the body of a restock, a waste or an adjustment. Qty is
+// how much comes in or goes out, an adjustment takes a signed Qty and needs
+// a Note.
+type StockRequest struct {
+	Qty  int    `json:"qty" binding:"required"`
+	Note string `json:"note"`
+}
+
+// StockAlert is a menu or an ingredient whose available stock is under its
+// reorder level. Unit is only set for ingredients.
+type StockAlert struct {
+	Kind         string `json:"kind" db:"kind"`
+	Id           string `json:"id" db:"id"`
+	Name         string `json:"name" db:"name"`
+	Unit         string `json:"unit,omitempty" db:"unit"`
+	Available    int    `json:"available" db:"available"`
+	ReorderLevel int    `json:"reorder_level" db:"reorder_level"`
+}
@@ -0,0 +1,110 @@
+package model
+
+import "time"
+
+// a stock take is counted until it is approved, which adjusts the stock by
+// its variances, or cancelled.
+const (
+	StockTakeCounting  = "counting"
+	StockTakeApproved  = "approved"
+	StockTakeCancelled = "cancelled"
+)
+
+// StockTake is a physical count of the stock. Lines is how many items were
+// counted and VarianceValue what their variances are worth at cost, the
+// lines themselves are only loaded with one stock take.
+type StockTake struct {
+	Id            string          `json:"id" db:"id"`
+	Status        string          `json:"status" db:"status"`
+	Note          *string         `json:"note" db:"note"`
+	UserId        *string         `json:"user_id,omitempty" db:"user_id"`
+	ApprovedBy    *string         `json:"approved_by,omitempty" db:"approved_by"`
+	LineCount     int             `json:"lines_counted" db:"lines"`
+	VarianceValue int             `json:"variance_value" db:"variance_value"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	ApprovedAt    *time.Time      `json:"approved_at" db:"approved_at"`
+	Lines         []StockTakeLine `json:"lines,omitempty" db:"-"`
+}
+
+// StockTakeLine is what was counted of a menu or an ingredient against the
+// stock that was expected when it was counted. Variance is counted less
+// expected, negative when stock is missing. UnitCost is what the item cost
+// on its last purchase, without one the variance has no VarianceValue.
+type StockTakeLine struct {
+	Id            int64     `json:"id" db:"id"`
+	StockTakeId   string    `json:"-" db:"stock_take_id"`
+	MenuId        *string   `json:"menu_id,omitempty" db:"menu_id"`
+	IngredientId  *string   `json:"ingredient_id,omitempty" db:"ingredient_id"`
+	Name          string    `json:"name" db:"name"`
+	Unit          string    `json:"unit,omitempty" db:"unit"`
+	Expected      int       `json:"expected" db:"expected"`
+	Counted       int       `json:"counted" db:"counted"`
+	Variance      int       `json:"variance" db:"variance"`
+	UnitCost      *int      `json:"unit_cost" db:"unit_cost"`
+	VarianceValue *int      `json:"variance_value" db:"variance_value"`
+	UserId        *string   `json:"user_id,omitempty" db:"user_id"`
+	CountedAt     time.Time `json:"counted_at" db:"counted_at"`
+}
+
+// Item is the menu or the ingredient the line is of.
+func (l StockTakeLine) Item() StockItem {
+	if l.IngredientId != nil {
+		return StockItem{Kind: StockItemIngredient, Id: *l.IngredientId}
+	}
+	if l.MenuId != nil {
+		return StockItem{Kind: StockItemMenu, Id: *l.MenuId}
+	}
+	return StockItem{}
+}
+
+// StockCountRequest is a batch of counts of a stock take, an item that was
+// already counted is replaced.
+type StockCountRequest struct {
+	Counts []StockCount `json:"counts" binding:"required"`
+}
+
+type StockCount struct {
+	MenuId       *string `json:"menu_id"`
+	IngredientId *string `json:"ingredient_id"`
+	Counted      *int    `json:"counted"`
+}
+
+// Item is the menu or the ingredient that was counted.
+func (c StockCount) Item() StockItem {
+	return StockTakeLine{MenuId: c.MenuId, IngredientId: c.IngredientId}.Item()
+}
+
+// ShrinkageSummary is what approved stock takes found missing (Lost) and
+// over (Found) at cost. Unvalued is how many variances had no unit cost.
+type ShrinkageSummary struct {
+	StockTakes int `json:"stock_takes" db:"stock_takes"`
+	Lost       int `json:"lost" db:"lost"`
+	Found      int `json:"found" db:"found"`
+	Unvalued   int `json:"unvalued" db:"unvalued"`
+}
+
+// ShrinkagePeriod is the shrinkage of one day (2006-01-02) or month
+// (2006-01).
+type ShrinkagePeriod struct {
+	Period string `json:"period" db:"period"`
+	ShrinkageSummary
+}
+
+// ShrinkageItem is the shrinkage of one menu or ingredient, Variance is the
+// sum of its variances in its own unit.
+type ShrinkageItem struct {
+	Kind     string `json:"kind" db:"kind"`
+	Id       string `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	Unit     string `json:"unit,omitempty" db:"unit"`
+	Variance int    `json:"variance" db:"variance"`
+	ShrinkageSummary
+}
+
+type ShrinkageReport struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Summary ShrinkageSummary  `json:"summary"`
+	Periods []ShrinkagePeriod `json:"periods"`
+	Items   []ShrinkageItem   `json:"items"`
+}
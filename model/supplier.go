@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Supplier is who stock is bought from with purchase orders.
+type Supplier struct {
+	Id        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name" binding:"required"`
+	Phone     *string   `json:"phone" db:"phone"`
+	Email     *string   `json:"email" db:"email"`
+	Address   *string   `json:"address" db:"address"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SupplierSpending is what was received from a supplier in a range, at the
+// unit cost it was received with.
+type SupplierSpending struct {
+	SupplierId     string `json:"supplier_id" db:"supplier_id"`
+	Name           string `json:"name" db:"name"`
+	PurchaseOrders int    `json:"purchase_orders" db:"purchase_orders"`
+	Spent          int    `json:"spent" db:"spent"`
+}
+
+type SpendingReport struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Total     int                `json:"total"`
+	Suppliers []SupplierSpending `json:"suppliers"`
+}
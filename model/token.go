@@ -0,0 +1,25 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type RefreshToken struct {
+	Id        string       `db:"id"`
+	UserId    string       `db:"user_id"`
+	TokenHash string       `db:"token_hash"`
+	AccessJti string       `db:"access_jti"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	RevokedAt sql.NullTime `db:"revoked_at"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -2,12 +2,42 @@ package model
 
 import "database/sql"
 
+// an order goes open, sent_to_kitchen, served and paid, or is cancelled
+// before it is paid. a sale made in one go starts as paid. a paid
+// transaction can be voided or refunded.
+const (
+	StatusOpen              = "open"
+	StatusSentToKitchen     = "sent_to_kitchen"
+	StatusServed            = "served"
+	StatusPaid              = "paid"
+	StatusCancelled         = "cancelled"
+	StatusVoided            = "voided"
+	StatusPartiallyRefunded = "partially_refunded"
+	StatusRefunded          = "refunded"
+)
+
+// Transaction keeps its price breakdown, TotalPrice is Subtotal minus
+// DiscountAmount plus ServiceCharge and Tax. Discount and PromoCode are
+// the order discount of a sale, only one of them can be given.
 type Transaction struct {
-	Id         string              `json:"id"`
-	TotalPrice int                 `json:"total" db:"total_price"`
-	Created_at string              `db:"created_at" json:"created_at"`
-	Updated_at sql.NullTime        `db:"updated_at" json:"updated_at,omitempty"`
-	Items      []TransactionDetail `json:"items" binding:"required" db:"items"`
+	Id             string              `json:"id"`
+	Subtotal       int                 `json:"subtotal" db:"subtotal"`
+	Discount       *Discount           `json:"discount,omitempty" db:"-"`
+	PromoCode      string              `json:"promo_code,omitempty" db:"promo_code"`
+	DiscountAmount int                 `json:"discount_amount" db:"discount_amount"`
+	ServiceCharge  int                 `json:"service_charge" db:"service_charge"`
+	Tax            int                 `json:"tax" db:"tax"`
+	TotalPrice     int                 `json:"total" db:"total_price"`
+	Status         string              `json:"status" db:"status"`
+	Created_at     string              `db:"created_at" json:"created_at"`
+	Updated_at     sql.NullTime        `db:"updated_at" json:"updated_at,omitempty"`
+	Items          []TransactionDetail `json:"items" binding:"required" db:"items"`
+	// Payments are read when a sale is made and loaded by GetById
+	Payments []Payment `json:"payments,omitempty" db:"-"`
+	// Refunds are only loaded by GetById
+	Refunds []Refund `json:"refunds,omitempty" db:"-"`
+	// RejectedItems are the lines dropped from a partial order, never saved
+	RejectedItems []RejectedItem `json:"rejected_items,omitempty" db:"-"`
 }
 
 type TransactionTest struct {
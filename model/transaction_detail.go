@@ -1,13 +1,52 @@
 package model
 
 type TransactionDetail struct {
+	Id            int64  `json:"id,omitempty" db:"id"`
 	TransactionId string `json:"transaction_id" db:"transaction_id"`
 	MenuId        string `json:"menu_id" binding:"required" db:"menu_id"`
 	Qty           int    `json:"qty" binding:"required" db:"qty"`
-	Subtotal      int    `json:"subtotal" `
+	// MenuName and UnitPrice are the menu as it was sold, they do not
+	// change with the menu. UnitPrice includes the Modifiers
+	MenuName  string        `json:"menu_name" db:"menu_name"`
+	UnitPrice int           `json:"unit_price" db:"unit_price"`
+	Modifiers LineModifiers `json:"modifiers" db:"modifiers"`
+	// Ingredients is the recipe of the menu when the line was added, empty
+	// for a menu sold from its own stock
+	Ingredients LineIngredients `json:"ingredients,omitempty" db:"ingredients"`
+	Subtotal    int             `json:"subtotal" `
+	// Discount is the line discount of a new line, DiscountAmount what it
+	// took off the Subtotal
+	Discount       *Discount `json:"discount,omitempty" db:"-"`
+	DiscountAmount int       `json:"discount_amount" db:"discount_amount"`
+}
+
+// OrderLines is the body that opens an order or adds items to it, only
+// MenuId, Qty, Discount and the option ids of the Modifiers of the items
+// are read.
+type OrderLines struct {
+	Items []TransactionDetail `json:"items"`
 }
 
 type ItemList struct {
 	MenuId string `json:"menu_id" binding:"required" db:"menu_id"`
 	Qty    int    `json:"qty" binding:"required" db:"qty"`
 }
+
+const (
+	RejectInvalidQty        = "invalid_qty"
+	RejectMenuNotFound      = "menu_not_found"
+	RejectInsufficientStock = "insufficient_stock"
+	RejectInvalidModifiers  = "invalid_modifiers"
+)
+
+// RejectedItem is an order line that cannot be sold. Index is the position
+// of the line in the items of the request, Available is only set when the
+// stock is short and Problem when the modifiers are invalid.
+type RejectedItem struct {
+	Index     int    `json:"index"`
+	MenuId    string `json:"menu_id"`
+	Qty       int    `json:"qty"`
+	Reason    string `json:"reason"`
+	Available *int   `json:"available,omitempty"`
+	Problem   string `json:"problem,omitempty"`
+}
@@ -1,14 +1,31 @@
 package model
 
+import "time"
+
+const (
+	RoleOwner   = "owner"
+	RoleCashier = "cashier"
+	RoleKitchen = "kitchen"
+	RoleViewer  = "viewer"
+)
+
 type User struct {
 	Id       string `json:"id" form:"id" db:"id" `
 	Name     string `json:"name" form:"name" db:"name" binding:"required"`
 	Username string `json:"username" form:"username" db:"username" binding:"required"`
 	Password string `json:"password,omitempty" form:"password" db:"password" binding:"required"`
+	Role     string `json:"role" form:"role" db:"role" binding:"omitempty,oneof=owner cashier kitchen viewer"`
 	Image    string `json:"image" db:"image" form:"image"`
+	// DeletedAt is set while the user is archived, it cannot log in then
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at" form:"-"`
 }
 
 type Credential struct {
 	Username string `json:"username" db:"username" binding:"required"`
 	Password string `json:"password" db:"password" binding:"required"`
 }
+
+type PasswordChange struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
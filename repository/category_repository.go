@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type categoryRepository struct {
+	db *sqlx.DB
+}
+
+type CategoryRepository interface {
+	// GetAll lists the categories in display order.
+	GetAll() ([]model.Category, error)
+	GetById(id string) (model.Category, error)
+	Insert(category *model.Category) (model.Category, error)
+	Update(category *model.Category) (model.Category, error)
+	// Delete removes a category, its menus are left without one.
+	Delete(id string) error
+}
+
+func (p *categoryRepository) GetAll() ([]model.Category, error) {
+	var categories []model.Category
+	err := p.db.Select(&categories, utils.CATEGORY_GET_ALL+" ORDER BY display_order, name, id")
+	if err != nil {
+		return nil, dbError(err, "categories")
+	}
+	return categories, nil
+}
+
+func (p *categoryRepository) GetById(id string) (model.Category, error) {
+	var category model.Category
+	err := p.db.Get(&category, utils.CATEGORY_GET_BY_ID, id)
+	if err != nil {
+		return model.Category{}, dbError(err, "category "+id)
+	}
+	return category, nil
+}
+
+func (p *categoryRepository) Insert(category *model.Category) (model.Category, error) {
+	_, err := p.db.NamedExec(utils.CATEGORY_INSERT, category)
+	if err != nil {
+		return model.Category{}, dbError(err, "category "+category.Name)
+	}
+	return p.GetById(category.Id)
+}
+
+func (p *categoryRepository) Update(category *model.Category) (model.Category, error) {
+	result, err := p.db.NamedExec(utils.CATEGORY_UPDATE, category)
+	if err := mustAffect(result, err, "category "+category.Id); err != nil {
+		return model.Category{}, err
+	}
+	return p.GetById(category.Id)
+}
+
+func (p *categoryRepository) Delete(id string) error {
+	result, err := p.db.Exec(utils.CATEGORY_DELETE, id)
+	return mustAffect(result, err, "category "+id)
+}
+
+func NewCategoryRepository(db *sqlx.DB) CategoryRepository {
+	repo := new(categoryRepository)
+	repo.db = db
+	return repo
+}
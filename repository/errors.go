@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"warung-makan/utils/apperror"
+
+	"github.com/lib/pq"
+)
+
+// dbError wraps err with what the query was about and gives it a kind, so
+// controllers can answer with the right status code.
+func dbError(err error, what string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperror.Wrap(apperror.KindNotFound, err, what+" not found")
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return apperror.Wrap(apperror.KindConflict, err, what+" already exists")
+		case "foreign_key_violation":
+			return apperror.Wrap(apperror.KindConflict, err, what+" is referenced by or references missing data")
+		case "not_null_violation", "check_violation":
+			return apperror.Wrap(apperror.KindValidation, err, what+" is invalid: "+pqErr.Message)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", what, err)
+}
+
+// mustAffect turns an update or delete that matched no row into not found.
+func mustAffect(result sql.Result, err error, what string) error {
+	if err != nil {
+		return dbError(err, what)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err, what)
+	}
+	if affected == 0 {
+		return apperror.NotFound(what + " not found")
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ingredientRepository struct {
+	db *sqlx.DB
+}
+
+type IngredientRepository interface {
+	GetAll() ([]model.Ingredient, error)
+	GetById(id string) (model.Ingredient, error)
+	// Insert saves an ingredient and records its stock as the opening
+	// movement in one db transaction.
+	Insert(ingredient *model.Ingredient) (model.Ingredient, error)
+	// Update changes the name, the unit and the reorder level, stock only
+	// changes with stock movements.
+	Update(ingredient *model.Ingredient) (model.Ingredient, error)
+	// Delete removes an ingredient, it fails while anything refers to it.
+	Delete(id string) error
+	// Usage counts the movements, recipes, purchase lines and stock take
+	// lines of an ingredient.
+	Usage(id string) (model.IngredientUsage, error)
+	// GetRecipe lists the ingredients of one portion of a menu.
+	GetRecipe(menuId string) ([]model.RecipeLine, error)
+	// SetRecipe replaces the recipe of a menu in one db transaction.
+	SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error)
+}
+
+// recipes reads the recipes of menus.
+func recipes(q sqlx.Queryer, menuIds []string) ([]model.RecipeLine, error) {
+	lines := []model.RecipeLine{}
+	if err := sqlx.Select(q, &lines, utils.RECIPE_GET_BY_MENUS, pq.Array(menuIds)); err != nil {
+		return nil, dbError(err, "recipes")
+	}
+	return lines, nil
+}
+
+func (p *ingredientRepository) GetAll() ([]model.Ingredient, error) {
+	ingredients := []model.Ingredient{}
+	err := p.db.Select(&ingredients, utils.INGREDIENT_GET_ALL+" ORDER BY name, id")
+	if err != nil {
+		return nil, dbError(err, "ingredients")
+	}
+	return ingredients, nil
+}
+
+func (p *ingredientRepository) GetById(id string) (model.Ingredient, error) {
+	var ingredient model.Ingredient
+	err := p.db.Get(&ingredient, utils.INGREDIENT_GET_BY_ID, id)
+	if err != nil {
+		return model.Ingredient{}, dbError(err, "ingredient "+id)
+	}
+	return ingredient, nil
+}
+
+func (p *ingredientRepository) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.Ingredient{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExec(utils.INGREDIENT_INSERT, ingredient)
+	if err != nil {
+		return model.Ingredient{}, dbError(err, "ingredient "+ingredient.Name)
+	}
+	if ingredient.Stock != 0 {
+		opening := model.StockItem{Kind: model.StockItemIngredient, Id: ingredient.Id}.Movement(ingredient.Stock, model.StockOpening)
+		if err := insertMovement(tx, &opening); err != nil {
+			return model.Ingredient{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Ingredient{}, dbError(err, "commit transaction")
+	}
+	return p.GetById(ingredient.Id)
+}
+
+func (p *ingredientRepository) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	result, err := p.db.NamedExec(utils.INGREDIENT_UPDATE, ingredient)
+	if err := mustAffect(result, err, "ingredient "+ingredient.Id); err != nil {
+		return model.Ingredient{}, err
+	}
+	return p.GetById(ingredient.Id)
+}
+
+func (p *ingredientRepository) Delete(id string) error {
+	result, err := p.db.Exec(utils.INGREDIENT_DELETE, id)
+	return mustAffect(result, err, "ingredient "+id)
+}
+
+func (p *ingredientRepository) Usage(id string) (model.IngredientUsage, error) {
+	var usage model.IngredientUsage
+	if err := p.db.Get(&usage, utils.INGREDIENT_USAGE, id); err != nil {
+		return model.IngredientUsage{}, dbError(err, "usage of ingredient "+id)
+	}
+	return usage, nil
+}
+
+func (p *ingredientRepository) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	return recipes(p.db, []string{menuId})
+}
+
+func (p *ingredientRepository) SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return nil, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(utils.RECIPE_DELETE, menuId); err != nil {
+		return nil, dbError(err, "recipe of menu "+menuId)
+	}
+	for _, line := range lines {
+		line.MenuId = menuId
+		if _, err := tx.NamedExec(utils.RECIPE_INSERT, line); err != nil {
+			return nil, dbError(err, "ingredient "+line.IngredientId+" of the recipe of menu "+menuId)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(err, "commit transaction")
+	}
+	return p.GetRecipe(menuId)
+}
+
+func NewIngredientRepository(db *sqlx.DB) IngredientRepository {
+	repo := new(ingredientRepository)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+)
+
+// sortColumn is a column a list can be sorted by. cast is the postgres type
+// the value kept in a cursor is read back as.
+type sortColumn struct {
+	column string
+	cast   string
+}
+
+// listSpec is what a repository allows in a model.ListQuery. every list is
+// sorted by id last, so rows with the same sort value keep their order
+// between pages.
+type listSpec struct {
+	sorts        map[string]sortColumn
+	defaultSort  string
+	defaultOrder string
+}
+
+// listCursor points after the last row of a page, it is sent to clients
+// base64 encoded.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	Id    string `json:"id"`
+}
+
+// conditions are where clauses joined with AND, written with ? placeholders
+// and rebound for the driver before running.
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+func (c *conditions) add(clause string, args ...interface{}) {
+	c.clauses = append(c.clauses, clause)
+	c.args = append(c.args, args...)
+}
+
+func (c conditions) sql() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// listPlan is a validated model.ListQuery.
+type listPlan struct {
+	sort   string
+	order  string
+	page   int
+	limit  int
+	after  *listCursor
+	column sortColumn
+}
+
+func (s listSpec) plan(query model.ListQuery) (listPlan, error) {
+	plan := listPlan{
+		sort:  query.Sort,
+		order: strings.ToLower(query.Order),
+		page:  query.Page,
+		limit: query.Limit,
+	}
+
+	if plan.limit == 0 {
+		plan.limit = model.DefaultPageLimit
+	}
+	if plan.limit < 1 || plan.limit > model.MaxPageLimit {
+		return listPlan{}, apperror.Validation(fmt.Sprintf("limit must be between 1 and %d", model.MaxPageLimit), nil)
+	}
+
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return listPlan{}, err
+		}
+		plan.after = &after
+		plan.sort, plan.order, plan.page = after.Sort, after.Order, 0
+	} else if plan.page == 0 {
+		plan.page = 1
+	}
+	if plan.page < 0 {
+		return listPlan{}, apperror.Validation("page must be at least 1", nil)
+	}
+
+	if plan.sort == "" {
+		plan.sort = s.defaultSort
+	}
+	column, ok := s.sorts[plan.sort]
+	if !ok {
+		return listPlan{}, apperror.Validation("sort must be one of "+strings.Join(s.sortNames(), ", "), nil)
+	}
+	plan.column = column
+
+	if plan.order == "" {
+		plan.order = s.defaultOrder
+	}
+	if plan.order != model.OrderAsc && plan.order != model.OrderDesc {
+		return listPlan{}, apperror.Validation("order must be asc or desc", nil)
+	}
+
+	return plan, nil
+}
+
+func (s listSpec) sortNames() []string {
+	names := make([]string, 0, len(s.sorts))
+	for name := range s.sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// query is selectAll with the filters, the cursor and the paging. it asks
+// for one row more than the limit to know if there is a next page.
+func (p listPlan) query(selectAll string, where conditions) (string, []interface{}) {
+	direction, compare := "ASC", ">"
+	if p.order == model.OrderDesc {
+		direction, compare = "DESC", "<"
+	}
+	where = conditions{
+		clauses: append([]string{}, where.clauses...),
+		args:    append([]interface{}{}, where.args...),
+	}
+
+	if p.after != nil {
+		where.add(fmt.Sprintf("(%s, id) %s (CAST(? AS %s), ?)", p.column.column, compare, p.column.cast), p.after.Value, p.after.Id)
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY %s %s, id %s LIMIT ?", selectAll, where.sql(), p.column.column, direction, direction)
+	args := append(where.args, p.limit+1)
+	if p.after == nil {
+		query += " OFFSET ?"
+		args = append(args, (p.page-1)*p.limit)
+	}
+	return query, args
+}
+
+func (p listPlan) info(total int) model.PageInfo {
+	return model.PageInfo{
+		Total: total,
+		Page:  p.page,
+		Limit: p.limit,
+		Sort:  p.sort,
+		Order: p.order,
+	}
+}
+
+// next is the cursor of the page after the row with the given sort value
+// and id.
+func (p listPlan) next(value, id string) string {
+	content, _ := json.Marshal(listCursor{Sort: p.sort, Order: p.order, Value: value, Id: id})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decodeCursor(encoded string) (listCursor, error) {
+	var cursor listCursor
+	content, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		err = json.Unmarshal(content, &cursor)
+	}
+	if err != nil || cursor.Id == "" {
+		return listCursor{}, apperror.Validation("cursor is invalid", nil)
+	}
+	return cursor, nil
+}
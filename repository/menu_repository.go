@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"strconv"
 	"warung-makan/model"
 	"warung-makan/utils"
 
@@ -14,24 +15,118 @@ type menuRepository struct {
 type MenuRepository interface {
 	// GetAllPaginated(page int, rows int) ([]model.Menu, error)
 	GetAll() ([]model.Menu, error)
-	GetById(id string) (model.Menu, error)
+	List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error)
+	// GetById finds an archived menu only with includeDeleted.
+	GetById(id string, includeDeleted bool) (model.Menu, error)
 	GetByName(name string) ([]model.Menu, error)
 
+	// Insert records the stock of a new menu as its opening stock movement.
 	Insert(menu *model.Menu) (model.Menu, error)
+	// Update changes everything but the stock and returns the saved menu.
 	Update(menu *model.Menu) (model.Menu, error)
+	// Delete archives a menu, Restore brings it back.
 	Delete(id string) error
+	Restore(id string) error
 }
 
 func (p *menuRepository) GetAll() ([]model.Menu, error) {
 	var menus []model.Menu
-	err := p.db.Select(&menus, utils.MENU_GET_ALL+" order by id")
+	err := p.db.Select(&menus, utils.MENU_GET_ALL+" WHERE deleted_at IS NULL order by id")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "menus")
 	}
 
 	return menus, nil
 }
 
+// menuAvailableAt keeps the menus served at a time of day, it takes the
+// time four times. a window that ends before it starts runs past midnight.
+const menuAvailableAt = "(available_from IS NULL" +
+	" OR (available_from < available_until AND available_from <= CAST(? AS time) AND CAST(? AS time) < available_until)" +
+	" OR (available_from > available_until AND (available_from <= CAST(? AS time) OR CAST(? AS time) < available_until)))"
+
+var menuList = listSpec{
+	sorts: map[string]sortColumn{
+		"id":    {"id", "text"},
+		"name":  {"name", "text"},
+		"price": {"price", "integer"},
+		"stock": {"stock", "integer"},
+	},
+	defaultSort:  "id",
+	defaultOrder: model.OrderAsc,
+}
+
+func (p *menuRepository) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	plan, err := menuList.plan(filter.ListQuery)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	var where conditions
+	if !filter.IncludeDeleted {
+		where.add("deleted_at IS NULL")
+	}
+	if filter.Name != "" {
+		where.add("name like ?", "%"+filter.Name+"%")
+	}
+	if filter.MinPrice != nil {
+		where.add("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where.add("price <= ?", *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			where.add("COALESCE(" + utils.MENU_PORTIONS + ", stock - reserved) > 0")
+		} else {
+			where.add("COALESCE(" + utils.MENU_PORTIONS + ", stock - reserved) <= 0")
+		}
+	}
+	if filter.Category != "" {
+		where.add("category_id = ?", filter.Category)
+	}
+	if filter.Tag != "" {
+		where.add("? = ANY(tags)", filter.Tag)
+	}
+	if filter.AvailableAt != "" {
+		at := filter.AvailableAt
+		where.add(menuAvailableAt, at, at, at, at)
+	}
+
+	var total int
+	err = p.db.Get(&total, p.db.Rebind(utils.MENU_COUNT+where.sql()), where.args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "menus")
+	}
+
+	query, args := plan.query(utils.MENU_GET_ALL, where)
+	var menus []model.Menu
+	err = p.db.Select(&menus, p.db.Rebind(query), args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "menus")
+	}
+
+	info := plan.info(total)
+	if len(menus) > plan.limit {
+		menus = menus[:plan.limit]
+		last := menus[len(menus)-1]
+		info.NextCursor = plan.next(menuSortValue(last, plan.sort), last.Id)
+	}
+	return menus, info, nil
+}
+
+func menuSortValue(menu model.Menu, sort string) string {
+	switch sort {
+	case "name":
+		return menu.Name
+	case "price":
+		return strconv.Itoa(menu.Price)
+	case "stock":
+		return strconv.Itoa(menu.Stock)
+	}
+	return menu.Id
+}
+
 // func (p *menuRepository) GetAllPaginated(page int, rows int) ([]model.Menu, error) {
 // 	var menus []model.Menu
 // 	limit := rows
@@ -44,11 +139,16 @@ func (p *menuRepository) GetAll() ([]model.Menu, error) {
 // 	return menus, nil
 // }
 
-func (p *menuRepository) GetById(id string) (model.Menu, error) {
+func (p *menuRepository) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	query := utils.MENU_GET_BY_ID
+	if !includeDeleted {
+		query += utils.NOT_DELETED
+	}
+
 	var menu model.Menu
-	err := p.db.Get(&menu, utils.MENU_GET_BY_ID, id)
+	err := p.db.Get(&menu, query, id)
 	if err != nil {
-		return model.Menu{}, err
+		return model.Menu{}, dbError(err, "menu "+id)
 	}
 	return menu, nil
 }
@@ -57,32 +157,52 @@ func (p *menuRepository) GetByName(name string) ([]model.Menu, error) {
 	var menus []model.Menu
 	err := p.db.Select(&menus, utils.MENU_GET_BY_NAME+" order by id", "%"+name+"%")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "menus")
 	}
 
 	return menus, nil
 }
 
 func (p *menuRepository) Insert(newMenu *model.Menu) (model.Menu, error) {
-	_, err := p.db.NamedExec(utils.MENU_INSERT, newMenu)
+	tx, err := p.db.Beginx()
 	if err != nil {
-		return model.Menu{}, err
+		return model.Menu{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExec(utils.MENU_INSERT, newMenu)
+	if err != nil {
+		return model.Menu{}, dbError(err, "menu "+newMenu.Id)
+	}
+	if newMenu.Stock != 0 {
+		opening := model.StockItem{Kind: model.StockItemMenu, Id: newMenu.Id}.Movement(newMenu.Stock, model.StockOpening)
+		if err := insertMovement(tx, &opening); err != nil {
+			return model.Menu{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Menu{}, dbError(err, "commit transaction")
 	}
 	menu := newMenu
 	return *menu, nil
 }
 
 func (p *menuRepository) Update(newData *model.Menu) (model.Menu, error) {
-	_, err := p.db.NamedExec(utils.MENU_UPDATE, newData)
-	if err != nil {
+	result, err := p.db.NamedExec(utils.MENU_UPDATE, newData)
+	if err := mustAffect(result, err, "menu "+newData.Id); err != nil {
 		return model.Menu{}, err
 	}
-	return *newData, nil
+	return p.GetById(newData.Id, false)
 }
 
 func (p *menuRepository) Delete(id string) error {
-	_, err := p.db.Exec(utils.MENU_DELETE, id)
-	return err
+	result, err := p.db.Exec(utils.MENU_DELETE, id)
+	return mustAffect(result, err, "menu "+id)
+}
+
+func (p *menuRepository) Restore(id string) error {
+	result, err := p.db.Exec(utils.MENU_RESTORE, id)
+	return mustAffect(result, err, "deleted menu "+id)
 }
 
 func NewMenuRepository(db *sqlx.DB) MenuRepository {
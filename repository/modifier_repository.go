@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type modifierRepository struct {
+	db *sqlx.DB
+}
+
+type ModifierRepository interface {
+	// GetByMenu lists the modifier groups of a menu with their options, in
+	// display order.
+	GetByMenu(menuId string) ([]model.ModifierGroup, error)
+	// Insert saves a group with its options in one db transaction.
+	Insert(group *model.ModifierGroup) (model.ModifierGroup, error)
+	// Update replaces a group and its options, options with an id are kept,
+	// the others are added and the ones left out are removed.
+	Update(group *model.ModifierGroup) (model.ModifierGroup, error)
+	Delete(menuId string, groupId int64) error
+}
+
+// modifierGroups reads the modifier groups of menus with their options.
+func modifierGroups(q sqlx.Queryer, menuIds []string) ([]model.ModifierGroup, error) {
+	var groups []model.ModifierGroup
+	if err := sqlx.Select(q, &groups, utils.MODIFIER_GROUP_GET_BY_MENUS, pq.Array(menuIds)); err != nil {
+		return nil, dbError(err, "modifier groups")
+	}
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	var options []model.ModifierOption
+	if err := sqlx.Select(q, &options, utils.MODIFIER_OPTION_GET_BY_MENUS, pq.Array(menuIds)); err != nil {
+		return nil, dbError(err, "modifier options")
+	}
+	byGroup := map[int64][]model.ModifierOption{}
+	for _, option := range options {
+		byGroup[option.GroupId] = append(byGroup[option.GroupId], option)
+	}
+	for i := range groups {
+		groups[i].Options = byGroup[groups[i].Id]
+	}
+	return groups, nil
+}
+
+func (p *modifierRepository) GetByMenu(menuId string) ([]model.ModifierGroup, error) {
+	return modifierGroups(p.db, []string{menuId})
+}
+
+func (p *modifierRepository) Insert(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.ModifierGroup{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.NamedQuery(utils.MODIFIER_GROUP_INSERT, group)
+	if err != nil {
+		return model.ModifierGroup{}, dbError(err, "modifier group "+group.Name)
+	}
+	if rows.Next() {
+		err = rows.Scan(&group.Id)
+	}
+	rows.Close()
+	if err != nil {
+		return model.ModifierGroup{}, dbError(err, "modifier group "+group.Name)
+	}
+
+	if err := insertOptions(tx, group); err != nil {
+		return model.ModifierGroup{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.ModifierGroup{}, dbError(err, "commit transaction")
+	}
+	return p.get(group.MenuId, group.Id)
+}
+
+func (p *modifierRepository) Update(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	what := fmt.Sprintf("modifier group %d of menu %s", group.Id, group.MenuId)
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.ModifierGroup{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.NamedExec(utils.MODIFIER_GROUP_UPDATE, group)
+	if err := mustAffect(result, err, what); err != nil {
+		return model.ModifierGroup{}, err
+	}
+
+	kept := []int64{}
+	for _, option := range group.Options {
+		if option.Id != 0 {
+			kept = append(kept, option.Id)
+		}
+	}
+	if _, err := tx.Exec(utils.MODIFIER_OPTION_DELETE_OTHER, group.Id, pq.Array(kept)); err != nil {
+		return model.ModifierGroup{}, dbError(err, "options of "+what)
+	}
+	for i := range group.Options {
+		option := &group.Options[i]
+		if option.Id == 0 {
+			continue
+		}
+		option.GroupId = group.Id
+		result, err := tx.NamedExec(utils.MODIFIER_OPTION_UPDATE, option)
+		if err := mustAffect(result, err, fmt.Sprintf("option %d of %s", option.Id, what)); err != nil {
+			return model.ModifierGroup{}, err
+		}
+	}
+	if err := insertOptions(tx, group); err != nil {
+		return model.ModifierGroup{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.ModifierGroup{}, dbError(err, "commit transaction")
+	}
+	return p.get(group.MenuId, group.Id)
+}
+
+// insertOptions saves the options of a group that have no id yet.
+func insertOptions(tx *sqlx.Tx, group *model.ModifierGroup) error {
+	for i := range group.Options {
+		option := &group.Options[i]
+		if option.Id != 0 {
+			continue
+		}
+		option.GroupId = group.Id
+		if _, err := tx.NamedExec(utils.MODIFIER_OPTION_INSERT, option); err != nil {
+			return dbError(err, "option "+option.Name+" of modifier group "+group.Name)
+		}
+	}
+	return nil
+}
+
+func (p *modifierRepository) Delete(menuId string, groupId int64) error {
+	result, err := p.db.Exec(utils.MODIFIER_GROUP_DELETE, groupId, menuId)
+	return mustAffect(result, err, fmt.Sprintf("modifier group %d of menu %s", groupId, menuId))
+}
+
+// get reads a group as it is saved, with the ids of its options.
+func (p *modifierRepository) get(menuId string, groupId int64) (model.ModifierGroup, error) {
+	groups, err := p.GetByMenu(menuId)
+	if err != nil {
+		return model.ModifierGroup{}, err
+	}
+	for _, group := range groups {
+		if group.Id == groupId {
+			return group, nil
+		}
+	}
+	return model.ModifierGroup{}, dbError(sql.ErrNoRows, fmt.Sprintf("modifier group %d of menu %s", groupId, menuId))
+}
+
+func NewModifierRepository(db *sqlx.DB) ModifierRepository {
+	repo := new(modifierRepository)
+	repo.db = db
+	return repo
+}
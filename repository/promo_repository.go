@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type promoRepository struct {
+	db *sqlx.DB
+}
+
+type PromoRepository interface {
+	GetAll() ([]model.Promo, error)
+	GetByCode(code string) (model.Promo, error)
+	Insert(promo *model.Promo) (model.Promo, error)
+	Update(promo *model.Promo) (model.Promo, error)
+}
+
+func (p *promoRepository) GetAll() ([]model.Promo, error) {
+	var promos []model.Promo
+	err := p.db.Select(&promos, utils.PROMO_GET_ALL+" ORDER BY created_at DESC, code")
+	if err != nil {
+		return nil, dbError(err, "promos")
+	}
+	return promos, nil
+}
+
+func (p *promoRepository) GetByCode(code string) (model.Promo, error) {
+	var promo model.Promo
+	err := p.db.Get(&promo, utils.PROMO_GET_BY_CODE, code)
+	if err != nil {
+		return model.Promo{}, dbError(err, "promo "+code)
+	}
+	return promo, nil
+}
+
+// Insert returns the promo as it is saved, with its use count and creation
+// time.
+func (p *promoRepository) Insert(promo *model.Promo) (model.Promo, error) {
+	_, err := p.db.NamedExec(utils.PROMO_INSERT, promo)
+	if err != nil {
+		return model.Promo{}, dbError(err, "promo "+promo.Code)
+	}
+	return p.GetByCode(promo.Code)
+}
+
+func (p *promoRepository) Update(promo *model.Promo) (model.Promo, error) {
+	result, err := p.db.NamedExec(utils.PROMO_UPDATE, promo)
+	if err := mustAffect(result, err, "promo "+promo.Code); err != nil {
+		return model.Promo{}, err
+	}
+	return p.GetByCode(promo.Code)
+}
+
+func NewPromoRepository(db *sqlx.DB) PromoRepository {
+	repo := new(promoRepository)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type purchaseOrderRepository struct {
+	db *sqlx.DB
+}
+
+type PurchaseOrderRepository interface {
+	// List lists the purchase orders without their lines, the newest first.
+	List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error)
+	// GetById reads a purchase order with its lines.
+	GetById(id string) (model.PurchaseOrder, error)
+	// Insert saves a draft with its lines in one db transaction.
+	Insert(order *model.PurchaseOrder) (model.PurchaseOrder, error)
+	// Update replaces the supplier, the note and the lines of a draft in one
+	// db transaction.
+	Update(order *model.PurchaseOrder) (model.PurchaseOrder, error)
+	// Delete removes a draft.
+	Delete(id string) error
+	// SetOrdered marks a draft as sent to its supplier.
+	SetOrdered(id string) error
+	// Receive books what came in for an ordered purchase order in one db
+	// transaction. every line adds its qty to the received qty and cost of
+	// the order line and to the stock of its item, and is recorded as a
+	// purchase movement. the order is received once every line came in.
+	Receive(id string, lines []model.ReceiveLine, note *string, userId string) error
+}
+
+func (p *purchaseOrderRepository) List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error) {
+	orders := []model.PurchaseOrder{}
+	err := p.db.Select(&orders, utils.PURCHASE_ORDER_LIST, filter.SupplierId, filter.Status)
+	if err != nil {
+		return nil, dbError(err, "purchase orders")
+	}
+	return orders, nil
+}
+
+func (p *purchaseOrderRepository) GetById(id string) (model.PurchaseOrder, error) {
+	var order model.PurchaseOrder
+	if err := p.db.Get(&order, utils.PURCHASE_ORDER_GET_BY_ID, id); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "purchase order "+id)
+	}
+
+	order.Lines = []model.PurchaseOrderLine{}
+	if err := p.db.Select(&order.Lines, utils.PURCHASE_LINE_GET_BY_ORDER, id); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "lines of purchase order "+id)
+	}
+	return order, nil
+}
+
+// insertLines saves the lines of a purchase order.
+func insertLines(tx *sqlx.Tx, order *model.PurchaseOrder) error {
+	for _, line := range order.Lines {
+		line.PurchaseOrderId = order.Id
+		if _, err := tx.NamedExec(utils.PURCHASE_LINE_INSERT, line); err != nil {
+			return dbError(err, line.Item().String()+" of purchase order "+order.Id)
+		}
+	}
+	return nil
+}
+
+func (p *purchaseOrderRepository) Insert(order *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.PurchaseOrder{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExec(utils.PURCHASE_ORDER_INSERT, order); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "purchase order "+order.Id)
+	}
+	if err := insertLines(tx, order); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "commit transaction")
+	}
+	return p.GetById(order.Id)
+}
+
+func (p *purchaseOrderRepository) Update(order *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return model.PurchaseOrder{}, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.NamedExec(utils.PURCHASE_ORDER_UPDATE, order)
+	if err := mustAffect(result, err, "draft purchase order "+order.Id); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if _, err := tx.Exec(utils.PURCHASE_LINE_DELETE_BY_ORDER, order.Id); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "lines of purchase order "+order.Id)
+	}
+	if err := insertLines(tx, order); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.PurchaseOrder{}, dbError(err, "commit transaction")
+	}
+	return p.GetById(order.Id)
+}
+
+func (p *purchaseOrderRepository) Delete(id string) error {
+	result, err := p.db.Exec(utils.PURCHASE_ORDER_DELETE, id)
+	return mustAffect(result, err, "draft purchase order "+id)
+}
+
+func (p *purchaseOrderRepository) SetOrdered(id string) error {
+	result, err := p.db.Exec(utils.PURCHASE_ORDER_SET_ORDERED, id)
+	return mustAffect(result, err, "draft purchase order "+id)
+}
+
+func (p *purchaseOrderRepository) Receive(id string, lines []model.ReceiveLine, note *string, userId string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.Get(&status, utils.PURCHASE_ORDER_LOCK_STATUS, id); err != nil {
+		return dbError(err, "purchase order "+id)
+	}
+	if status != model.PurchaseOrdered && status != model.PurchasePartiallyReceived {
+		return apperror.Conflict(fmt.Sprintf("purchase order %s is %s, it must be ordered or partially_received", id, status), nil)
+	}
+
+	for _, each := range lines {
+		var line model.PurchaseOrderLine
+		err := tx.QueryRowx(utils.PURCHASE_LINE_RECEIVE, each.Qty, *each.UnitCost, each.LineId, id).Scan(&line.MenuId, &line.IngredientId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.Conflict(fmt.Sprintf("line %d of purchase order %s has less than %d left to receive", each.LineId, id, each.Qty), nil)
+		}
+		if err != nil {
+			return dbError(err, fmt.Sprintf("line %d of purchase order %s", each.LineId, id))
+		}
+
+		item := line.Item()
+		query := utils.MENU_RECEIVE_STOCK
+		if item.Kind == model.StockItemIngredient {
+			query = utils.INGREDIENT_INCREASE_STOCK
+		}
+		result, err := tx.Exec(query, each.Qty, item.Id)
+		if err != nil {
+			return dbError(err, "stock of "+item.String())
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return dbError(err, "stock of "+item.String())
+		}
+		if affected == 0 {
+			return apperror.Conflict(fmt.Sprintf("line %d of purchase order %s cannot be received, %s is archived", each.LineId, id, item), nil)
+		}
+
+		movement := item.Movement(each.Qty, model.StockPurchase)
+		movement.Note = note
+		movement.UserId = &userId
+		movement.PurchaseOrderId = &id
+		movement.UnitCost = each.UnitCost
+		if err := insertMovement(tx, &movement); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(utils.PURCHASE_ORDER_SET_RECEIVED, id); err != nil {
+		return dbError(err, "purchase order "+id)
+	}
+	if err := tx.Commit(); err != nil {
+		return dbError(err, "commit transaction")
+	}
+	return nil
+}
+
+func NewPurchaseOrderRepository(db *sqlx.DB) PurchaseOrderRepository {
+	repo := new(purchaseOrderRepository)
+	repo.db = db
+	return repo
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"time"
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// to_char formats of the report periods
+var periodFormats = map[string]string{
+	model.PeriodDay:   "YYYY-MM-DD",
+	model.PeriodMonth: "YYYY-MM",
+}
+
+type reportRepository struct {
+	db *sqlx.DB
+}
+
+type ReportRepository interface {
+	Summary(from, to time.Time) (model.SalesSummary, error)
+	// ByPeriod groups the sales by day or month of the given time zone. periods
+	// without sales are left out.
+	ByPeriod(from, to time.Time, period string, location *time.Location) ([]model.SalesPeriod, error)
+	MenuSales(from, to time.Time, sort string, limit int) ([]model.MenuSales, error)
+	PaymentSales(from, to time.Time) ([]model.PaymentSales, error)
+	// SupplierSpending is what was received from every supplier in the
+	// range, the most spent first.
+	SupplierSpending(from, to time.Time) ([]model.SupplierSpending, error)
+	// ShrinkageByPeriod is what the stock takes approved in the range found,
+	// per day or month.
+	ShrinkageByPeriod(from, to time.Time, period string, location *time.Location) ([]model.ShrinkagePeriod, error)
+	// ShrinkageByItem is what the stock takes approved in the range found
+	// per item, the most lost first.
+	ShrinkageByItem(from, to time.Time) ([]model.ShrinkageItem, error)
+}
+
+func (p *reportRepository) Summary(from, to time.Time) (model.SalesSummary, error) {
+	var summary model.SalesSummary
+	err := p.db.Get(&summary, utils.REPORT_SUMMARY, from, to)
+	if err != nil {
+		return model.SalesSummary{}, dbError(err, "sales summary")
+	}
+	return summary, nil
+}
+
+func (p *reportRepository) ByPeriod(from, to time.Time, period string, location *time.Location) ([]model.SalesPeriod, error) {
+	var periods []model.SalesPeriod
+	err := p.db.Select(&periods, utils.REPORT_BY_PERIOD, from, to, location.String(), periodFormats[period])
+	if err != nil {
+		return nil, dbError(err, "sales per "+period)
+	}
+	return periods, nil
+}
+
+func (p *reportRepository) MenuSales(from, to time.Time, sort string, limit int) ([]model.MenuSales, error) {
+	query := utils.REPORT_MENU_SALES_BY_QTY
+	if sort == model.MenuSalesByRevenue {
+		query = utils.REPORT_MENU_SALES_BY_REVENUE
+	}
+
+	var menus []model.MenuSales
+	err := p.db.Select(&menus, query, from, to, limit)
+	if err != nil {
+		return nil, dbError(err, "menu sales")
+	}
+	return menus, nil
+}
+
+func (p *reportRepository) PaymentSales(from, to time.Time) ([]model.PaymentSales, error) {
+	var payments []model.PaymentSales
+	err := p.db.Select(&payments, utils.REPORT_PAYMENT_SALES, from, to)
+	if err != nil {
+		return nil, dbError(err, "sales per payment method")
+	}
+	return payments, nil
+}
+
+func (p *reportRepository) SupplierSpending(from, to time.Time) ([]model.SupplierSpending, error) {
+	suppliers := []model.SupplierSpending{}
+	err := p.db.Select(&suppliers, utils.REPORT_SUPPLIER_SPENDING, from, to)
+	if err != nil {
+		return nil, dbError(err, "spending per supplier")
+	}
+	return suppliers, nil
+}
+
+func (p *reportRepository) ShrinkageByPeriod(from, to time.Time, period string, location *time.Location) ([]model.ShrinkagePeriod, error) {
+	var periods []model.ShrinkagePeriod
+	err := p.db.Select(&periods, utils.REPORT_SHRINKAGE_BY_PERIOD, from, to, location.String(), periodFormats[period])
+	if err != nil {
+		return nil, dbError(err, "shrinkage per "+period)
+	}
+	return periods, nil
+}
+
+func (p *reportRepository) ShrinkageByItem(from, to time.Time) ([]model.ShrinkageItem, error) {
+	items := []model.ShrinkageItem{}
+	err := p.db.Select(&items, utils.REPORT_SHRINKAGE_BY_ITEM, from, to)
+	if err != nil {
+		return nil, dbError(err, "shrinkage per item")
+	}
+	return items, nil
+}
+
+func NewReportRepository(db *sqlx.DB) ReportRepository {
+	repo := new(reportRepository)
+	repo.db = db
+	return repo
+}
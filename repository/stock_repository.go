@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type stockRepository struct {
+	db *sqlx.DB
+}
+
+type StockRepository interface {
+	// GetByItem lists the movements of a menu or an ingredient, the newest
+	// first.
+	GetByItem(item model.StockItem) ([]model.StockMovement, error)
+	// Move changes the stock of a menu or an ingredient by the qty of the
+	// movement and records it in one db transaction. it returns false when
+	// the menu is archived or the item would have less stock than it has
+	// reserved.
+	Move(movement *model.StockMovement) (bool, error)
+	// Alerts lists the menus and the ingredients under their reorder level,
+	// menus first.
+	Alerts() ([]model.StockAlert, error)
+}
+
+// insertMovement records a movement and reads back its id and time.
+func insertMovement(e sqlx.Ext, movement *model.StockMovement) error {
+	rows, err := sqlx.NamedQuery(e, utils.STOCK_MOVEMENT_INSERT, movement)
+	if err != nil {
+		return dbError(err, "stock movement of "+movement.Item().String())
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = rows.Scan(&movement.Id, &movement.CreatedAt)
+	}
+	return dbError(err, "stock movement of "+movement.Item().String())
+}
+
+func (p *stockRepository) GetByItem(item model.StockItem) ([]model.StockMovement, error) {
+	query := utils.STOCK_MOVEMENT_GET_BY_MENU
+	if item.Kind == model.StockItemIngredient {
+		query = utils.STOCK_MOVEMENT_GET_BY_INGREDIENT
+	}
+
+	movements := []model.StockMovement{}
+	err := p.db.Select(&movements, query, item.Id)
+	if err != nil {
+		return nil, dbError(err, "stock movements of "+item.String())
+	}
+	return movements, nil
+}
+
+func (p *stockRepository) Move(movement *model.StockMovement) (bool, error) {
+	item := movement.Item()
+	query := utils.MENU_MOVE_STOCK
+	if item.Kind == model.StockItemIngredient {
+		query = utils.INGREDIENT_MOVE_STOCK
+	}
+
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return false, dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, movement.Qty, item.Id)
+	if err != nil {
+		return false, dbError(err, "stock of "+item.String())
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "stock of "+item.String())
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err := insertMovement(tx, movement); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, dbError(err, "commit transaction")
+	}
+	return true, nil
+}
+
+func (p *stockRepository) Alerts() ([]model.StockAlert, error) {
+	alerts := []model.StockAlert{}
+	if err := p.db.Select(&alerts, utils.STOCK_ALERT_GET_ALL); err != nil {
+		return nil, dbError(err, "stock alerts")
+	}
+	return alerts, nil
+}
+
+func NewStockRepository(db *sqlx.DB) StockRepository {
+	repo := new(stockRepository)
+	repo.db = db
+	return repo
+}
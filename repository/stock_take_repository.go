@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type stockTakeRepository struct {
+	db *sqlx.DB
+}
+
+type StockTakeRepository interface {
+	// List lists the stock takes without their lines, the newest first, an
+	// empty status matches all.
+	List(status string) ([]model.StockTake, error)
+	// GetById reads a stock take with its lines.
+	GetById(id string) (model.StockTake, error)
+	Insert(stockTake *model.StockTake) (model.StockTake, error)
+	// Count saves counts of a stock take that is still counting in one db
+	// transaction, the stock of every item is taken as expected.
+	Count(id string, counts []model.StockCount, userId string) error
+	// Approve adjusts the stock of every item by its variance and records
+	// it as an adjustment of the stock take in one db transaction.
+	Approve(id string, userId string) error
+	// Cancel drops a stock take that is still counting, the stock is left
+	// alone.
+	Cancel(id string) error
+}
+
+func (p *stockTakeRepository) List(status string) ([]model.StockTake, error) {
+	stockTakes := []model.StockTake{}
+	err := p.db.Select(&stockTakes, utils.STOCK_TAKE_LIST, status)
+	if err != nil {
+		return nil, dbError(err, "stock takes")
+	}
+	return stockTakes, nil
+}
+
+func (p *stockTakeRepository) GetById(id string) (model.StockTake, error) {
+	var stockTake model.StockTake
+	if err := p.db.Get(&stockTake, utils.STOCK_TAKE_GET_BY_ID, id); err != nil {
+		return model.StockTake{}, dbError(err, "stock take "+id)
+	}
+
+	stockTake.Lines = []model.StockTakeLine{}
+	if err := p.db.Select(&stockTake.Lines, utils.STOCK_TAKE_LINE_GET_BY_TAKE, id); err != nil {
+		return model.StockTake{}, dbError(err, "lines of stock take "+id)
+	}
+	return stockTake, nil
+}
+
+func (p *stockTakeRepository) Insert(stockTake *model.StockTake) (model.StockTake, error) {
+	if _, err := p.db.NamedExec(utils.STOCK_TAKE_INSERT, stockTake); err != nil {
+		return model.StockTake{}, dbError(err, "stock take "+stockTake.Id)
+	}
+	return p.GetById(stockTake.Id)
+}
+
+// lockCounting locks a stock take that must still be counting.
+func lockCounting(tx *sqlx.Tx, id string) error {
+	var status string
+	if err := tx.Get(&status, utils.STOCK_TAKE_LOCK_STATUS, id); err != nil {
+		return dbError(err, "stock take "+id)
+	}
+	if status != model.StockTakeCounting {
+		return apperror.Conflict(fmt.Sprintf("stock take %s is %s, it must be counting", id, status), nil)
+	}
+	return nil
+}
+
+func (p *stockTakeRepository) Count(id string, counts []model.StockCount, userId string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := lockCounting(tx, id); err != nil {
+		return err
+	}
+	for _, count := range counts {
+		item := count.Item()
+		query := utils.STOCK_TAKE_LINE_COUNT_MENU
+		if item.Kind == model.StockItemIngredient {
+			query = utils.STOCK_TAKE_LINE_COUNT_INGREDIENT
+		}
+		result, err := tx.Exec(query, id, item.Id, *count.Counted, userId)
+		if err := mustAffect(result, err, item.String()); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return dbError(err, "commit transaction")
+	}
+	return nil
+}
+
+func (p *stockTakeRepository) Approve(id string, userId string) error {
+	tx, err := p.db.Beginx()
+	if err != nil {
+		return dbError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := lockCounting(tx, id); err != nil {
+		return err
+	}
+	lines := []model.StockTakeLine{}
+	if err := tx.Select(&lines, utils.STOCK_TAKE_LINE_GET_BY_TAKE, id); err != nil {
+		return dbError(err, "lines of stock take "+id)
+	}
+
+	note := "stock take " + id
+	for _, line := range lines {
+		if line.Variance == 0 {
+			continue
+		}
+		item := line.Item()
+		query := utils.MENU_MOVE_STOCK
+		if item.Kind == model.StockItemIngredient {
+			query = utils.INGREDIENT_MOVE_STOCK
+		}
+		result, err := tx.Exec(query, line.Variance, item.Id)
+		if err != nil {
+			return dbError(err, "stock of "+item.String())
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return dbError(err, "stock of "+item.String())
+		}
+		if affected == 0 {
+			return apperror.Conflict(fmt.Sprintf("stock of %s cannot be adjusted by %d, it is archived or orders hold more than would be left", item, line.Variance), nil)
+		}
+
+		movement := item.Movement(line.Variance, model.StockAdjustment)
+		movement.Note = &note
+		movement.UserId = &userId
+		movement.UnitCost = line.UnitCost
+		movement.StockTakeId = &id
+		if err := insertMovement(tx, &movement); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(utils.STOCK_TAKE_APPROVE, id, userId); err != nil {
+		return dbError(err, "stock take "+id)
+	}
+	if err := tx.Commit(); err != nil {
+		return dbError(err, "commit transaction")
+	}
+	return nil
+}
+
+func (p *stockTakeRepository) Cancel(id string) error {
+	result, err := p.db.Exec(utils.STOCK_TAKE_CANCEL, id)
+	return mustAffect(result, err, "counting stock take "+id)
+}
+
+func NewStockTakeRepository(db *sqlx.DB) StockTakeRepository {
+	repo := new(stockTakeRepository)
+	repo.db = db
+	return repo
+}
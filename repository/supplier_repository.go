@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type supplierRepository struct {
+	db *sqlx.DB
+}
+
+type SupplierRepository interface {
+	GetAll() ([]model.Supplier, error)
+	GetById(id string) (model.Supplier, error)
+	Insert(supplier *model.Supplier) (model.Supplier, error)
+	Update(supplier *model.Supplier) (model.Supplier, error)
+	// Delete removes a supplier, it fails while a purchase order is of it.
+	Delete(id string) error
+}
+
+func (p *supplierRepository) GetAll() ([]model.Supplier, error) {
+	suppliers := []model.Supplier{}
+	err := p.db.Select(&suppliers, utils.SUPPLIER_GET_ALL+" ORDER BY name, id")
+	if err != nil {
+		return nil, dbError(err, "suppliers")
+	}
+	return suppliers, nil
+}
+
+func (p *supplierRepository) GetById(id string) (model.Supplier, error) {
+	var supplier model.Supplier
+	err := p.db.Get(&supplier, utils.SUPPLIER_GET_BY_ID, id)
+	if err != nil {
+		return model.Supplier{}, dbError(err, "supplier "+id)
+	}
+	return supplier, nil
+}
+
+func (p *supplierRepository) Insert(supplier *model.Supplier) (model.Supplier, error) {
+	_, err := p.db.NamedExec(utils.SUPPLIER_INSERT, supplier)
+	if err != nil {
+		return model.Supplier{}, dbError(err, "supplier "+supplier.Name)
+	}
+	return p.GetById(supplier.Id)
+}
+
+func (p *supplierRepository) Update(supplier *model.Supplier) (model.Supplier, error) {
+	result, err := p.db.NamedExec(utils.SUPPLIER_UPDATE, supplier)
+	if err := mustAffect(result, err, "supplier "+supplier.Id); err != nil {
+		return model.Supplier{}, err
+	}
+	return p.GetById(supplier.Id)
+}
+
+func (p *supplierRepository) Delete(id string) error {
+	result, err := p.db.Exec(utils.SUPPLIER_DELETE, id)
+	return mustAffect(result, err, "supplier "+id)
+}
+
+func NewSupplierRepository(db *sqlx.DB) SupplierRepository {
+	repo := new(supplierRepository)
+	repo.db = db
+	return repo
+}
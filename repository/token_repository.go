@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type tokenRepository struct {
+	db *sqlx.DB
+}
+
+type TokenRepository interface {
+	GetRefreshTokenByHash(hash string) (model.RefreshToken, error)
+	InsertRefreshToken(refreshToken *model.RefreshToken) error
+	RevokeRefreshToken(id string) (bool, error)
+	RevokeRefreshTokenByAccessJti(jti string) error
+	RevokeUserRefreshTokens(userId string) ([]string, error)
+
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+}
+
+func (p *tokenRepository) GetRefreshTokenByHash(hash string) (model.RefreshToken, error) {
+	var refreshToken model.RefreshToken
+	err := p.db.Get(&refreshToken, utils.REFRESH_TOKEN_GET_BY_HASH, hash)
+	if err != nil {
+		return model.RefreshToken{}, dbError(err, "refresh token")
+	}
+	return refreshToken, nil
+}
+
+func (p *tokenRepository) InsertRefreshToken(refreshToken *model.RefreshToken) error {
+	_, err := p.db.NamedExec(utils.REFRESH_TOKEN_INSERT, refreshToken)
+	return dbError(err, "refresh token")
+}
+
+// returns false when the token was already revoked, so a refresh token
+// can only be used once even with concurrent requests
+func (p *tokenRepository) RevokeRefreshToken(id string) (bool, error) {
+	result, err := p.db.Exec(utils.REFRESH_TOKEN_REVOKE, id)
+	if err != nil {
+		return false, dbError(err, "refresh token "+id)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "refresh token "+id)
+	}
+	return affected > 0, nil
+}
+
+func (p *tokenRepository) RevokeRefreshTokenByAccessJti(jti string) error {
+	_, err := p.db.Exec(utils.REFRESH_TOKEN_REVOKE_BY_ACCESS_JTI, jti)
+	return dbError(err, "refresh token of access token "+jti)
+}
+
+// returns the id of the last access token issued for every revoked session
+func (p *tokenRepository) RevokeUserRefreshTokens(userId string) ([]string, error) {
+	var accessJtis []string
+	err := p.db.Select(&accessJtis, utils.REFRESH_TOKEN_REVOKE_BY_USER, userId)
+	if err != nil {
+		return nil, dbError(err, "refresh tokens of user "+userId)
+	}
+	return accessJtis, nil
+}
+
+func (p *tokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	_, err := p.db.Exec(utils.REVOKED_TOKEN_INSERT, jti, expiresAt)
+	return dbError(err, "revoked token "+jti)
+}
+
+func (p *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := p.db.Get(&revoked, utils.REVOKED_TOKEN_EXISTS, jti)
+	if err != nil {
+		return false, dbError(err, "revoked token "+jti)
+	}
+	return revoked, nil
+}
+
+func NewTokenRepository(db *sqlx.DB) TokenRepository {
+	repo := new(tokenRepository)
+	repo.db = db
+	return repo
+}
@@ -5,6 +5,7 @@ import (
 	"warung-makan/utils"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type transactionDetailRepository struct {
@@ -14,6 +15,9 @@ type transactionDetailRepository struct {
 type TransactionDetailRepository interface {
 	// GetAll() ([]model.TransactionDetail, error)
 	GetByTrasactionId(id string) ([]model.TransactionDetail, error)
+	// GetByTransactionIds reads the items of many transactions in one query,
+	// keyed by transaction id
+	GetByTransactionIds(ids []string) (map[string][]model.TransactionDetail, error)
 	// GetByName(name string) ([]model.TransactionDetail, error)
 
 	Insert(transactionDetail *model.TransactionDetail) (model.TransactionDetail, error)
@@ -53,16 +57,34 @@ func (p *transactionDetailRepository) GetByTrasactionId(id string) ([]model.Tran
 	var transactionDetails []model.TransactionDetail
 	err := p.db.Select(&transactionDetails, utils.TRANSACTION_DETAIL_GET_BY_ID_TRANSACTION, id)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "items of transaction "+id)
 	}
 	return transactionDetails, nil
 }
 
+func (p *transactionDetailRepository) GetByTransactionIds(ids []string) (map[string][]model.TransactionDetail, error) {
+	items := map[string][]model.TransactionDetail{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	var transactionDetails []model.TransactionDetail
+	err := p.db.Select(&transactionDetails, utils.TRANSACTION_DETAIL_GET_BY_TRANSACTIONS, pq.Array(ids))
+	if err != nil {
+		return nil, dbError(err, "transaction items")
+	}
+
+	for _, each := range transactionDetails {
+		items[each.TransactionId] = append(items[each.TransactionId], each)
+	}
+	return items, nil
+}
+
 func (p *transactionDetailRepository) Insert(newTransactionDetail *model.TransactionDetail) (model.TransactionDetail, error) {
 
 	_, err := p.db.NamedExec(utils.TRANSACTION_DETAIL_INSERT, newTransactionDetail)
 	if err != nil {
-		return model.TransactionDetail{}, err
+		return model.TransactionDetail{}, dbError(err, "item of transaction "+newTransactionDetail.TransactionId)
 	}
 	transactionDetail := newTransactionDetail
 	return *transactionDetail, nil
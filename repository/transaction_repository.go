@@ -1,10 +1,12 @@
 package repository
 
 import (
+	"strconv"
 	"warung-makan/model"
 	"warung-makan/utils"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type transactionRepository struct {
@@ -14,13 +16,19 @@ type transactionRepository struct {
 type TransactionRepository interface {
 	// GetAllPaginated(page int, rows int) ([]model.Transaction, error)
 	GetAll() ([]model.Transaction, error)
+	List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error)
+	// Export streams the filtered transactions oldest first, each with its
+	// items and their menu names. paging of the filter is not used.
+	Export(filter model.TransactionFilter, each func(model.Transaction) error) error
 	GetAllTest() ([]model.Transaction, error)
 	// GetAllTransaction() ([]model.TransactionTest, error)
 	GetById(id string) (model.Transaction, error)
 	GetByIdTest(id string) (model.TransactionTest, error)
 	// GetByName(name string) ([]model.Transaction, error)
 
-	Insert(transaction *model.Transaction) (model.Transaction, error)
+	// Begin starts a db transaction for creating a transaction together with
+	// its stock changes
+	Begin() (TransactionTx, error)
 	InsertTest(transaction *model.TransactionTest) (model.TransactionTest, error)
 	// Update(transaction *model.Transaction) (model.Transaction, error)
 	// Delete(id string) error
@@ -31,20 +39,169 @@ func (p *transactionRepository) GetAll() ([]model.Transaction, error) {
 
 	err := p.db.Select(&transactions, utils.TRANSACTION_GET_ALL+" order by created_at desc")
 	if err != nil {
+		return nil, dbError(err, "transactions")
+	}
+
+	if err := p.loadItems(transactions); err != nil {
 		return nil, err
 	}
+	return transactions, nil
+}
 
-	tdRepo := NewTransactionDetailRepository(p.db)
+var transactionList = listSpec{
+	sorts: map[string]sortColumn{
+		"id":         {"id", "text"},
+		"created_at": {"created_at", "timestamptz"},
+		"total":      {"total_price", "integer"},
+	},
+	defaultSort:  "created_at",
+	defaultOrder: model.OrderDesc,
+}
 
-	for i, transaction := range transactions {
-		items, err := tdRepo.GetByTrasactionId(transaction.Id)
-		if err != nil {
-			panic(err)
+func (p *transactionRepository) List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error) {
+	plan, err := transactionList.plan(filter.ListQuery)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	var where conditions
+	if filter.From != nil {
+		where.add("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		where.add("created_at < ?", *filter.To)
+	}
+	if filter.MinTotal != nil {
+		where.add("total_price >= ?", *filter.MinTotal)
+	}
+	if filter.Status != "" {
+		where.add("status = ?", filter.Status)
+	}
+
+	var total int
+	err = p.db.Get(&total, p.db.Rebind(utils.TRANSACTION_COUNT+where.sql()), where.args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "transactions")
+	}
+
+	query, args := plan.query(utils.TRANSACTION_GET_ALL, where)
+	var transactions []model.Transaction
+	err = p.db.Select(&transactions, p.db.Rebind(query), args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "transactions")
+	}
+
+	info := plan.info(total)
+	if len(transactions) > plan.limit {
+		transactions = transactions[:plan.limit]
+		last := transactions[len(transactions)-1]
+		info.NextCursor = plan.next(transactionSortValue(last, plan.sort), last.Id)
+	}
+
+	if err := p.loadItems(transactions); err != nil {
+		return nil, model.PageInfo{}, err
+	}
+	return transactions, info, nil
+}
+
+// exportRow is one item of a transaction, as read by TRANSACTION_EXPORT.
+type exportRow struct {
+	Id             string `db:"id"`
+	Subtotal       int    `db:"transaction_subtotal"`
+	DiscountAmount int    `db:"transaction_discount"`
+	ServiceCharge  int    `db:"service_charge"`
+	Tax            int    `db:"tax"`
+	TotalPrice     int    `db:"total_price"`
+	Status         string `db:"status"`
+	Created_at     string `db:"created_at"`
+	model.TransactionDetail
+}
+
+func (p *transactionRepository) Export(filter model.TransactionFilter, each func(model.Transaction) error) error {
+	var where conditions
+	if filter.From != nil {
+		where.add("t.created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		where.add("t.created_at < ?", *filter.To)
+	}
+	if filter.MinTotal != nil {
+		where.add("t.total_price >= ?", *filter.MinTotal)
+	}
+	if filter.Status != "" {
+		where.add("t.status = ?", filter.Status)
+	}
+
+	rows, err := p.db.Queryx(p.db.Rebind(utils.TRANSACTION_EXPORT+where.sql()+utils.TRANSACTION_EXPORT_ORDER), where.args...)
+	if err != nil {
+		return dbError(err, "transactions")
+	}
+	defer rows.Close()
+
+	// rows of one transaction come together, it is passed on when the next
+	// one starts
+	var current model.Transaction
+	for rows.Next() {
+		var row exportRow
+		if err := rows.StructScan(&row); err != nil {
+			return dbError(err, "transactions")
+		}
+		row.TransactionId = row.Id
+
+		if row.Id != current.Id && current.Id != "" {
+			if err := each(current); err != nil {
+				return err
+			}
+		}
+		if row.Id != current.Id {
+			current = model.Transaction{
+				Id:             row.Id,
+				Subtotal:       row.Subtotal,
+				DiscountAmount: row.DiscountAmount,
+				ServiceCharge:  row.ServiceCharge,
+				Tax:            row.Tax,
+				TotalPrice:     row.TotalPrice,
+				Status:         row.Status,
+				Created_at:     row.Created_at,
+			}
 		}
-		transactions[i].Items = items
+		current.Items = append(current.Items, row.TransactionDetail)
+	}
+	if err := rows.Err(); err != nil {
+		return dbError(err, "transactions")
 	}
 
-	return transactions, nil
+	if current.Id != "" {
+		return each(current)
+	}
+	return nil
+}
+
+func transactionSortValue(transaction model.Transaction, sort string) string {
+	switch sort {
+	case "created_at":
+		return transaction.Created_at
+	case "total":
+		return strconv.Itoa(transaction.TotalPrice)
+	}
+	return transaction.Id
+}
+
+// loadItems fills the items of all transactions with one query.
+func (p *transactionRepository) loadItems(transactions []model.Transaction) error {
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.Id
+	}
+
+	items, err := NewTransactionDetailRepository(p.db).GetByTransactionIds(ids)
+	if err != nil {
+		return err
+	}
+	for i, transaction := range transactions {
+		transactions[i].Items = items[transaction.Id]
+	}
+	return nil
 }
 
 func (p *transactionRepository) GetAllTest() ([]model.Transaction, error) {
@@ -52,7 +209,7 @@ func (p *transactionRepository) GetAllTest() ([]model.Transaction, error) {
 
 	err := p.db.Select(&transactions, utils.TRANSACTION_GET_ALL+" order by created_at desc")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "transactions")
 	}
 
 	return transactions, nil
@@ -96,71 +253,84 @@ func (p *transactionRepository) GetById(id string) (model.Transaction, error) {
 	var transaction model.Transaction
 	err := p.db.Get(&transaction, utils.TRANSACTION_GET_BY_ID, id)
 	if err != nil {
-		return model.Transaction{}, err
+		return model.Transaction{}, dbError(err, "transaction "+id)
 	}
 
 	tdRepo := NewTransactionDetailRepository(p.db)
 
 	items, err := tdRepo.GetByTrasactionId(transaction.Id)
 	if err != nil {
-		panic(err)
+		return model.Transaction{}, err
 	}
 	transaction.Items = items
 
-	return transaction, nil
-}
+	err = p.db.Select(&transaction.Payments, utils.PAYMENT_GET_BY_TRANSACTION, transaction.Id)
+	if err != nil {
+		return model.Transaction{}, dbError(err, "payments of transaction "+transaction.Id)
+	}
 
-func (p *transactionRepository) GetByIdTest(id string) (model.TransactionTest, error) {
-	var transaction model.TransactionTest
-	err := p.db.Get(&transaction, utils.TRANSACTION_GET_BY_ID, id)
+	refunds, err := p.refunds(transaction.Id)
 	if err != nil {
-		return model.TransactionTest{}, err
+		return model.Transaction{}, err
 	}
+	transaction.Refunds = refunds
 
 	return transaction, nil
 }
 
-func (p *transactionRepository) Insert(newTransaction *model.Transaction) (model.Transaction, error) {
-	// ===================================
-	tx, err := p.db.Beginx()
+func (p *transactionRepository) refunds(transactionId string) ([]model.Refund, error) {
+	var refunds []model.Refund
+	err := p.db.Select(&refunds, utils.TRANSACTION_REFUND_GET_BY_TRANSACTION, transactionId)
 	if err != nil {
-		panic(err)
+		return nil, dbError(err, "refunds of transaction "+transactionId)
 	}
-	_, err = tx.NamedExec(utils.TRANSACTION_INSERT, newTransaction)
-	if err != nil {
-		panic(err)
+	if len(refunds) == 0 {
+		return nil, nil
 	}
 
-	for _, each := range newTransaction.Items {
-		_, err = tx.NamedExec(utils.TRANSACTION_DETAIL_INSERT, each)
-		if err != nil {
-			panic(err)
-		}
+	ids := make([]string, len(refunds))
+	for i, refund := range refunds {
+		ids[i] = refund.Id
+	}
+	var items []model.RefundItem
+	err = p.db.Select(&items, utils.TRANSACTION_REFUND_ITEM_GET_BY_REFUNDS, pq.Array(ids))
+	if err != nil {
+		return nil, dbError(err, "refunds of transaction "+transactionId)
+	}
 
-		_, err = tx.NamedExec(utils.MENU_UPDATE_STOCK, each)
-		if err != nil {
-			panic(err)
-		}
+	byRefund := map[string][]model.RefundItem{}
+	for _, item := range items {
+		byRefund[item.RefundId] = append(byRefund[item.RefundId], item)
 	}
+	for i, refund := range refunds {
+		refunds[i].Items = byRefund[refund.Id]
+	}
+	return refunds, nil
+}
 
-	err = tx.Commit()
+func (p *transactionRepository) GetByIdTest(id string) (model.TransactionTest, error) {
+	var transaction model.TransactionTest
+	err := p.db.Get(&transaction, utils.TRANSACTION_GET_BY_ID, id)
 	if err != nil {
-		panic(err)
+		return model.TransactionTest{}, dbError(err, "transaction "+id)
 	}
-	// ===================================
 
+	return transaction, nil
+}
+
+func (p *transactionRepository) Begin() (TransactionTx, error) {
+	tx, err := p.db.Beginx()
 	if err != nil {
-		return model.Transaction{}, err
+		return nil, dbError(err, "begin transaction")
 	}
-
-	return *newTransaction, nil
+	return &transactionTx{tx: tx}, nil
 }
 
 func (p *transactionRepository) InsertTest(newTransaction *model.TransactionTest) (model.TransactionTest, error) {
 
-	_, err := p.db.NamedExec(utils.TRANSACTION_INSERT, newTransaction)
+	_, err := p.db.Exec(utils.TRANSACTION_INSERT_TEST, newTransaction.Id, newTransaction.TotalPrice)
 	if err != nil {
-		return model.TransactionTest{}, nil
+		return model.TransactionTest{}, dbError(err, "transaction "+newTransaction.Id)
 	}
 
 	return *newTransaction, nil
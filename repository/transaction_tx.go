@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type transactionTx struct {
+	tx *sqlx.Tx
+}
+
+type TransactionTx interface {
+	// LockMenus reads the menus and locks their rows until Commit or Rollback.
+	// unknown ids are left out.
+	LockMenus(ids []string) ([]model.Menu, error)
+	// ModifierGroups reads the modifier groups of menus with their options.
+	ModifierGroups(menuIds []string) ([]model.ModifierGroup, error)
+	// DecreaseStock returns false when the menu has less than qty available.
+	DecreaseStock(menuId string, qty int) (bool, error)
+	// ReserveStock holds qty of the available stock for an order, it returns
+	// false when less is available.
+	ReserveStock(menuId string, qty int) (bool, error)
+	ReleaseStock(menuId string, qty int) error
+	// CommitStock takes reserved stock out of the stock.
+	CommitStock(menuId string, qty int) error
+	// Recipes reads the recipes of menus.
+	Recipes(menuIds []string) ([]model.RecipeLine, error)
+	// LockIngredients reads the ingredients and locks their rows until Commit
+	// or Rollback. unknown ids are left out.
+	LockIngredients(ids []string) ([]model.Ingredient, error)
+	// DecreaseIngredient returns false when the ingredient has less than qty
+	// available.
+	DecreaseIngredient(ingredientId string, qty int) (bool, error)
+	// ReserveIngredient holds qty of an ingredient for an order, it returns
+	// false when less is available.
+	ReserveIngredient(ingredientId string, qty int) (bool, error)
+	ReleaseIngredient(ingredientId string, qty int) error
+	// CommitIngredient takes the reserved qty out of the stock.
+	CommitIngredient(ingredientId string, qty int) error
+	Insert(transaction *model.Transaction) error
+	InsertItems(items []model.TransactionDetail) error
+	InsertPayments(payments []model.Payment) error
+	DeleteItem(transactionId string, itemId int64) error
+	// SetPrices saves the price breakdown of a transaction.
+	SetPrices(transaction *model.Transaction) error
+	// LockPromo reads a promo and locks its row until Commit or Rollback.
+	LockPromo(code string) (model.Promo, error)
+	UsePromo(code string) error
+	// ReleasePromo gives back the use of a promo by a sale that is voided or
+	// refunded in full.
+	ReleasePromo(code string) error
+
+	// LockTransaction reads a transaction with its items and locks its row
+	// until Commit or Rollback.
+	LockTransaction(id string) (model.Transaction, error)
+	// RefundedQty is how much of each item of the transaction was already
+	// given back, keyed by item id.
+	RefundedQty(transactionId string) (map[int64]int, error)
+	InsertRefund(refund *model.Refund) error
+	IncreaseStock(menuId string, qty int) error
+	IncreaseIngredient(ingredientId string, qty int) error
+	// InsertMovements records the stock movements of a transaction.
+	InsertMovements(movements []model.StockMovement) error
+	SetStatus(transactionId string, status string) error
+
+	Commit() error
+	Rollback() error
+}
+
+func (t *transactionTx) LockMenus(ids []string) ([]model.Menu, error) {
+	var menus []model.Menu
+	err := t.tx.Select(&menus, utils.MENU_LOCK_BY_IDS, pq.Array(ids))
+	if err != nil {
+		return nil, dbError(err, "menus")
+	}
+	return menus, nil
+}
+
+func (t *transactionTx) ModifierGroups(menuIds []string) ([]model.ModifierGroup, error) {
+	return modifierGroups(t.tx, menuIds)
+}
+
+func (t *transactionTx) DecreaseStock(menuId string, qty int) (bool, error) {
+	result, err := t.tx.Exec(utils.MENU_DECREASE_STOCK, qty, menuId)
+	if err != nil {
+		return false, dbError(err, "stock of menu "+menuId)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "stock of menu "+menuId)
+	}
+	return affected == 1, nil
+}
+
+func (t *transactionTx) ReserveStock(menuId string, qty int) (bool, error) {
+	result, err := t.tx.Exec(utils.MENU_RESERVE_STOCK, qty, menuId)
+	if err != nil {
+		return false, dbError(err, "stock of menu "+menuId)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "stock of menu "+menuId)
+	}
+	return affected == 1, nil
+}
+
+func (t *transactionTx) ReleaseStock(menuId string, qty int) error {
+	_, err := t.tx.Exec(utils.MENU_RELEASE_STOCK, qty, menuId)
+	return dbError(err, "stock of menu "+menuId)
+}
+
+func (t *transactionTx) CommitStock(menuId string, qty int) error {
+	_, err := t.tx.Exec(utils.MENU_COMMIT_STOCK, qty, menuId)
+	return dbError(err, "stock of menu "+menuId)
+}
+
+func (t *transactionTx) Recipes(menuIds []string) ([]model.RecipeLine, error) {
+	return recipes(t.tx, menuIds)
+}
+
+func (t *transactionTx) LockIngredients(ids []string) ([]model.Ingredient, error) {
+	var ingredients []model.Ingredient
+	err := t.tx.Select(&ingredients, utils.INGREDIENT_LOCK_BY_IDS, pq.Array(ids))
+	if err != nil {
+		return nil, dbError(err, "ingredients")
+	}
+	return ingredients, nil
+}
+
+func (t *transactionTx) DecreaseIngredient(ingredientId string, qty int) (bool, error) {
+	result, err := t.tx.Exec(utils.INGREDIENT_DECREASE_STOCK, qty, ingredientId)
+	if err != nil {
+		return false, dbError(err, "stock of ingredient "+ingredientId)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "stock of ingredient "+ingredientId)
+	}
+	return affected == 1, nil
+}
+
+func (t *transactionTx) ReserveIngredient(ingredientId string, qty int) (bool, error) {
+	result, err := t.tx.Exec(utils.INGREDIENT_RESERVE_STOCK, qty, ingredientId)
+	if err != nil {
+		return false, dbError(err, "stock of ingredient "+ingredientId)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err, "stock of ingredient "+ingredientId)
+	}
+	return affected == 1, nil
+}
+
+func (t *transactionTx) ReleaseIngredient(ingredientId string, qty int) error {
+	_, err := t.tx.Exec(utils.INGREDIENT_RELEASE_STOCK, qty, ingredientId)
+	return dbError(err, "stock of ingredient "+ingredientId)
+}
+
+func (t *transactionTx) CommitIngredient(ingredientId string, qty int) error {
+	_, err := t.tx.Exec(utils.INGREDIENT_COMMIT_STOCK, qty, ingredientId)
+	return dbError(err, "stock of ingredient "+ingredientId)
+}
+
+func (t *transactionTx) Insert(transaction *model.Transaction) error {
+	_, err := t.tx.NamedExec(utils.TRANSACTION_INSERT, transaction)
+	if err != nil {
+		return dbError(err, "transaction "+transaction.Id)
+	}
+	if err := t.InsertItems(transaction.Items); err != nil {
+		return err
+	}
+	return t.InsertPayments(transaction.Payments)
+}
+
+func (t *transactionTx) InsertItems(items []model.TransactionDetail) error {
+	for _, each := range items {
+		_, err := t.tx.NamedExec(utils.TRANSACTION_DETAIL_INSERT, each)
+		if err != nil {
+			return dbError(err, "item "+each.MenuId+" of transaction "+each.TransactionId)
+		}
+	}
+	return nil
+}
+
+func (t *transactionTx) InsertPayments(payments []model.Payment) error {
+	for _, each := range payments {
+		_, err := t.tx.NamedExec(utils.PAYMENT_INSERT, each)
+		if err != nil {
+			return dbError(err, each.Method+" payment of transaction "+each.TransactionId)
+		}
+	}
+	return nil
+}
+
+func (t *transactionTx) DeleteItem(transactionId string, itemId int64) error {
+	result, err := t.tx.Exec(utils.TRANSACTION_DETAIL_DELETE, itemId, transactionId)
+	return mustAffect(result, err, fmt.Sprintf("item %d of transaction %s", itemId, transactionId))
+}
+
+func (t *transactionTx) SetPrices(transaction *model.Transaction) error {
+	result, err := t.tx.NamedExec(utils.TRANSACTION_SET_PRICES, transaction)
+	return mustAffect(result, err, "transaction "+transaction.Id)
+}
+
+func (t *transactionTx) LockPromo(code string) (model.Promo, error) {
+	var promo model.Promo
+	err := t.tx.Get(&promo, utils.PROMO_LOCK, code)
+	if err != nil {
+		return model.Promo{}, dbError(err, "promo "+code)
+	}
+	return promo, nil
+}
+
+func (t *transactionTx) UsePromo(code string) error {
+	result, err := t.tx.Exec(utils.PROMO_USE, code)
+	return mustAffect(result, err, "promo "+code)
+}
+
+func (t *transactionTx) ReleasePromo(code string) error {
+	result, err := t.tx.Exec(utils.PROMO_RELEASE, code)
+	return mustAffect(result, err, "promo "+code)
+}
+
+func (t *transactionTx) LockTransaction(id string) (model.Transaction, error) {
+	var transaction model.Transaction
+	err := t.tx.Get(&transaction, utils.TRANSACTION_LOCK, id)
+	if err != nil {
+		return model.Transaction{}, dbError(err, "transaction "+id)
+	}
+
+	err = t.tx.Select(&transaction.Items, utils.TRANSACTION_DETAIL_GET_BY_ID_TRANSACTION, id)
+	if err != nil {
+		return model.Transaction{}, dbError(err, "items of transaction "+id)
+	}
+	return transaction, nil
+}
+
+func (t *transactionTx) RefundedQty(transactionId string) (map[int64]int, error) {
+	var rows []struct {
+		DetailId int64 `db:"detail_id"`
+		Qty      int   `db:"qty"`
+	}
+	err := t.tx.Select(&rows, utils.TRANSACTION_REFUNDED_QTY, transactionId)
+	if err != nil {
+		return nil, dbError(err, "refunds of transaction "+transactionId)
+	}
+
+	refunded := map[int64]int{}
+	for _, row := range rows {
+		refunded[row.DetailId] = row.Qty
+	}
+	return refunded, nil
+}
+
+func (t *transactionTx) InsertRefund(refund *model.Refund) error {
+	_, err := t.tx.NamedExec(utils.TRANSACTION_REFUND_INSERT, refund)
+	if err != nil {
+		return dbError(err, "refund of transaction "+refund.TransactionId)
+	}
+
+	for _, each := range refund.Items {
+		_, err = t.tx.NamedExec(utils.TRANSACTION_REFUND_ITEM_INSERT, each)
+		if err != nil {
+			return dbError(err, "refund of transaction "+refund.TransactionId)
+		}
+	}
+	return nil
+}
+
+// IncreaseStock does nothing for a menu that no longer exists.
+func (t *transactionTx) IncreaseStock(menuId string, qty int) error {
+	_, err := t.tx.Exec(utils.MENU_INCREASE_STOCK, qty, menuId)
+	return dbError(err, "stock of menu "+menuId)
+}
+
+// IncreaseIngredient does nothing for an ingredient that no longer exists.
+func (t *transactionTx) IncreaseIngredient(ingredientId string, qty int) error {
+	_, err := t.tx.Exec(utils.INGREDIENT_INCREASE_STOCK, qty, ingredientId)
+	return dbError(err, "stock of ingredient "+ingredientId)
+}
+
+func (t *transactionTx) InsertMovements(movements []model.StockMovement) error {
+	for i := range movements {
+		if err := insertMovement(t.tx, &movements[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *transactionTx) SetStatus(transactionId string, status string) error {
+	result, err := t.tx.Exec(utils.TRANSACTION_SET_STATUS, status, transactionId)
+	return mustAffect(result, err, "transaction "+transactionId)
+}
+
+func (t *transactionTx) Commit() error {
+	return dbError(t.tx.Commit(), "commit transaction")
+}
+
+// Rollback after Commit does nothing, so it can always be deferred.
+func (t *transactionTx) Rollback() error {
+	err := t.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return dbError(err, "rollback transaction")
+}
@@ -13,30 +13,104 @@ type userRepository struct {
 
 type UserRepository interface {
 	GetAll() ([]model.User, error)
-	GetById(id string) (model.User, error)
+	List(filter model.UserFilter) ([]model.User, model.PageInfo, error)
+	// GetById finds an archived user only with includeDeleted.
+	GetById(id string, includeDeleted bool) (model.User, error)
 	GetByName(name string) ([]model.User, error)
-	GetByCredentials(username, password string) (model.User, error)
+	GetByUsername(username string) (model.User, error)
+	GetPasswordById(id string) (string, error)
 
 	Insert(user *model.User) (model.User, error)
 	Update(user *model.User) (model.User, error)
+	UpdatePassword(id, password string) error
+	SetRole(username, role string) error
+	// Delete archives a user, Restore brings them back.
 	Delete(id string) error
+	Restore(id string) error
 }
 
 func (p *userRepository) GetAll() ([]model.User, error) {
 	var users []model.User
-	err := p.db.Select(&users, utils.USER_GET_ALL+" order by id")
+	err := p.db.Select(&users, utils.USER_GET_ALL+" WHERE deleted_at IS NULL order by id")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "users")
 	}
 
 	return users, nil
 }
 
-func (p *userRepository) GetById(id string) (model.User, error) {
+var userList = listSpec{
+	sorts: map[string]sortColumn{
+		"id":       {"id", "text"},
+		"name":     {"name", "text"},
+		"username": {"username", "text"},
+		"role":     {"role", "text"},
+	},
+	defaultSort:  "id",
+	defaultOrder: model.OrderAsc,
+}
+
+func (p *userRepository) List(filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	plan, err := userList.plan(filter.ListQuery)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	var where conditions
+	if !filter.IncludeDeleted {
+		where.add("deleted_at IS NULL")
+	}
+	if filter.Name != "" {
+		where.add("name like ?", "%"+filter.Name+"%")
+	}
+	if filter.Role != "" {
+		where.add("role = ?", filter.Role)
+	}
+
+	var total int
+	err = p.db.Get(&total, p.db.Rebind(utils.USER_COUNT+where.sql()), where.args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "users")
+	}
+
+	query, args := plan.query(utils.USER_GET_ALL, where)
+	var users []model.User
+	err = p.db.Select(&users, p.db.Rebind(query), args...)
+	if err != nil {
+		return nil, model.PageInfo{}, dbError(err, "users")
+	}
+
+	info := plan.info(total)
+	if len(users) > plan.limit {
+		users = users[:plan.limit]
+		last := users[len(users)-1]
+		info.NextCursor = plan.next(userSortValue(last, plan.sort), last.Id)
+	}
+	return users, info, nil
+}
+
+func userSortValue(user model.User, sort string) string {
+	switch sort {
+	case "name":
+		return user.Name
+	case "username":
+		return user.Username
+	case "role":
+		return user.Role
+	}
+	return user.Id
+}
+
+func (p *userRepository) GetById(id string, includeDeleted bool) (model.User, error) {
+	query := utils.USER_GET_BY_ID
+	if !includeDeleted {
+		query += utils.NOT_DELETED
+	}
+
 	var user model.User
-	err := p.db.Get(&user, utils.USER_GET_BY_ID, id)
+	err := p.db.Get(&user, query, id)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, dbError(err, "user "+id)
 	}
 	return user, nil
 }
@@ -45,40 +119,86 @@ func (p *userRepository) GetByName(name string) ([]model.User, error) {
 	var user []model.User
 	err := p.db.Select(&user, utils.USER_GET_BY_NAME, "%"+name+"%")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, "users")
 	}
 	return user, nil
 }
 
-func (p *userRepository) GetByCredentials(username, password string) (model.User, error) {
+func (p *userRepository) GetByUsername(username string) (model.User, error) {
 	var user model.User
-	err := p.db.Get(&user, utils.USER_GET_BY_CREDENTIALS, username, password)
+	err := p.db.Get(&user, utils.USER_GET_BY_USERNAME, username)
 	if err != nil {
-		return model.User{}, err
+		return model.User{}, dbError(err, "user "+username)
 	}
 	return user, nil
 }
 
+func (p *userRepository) GetPasswordById(id string) (string, error) {
+	var password string
+	err := p.db.Get(&password, utils.USER_GET_PASSWORD, id)
+	if err != nil {
+		return "", dbError(err, "user "+id)
+	}
+	return password, nil
+}
+
 func (p *userRepository) Insert(newUser *model.User) (model.User, error) {
-	_, err := p.db.NamedExec(utils.USER_INSERT, newUser)
+	hashed, err := utils.HashPassword(newUser.Password)
 	if err != nil {
 		return model.User{}, err
 	}
-	user := newUser
-	return *user, nil
+
+	user := *newUser
+	user.Password = hashed
+	_, err = p.db.NamedExec(utils.USER_INSERT, &user)
+	if err != nil {
+		return model.User{}, dbError(err, "user "+user.Username)
+	}
+
+	user.Password = ""
+	return user, nil
 }
 
 func (p *userRepository) Update(newData *model.User) (model.User, error) {
-	_, err := p.db.NamedExec(utils.USER_UPDATE, newData)
+	hashed, err := utils.HashPassword(newData.Password)
 	if err != nil {
 		return model.User{}, err
 	}
-	return *newData, nil
+
+	user := *newData
+	user.Password = hashed
+	result, err := p.db.NamedExec(utils.USER_UPDATE, &user)
+	if err := mustAffect(result, err, "user "+user.Id); err != nil {
+		return model.User{}, err
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+func (p *userRepository) UpdatePassword(id, password string) error {
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.db.Exec(utils.USER_UPDATE_PASSWORD, hashed, id)
+	return mustAffect(result, err, "user "+id)
+}
+
+func (p *userRepository) SetRole(username, role string) error {
+	result, err := p.db.Exec(utils.USER_SET_ROLE, role, username)
+	return mustAffect(result, err, "user "+username)
 }
 
 func (p *userRepository) Delete(id string) error {
-	_, err := p.db.Exec(utils.USER_DELETE, id)
-	return err
+	result, err := p.db.Exec(utils.USER_DELETE, id)
+	return mustAffect(result, err, "user "+id)
+}
+
+func (p *userRepository) Restore(id string) error {
+	result, err := p.db.Exec(utils.USER_RESTORE, id)
+	return mustAffect(result, err, "deleted user "+id)
 }
 
 func NewUserRepository(db *sqlx.DB) UserRepository {
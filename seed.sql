@@ -0,0 +1,20 @@
+-- optional sample data, run it after `migrate up`:
+-- psql -d warung_makan -f seed.sql
+
+-- the stock of a new menu is its opening stock movement
+WITH inserted AS (
+    INSERT INTO menu (id, name, price, stock, image) VALUES
+        ('8503e898-ab0a-4691-80af-4eb04f7065dd', 'ketoprak', 12500, 51, '8503e898-ab0a-4691-80af-4eb04f7065dd.jpg'),
+        ('aceabd1a-beab-4884-bb7c-3665271a3b10', 'mie ayam', 10000, 50, 'aceabd1a-beab-4884-bb7c-3665271a3b10.jpg')
+    ON CONFLICT (id) DO NOTHING
+    RETURNING id, stock
+)
+INSERT INTO stock_movement (menu_id, qty, reason)
+SELECT id, stock, 'opening' FROM inserted WHERE stock <> 0;
+
+-- plain text passwords are hashed on the first login
+INSERT INTO users (id, name, username, password, role, image) VALUES
+    ('8ded252a-f510-4a67-b711-73a31cc208be', 'vuero eruko', 'vueko', 'vueko123', 'cashier', '8ded252a-f510-4a67-b711-73a31cc208be.jpg'),
+    ('449360c8-914d-4c03-8c2a-d511dcfd4b82', 'admin', 'admin', 'admin123', 'owner', '449360c8-914d-4c03-8c2a-d511dcfd4b82.jpg'),
+    ('4c180e5a-cab4-40f6-855b-af45ab884b05', 'ueno', 'ueno', 'ueno123', 'kitchen', '4c180e5a-cab4-40f6-855b-af45ab884b05.jpg')
+ON CONFLICT (id) DO NOTHING;
@@ -1,60 +1,163 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"warung-makan/config"
 	"warung-makan/controller"
 	"warung-makan/manager"
+	"warung-makan/middleware"
+	"warung-makan/migration"
 	"warung-makan/utils/authenticator"
+	"warung-makan/utils/notifier"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
+// how many ports above the configured one are tried in dev mode
+const devPortAttempts = 10
+
 type appServer struct {
+	infraMan     manager.InfraManager
 	ucMan        manager.UsecaseManager
 	engine       *gin.Engine
 	config       config.Config
 	tokenService authenticator.AccessToken
+	notifier     notifier.Notifier
 }
 
-func NewAppServer() *appServer {
-	config := config.NewConfig()
+func NewAppServer(config config.Config) *appServer {
 	infraMan := manager.NewInfraManager(config)
 	repoMan := manager.NewRepoManager(infraMan)
+	alertNotifier := notifier.New(config.AlertConfig)
 
 	return &appServer{
-		ucMan:        manager.NewUsecaseManager(repoMan),
+		infraMan:     infraMan,
+		ucMan:        manager.NewUsecaseManager(repoMan, config.TokenConfig, config.ApiConfig.Location, config.PricingConfig, alertNotifier),
 		engine:       gin.Default(),
 		config:       config,
 		tokenService: authenticator.NewAccessToken(config.TokenConfig),
+		notifier:     alertNotifier,
 	}
 }
 
 func (a *appServer) initHandlers() {
-	controller.NewController(a.ucMan, a.engine)
+	a.engine.Use(middleware.Cors(a.config.CorsConfig.AllowedOrigins))
+	authMiddleware := middleware.NewAuthTokenMiddleware(a.tokenService, a.ucMan.TokenUsecase())
+
+	controller.NewController(a.ucMan, a.engine, a.tokenService, authMiddleware, a.config.UploadConfig.ImageDir)
 
-	controller.NewUserController(a.ucMan.UserUsecase(), a.engine)
-	controller.NewMenuController(a.ucMan.MenuUsecase(), a.engine)
-	controller.NewTransactionController(a.ucMan.TransactionUsecase(), a.ucMan.MenuUsecase(), a.engine)
-	controller.NewLoginController(a.ucMan.UserUsecase(), a.engine)
+	controller.NewUserController(a.ucMan.UserUsecase(), a.engine, authMiddleware, a.config.UploadConfig.UserImageDir)
+	controller.NewMenuController(a.ucMan.MenuUsecase(), a.engine, authMiddleware, a.config.UploadConfig.MenuImageDir)
+	controller.NewTransactionController(a.ucMan.TransactionUsecase(), a.engine, authMiddleware)
+	controller.NewReportController(a.ucMan.ReportUsecase(), a.engine, authMiddleware)
+	controller.NewPromoController(a.ucMan.PromoUsecase(), a.engine, authMiddleware)
+	controller.NewCategoryController(a.ucMan.CategoryUsecase(), a.engine, authMiddleware)
+	controller.NewModifierController(a.ucMan.ModifierUsecase(), a.engine, authMiddleware)
+	controller.NewStockController(a.ucMan.StockUsecase(), a.engine, authMiddleware)
+	controller.NewIngredientController(a.ucMan.IngredientUsecase(), a.engine, authMiddleware)
+	controller.NewSupplierController(a.ucMan.SupplierUsecase(), a.engine, authMiddleware)
+	controller.NewPurchaseOrderController(a.ucMan.PurchaseOrderUsecase(), a.engine, authMiddleware)
+	controller.NewStockTakeController(a.ucMan.StockTakeUsecase(), a.engine, authMiddleware)
+	controller.NewLoginController(a.ucMan.UserUsecase(), a.ucMan.TokenUsecase(), a.engine, authMiddleware)
 }
 
-func (a *appServer) Run() {
-	a.initHandlers()
-	apiPort := a.config.ApiConfig.Port
-	if apiPort == "" {
-		apiPort = "8000"
+// listen opens the configured port. only in dev mode the next ports are
+// tried when it is already taken.
+func (a *appServer) listen() (net.Listener, error) {
+	apiConfig := a.config.ApiConfig
+	port, err := strconv.Atoi(apiConfig.Port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %v", apiConfig.Port, err)
+	}
+
+	attempts := 1
+	if apiConfig.DevMode {
+		attempts = devPortAttempts
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(apiConfig.Host, strconv.Itoa(port+i)))
+		if err == nil {
+			if i > 0 {
+				log.Printf("port %d is in use, dev mode picked port %d instead\n", port, port+i)
+			}
+			return listener, nil
+		}
+		lastErr = err
 	}
+	return nil, lastErr
+}
+
+// Run serves the api until SIGINT or SIGTERM, then waits for in-flight
+// requests and the stock alerts they raised and closes the database.
+func (a *appServer) Run() error {
+	defer func() {
+		if err := a.infraMan.Close(); err != nil {
+			log.Println("cannot close database:", err)
+		}
+	}()
 
-	for {
-		err := a.engine.Run(":" + apiPort)
+	if a.config.DbConfig.AutoMigrate {
+		migrations, err := migration.Embedded()
 		if err != nil {
-			apiPortInt, _ := strconv.Atoi(apiPort)
-			apiPort = strconv.Itoa(apiPortInt + 1)
-		} else {
-			break
+			return err
+		}
+		if _, err := migration.NewMigrator(a.infraMan.GetSqlDb(), migrations).Up(); err != nil {
+			return err
 		}
 	}
 
+	if owner := a.config.ApiConfig.OwnerUsername; owner != "" {
+		if err := a.ucMan.UserUsecase().PromoteOwner(owner); err != nil {
+			return fmt.Errorf("cannot make %q an owner: %v", owner, err)
+		}
+	}
+
+	a.initHandlers()
+
+	listener, err := a.listen()
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Handler:      a.engine,
+		ReadTimeout:  a.config.ApiConfig.ReadTimeout,
+		WriteTimeout: a.config.ApiConfig.WriteTimeout,
+		IdleTimeout:  a.config.ApiConfig.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("listening on", listener.Addr())
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.ApiConfig.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	return a.notifier.Close(shutdownCtx)
 }
@@ -0,0 +1,224 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"warung-makan/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var configKeys = []string{
+	"DB_HOST", "DB_PORT", "DB_USER", "DB_PASS", "DB_NAME", "DB_DRIVER", "DB_AUTO_MIGRATE",
+	"API_HOST", "API_PORT", "API_DEV_MODE",
+	"API_READ_TIMEOUT", "API_WRITE_TIMEOUT", "API_IDLE_TIMEOUT", "API_SHUTDOWN_TIMEOUT",
+	"APP_TIMEZONE", "APP_OWNER",
+	"APP_NAME", "JWT_SECRET", "ACCESS_TOKEN_LIFETIME", "REFRESH_TOKEN_LIFETIME",
+	"UPLOAD_DIR", "UPLOAD_MENU_DIR", "UPLOAD_USER_DIR",
+	"CORS_ALLOWED_ORIGINS",
+	"PRICING_SERVICE_PERCENT", "PRICING_TAX_PERCENT",
+	"ALERT_NOTIFIER", "ALERT_WEBHOOK_URL",
+	"ALERT_SMTP_HOST", "ALERT_SMTP_PORT", "ALERT_SMTP_USER", "ALERT_SMTP_PASS", "ALERT_SMTP_FROM", "ALERT_SMTP_TO",
+}
+
+const dummyYaml = `
+app_name: warung_makan_yaml
+owner: admin
+db:
+  host: yaml-host
+  user: yaml-user
+  name: yaml-db
+api:
+  port: "9000"
+  dev_mode: "true"
+token:
+  jwt_secret: yaml secret
+  access_lifetime: 5m
+upload:
+  dir: /srv/images
+cors:
+  allowed_origins:
+    - https://yaml.example.com
+pricing:
+  tax_percent: "10"
+`
+
+const dummyDotEnv = `
+# comment
+DB_HOST=dotenv-host
+export JWT_SECRET="dotenv secret"
+`
+
+type ConfigTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func (suite *ConfigTestSuite) writeFile(name, content string) string {
+	path := filepath.Join(suite.dir, name)
+	err := os.WriteFile(path, []byte(content), 0600)
+	suite.Require().Nil(err)
+	return path
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_Defaults() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+
+	conf, err := config.NewConfig()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "localhost", conf.DbConfig.Host)
+	assert.Equal(suite.T(), "5432", conf.DbConfig.Port)
+	assert.Equal(suite.T(), "8000", conf.ApiConfig.Port)
+	assert.Equal(suite.T(), time.Minute*15, conf.TokenConfig.AccessTokenLifetime)
+	assert.False(suite.T(), conf.ApiConfig.DevMode)
+	assert.True(suite.T(), conf.DbConfig.AutoMigrate)
+	assert.Equal(suite.T(), time.Second*15, conf.ApiConfig.ShutdownTimeout)
+	assert.Equal(suite.T(), "./images/menu", conf.UploadConfig.MenuImageDir)
+	assert.Nil(suite.T(), conf.CorsConfig.AllowedOrigins)
+	assert.Equal(suite.T(), "Asia/Jakarta", conf.ApiConfig.Location.String())
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_Yaml() {
+	suite.T().Setenv("CONFIG_FILE", suite.writeFile("config.yaml", dummyYaml))
+
+	conf, err := config.NewConfig()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "yaml-host", conf.DbConfig.Host)
+	assert.Equal(suite.T(), "9000", conf.ApiConfig.Port)
+	assert.True(suite.T(), conf.ApiConfig.DevMode)
+	assert.Equal(suite.T(), "admin", conf.ApiConfig.OwnerUsername)
+	assert.Equal(suite.T(), "yaml secret", conf.TokenConfig.JwtSignatureKey)
+	assert.Equal(suite.T(), time.Minute*5, conf.TokenConfig.AccessTokenLifetime)
+	assert.Equal(suite.T(), "/srv/images/user", conf.UploadConfig.UserImageDir)
+	assert.Equal(suite.T(), []string{"https://yaml.example.com"}, conf.CorsConfig.AllowedOrigins)
+	assert.Equal(suite.T(), config.PricingConfig{TaxRate: 1000}, conf.PricingConfig)
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_Precedence() {
+	suite.T().Setenv("CONFIG_FILE", suite.writeFile("config.yaml", dummyYaml))
+	suite.T().Setenv("ENV_FILE", suite.writeFile(".env", dummyDotEnv))
+	suite.T().Setenv("DB_USER", "env-user")
+	suite.T().Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+
+	conf, err := config.NewConfig()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "env-user", conf.DbConfig.User)
+	assert.Equal(suite.T(), "dotenv-host", conf.DbConfig.Host)
+	assert.Equal(suite.T(), "yaml-db", conf.DbConfig.DbName)
+	assert.Equal(suite.T(), "dotenv secret", conf.TokenConfig.JwtSignatureKey)
+	assert.Equal(suite.T(), []string{"https://a.example.com", "https://b.example.com"}, conf.CorsConfig.AllowedOrigins)
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_MissingSecret() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+
+	_, err := config.NewConfig()
+
+	assert.ErrorContains(suite.T(), err, "JWT_SECRET is required")
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_InvalidLifetime() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("ACCESS_TOKEN_LIFETIME", "15 minutes")
+
+	_, err := config.NewConfig()
+
+	assert.ErrorContains(suite.T(), err, "ACCESS_TOKEN_LIFETIME")
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_InvalidTimezone() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("APP_TIMEZONE", "Middle/Earth")
+
+	_, err := config.NewConfig()
+
+	assert.ErrorContains(suite.T(), err, "APP_TIMEZONE")
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_Pricing() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("PRICING_SERVICE_PERCENT", "5.5")
+	suite.T().Setenv("PRICING_TAX_PERCENT", "11")
+
+	conf, err := config.NewConfig()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), config.PricingConfig{ServiceRate: 550, TaxRate: 1100}, conf.PricingConfig)
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_InvalidTax() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("PRICING_TAX_PERCENT", "10%")
+
+	_, err := config.NewConfig()
+
+	assert.ErrorContains(suite.T(), err, "PRICING_TAX_PERCENT must be a percentage between 0 and 100")
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_AlertSMTP() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("ALERT_NOTIFIER", "smtp")
+	suite.T().Setenv("ALERT_SMTP_HOST", "mail.example.com")
+	suite.T().Setenv("ALERT_SMTP_FROM", "warung@example.com")
+	suite.T().Setenv("ALERT_SMTP_TO", "owner@example.com, chef@example.com")
+
+	conf, err := config.NewConfig()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), config.AlertConfig{
+		Notifier: config.AlertSMTP,
+		SMTPHost: "mail.example.com",
+		SMTPPort: "587",
+		SMTPFrom: "warung@example.com",
+		SMTPTo:   []string{"owner@example.com", "chef@example.com"},
+	}, conf.AlertConfig)
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_AlertWebhookWithoutURL() {
+	suite.T().Setenv("DB_USER", "postgres")
+	suite.T().Setenv("DB_NAME", "warung_makan")
+	suite.T().Setenv("JWT_SECRET", "secret")
+	suite.T().Setenv("ALERT_NOTIFIER", "webhook")
+
+	_, err := config.NewConfig()
+
+	assert.ErrorContains(suite.T(), err, "ALERT_WEBHOOK_URL is required for the webhook notifier")
+}
+
+func (suite *ConfigTestSuite) TestNewConfig_MissingFile() {
+	suite.T().Setenv("CONFIG_FILE", filepath.Join(suite.dir, "missing.yaml"))
+
+	_, err := config.NewConfig()
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ConfigTestSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	for _, key := range append(configKeys, "ENV_FILE", "CONFIG_FILE") {
+		suite.T().Setenv(key, "")
+	}
+}
+
+func TestConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfigTestSuite))
+}
@@ -0,0 +1,157 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyCategories = []model.Category{
+	{Id: "dummy category 1", Name: "rice", DisplayOrder: 1, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+	{Id: "dummy category 2", Name: "drinks", DisplayOrder: 2, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+type CategoryUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *CategoryUsecaseMock) GetAll() ([]model.Category, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Category), nil
+}
+
+func (r *CategoryUsecaseMock) GetById(id string) (model.Category, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *CategoryUsecaseMock) Insert(category *model.Category) (model.Category, error) {
+	args := r.Called(category)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *CategoryUsecaseMock) Update(category *model.Category) (model.Category, error) {
+	args := r.Called(category)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *CategoryUsecaseMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type CategoryControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *CategoryUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *CategoryControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(CategoryUsecaseMock)
+}
+
+func (suite *CategoryControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewCategoryController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if bearer != "" {
+		request.Header.Add("Authorization", "Bearer "+bearer)
+	}
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *CategoryControllerTestSuite) TestListCategoryApi_Public() {
+	suite.useCaseMock.On("GetAll").Return(dummyCategories, nil)
+
+	r := suite.serve(http.MethodGet, "/category", "", "")
+
+	var actual []model.Category
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), dummyCategories, actual)
+}
+
+func (suite *CategoryControllerTestSuite) TestCreateCategoryApi_Success() {
+	suite.useCaseMock.On("Insert", &model.Category{Name: "rice", DisplayOrder: 1}).Return(dummyCategories[0], nil)
+
+	r := suite.serve(http.MethodPost, "/category", `{"name": "rice", "display_order": 1}`, token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *CategoryControllerTestSuite) TestCreateCategoryApi_ForbiddenRole() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "cashier",
+		Role:     model.RoleCashier,
+	}, "test token id")
+
+	r := suite.serve(http.MethodPost, "/category", `{"name": "rice"}`, cashierToken)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *CategoryControllerTestSuite) TestDeleteCategoryApi_NotFound() {
+	suite.useCaseMock.On("Delete", "nope").Return(apperror.NotFound("category nope not found"))
+
+	r := suite.serve(http.MethodDelete, "/category/nope", "", token)
+
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+}
+
+func TestCategoryControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(CategoryControllerTestSuite))
+}
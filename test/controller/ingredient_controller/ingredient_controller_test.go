@@ -0,0 +1,195 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyIngredients = []model.Ingredient{
+	{Id: "dummy ingredient 1", Name: "egg", Unit: "pcs", Stock: 30, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+	{Id: "dummy ingredient 2", Name: "rice", Unit: "g", Stock: 5000, Reserved: 400, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+type IngredientUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *IngredientUsecaseMock) GetAll() ([]model.Ingredient, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (r *IngredientUsecaseMock) GetById(id string) (model.Ingredient, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *IngredientUsecaseMock) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *IngredientUsecaseMock) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *IngredientUsecaseMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *IngredientUsecaseMock) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *IngredientUsecaseMock) SetRecipe(menuId string, recipe model.Recipe) ([]model.RecipeLine, error) {
+	args := r.Called(menuId, recipe)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+type IngredientControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *IngredientUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *IngredientControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(IngredientUsecaseMock)
+}
+
+func (suite *IngredientControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewIngredientController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if bearer != "" {
+		request.Header.Add("Authorization", "Bearer "+bearer)
+	}
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func tokenAs(role string) string {
+	token, _ := auth.GenerateAccessToken(&model.User{
+		Id:       "user 1",
+		Username: role,
+		Role:     role,
+	}, "test token id")
+	return token
+}
+
+func (suite *IngredientControllerTestSuite) TestListIngredientApi_Kitchen() {
+	suite.useCaseMock.On("GetAll").Return(dummyIngredients, nil)
+
+	r := suite.serve(http.MethodGet, "/ingredient", "", tokenAs(model.RoleKitchen))
+
+	var actual []model.Ingredient
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), dummyIngredients, actual)
+}
+
+func (suite *IngredientControllerTestSuite) TestCreateIngredientApi_Success() {
+	suite.useCaseMock.On("Insert", &model.Ingredient{Name: "egg", Unit: "pcs", Stock: 30}).Return(dummyIngredients[0], nil)
+
+	r := suite.serve(http.MethodPost, "/ingredient", `{"name": "egg", "unit": "pcs", "stock": 30}`, token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *IngredientControllerTestSuite) TestCreateIngredientApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPost, "/ingredient", `{"name": "egg", "unit": "pcs"}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *IngredientControllerTestSuite) TestDeleteIngredientApi_UsedByRecipe() {
+	suite.useCaseMock.On("Delete", "dummy-ingredient-1").Return(apperror.Conflict("ingredient dummy-ingredient-1 is still used", nil))
+
+	r := suite.serve(http.MethodDelete, "/ingredient/dummy-ingredient-1", "", token)
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *IngredientControllerTestSuite) TestSetRecipeApi_Success() {
+	recipe := model.Recipe{Ingredients: []model.RecipeLine{{IngredientId: "dummy-ingredient-2", Qty: 200}}}
+	suite.useCaseMock.On("SetRecipe", "dummy-menu-1", recipe).Return(recipe.Ingredients, nil)
+
+	r := suite.serve(http.MethodPut, "/menu/dummy-menu-1/recipe", `{"ingredients": [{"ingredient_id": "dummy-ingredient-2", "qty": 200}]}`, token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *IngredientControllerTestSuite) TestGetRecipeApi_Viewer() {
+	suite.useCaseMock.On("GetRecipe", "dummy-menu-1").Return([]model.RecipeLine{}, nil)
+
+	r := suite.serve(http.MethodGet, "/menu/dummy-menu-1/recipe", "", tokenAs(model.RoleViewer))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+}
+
+func TestIngredientControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(IngredientControllerTestSuite))
+}
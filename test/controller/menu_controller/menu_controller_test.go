@@ -6,13 +6,18 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sort"
 	"testing"
+	"time"
 	"warung-makan/config"
 	"warung-makan/controller"
+	"warung-makan/middleware"
 	"warung-makan/model"
+	"warung-makan/utils/apperror"
 	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -35,11 +40,26 @@ var dummyMenus = []model.Menu{
 	},
 }
 
-var auth = authenticator.NewAccessToken(config.NewConfig().TokenConfig)
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
 var token, _ = auth.GenerateAccessToken(&model.User{
 	Username: "admin",
 	Password: "admin",
-})
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
 
 type ErrorResponse struct {
 	Error   string
@@ -70,8 +90,16 @@ func (r *MenuUsecaseMock) GetAll() ([]model.Menu, error) {
 	return args.Get(0).([]model.Menu), nil
 }
 
-func (r *MenuUsecaseMock) GetById(id string) (model.Menu, error) {
-	args := r.Called(id)
+func (r *MenuUsecaseMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *MenuUsecaseMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
 	if args.Get(1) != nil {
 		return model.Menu{}, args.Error(1)
 	}
@@ -110,6 +138,14 @@ func (r *MenuUsecaseMock) Update(newMenu *model.Menu) (model.Menu, error) {
 	return args.Get(0).(model.Menu), nil
 }
 
+func (r *MenuUsecaseMock) Restore(id string) (model.Menu, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
 func (r *MenuUsecaseMock) Delete(id string) error {
 	args := r.Called(id)
 	if args.Get(0) != nil {
@@ -118,11 +154,11 @@ func (r *MenuUsecaseMock) Delete(id string) error {
 	return nil
 }
 
-func (suite MenuControllerTestSuite) TestGetAllMenuApi_Success() {
+func (suite *MenuControllerTestSuite) TestGetAllMenuApi_Success() {
 	menus := dummyMenus
-	suite.useCaseMock.On("GetAll").Return(menus, nil)
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.MenuFilter")).Return(menus, model.PageInfo{Total: 2, Page: 1, Limit: 20}, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/menu", nil)
@@ -131,7 +167,12 @@ func (suite MenuControllerTestSuite) TestGetAllMenuApi_Success() {
 	var actualMenus []model.Menu
 	response := r.Body.String()
 
-	jsonerr := json.Unmarshal([]byte(response), &actualMenus)
+	var body struct {
+		Data []model.Menu
+		Meta model.PageInfo
+	}
+	jsonerr := json.Unmarshal([]byte(response), &body)
+	actualMenus = body.Data
 
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), err)
@@ -140,10 +181,10 @@ func (suite MenuControllerTestSuite) TestGetAllMenuApi_Success() {
 	assert.Equal(suite.T(), menus[0].Id, actualMenus[0].Id)
 }
 
-func (suite MenuControllerTestSuite) TestGetAllMenuApi_Failed() {
-	suite.useCaseMock.On("GetAll").Return(nil, errors.New("failed"))
+func (suite *MenuControllerTestSuite) TestGetAllMenuApi_Failed() {
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.MenuFilter")).Return(nil, model.PageInfo{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/menu", nil)
@@ -159,11 +200,11 @@ func (suite MenuControllerTestSuite) TestGetAllMenuApi_Failed() {
 
 }
 
-func (suite MenuControllerTestSuite) TestGetByIdMenuApi_Success() {
+func (suite *MenuControllerTestSuite) TestGetByIdMenuApi_Success() {
 	menu := dummyMenus[0]
-	suite.useCaseMock.On("GetById", menu.Id).Return(menu, nil)
+	suite.useCaseMock.On("GetById", menu.Id, false).Return(menu, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/menu/"+menu.Id, nil)
@@ -180,11 +221,53 @@ func (suite MenuControllerTestSuite) TestGetByIdMenuApi_Success() {
 	assert.Equal(suite.T(), menu.Id, actualMenu.Id)
 }
 
-func (suite MenuControllerTestSuite) TestGetByIdMenuApi_Failed() {
+func (suite *MenuControllerTestSuite) TestGetByIdMenuApi_IncludeDeleted() {
 	menu := dummyMenus[0]
-	suite.useCaseMock.On("GetById", menu.Id).Return(model.Menu{}, errors.New("failed"))
+	suite.useCaseMock.On("GetById", menu.Id, true).Return(menu, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu/"+menu.Id+"?include_deleted=true", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *MenuControllerTestSuite) TestGetByIdMenuApi_IncludeDeletedWithoutToken() {
+	menu := dummyMenus[0]
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu/"+menu.Id+"?include_deleted=true", nil)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "GetById", menu.Id, true)
+}
+
+func (suite *MenuControllerTestSuite) TestListMenuApi_IncludeDeletedNotOwner() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{Username: "cashier", Role: model.RoleCashier}, "test token id")
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu?include_deleted=true", nil)
+	request.Header.Add("Authorization", "Bearer "+cashierToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *MenuControllerTestSuite) TestGetByIdMenuApi_Failed() {
+	menu := dummyMenus[0]
+	suite.useCaseMock.On("GetById", menu.Id, false).Return(model.Menu{}, apperror.NotFound("menu dummy id 1 not found"))
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/menu/"+menu.Id, nil)
@@ -198,11 +281,11 @@ func (suite MenuControllerTestSuite) TestGetByIdMenuApi_Failed() {
 	assert.NotEqual(suite.T(), menu, actualMenu)
 }
 
-func (suite MenuControllerTestSuite) TestGetByNameMenuApi_Success() {
+func (suite *MenuControllerTestSuite) TestGetByNameMenuApi_Success() {
 	menus := dummyMenus
-	suite.useCaseMock.On("GetByName", "dummy").Return(menus, nil)
+	suite.useCaseMock.On("List", mock.MatchedBy(func(filter model.MenuFilter) bool { return filter.Name == "dummy" })).Return(menus, model.PageInfo{Total: 2, Page: 1, Limit: 20}, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/menu?name=dummy", nil)
@@ -211,7 +294,12 @@ func (suite MenuControllerTestSuite) TestGetByNameMenuApi_Success() {
 	var actualMenus []model.Menu
 	response := r.Body.String()
 
-	jsonerr := json.Unmarshal([]byte(response), &actualMenus)
+	var body struct {
+		Data []model.Menu
+		Meta model.PageInfo
+	}
+	jsonerr := json.Unmarshal([]byte(response), &body)
+	actualMenus = body.Data
 
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), err)
@@ -220,10 +308,10 @@ func (suite MenuControllerTestSuite) TestGetByNameMenuApi_Success() {
 	assert.Equal(suite.T(), menus[0].Id, actualMenus[0].Id)
 }
 
-func (suite MenuControllerTestSuite) TestGetByNameMenuApi_Failed() {
-	suite.useCaseMock.On("GetByName", "dummy").Return(nil, errors.New("failed"))
+func (suite *MenuControllerTestSuite) TestGetByNameMenuApi_Failed() {
+	suite.useCaseMock.On("List", mock.MatchedBy(func(filter model.MenuFilter) bool { return filter.Name == "dummy" })).Return(nil, model.PageInfo{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/menu?name=dummy", nil)
@@ -233,17 +321,90 @@ func (suite MenuControllerTestSuite) TestGetByNameMenuApi_Failed() {
 	response := r.Body.String()
 	jsonerr := json.Unmarshal([]byte(response), &errorResponse)
 
-	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
 	assert.Equal(suite.T(), "failed", errorResponse.Error)
 	assert.Nil(suite.T(), jsonerr)
 }
 
-func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_Success() {
+func (suite *MenuControllerTestSuite) TestListMenuApi_Filters() {
+	minPrice, maxPrice, inStock := 5000, 15000, true
+	expected := model.MenuFilter{
+		ListQuery: model.ListQuery{Page: 2, Limit: 10, Sort: "price", Order: "desc"},
+		MinPrice:  &minPrice,
+		MaxPrice:  &maxPrice,
+		InStock:   &inStock,
+	}
+	page := model.PageInfo{Total: 12, Page: 2, Limit: 10, Sort: "price", Order: "desc"}
+	suite.useCaseMock.On("List", expected).Return(dummyMenus, page, nil)
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu?page=2&limit=10&sort=price&order=desc&min_price=5000&max_price=15000&in_stock=true", nil)
+	suite.routerMock.ServeHTTP(r, request)
+
+	var body struct {
+		Data []model.Menu
+		Meta model.PageInfo
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &body)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), page, body.Meta)
+}
+
+func (suite *MenuControllerTestSuite) TestListMenuApi_CategoryTagAvailable() {
+	expected := model.MenuFilter{
+		Category:     "rice",
+		Tag:          "spicy",
+		AvailableNow: true,
+	}
+	suite.useCaseMock.On("List", expected).Return(dummyMenus[:1], model.PageInfo{Total: 1}, nil)
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu?category=rice&tag=spicy&available_now=true", nil)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *MenuControllerTestSuite) TestListMenuApi_InvalidQuery() {
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/menu?limit=ten&in_stock=maybe&min_price=9&max_price=1", nil)
+	suite.routerMock.ServeHTTP(r, request)
+
+	var body struct {
+		Details map[string]string
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &body)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), []string{"in_stock", "limit", "min_price"}, sortedKeys(body.Details))
+	suite.useCaseMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (suite *MenuControllerTestSuite) TestInsertMenuNoImageApi_Success() {
 	menu := dummyMenus[0]
 
 	suite.useCaseMock.On("Insert", &menu).Return(menu, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -263,10 +424,10 @@ func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_Success() {
 
 }
 
-func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_FailedBinding() {
+func (suite *MenuControllerTestSuite) TestInsertMenuNoImageApi_FailedBinding() {
 	suite.useCaseMock.On("Insert").Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -282,11 +443,11 @@ func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_FailedBinding() {
 	assert.Equal(suite.T(), model.Menu{}, actualMenu)
 }
 
-func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_Failed() {
+func (suite *MenuControllerTestSuite) TestInsertMenuNoImageApi_Failed() {
 	menu := dummyMenus[0]
 	suite.useCaseMock.On("Insert", &menu).Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -302,12 +463,12 @@ func (suite MenuControllerTestSuite) TestInsertMenuNoImageApi_Failed() {
 	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
 }
 
-func (suite MenuControllerTestSuite) TestUpdateMenuApi_Success() {
+func (suite *MenuControllerTestSuite) TestUpdateMenuApi_Success() {
 	menu := dummyMenus[0]
 
 	suite.useCaseMock.On("Update", &menu).Return(menu, nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -326,10 +487,10 @@ func (suite MenuControllerTestSuite) TestUpdateMenuApi_Success() {
 	assert.Equal(suite.T(), menu.Name, actualMenu.Name)
 }
 
-func (suite MenuControllerTestSuite) TestUpdateMenuApi_FailedBindingAndNoId() {
+func (suite *MenuControllerTestSuite) TestUpdateMenuApi_FailedBindingAndNoId() {
 	suite.useCaseMock.On("Update").Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -356,12 +517,12 @@ func (suite MenuControllerTestSuite) TestUpdateMenuApi_FailedBindingAndNoId() {
 	assert.Equal(suite.T(), model.Menu{}, actualMenu)
 }
 
-func (suite MenuControllerTestSuite) TestUpdateMenuApi_Failed() {
+func (suite *MenuControllerTestSuite) TestUpdateMenuApi_Failed() {
 	menu := dummyMenus[0]
 
 	suite.useCaseMock.On("Update", &menu).Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 
@@ -378,13 +539,12 @@ func (suite MenuControllerTestSuite) TestUpdateMenuApi_Failed() {
 	assert.Equal(suite.T(), model.Menu{}, actualMenu)
 }
 
-func (suite MenuControllerTestSuite) TestDeleteMenuApi_Success() {
+func (suite *MenuControllerTestSuite) TestDeleteMenuApi_Success() {
 	menu := dummyMenus[0]
 
-	suite.useCaseMock.On("GetById", menu.Id).Return(menu, nil)
 	suite.useCaseMock.On("Delete", menu.Id).Return(nil)
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/menu/"+menu.Id, nil)
@@ -395,30 +555,28 @@ func (suite MenuControllerTestSuite) TestDeleteMenuApi_Success() {
 
 }
 
-func (suite MenuControllerTestSuite) TestDeleteMenuApi_FailedNotFound() {
+func (suite *MenuControllerTestSuite) TestDeleteMenuApi_FailedNotFound() {
 	menu := dummyMenus[0]
 
-	suite.useCaseMock.On("GetById", menu.Id).Return(model.Menu{}, errors.New("not found"))
-	suite.useCaseMock.On("Delete", menu.Id).Return(errors.New("failed"))
+	suite.useCaseMock.On("Delete", menu.Id).Return(apperror.NotFound("menu dummy id 1 not found"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/menu/"+menu.Id, nil)
 	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
-	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
 
 }
 
-func (suite MenuControllerTestSuite) TestDeleteMenuApi_Failed() {
+func (suite *MenuControllerTestSuite) TestDeleteMenuApi_Failed() {
 	menu := dummyMenus[0]
 
-	suite.useCaseMock.On("GetById", menu.Id).Return(menu, nil)
 	suite.useCaseMock.On("Delete", menu.Id).Return(errors.New("failed"))
 
-	controller.NewMenuController(suite.useCaseMock, suite.routerMock)
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/menu/"+menu.Id, nil)
@@ -429,6 +587,37 @@ func (suite MenuControllerTestSuite) TestDeleteMenuApi_Failed() {
 
 }
 
+func (suite *MenuControllerTestSuite) TestRestoreMenuApi_Success() {
+	menu := dummyMenus[0]
+
+	suite.useCaseMock.On("Restore", menu.Id).Return(menu, nil)
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/menu/"+menu.Id+"/restore", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *MenuControllerTestSuite) TestRestoreMenuApi_NotArchived() {
+	menu := dummyMenus[0]
+
+	suite.useCaseMock.On("Restore", menu.Id).Return(nil, apperror.NotFound("deleted menu "+menu.Id+" not found"))
+
+	controller.NewMenuController(suite.useCaseMock, suite.routerMock, authMiddleware, "./images/menu")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/menu/"+menu.Id+"/restore", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+}
+
 func TestMenuControllerTestSuite(t *testing.T) {
 	suite.Run(t, new(MenuControllerTestSuite))
 }
@@ -0,0 +1,164 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyGroups = []model.ModifierGroup{
+	{Id: 1, MenuId: "dummy menu 1", Name: "size", MinSelect: 1, MaxSelect: 1, Options: []model.ModifierOption{
+		{Id: 11, GroupId: 1, Name: "regular"},
+		{Id: 12, GroupId: 1, Name: "large", PriceDelta: 3000},
+	}},
+}
+
+type ModifierUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *ModifierUsecaseMock) GetByMenu(menuId string) ([]model.ModifierGroup, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ModifierGroup), nil
+}
+
+func (r *ModifierUsecaseMock) Insert(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	args := r.Called(group)
+	if args.Get(1) != nil {
+		return model.ModifierGroup{}, args.Error(1)
+	}
+	return args.Get(0).(model.ModifierGroup), nil
+}
+
+func (r *ModifierUsecaseMock) Update(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	args := r.Called(group)
+	if args.Get(1) != nil {
+		return model.ModifierGroup{}, args.Error(1)
+	}
+	return args.Get(0).(model.ModifierGroup), nil
+}
+
+func (r *ModifierUsecaseMock) Delete(menuId string, groupId int64) error {
+	args := r.Called(menuId, groupId)
+	return args.Error(0)
+}
+
+type ModifierControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *ModifierUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *ModifierControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(ModifierUsecaseMock)
+}
+
+func (suite *ModifierControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewModifierController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if bearer != "" {
+		request.Header.Add("Authorization", "Bearer "+bearer)
+	}
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *ModifierControllerTestSuite) TestListModifiersApi_Public() {
+	suite.useCaseMock.On("GetByMenu", "dummy menu 1").Return(dummyGroups, nil)
+
+	r := suite.serve(http.MethodGet, "/menu/dummy menu 1/modifiers", "", "")
+
+	var actual []model.ModifierGroup
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), dummyGroups, actual)
+}
+
+func (suite *ModifierControllerTestSuite) TestCreateGroupApi_Success() {
+	suite.useCaseMock.On("Insert", &model.ModifierGroup{
+		MenuId:    "dummy menu 1",
+		Name:      "size",
+		MinSelect: 1,
+		Options:   []model.ModifierOption{{Name: "regular"}, {Name: "large", PriceDelta: 3000}},
+	}).Return(dummyGroups[0], nil)
+
+	body := `{"name": "size", "min_select": 1, "options": [{"name": "regular"}, {"name": "large", "price_delta": 3000}]}`
+	r := suite.serve(http.MethodPost, "/menu/dummy menu 1/modifiers", body, token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *ModifierControllerTestSuite) TestCreateGroupApi_ForbiddenRole() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "cashier",
+		Role:     model.RoleCashier,
+	}, "test token id")
+
+	r := suite.serve(http.MethodPost, "/menu/dummy menu 1/modifiers", `{"name": "size"}`, cashierToken)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *ModifierControllerTestSuite) TestUpdateGroupApi_InvalidGroupId() {
+	r := suite.serve(http.MethodPut, "/menu/dummy menu 1/modifiers/size", `{"name": "size"}`, token)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *ModifierControllerTestSuite) TestDeleteGroupApi_NotFound() {
+	suite.useCaseMock.On("Delete", "dummy menu 1", int64(9)).Return(apperror.NotFound("modifier group 9 of menu dummy menu 1 not found"))
+
+	r := suite.serve(http.MethodDelete, "/menu/dummy menu 1/modifiers/9", "", token)
+
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+}
+
+func TestModifierControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(ModifierControllerTestSuite))
+}
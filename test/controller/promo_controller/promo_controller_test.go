@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyPromo = model.Promo{
+	Code:      "HEMAT10",
+	Discount:  model.Discount{Type: model.DiscountPercent, Value: 10},
+	StartsAt:  time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC),
+	Active:    true,
+	CreatedAt: time.Date(2022, time.September, 30, 0, 0, 0, 0, time.UTC),
+}
+
+type PromoUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *PromoUsecaseMock) GetAll() ([]model.Promo, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Promo), nil
+}
+
+func (r *PromoUsecaseMock) GetByCode(code string) (model.Promo, error) {
+	args := r.Called(code)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (r *PromoUsecaseMock) Insert(promo *model.Promo) (model.Promo, error) {
+	args := r.Called(promo)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (r *PromoUsecaseMock) Update(promo *model.Promo) (model.Promo, error) {
+	args := r.Called(promo)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+type PromoControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *PromoUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *PromoControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(PromoUsecaseMock)
+}
+
+func (suite *PromoControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewPromoController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	request.Header.Add("Authorization", "Bearer "+bearer)
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *PromoControllerTestSuite) TestListPromoApi_Success() {
+	suite.useCaseMock.On("GetAll").Return([]model.Promo{dummyPromo}, nil)
+
+	r := suite.serve(http.MethodGet, "/promo", "", token)
+
+	var actual []model.Promo
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), []model.Promo{dummyPromo}, actual)
+}
+
+func (suite *PromoControllerTestSuite) TestCreatePromoApi_Success() {
+	suite.useCaseMock.On("Insert", &model.Promo{Code: "hemat10", Discount: dummyPromo.Discount}).Return(dummyPromo, nil)
+
+	r := suite.serve(http.MethodPost, "/promo", `{"code": "hemat10", "discount": {"type": "percent", "value": 10}}`, token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *PromoControllerTestSuite) TestUpdatePromoApi_NotFound() {
+	suite.useCaseMock.On("Update", mock.MatchedBy(func(promo *model.Promo) bool {
+		return promo.Code == "NOPE"
+	})).Return(nil, apperror.NotFound("promo NOPE not found"))
+
+	r := suite.serve(http.MethodPut, "/promo/NOPE", `{"discount": {"type": "fixed", "value": 5000}, "active": false}`, token)
+
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+}
+
+func (suite *PromoControllerTestSuite) TestCreatePromoApi_ForbiddenRole() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "cashier",
+		Role:     model.RoleCashier,
+	}, "test token id")
+
+	r := suite.serve(http.MethodPost, "/promo", `{"code": "hemat10"}`, cashierToken)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func TestPromoControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(PromoControllerTestSuite))
+}
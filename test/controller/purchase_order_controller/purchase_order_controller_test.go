@@ -0,0 +1,201 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+func tokenAs(role string) string {
+	token, _ := auth.GenerateAccessToken(&model.User{
+		Id:       "user 1",
+		Username: role,
+		Role:     role,
+	}, "test token id")
+	return token
+}
+
+var riceId = "dummy-ingredient-1"
+
+var dummyPurchaseOrder = model.PurchaseOrder{
+	Id:           "dummy-po-1",
+	SupplierId:   "dummy-supplier-1",
+	SupplierName: "pasar induk",
+	Status:       model.PurchaseOrdered,
+	ExpectedCost: 70000,
+	CreatedAt:    time.Date(2022, time.October, 1, 8, 0, 0, 0, time.UTC),
+	Lines:        []model.PurchaseOrderLine{{Id: 1, IngredientId: &riceId, Name: "rice", Qty: 5000, UnitCost: 14}},
+}
+
+type PurchaseOrderUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *PurchaseOrderUsecaseMock) List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PurchaseOrder), nil
+}
+
+func (r *PurchaseOrderUsecaseMock) GetById(id string) (model.PurchaseOrder, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *PurchaseOrderUsecaseMock) Insert(order *model.PurchaseOrder, userId string) (model.PurchaseOrder, error) {
+	args := r.Called(order, userId)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *PurchaseOrderUsecaseMock) Update(order *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	args := r.Called(order)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *PurchaseOrderUsecaseMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *PurchaseOrderUsecaseMock) Order(id string) (model.PurchaseOrder, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *PurchaseOrderUsecaseMock) Receive(id string, request model.ReceiveRequest, userId string) (model.PurchaseOrder, error) {
+	args := r.Called(id, request, userId)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+type PurchaseOrderControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *PurchaseOrderUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *PurchaseOrderControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(PurchaseOrderUsecaseMock)
+}
+
+func (suite *PurchaseOrderControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewPurchaseOrderController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	request.Header.Add("Authorization", "Bearer "+bearer)
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestListPurchaseOrderApi_Filter() {
+	list := []model.PurchaseOrder{dummyPurchaseOrder}
+	list[0].Lines = nil
+	suite.useCaseMock.On("List", model.PurchaseFilter{SupplierId: "dummy-supplier-1", Status: model.PurchaseOrdered}).Return(list, nil)
+
+	r := suite.serve(http.MethodGet, "/purchase-order?supplier_id=dummy-supplier-1&status=ordered", "", tokenAs(model.RoleViewer))
+
+	var actual []model.PurchaseOrder
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), list, actual)
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestCreatePurchaseOrderApi_Success() {
+	suite.useCaseMock.On("Insert", &model.PurchaseOrder{
+		SupplierId: "dummy-supplier-1",
+		Lines:      []model.PurchaseOrderLine{{IngredientId: &riceId, Qty: 5000, UnitCost: 14}},
+	}, "user 1").Return(dummyPurchaseOrder, nil)
+
+	r := suite.serve(http.MethodPost, "/purchase-order", `{"supplier_id": "dummy-supplier-1", "lines": [{"ingredient_id": "dummy-ingredient-1", "qty": 5000, "unit_cost": 14}]}`, tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestCreatePurchaseOrderApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPost, "/purchase-order", `{"supplier_id": "dummy-supplier-1"}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestOrderPurchaseOrderApi_NotDraft() {
+	suite.useCaseMock.On("Order", "dummy-po-1").Return(nil, apperror.Conflict("purchase order dummy-po-1 is ordered, only a draft can be changed", nil))
+
+	r := suite.serve(http.MethodPost, "/purchase-order/dummy-po-1/order", "", tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestReceivePurchaseOrderApi_Kitchen() {
+	unitCost := 13
+	request := model.ReceiveRequest{Lines: []model.ReceiveLine{{LineId: 1, Qty: 3000, UnitCost: &unitCost}}, Note: "first delivery"}
+	suite.useCaseMock.On("Receive", "dummy-po-1", request, "user 1").Return(dummyPurchaseOrder, nil)
+
+	r := suite.serve(http.MethodPost, "/purchase-order/dummy-po-1/receive", `{"lines": [{"line_id": 1, "qty": 3000, "unit_cost": 13}], "note": "first delivery"}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *PurchaseOrderControllerTestSuite) TestReceivePurchaseOrderApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPost, "/purchase-order/dummy-po-1/receive", `{"lines": [{"line_id": 1, "qty": 1}]}`, tokenAs(model.RoleCashier))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Receive", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPurchaseOrderControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(PurchaseOrderControllerTestSuite))
+}
@@ -0,0 +1,232 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyReport = model.SalesReport{
+	From:     time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC),
+	To:       time.Date(2022, time.October, 2, 0, 0, 0, 0, time.UTC),
+	Summary:  model.SalesSummary{Transactions: 2, GrossRevenue: 30000, Refunded: 10000, NetRevenue: 20000, AverageTicket: 15000},
+	Periods:  []model.SalesPeriod{{Period: "2022-10-01", SalesSummary: model.SalesSummary{Transactions: 2, GrossRevenue: 30000, Refunded: 10000, NetRevenue: 20000, AverageTicket: 15000}}},
+	Menus:    []model.MenuSales{{MenuId: "menu 1", Name: "nasi goreng", Qty: 3, RefundedQty: 1, Revenue: 30000, NetRevenue: 20000, Transactions: 2}},
+	Payments: []model.PaymentSales{{Method: model.PaymentCash, Transactions: 2, Revenue: 30000, Refunded: 10000, NetRevenue: 20000}},
+}
+
+type ReportUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *ReportUsecaseMock) Daily(filter model.ReportFilter) (model.SalesReport, error) {
+	args := r.Called(filter)
+	if args.Get(1) != nil {
+		return model.SalesReport{}, args.Error(1)
+	}
+	return args.Get(0).(model.SalesReport), nil
+}
+
+func (r *ReportUsecaseMock) Monthly(filter model.ReportFilter) (model.SalesReport, error) {
+	args := r.Called(filter)
+	if args.Get(1) != nil {
+		return model.SalesReport{}, args.Error(1)
+	}
+	return args.Get(0).(model.SalesReport), nil
+}
+
+func (r *ReportUsecaseMock) Menu(filter model.ReportFilter) (model.SalesReport, error) {
+	args := r.Called(filter)
+	if args.Get(1) != nil {
+		return model.SalesReport{}, args.Error(1)
+	}
+	return args.Get(0).(model.SalesReport), nil
+}
+
+func (r *ReportUsecaseMock) Suppliers(filter model.ReportFilter) (model.SpendingReport, error) {
+	args := r.Called(filter)
+	if args.Get(1) != nil {
+		return model.SpendingReport{}, args.Error(1)
+	}
+	return args.Get(0).(model.SpendingReport), nil
+}
+
+func (r *ReportUsecaseMock) Shrinkage(filter model.ReportFilter) (model.ShrinkageReport, error) {
+	args := r.Called(filter)
+	if args.Get(1) != nil {
+		return model.ShrinkageReport{}, args.Error(1)
+	}
+	return args.Get(0).(model.ShrinkageReport), nil
+}
+
+type ReportControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *ReportUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *ReportControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(ReportUsecaseMock)
+}
+
+func (suite *ReportControllerTestSuite) get(url, bearer string) *httptest.ResponseRecorder {
+	controller.NewReportController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	request.Header.Add("Authorization", "Bearer "+bearer)
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *ReportControllerTestSuite) TestDailyApi_Success() {
+	from := time.Date(2022, time.October, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+	suite.useCaseMock.On("Daily", model.ReportFilter{From: &from, To: &to}).Return(dummyReport, nil)
+
+	r := suite.get("/report/daily?from=2022-10-01&to=2022-10-01", token)
+
+	var actual model.SalesReport
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), dummyReport, actual)
+}
+
+func (suite *ReportControllerTestSuite) TestMenuApi_CSV() {
+	suite.useCaseMock.On("Menu", model.ReportFilter{}).Return(dummyReport, nil)
+
+	r := suite.get("/report/menu?format=csv", token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), `attachment; filename="menu-report.csv"`, r.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "menu_id,name,qty,refunded_qty,revenue,net_revenue,transactions\nmenu 1,nasi goreng,3,1,30000,20000,2\n", r.Body.String())
+}
+
+func (suite *ReportControllerTestSuite) TestSuppliersApi_CSV() {
+	suite.useCaseMock.On("Suppliers", model.ReportFilter{}).Return(model.SpendingReport{
+		Total:     450000,
+		Suppliers: []model.SupplierSpending{{SupplierId: "supplier 1", Name: "pasar induk", PurchaseOrders: 2, Spent: 450000}},
+	}, nil)
+
+	r := suite.get("/report/suppliers?format=csv", token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), `attachment; filename="supplier-report.csv"`, r.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "supplier_id,name,purchase_orders,spent\nsupplier 1,pasar induk,2,450000\n", r.Body.String())
+}
+
+func (suite *ReportControllerTestSuite) TestShrinkageApi_Month() {
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.Local)
+	report := model.ShrinkageReport{
+		Summary: model.ShrinkageSummary{StockTakes: 1, Lost: 28000},
+		Periods: []model.ShrinkagePeriod{{Period: "2022-10", ShrinkageSummary: model.ShrinkageSummary{StockTakes: 1, Lost: 28000}}},
+		Items:   []model.ShrinkageItem{{Kind: model.StockItemIngredient, Id: "ingredient 1", Name: "rice", Unit: "g", Variance: -2000, ShrinkageSummary: model.ShrinkageSummary{StockTakes: 1, Lost: 28000}}},
+	}
+	suite.useCaseMock.On("Shrinkage", model.ReportFilter{From: &from, To: &to, Period: model.PeriodMonth}).Return(report, nil)
+
+	r := suite.get("/report/shrinkage?from=2022-01-01&to=2022-12-31&period=month", token)
+
+	var actual model.ShrinkageReport
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), report, actual)
+}
+
+func (suite *ReportControllerTestSuite) TestShrinkageApi_CSV() {
+	suite.useCaseMock.On("Shrinkage", model.ReportFilter{}).Return(model.ShrinkageReport{
+		Items: []model.ShrinkageItem{{Kind: model.StockItemMenu, Id: "menu 1", Name: "es teh", Variance: -3, ShrinkageSummary: model.ShrinkageSummary{StockTakes: 1, Unvalued: 1}}},
+	}, nil)
+
+	r := suite.get("/report/shrinkage?format=csv", token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), `attachment; filename="shrinkage-report.csv"`, r.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "kind,id,name,unit,variance,stock_takes,lost,found,unvalued\nmenu,menu 1,es teh,,-3,1,0,0,1\n", r.Body.String())
+}
+
+func (suite *ReportControllerTestSuite) TestMonthlyApi_Failed() {
+	suite.useCaseMock.On("Monthly", model.ReportFilter{}).Return(nil, errors.New("failed"))
+
+	r := suite.get("/report/monthly", token)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
+}
+
+func (suite *ReportControllerTestSuite) TestMenuApi_Success() {
+	suite.useCaseMock.On("Menu", model.ReportFilter{Sort: model.MenuSalesByRevenue, Limit: 3}).Return(dummyReport, nil)
+
+	r := suite.get("/report/menu?sort=revenue&limit=3", token)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+}
+
+func (suite *ReportControllerTestSuite) TestMenuApi_InvalidQuery() {
+	r := suite.get("/report/menu?limit=many&from=yesterday", token)
+
+	var body struct {
+		Details map[string]string
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &body)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Contains(suite.T(), body.Details, "limit")
+	assert.Contains(suite.T(), body.Details, "from")
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Menu", mock.Anything)
+}
+
+func (suite *ReportControllerTestSuite) TestDailyApi_ForbiddenRole() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "cashier",
+		Role:     model.RoleCashier,
+	}, "test token id")
+
+	r := suite.get("/report/daily", cashierToken)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Daily", mock.Anything)
+}
+
+func TestReportControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(ReportControllerTestSuite))
+}
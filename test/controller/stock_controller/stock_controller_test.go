@@ -0,0 +1,212 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+var token, _ = auth.GenerateAccessToken(&model.User{
+	Username: "admin",
+	Role:     model.RoleOwner,
+}, "test token id")
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+var dummyMenuId = "dummy menu 1"
+
+var dummyMovement = model.StockMovement{
+	Id:        1,
+	MenuId:    &dummyMenuId,
+	Qty:       -2,
+	Reason:    model.StockWaste,
+	CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC),
+}
+
+var (
+	menuItem       = model.StockItem{Kind: model.StockItemMenu, Id: "dummy-menu-1"}
+	ingredientItem = model.StockItem{Kind: model.StockItemIngredient, Id: "dummy-ingredient-1"}
+)
+
+type StockUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *StockUsecaseMock) GetByItem(item model.StockItem) ([]model.StockMovement, error) {
+	args := r.Called(item)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockMovement), nil
+}
+
+func (r *StockUsecaseMock) Restock(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	args := r.Called(item, request, userId)
+	if args.Get(1) != nil {
+		return model.StockMovement{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockMovement), nil
+}
+
+func (r *StockUsecaseMock) Waste(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	args := r.Called(item, request, userId)
+	if args.Get(1) != nil {
+		return model.StockMovement{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockMovement), nil
+}
+
+func (r *StockUsecaseMock) Adjust(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	args := r.Called(item, request, userId)
+	if args.Get(1) != nil {
+		return model.StockMovement{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockMovement), nil
+}
+
+func (r *StockUsecaseMock) Alerts() ([]model.StockAlert, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockAlert), nil
+}
+
+type StockControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *StockUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *StockControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(StockUsecaseMock)
+}
+
+func (suite *StockControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewStockController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if bearer != "" {
+		request.Header.Add("Authorization", "Bearer "+bearer)
+	}
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func tokenAs(role string) string {
+	token, _ := auth.GenerateAccessToken(&model.User{
+		Id:       "user 1",
+		Username: role,
+		Role:     role,
+	}, "test token id")
+	return token
+}
+
+func (suite *StockControllerTestSuite) TestListMovementsApi_Viewer() {
+	suite.useCaseMock.On("GetByItem", menuItem).Return([]model.StockMovement{dummyMovement}, nil)
+
+	r := suite.serve(http.MethodGet, "/menu/dummy-menu-1/stock", "", tokenAs(model.RoleViewer))
+
+	var actual []model.StockMovement
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), []model.StockMovement{dummyMovement}, actual)
+}
+
+func (suite *StockControllerTestSuite) TestWasteApi_Kitchen() {
+	suite.useCaseMock.On("Waste", menuItem, model.StockRequest{Qty: 2, Note: "dropped"}, "user 1").Return(dummyMovement, nil)
+
+	r := suite.serve(http.MethodPost, "/menu/dummy-menu-1/stock/waste", `{"qty": 2, "note": "dropped"}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *StockControllerTestSuite) TestRestockApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPost, "/menu/dummy-menu-1/stock/restock", `{"qty": 2}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Restock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *StockControllerTestSuite) TestAdjustApi_NoQty() {
+	r := suite.serve(http.MethodPost, "/menu/dummy-menu-1/stock/adjust", `{"note": "miscounted"}`, token)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Adjust", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *StockControllerTestSuite) TestWasteApi_Reserved() {
+	suite.useCaseMock.On("Waste", menuItem, model.StockRequest{Qty: 9}, mock.Anything).
+		Return(nil, apperror.Conflict("menu dummy-menu-1 has 6 in stock that is not reserved, 9 cannot be taken", nil))
+
+	r := suite.serve(http.MethodPost, "/menu/dummy-menu-1/stock/waste", `{"qty": 9}`, token)
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *StockControllerTestSuite) TestRestockApi_Ingredient() {
+	suite.useCaseMock.On("Restock", ingredientItem, model.StockRequest{Qty: 5000}, "user 1").Return(dummyMovement, nil)
+
+	r := suite.serve(http.MethodPost, "/ingredient/dummy-ingredient-1/stock/restock", `{"qty": 5000}`, tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *StockControllerTestSuite) TestListAlertsApi_Kitchen() {
+	alerts := []model.StockAlert{{Kind: model.StockItemIngredient, Id: "dummy-ingredient-1", Name: "rice", Unit: "g", Available: 400, ReorderLevel: 1000}}
+	suite.useCaseMock.On("Alerts").Return(alerts, nil)
+
+	r := suite.serve(http.MethodGet, "/stock/alerts", "", tokenAs(model.RoleKitchen))
+
+	var actual []model.StockAlert
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), alerts, actual)
+}
+
+func (suite *StockControllerTestSuite) TestListAlertsApi_ForbiddenRole() {
+	r := suite.serve(http.MethodGet, "/stock/alerts", "", tokenAs(model.RoleCashier))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Alerts")
+}
+
+func TestStockControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(StockControllerTestSuite))
+}
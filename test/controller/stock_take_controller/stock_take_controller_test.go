@@ -0,0 +1,190 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+func tokenAs(role string) string {
+	token, _ := auth.GenerateAccessToken(&model.User{
+		Id:       "user 1",
+		Username: role,
+		Role:     role,
+	}, "test token id")
+	return token
+}
+
+var riceId = "dummy-ingredient-1"
+
+var dummyStockTake = model.StockTake{
+	Id:        "dummy-take-1",
+	Status:    model.StockTakeCounting,
+	CreatedAt: time.Date(2022, time.October, 31, 21, 0, 0, 0, time.UTC),
+	Lines:     []model.StockTakeLine{},
+}
+
+type StockTakeUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *StockTakeUsecaseMock) List(status string) ([]model.StockTake, error) {
+	args := r.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockTake), nil
+}
+
+func (r *StockTakeUsecaseMock) GetById(id string) (model.StockTake, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *StockTakeUsecaseMock) Insert(stockTake *model.StockTake, userId string) (model.StockTake, error) {
+	args := r.Called(stockTake, userId)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *StockTakeUsecaseMock) Count(id string, request model.StockCountRequest, userId string) (model.StockTake, error) {
+	args := r.Called(id, request, userId)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *StockTakeUsecaseMock) Approve(id string, userId string) (model.StockTake, error) {
+	args := r.Called(id, userId)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *StockTakeUsecaseMock) Cancel(id string) (model.StockTake, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+type StockTakeControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *StockTakeUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *StockTakeControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(StockTakeUsecaseMock)
+}
+
+func (suite *StockTakeControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewStockTakeController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	request.Header.Add("Authorization", "Bearer "+bearer)
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *StockTakeControllerTestSuite) TestListStockTakeApi_Status() {
+	list := []model.StockTake{dummyStockTake}
+	list[0].Lines = nil
+	suite.useCaseMock.On("List", model.StockTakeCounting).Return(list, nil)
+
+	r := suite.serve(http.MethodGet, "/stock-take?status=counting", "", tokenAs(model.RoleViewer))
+
+	var actual []model.StockTake
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), list, actual)
+}
+
+func (suite *StockTakeControllerTestSuite) TestCreateStockTakeApi_EmptyBody() {
+	suite.useCaseMock.On("Insert", &model.StockTake{}, "user 1").Return(dummyStockTake, nil)
+
+	r := suite.serve(http.MethodPost, "/stock-take", "", tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *StockTakeControllerTestSuite) TestCountStockApi_Cashier() {
+	counted := 1800
+	request := model.StockCountRequest{Counts: []model.StockCount{{IngredientId: &riceId, Counted: &counted}}}
+	suite.useCaseMock.On("Count", "dummy-take-1", request, "user 1").Return(dummyStockTake, nil)
+
+	r := suite.serve(http.MethodPut, "/stock-take/dummy-take-1/counts", `{"counts": [{"ingredient_id": "dummy-ingredient-1", "counted": 1800}]}`, tokenAs(model.RoleCashier))
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *StockTakeControllerTestSuite) TestCountStockApi_NoCounts() {
+	r := suite.serve(http.MethodPut, "/stock-take/dummy-take-1/counts", `{}`, tokenAs(model.RoleCashier))
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Count", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *StockTakeControllerTestSuite) TestApproveStockTakeApi_Conflict() {
+	suite.useCaseMock.On("Approve", "dummy-take-1", "user 1").Return(nil, apperror.Conflict("stock take dummy-take-1 is approved, it must be counting", nil))
+
+	r := suite.serve(http.MethodPost, "/stock-take/dummy-take-1/approve", "", tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *StockTakeControllerTestSuite) TestApproveStockTakeApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPost, "/stock-take/dummy-take-1/approve", "", tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Approve", mock.Anything, mock.Anything)
+}
+
+func TestStockTakeControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(StockTakeControllerTestSuite))
+}
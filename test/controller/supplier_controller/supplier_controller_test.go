@@ -0,0 +1,152 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/controller"
+	"warung-makan/middleware"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/authenticator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
+}
+
+func tokenAs(role string) string {
+	token, _ := auth.GenerateAccessToken(&model.User{
+		Id:       "user 1",
+		Username: role,
+		Role:     role,
+	}, "test token id")
+	return token
+}
+
+var dummySuppliers = []model.Supplier{
+	{Id: "dummy-supplier-1", Name: "pasar induk", CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+type SupplierUsecaseMock struct {
+	mock.Mock
+}
+
+func (r *SupplierUsecaseMock) GetAll() ([]model.Supplier, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Supplier), nil
+}
+
+func (r *SupplierUsecaseMock) GetById(id string) (model.Supplier, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *SupplierUsecaseMock) Insert(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *SupplierUsecaseMock) Update(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *SupplierUsecaseMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type SupplierControllerTestSuite struct {
+	suite.Suite
+	useCaseMock *SupplierUsecaseMock
+	routerMock  *gin.Engine
+}
+
+func (suite *SupplierControllerTestSuite) SetupTest() {
+	suite.routerMock = gin.Default()
+	suite.useCaseMock = new(SupplierUsecaseMock)
+}
+
+func (suite *SupplierControllerTestSuite) serve(method, url, body, bearer string) *httptest.ResponseRecorder {
+	controller.NewSupplierController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+	request.Header.Add("Authorization", "Bearer "+bearer)
+	suite.routerMock.ServeHTTP(r, request)
+	return r
+}
+
+func (suite *SupplierControllerTestSuite) TestListSupplierApi_Viewer() {
+	suite.useCaseMock.On("GetAll").Return(dummySuppliers, nil)
+
+	r := suite.serve(http.MethodGet, "/supplier", "", tokenAs(model.RoleViewer))
+
+	var actual []model.Supplier
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actual)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), dummySuppliers, actual)
+}
+
+func (suite *SupplierControllerTestSuite) TestCreateSupplierApi_MissingName() {
+	r := suite.serve(http.MethodPost, "/supplier", `{"phone": "0812"}`, tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *SupplierControllerTestSuite) TestDeleteSupplierApi_HasPurchaseOrders() {
+	suite.useCaseMock.On("Delete", "dummy-supplier-1").Return(apperror.Conflict("supplier dummy-supplier-1 is still used", nil))
+
+	r := suite.serve(http.MethodDelete, "/supplier/dummy-supplier-1", "", tokenAs(model.RoleOwner))
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *SupplierControllerTestSuite) TestUpdateSupplierApi_ForbiddenRole() {
+	r := suite.serve(http.MethodPut, "/supplier/dummy-supplier-1", `{"name": "pasar induk"}`, tokenAs(model.RoleKitchen))
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func TestSupplierControllerTestSuite(t *testing.T) {
+	suite.Run(t, new(SupplierControllerTestSuite))
+}
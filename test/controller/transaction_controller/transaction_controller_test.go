@@ -8,22 +8,34 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"warung-makan/config"
 	"warung-makan/controller"
+	"warung-makan/middleware"
 	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
 	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
-var auth = authenticator.NewAccessToken(config.NewConfig().TokenConfig)
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
 var token, _ = auth.GenerateAccessToken(&model.User{
 	Username: "admin",
 	Password: "admin",
-})
+	Role:     model.RoleOwner,
+}, "test token id")
 
 var dummyTransactions = []model.Transaction{
 	{
@@ -48,7 +60,8 @@ var dummyTransactions = []model.Transaction{
 }
 
 type itemList struct {
-	Items []model.TransactionDetail
+	Items    []model.TransactionDetail `json:"items"`
+	Payments []model.Payment           `json:"payments,omitempty"`
 }
 
 // var dummyItemList = []model.ItemList{
@@ -63,7 +76,16 @@ type itemList struct {
 // }
 
 var itemlist = itemList{
-	Items: dummyTransactions[0].Items,
+	Items:    dummyTransactions[0].Items,
+	Payments: []model.Payment{{Method: model.PaymentBankTransfer, Amount: 10000}},
+}
+
+var authMiddleware = middleware.NewAuthTokenMiddleware(auth, revocationCheckerStub{})
+
+type revocationCheckerStub struct{}
+
+func (revocationCheckerStub) IsRevoked(jti string) (bool, error) {
+	return false, nil
 }
 
 type ErrorResponse struct {
@@ -78,9 +100,8 @@ type TransactionUsecaseMock struct {
 // Buat TestSuite
 type TransactionControllerTestSuite struct {
 	suite.Suite
-	useCaseMock            *TransactionUsecaseMock
-	transactionUsecaseMock *MenuUsecaseMock
-	routerMock             *gin.Engine
+	useCaseMock *TransactionUsecaseMock
+	routerMock  *gin.Engine
 }
 
 func (suite *TransactionControllerTestSuite) SetupTest() {
@@ -96,6 +117,100 @@ func (r *TransactionUsecaseMock) GetAll() ([]model.Transaction, error) {
 	return args.Get(0).([]model.Transaction), nil
 }
 
+func (r *TransactionUsecaseMock) List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Transaction), args.Get(1).(model.PageInfo), nil
+}
+
+// Export passes the transactions given to Return to each, then returns the
+// error.
+func (r *TransactionUsecaseMock) Export(filter model.TransactionFilter, each func(model.Transaction) error) error {
+	args := r.Called(filter)
+	if transactions, ok := args.Get(0).([]model.Transaction); ok {
+		for _, transaction := range transactions {
+			if err := each(transaction); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (r *TransactionUsecaseMock) Open(items []model.TransactionDetail) (model.Transaction, error) {
+	args := r.Called(items)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) AddItems(id string, items []model.TransactionDetail) (model.Transaction, error) {
+	args := r.Called(id, items)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) RemoveItem(id string, itemId int64) (model.Transaction, error) {
+	args := r.Called(id, itemId)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) Send(id string) (model.Transaction, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) Serve(id string) (model.Transaction, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) Pay(id string, request model.PaymentRequest) (model.Transaction, error) {
+	args := r.Called(id, request)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) Cancel(id string) (model.Transaction, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Transaction{}, args.Error(1)
+	}
+	return args.Get(0).(model.Transaction), nil
+}
+
+func (r *TransactionUsecaseMock) Void(id string, request model.RefundRequest, userId string) (model.Refund, error) {
+	args := r.Called(id, request, userId)
+	if args.Get(1) != nil {
+		return model.Refund{}, args.Error(1)
+	}
+	return args.Get(0).(model.Refund), nil
+}
+
+func (r *TransactionUsecaseMock) Refund(id string, request model.RefundRequest, userId string) (model.Refund, error) {
+	args := r.Called(id, request, userId)
+	if args.Get(1) != nil {
+		return model.Refund{}, args.Error(1)
+	}
+	return args.Get(0).(model.Refund), nil
+}
+
 func (r *TransactionUsecaseMock) GetById(id string) (model.Transaction, error) {
 	args := r.Called(id)
 	if args.Get(1) != nil {
@@ -104,19 +219,19 @@ func (r *TransactionUsecaseMock) GetById(id string) (model.Transaction, error) {
 	return args.Get(0).(model.Transaction), nil
 }
 
-func (r *TransactionUsecaseMock) Insert(user *model.Transaction) (model.Transaction, error) {
-	args := r.Called(user)
+func (r *TransactionUsecaseMock) Insert(user *model.Transaction, partial bool) (model.Transaction, error) {
+	args := r.Called(user, partial)
 	if args.Get(1) != nil {
 		return model.Transaction{}, args.Error(1)
 	}
 	return args.Get(0).(model.Transaction), nil
 }
 
-func (suite TransactionControllerTestSuite) TestGetAllTransactionApi_Success() {
+func (suite *TransactionControllerTestSuite) TestGetAllTransactionApi_Success() {
 	transaction := dummyTransactions[0]
-	suite.useCaseMock.On("GetAll").Return(dummyTransactions, nil)
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.TransactionFilter")).Return(dummyTransactions, model.PageInfo{Total: 1, Page: 1, Limit: 20}, nil)
 
-	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/transaction", nil)
@@ -126,9 +241,11 @@ func (suite TransactionControllerTestSuite) TestGetAllTransactionApi_Success() {
 	var actualTransaction []model.Transaction
 	response := r.Body.String()
 
-	jsonerr := json.Unmarshal([]byte(response), &actualTransaction)
-
-	fmt.Println("err: ", err)
+	var body struct {
+		Data []model.Transaction
+	}
+	jsonerr := json.Unmarshal([]byte(response), &body)
+	actualTransaction = body.Data
 
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), err)
@@ -137,10 +254,10 @@ func (suite TransactionControllerTestSuite) TestGetAllTransactionApi_Success() {
 	assert.Equal(suite.T(), transaction.Id, actualTransaction[0].Id)
 }
 
-func (suite TransactionControllerTestSuite) TestGetAllTransactionApi_Failed() {
-	suite.useCaseMock.On("GetAll").Return(nil, errors.New("failed"))
+func (suite *TransactionControllerTestSuite) TestGetAllTransactionApi_Failed() {
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.TransactionFilter")).Return(nil, model.PageInfo{}, errors.New("failed"))
 
-	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/transaction", nil)
@@ -157,11 +274,90 @@ func (suite TransactionControllerTestSuite) TestGetAllTransactionApi_Failed() {
 
 }
 
-func (suite TransactionControllerTestSuite) TestGetByIdTransactionApi_Success() {
+func (suite *TransactionControllerTestSuite) TestGetAllTransactionApi_DateRange() {
+	from := time.Date(2022, 10, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2022, 11, 1, 0, 0, 0, 0, time.Local)
+	minTotal := 50000
+	expected := model.TransactionFilter{From: &from, To: &to, MinTotal: &minTotal}
+	suite.useCaseMock.On("List", expected).Return(dummyTransactions, model.PageInfo{Total: 1, Page: 1, Limit: 20}, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/transaction?from=2022-10-01&to=2022-10-31&min_total=50000", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestGetAllTransactionApi_InvalidRange() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/transaction?from=2022-10-31&to=2022-10-01", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestExportTransactionApi_CSV() {
+	transaction := dummyTransactions[0]
+	transaction.Created_at = "2022-10-01T10:00:00Z"
+	transaction.Status = model.StatusPaid
+	transaction.Subtotal = 16000
+	transaction.DiscountAmount = 1000
+	transaction.Items = []model.TransactionDetail{{MenuId: "menu 1", MenuName: "nasi goreng", Modifiers: model.LineModifiers{{Name: "large"}, {Name: "extra egg"}}, UnitPrice: 8000, Qty: 2, Subtotal: 16000, DiscountAmount: 1000}}
+	suite.useCaseMock.On("Export", mock.AnythingOfType("model.TransactionFilter")).Return([]model.Transaction{transaction}, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/transaction/export?from=2022-10-01", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC).In(time.Local).Format("2006-01-02 15:04:05")
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), `attachment; filename="transactions.csv"`, r.Header().Get("Content-Disposition"))
+	assert.Equal(suite.T(), "transaction_id,created_at,status,transaction_subtotal,transaction_discount,service_charge,tax,total,menu_id,menu_name,modifiers,unit_price,qty,subtotal,discount\n"+
+		"dummy transaction 1,"+created+",paid,16000,1000,0,0,15000,menu 1,nasi goreng,\"large, extra egg\",8000,2,16000,1000\n", r.Body.String())
+}
+
+func (suite *TransactionControllerTestSuite) TestExportTransactionApi_Failed() {
+	suite.useCaseMock.On("Export", mock.AnythingOfType("model.TransactionFilter")).Return(nil, errors.New("failed"))
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/transaction/export?format=xlsx", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
+	assert.Empty(suite.T(), r.Header().Get("Content-Disposition"))
+}
+
+func (suite *TransactionControllerTestSuite) TestExportTransactionApi_InvalidFormat() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/transaction/export?format=pdf", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Export", mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestGetByIdTransactionApi_Success() {
 	transaction := dummyTransactions[0]
 	suite.useCaseMock.On("GetById", transaction.Id).Return(transaction, nil)
 
-	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/transaction/"+transaction.Id, nil)
@@ -178,11 +374,11 @@ func (suite TransactionControllerTestSuite) TestGetByIdTransactionApi_Success()
 	assert.Equal(suite.T(), transaction.Id, actualTransaction.Id)
 }
 
-func (suite TransactionControllerTestSuite) TestGetByIdTransactionApi_Failed() {
+func (suite *TransactionControllerTestSuite) TestGetByIdTransactionApi_Failed() {
 	transaction := dummyTransactions[0]
-	suite.useCaseMock.On("GetById", transaction.Id).Return(model.Transaction{}, errors.New("failed"))
+	suite.useCaseMock.On("GetById", transaction.Id).Return(model.Transaction{}, apperror.NotFound("transaction dummy transaction 1 not found"))
 
-	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/transaction/"+transaction.Id, nil)
@@ -199,68 +395,308 @@ func (suite TransactionControllerTestSuite) TestGetByIdTransactionApi_Failed() {
 	assert.Equal(suite.T(), model.Transaction{}, actualTransaction)
 }
 
-func (suite TransactionControllerTestSuite) TestInsertTransactionApi_Success() {
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_Success() {
 	transaction := dummyTransactions[0]
+	suite.useCaseMock.On("Insert", mock.AnythingOfType("*model.Transaction"), false).Return(transaction, nil)
 
-	suite.useCaseMock.On("Insert", &transaction).Return(transaction, nil)
-
-	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
 	r := httptest.NewRecorder()
 
-	reqBody, _ := json.Marshal(transaction.Items)
+	reqBody, _ := json.Marshal(itemlist)
 	request, err := http.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(reqBody))
 	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
-	var actualTransaction = model.Transaction{}
-	response := r.Body.String()
-	jsonerr := json.Unmarshal([]byte(response), &actualTransaction.Items)
+	var response struct {
+		Data model.Transaction
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &response)
 
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), jsonerr)
-	assert.Equal(suite.T(), transaction, actualTransaction)
+	assert.Equal(suite.T(), transaction, response.Data)
+
+	sent := suite.useCaseMock.Calls[0].Arguments.Get(0).(*model.Transaction)
+	assert.Equal(suite.T(), transaction.Items[0].MenuId, sent.Items[0].MenuId)
+	assert.Equal(suite.T(), transaction.Items[0].Qty, sent.Items[0].Qty)
+	assert.Equal(suite.T(), itemlist.Payments, sent.Payments)
 }
 
-// func (suite TransactionControllerTestSuite) TestInsertTransactionApi_FailedBinding() {
-// 	suite.useCaseMock.On("Insert").Return(model.Menu{}, errors.New("failed"))
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_InsufficientStock() {
+	available := 3
+	rejected := []model.RejectedItem{{Index: 0, MenuId: "menu 1", Qty: 10, Reason: model.RejectInsufficientStock, Available: &available}}
+	suite.useCaseMock.On("Insert", mock.AnythingOfType("*model.Transaction"), false).Return(model.Transaction{}, apperror.Conflict("1 of the order lines cannot be sold", rejected))
 
-// 	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
-// 	r := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(itemlist)
+	request, _ := http.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
 
-// 	request, _ := http.NewRequest(http.MethodPost, "/transaction/no_image", nil)
-// 	request.Header.Add("Authorization", "Bearer "+token)
-// 	suite.routerMock.ServeHTTP(r, request)
+	var response struct {
+		Error   string
+		Details []model.RejectedItem
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &response)
 
-// 	var actualMenu = model.Menu{}
-// 	response := r.Body.String()
-// 	json.Unmarshal([]byte(response), &actualMenu)
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), rejected, response.Details)
+}
 
-// 	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
-// 	assert.Equal(suite.T(), model.Menu{}, actualMenu)
-// }
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_Partial() {
+	transaction := dummyTransactions[0]
+	transaction.RejectedItems = []model.RejectedItem{{Index: 1, MenuId: "menu x", Qty: 1, Reason: model.RejectMenuNotFound}}
+	suite.useCaseMock.On("Insert", mock.AnythingOfType("*model.Transaction"), true).Return(transaction, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(itemlist)
+	request, _ := http.NewRequest(http.MethodPost, "/transaction?partial=true", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	var response struct {
+		Data model.Transaction
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &response)
 
-// func (suite TransactionControllerTestSuite) TestInsertTransactionApi_Failed() {
-// 	transaction := dummyTransactions[0]
-// 	suite.useCaseMock.On("Insert", &transaction).Return(model.Menu{}, errors.New("failed"))
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), transaction.RejectedItems, response.Data.RejectedItems)
+}
 
-// 	controller.NewTransactionController(suite.useCaseMock, suite.transactionUsecaseMock, suite.routerMock)
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_InvalidPartial() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
 
-// 	r := httptest.NewRecorder()
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(itemlist)
+	request, _ := http.NewRequest(http.MethodPost, "/transaction?partial=maybe", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
 
-// 	reqBody, _ := json.Marshal(transaction)
-// 	request, _ := http.NewRequest(http.MethodPost, "/transaction/no_image", bytes.NewBuffer(reqBody))
-// 	request.Header.Add("Authorization", "Bearer "+token)
-// 	suite.routerMock.ServeHTTP(r, request)
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+}
 
-// 	var actualMenu = model.Menu{}
-// 	response := r.Body.String()
-// 	json.Unmarshal([]byte(response), &actualMenu)
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_InvalidOrder() {
+	suite.useCaseMock.On("Insert", mock.AnythingOfType("*model.Transaction"), false).Return(model.Transaction{}, fmt.Errorf("%w: menu x not found", usecase.ErrInvalidOrder))
 
-// 	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
-// }
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(itemlist)
+	request, _ := http.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_Failed() {
+	suite.useCaseMock.On("Insert", mock.AnythingOfType("*model.Transaction"), false).Return(model.Transaction{}, errors.New("failed"))
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(itemlist)
+	request, _ := http.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestOpenOrderApi_WithoutBody() {
+	suite.useCaseMock.On("Open", []model.TransactionDetail(nil)).Return(model.Transaction{Id: "order 1", Status: model.StatusOpen}, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/open", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	var response struct {
+		Data model.Transaction
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &response)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), model.StatusOpen, response.Data.Status)
+}
+
+func (suite *TransactionControllerTestSuite) TestRemoveOrderItemApi_InvalidItemId() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodDelete, "/transaction/order 1/items/first", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "RemoveItem", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestServeOrderApi_Kitchen() {
+	suite.useCaseMock.On("Serve", "order 1").Return(model.Transaction{Id: "order 1", Status: model.StatusServed}, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	kitchenToken, _ := auth.GenerateAccessToken(&model.User{Username: "kitchen", Role: model.RoleKitchen}, "test token id")
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/order 1/serve", nil)
+	request.Header.Add("Authorization", "Bearer "+kitchenToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestPayOrderApi_KitchenForbidden() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	kitchenToken, _ := auth.GenerateAccessToken(&model.User{Username: "kitchen", Role: model.RoleKitchen}, "test token id")
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/order 1/pay", bytes.NewBufferString(`{"payments": [{"method": "cash", "amount": 1000}]}`))
+	request.Header.Add("Authorization", "Bearer "+kitchenToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Pay", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestPayOrderApi_Success() {
+	tendered := 50000
+	payments := []model.Payment{{Method: model.PaymentCash, Tendered: &tendered}, {Method: model.PaymentQRIS, Amount: 10000}}
+	suite.useCaseMock.On("Pay", "order 1", model.PaymentRequest{Payments: payments}).Return(model.Transaction{Id: "order 1", Status: model.StatusPaid, Payments: payments}, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	body := `{"payments": [{"method": "cash", "tendered": 50000}, {"method": "qris", "amount": 10000}]}`
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/order 1/pay", bytes.NewBufferString(body))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionControllerTestSuite) TestSendOrderApi_NotOpen() {
+	suite.useCaseMock.On("Send", "order 1").Return(nil, apperror.Conflict("transaction order 1 is paid, it must be open", nil))
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/order 1/send", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestVoidTransactionApi_Success() {
+	request := model.RefundRequest{Reason: "wrong table"}
+	refund := model.Refund{Id: "refund 1", TransactionId: "dummy transaction 1", Kind: model.RefundKindVoid, Reason: "wrong table", Amount: 15000}
+	suite.useCaseMock.On("Void", "dummy transaction 1", request, "user 1").Return(refund, nil)
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	ownerToken, _ := auth.GenerateAccessToken(&model.User{Id: "user 1", Username: "owner", Role: model.RoleOwner}, "test token id")
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(request)
+	httpRequest, _ := http.NewRequest(http.MethodPost, "/transaction/dummy transaction 1/void", bytes.NewBuffer(reqBody))
+	httpRequest.Header.Add("Authorization", "Bearer "+ownerToken)
+	suite.routerMock.ServeHTTP(r, httpRequest)
+
+	var response struct {
+		Data model.Refund
+	}
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &response)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), 15000, response.Data.Amount)
+}
+
+func (suite *TransactionControllerTestSuite) TestVoidTransactionApi_ForbiddenCashier() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{Id: "user 1", Username: "cashier", Role: model.RoleCashier}, "test token id")
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(model.RefundRequest{Reason: "wrong table"})
+	httpRequest, _ := http.NewRequest(http.MethodPost, "/transaction/dummy transaction 1/void", bytes.NewBuffer(reqBody))
+	httpRequest.Header.Add("Authorization", "Bearer "+cashierToken)
+	suite.routerMock.ServeHTTP(r, httpRequest)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Void", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestRefundTransactionApi_ForbiddenCashier() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{Id: "user 1", Username: "cashier", Role: model.RoleCashier}, "test token id")
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(model.RefundRequest{Reason: "cold food"})
+	httpRequest, _ := http.NewRequest(http.MethodPost, "/transaction/dummy transaction 1/refund", bytes.NewBuffer(reqBody))
+	httpRequest.Header.Add("Authorization", "Bearer "+cashierToken)
+	suite.routerMock.ServeHTTP(r, httpRequest)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Refund", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestVoidTransactionApi_NoReason() {
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/transaction/dummy transaction 1/void", bytes.NewBufferString("{}"))
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Void", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionControllerTestSuite) TestRefundTransactionApi_AlreadyRefunded() {
+	request := model.RefundRequest{Reason: "cold food", Items: []model.RefundLine{{DetailId: 1, Qty: 1}}}
+	suite.useCaseMock.On("Refund", "dummy transaction 1", request, mock.Anything).Return(nil, apperror.Conflict("every item of transaction dummy transaction 1 is already refunded", nil))
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(request)
+	httpRequest, _ := http.NewRequest(http.MethodPost, "/transaction/dummy transaction 1/refund", bytes.NewBuffer(reqBody))
+	httpRequest.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, httpRequest)
+
+	assert.Equal(suite.T(), http.StatusConflict, r.Code)
+}
+
+func (suite *TransactionControllerTestSuite) TestInsertTransactionApi_ForbiddenRole() {
+	kitchenToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "kitchen",
+		Role:     model.RoleKitchen,
+	}, "test token id")
+
+	controller.NewTransactionController(suite.useCaseMock, suite.routerMock, authMiddleware)
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(dummyTransactions[0])
+	request, _ := http.NewRequest(http.MethodPost, "/transaction", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+kitchenToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "Insert", mock.Anything, mock.Anything)
+}
 
 func TestMenuControllerTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionControllerTestSuite))
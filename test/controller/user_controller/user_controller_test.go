@@ -7,12 +7,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"warung-makan/config"
 	"warung-makan/controller"
+	"warung-makan/middleware"
 	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
 	"warung-makan/utils/authenticator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -35,11 +40,18 @@ var dummyUsers = []model.User{
 	},
 }
 
-var auth = authenticator.NewAccessToken(config.NewConfig().TokenConfig)
+var auth = authenticator.NewAccessToken(config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+})
 var token, _ = auth.GenerateAccessToken(&model.User{
 	Username: "admin",
 	Password: "admin",
-})
+	Role:     model.RoleOwner,
+}, "test token id")
 
 type ErrorResponse struct {
 	Error   string
@@ -50,16 +62,25 @@ type UserUsecaseMock struct {
 	mock.Mock
 }
 
+type TokenUsecaseMock struct {
+	mock.Mock
+}
+
 // Buat TestSuite
 type UserControllerTestSuite struct {
 	suite.Suite
-	useCaseMock *UserUsecaseMock
-	routerMock  *gin.Engine
+	useCaseMock      *UserUsecaseMock
+	tokenUsecaseMock *TokenUsecaseMock
+	authMiddleware   middleware.AuthTokenMiddleware
+	routerMock       *gin.Engine
 }
 
 func (suite *UserControllerTestSuite) SetupTest() {
 	suite.routerMock = gin.Default()
 	suite.useCaseMock = new(UserUsecaseMock)
+	suite.tokenUsecaseMock = new(TokenUsecaseMock)
+	suite.tokenUsecaseMock.On("IsRevoked", mock.Anything).Return(false, nil)
+	suite.authMiddleware = middleware.NewAuthTokenMiddleware(auth, suite.tokenUsecaseMock)
 }
 
 func (r *UserUsecaseMock) GetAll() ([]model.User, error) {
@@ -70,8 +91,16 @@ func (r *UserUsecaseMock) GetAll() ([]model.User, error) {
 	return args.Get(0).([]model.User), nil
 }
 
-func (r *UserUsecaseMock) GetById(id string) (model.User, error) {
-	args := r.Called(id)
+func (r *UserUsecaseMock) List(filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *UserUsecaseMock) GetById(id string, includeDeleted bool) (model.User, error) {
+	args := r.Called(id, includeDeleted)
 	if args.Get(1) != nil {
 		return model.User{}, args.Error(1)
 	}
@@ -110,6 +139,27 @@ func (r *UserUsecaseMock) Update(newUser *model.User) (model.User, error) {
 	return args.Get(0).(model.User), nil
 }
 
+func (r *UserUsecaseMock) ChangePassword(id string, passwordChange *model.PasswordChange) error {
+	args := r.Called(id, passwordChange)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (r *UserUsecaseMock) PromoteOwner(username string) error {
+	args := r.Called(username)
+	return args.Error(0)
+}
+
+func (r *UserUsecaseMock) Restore(id string) (model.User, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.User{}, args.Error(1)
+	}
+	return args.Get(0).(model.User), nil
+}
+
 func (r *UserUsecaseMock) Delete(id string) error {
 	args := r.Called(id)
 	if args.Get(0) != nil {
@@ -118,20 +168,63 @@ func (r *UserUsecaseMock) Delete(id string) error {
 	return nil
 }
 
-func (suite UserControllerTestSuite) TestGetAllUserApi_Success() {
+func (r *TokenUsecaseMock) Issue(user *model.User) (model.TokenPair, error) {
+	args := r.Called(user)
+	if args.Get(1) != nil {
+		return model.TokenPair{}, args.Error(1)
+	}
+	return args.Get(0).(model.TokenPair), nil
+}
+
+func (r *TokenUsecaseMock) Refresh(refreshToken string) (model.TokenPair, error) {
+	args := r.Called(refreshToken)
+	if args.Get(1) != nil {
+		return model.TokenPair{}, args.Error(1)
+	}
+	return args.Get(0).(model.TokenPair), nil
+}
+
+func (r *TokenUsecaseMock) Logout(jti string, expiresAt time.Time) error {
+	args := r.Called(jti, expiresAt)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (r *TokenUsecaseMock) RevokeUserSessions(userId string) error {
+	args := r.Called(userId)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (r *TokenUsecaseMock) IsRevoked(jti string) (bool, error) {
+	args := r.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (suite *UserControllerTestSuite) TestGetAllUserApi_Success() {
 	users := dummyUsers
-	suite.useCaseMock.On("GetAll").Return(users, nil)
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.UserFilter")).Return(users, model.PageInfo{Total: 2, Page: 1, Limit: 20}, nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var actualUsers []model.User
 	response := r.Body.String()
 
-	jsonerr := json.Unmarshal([]byte(response), &actualUsers)
+	var body struct {
+		Data []model.User
+		Meta model.PageInfo
+	}
+	jsonerr := json.Unmarshal([]byte(response), &body)
+	actualUsers = body.Data
 
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), err)
@@ -140,13 +233,14 @@ func (suite UserControllerTestSuite) TestGetAllUserApi_Success() {
 	assert.Equal(suite.T(), users[0].Id, actualUsers[0].Id)
 }
 
-func (suite UserControllerTestSuite) TestGetAllUserApi_Failed() {
-	suite.useCaseMock.On("GetAll").Return(nil, errors.New("failed"))
+func (suite *UserControllerTestSuite) TestGetAllUserApi_Failed() {
+	suite.useCaseMock.On("List", mock.AnythingOfType("model.UserFilter")).Return(nil, model.PageInfo{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var errorResponse ErrorResponse
@@ -159,14 +253,15 @@ func (suite UserControllerTestSuite) TestGetAllUserApi_Failed() {
 
 }
 
-func (suite UserControllerTestSuite) TestGetByIdUserApi_Success() {
+func (suite *UserControllerTestSuite) TestGetByIdUserApi_Success() {
 	user := dummyUsers[0]
-	suite.useCaseMock.On("GetById", user.Id).Return(user, nil)
+	suite.useCaseMock.On("GetById", user.Id, false).Return(user, nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/user/"+user.Id, nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var actualUser model.User
@@ -180,14 +275,15 @@ func (suite UserControllerTestSuite) TestGetByIdUserApi_Success() {
 	assert.Equal(suite.T(), user.Id, actualUser.Id)
 }
 
-func (suite UserControllerTestSuite) TestGetByIdUserApi_Failed() {
+func (suite *UserControllerTestSuite) TestGetByIdUserApi_Failed() {
 	user := dummyUsers[0]
-	suite.useCaseMock.On("GetById", user.Id).Return(model.User{}, errors.New("failed"))
+	suite.useCaseMock.On("GetById", user.Id, false).Return(model.User{}, apperror.NotFound("user dummy id 1 not found"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/user/"+user.Id, nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var actualUser model.User
@@ -199,20 +295,26 @@ func (suite UserControllerTestSuite) TestGetByIdUserApi_Failed() {
 	assert.NotEqual(suite.T(), user, actualUser)
 }
 
-func (suite UserControllerTestSuite) TestGetByNameUserApi_Success() {
+func (suite *UserControllerTestSuite) TestGetByNameUserApi_Success() {
 	users := dummyUsers
-	suite.useCaseMock.On("GetByName", "dummy").Return(users, nil)
+	suite.useCaseMock.On("List", mock.MatchedBy(func(filter model.UserFilter) bool { return filter.Name == "dummy" })).Return(users, model.PageInfo{Total: 2, Page: 1, Limit: 20}, nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodGet, "/user?name=dummy", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var actualUsers []model.User
 	response := r.Body.String()
 
-	jsonerr := json.Unmarshal([]byte(response), &actualUsers)
+	var body struct {
+		Data []model.User
+		Meta model.PageInfo
+	}
+	jsonerr := json.Unmarshal([]byte(response), &body)
+	actualUsers = body.Data
 
 	assert.Equal(suite.T(), http.StatusOK, r.Code)
 	assert.Nil(suite.T(), err)
@@ -221,30 +323,31 @@ func (suite UserControllerTestSuite) TestGetByNameUserApi_Success() {
 	assert.Equal(suite.T(), users[0].Id, actualUsers[0].Id)
 }
 
-func (suite UserControllerTestSuite) TestGetByNameUserApi_Failed() {
-	suite.useCaseMock.On("GetByName", "dummy").Return(nil, errors.New("failed"))
+func (suite *UserControllerTestSuite) TestGetByNameUserApi_Failed() {
+	suite.useCaseMock.On("List", mock.MatchedBy(func(filter model.UserFilter) bool { return filter.Name == "dummy" })).Return(nil, model.PageInfo{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodGet, "/user?name=dummy", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
 	suite.routerMock.ServeHTTP(r, request)
 
 	var errorResponse ErrorResponse
 	response := r.Body.String()
 	jsonerr := json.Unmarshal([]byte(response), &errorResponse)
 
-	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
 	assert.Equal(suite.T(), "failed", errorResponse.Error)
 	assert.Nil(suite.T(), jsonerr)
 }
 
-func (suite UserControllerTestSuite) TestLoginUserApi_Success() {
+func (suite *UserControllerTestSuite) TestLoginUserApi_Success() {
 	user := dummyUsers[0]
 
 	suite.useCaseMock.On("GetByCredentials", user.Username, user.Password).Return(user, nil)
 
-	controller.NewLoginController(suite.useCaseMock, suite.routerMock)
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
 	var credentials = model.Credential{
 		Username: user.Username,
 		Password: user.Password,
@@ -266,12 +369,12 @@ func (suite UserControllerTestSuite) TestLoginUserApi_Success() {
 	assert.Equal(suite.T(), user.Id, actualUser.Id)
 }
 
-func (suite UserControllerTestSuite) TestLoginUserApi_Failed() {
+func (suite *UserControllerTestSuite) TestLoginUserApi_Failed() {
 	user := dummyUsers[0]
 
-	suite.useCaseMock.On("GetByCredentials", user.Username, user.Password).Return(model.User{}, errors.New("failed"))
+	suite.useCaseMock.On("GetByCredentials", user.Username, user.Password).Return(model.User{}, usecase.ErrInvalidCredentials)
 
-	controller.NewLoginController(suite.useCaseMock, suite.routerMock)
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
 	var credentials = model.Credential{
 		Username: user.Username,
 		Password: user.Password,
@@ -292,12 +395,32 @@ func (suite UserControllerTestSuite) TestLoginUserApi_Failed() {
 	assert.Equal(suite.T(), model.User{}, actualUser)
 }
 
-func (suite UserControllerTestSuite) TestInsertUserNoImageApi_Success() {
+func (suite *UserControllerTestSuite) TestLoginUserApi_InternalError() {
+	user := dummyUsers[0]
+
+	suite.useCaseMock.On("GetByCredentials", user.Username, user.Password).Return(model.User{}, errors.New("failed"))
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+	body, _ := json.Marshal(model.Credential{
+		Username: user.Username,
+		Password: user.Password,
+	})
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
+	suite.tokenUsecaseMock.AssertNotCalled(suite.T(), "Issue", mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestInsertUserNoImageApi_Success() {
 	user := dummyUsers[0]
 
 	suite.useCaseMock.On("Insert", &user).Return(user, nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -319,10 +442,10 @@ func (suite UserControllerTestSuite) TestInsertUserNoImageApi_Success() {
 
 }
 
-func (suite UserControllerTestSuite) TestInsertUserNoImageApi_FailedBinding() {
+func (suite *UserControllerTestSuite) TestInsertUserNoImageApi_FailedBinding() {
 	suite.useCaseMock.On("Insert").Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -338,11 +461,11 @@ func (suite UserControllerTestSuite) TestInsertUserNoImageApi_FailedBinding() {
 	assert.Equal(suite.T(), model.User{}, actualUser)
 }
 
-func (suite UserControllerTestSuite) TestInsertUserNoImageApi_Failed() {
+func (suite *UserControllerTestSuite) TestInsertUserNoImageApi_Failed() {
 	user := dummyUsers[0]
 	suite.useCaseMock.On("Insert", &user).Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -358,12 +481,12 @@ func (suite UserControllerTestSuite) TestInsertUserNoImageApi_Failed() {
 	assert.Equal(suite.T(), http.StatusInternalServerError, r.Code)
 }
 
-func (suite UserControllerTestSuite) TestUpdateUserApi_Success() {
+func (suite *UserControllerTestSuite) TestUpdateUserApi_Success() {
 	user := dummyUsers[0]
 
 	suite.useCaseMock.On("Update", &user).Return(user, nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -385,10 +508,10 @@ func (suite UserControllerTestSuite) TestUpdateUserApi_Success() {
 
 }
 
-func (suite UserControllerTestSuite) TestUpdateUserApi_FailedBindingAndNoId() {
+func (suite *UserControllerTestSuite) TestUpdateUserApi_FailedBindingAndNoId() {
 	suite.useCaseMock.On("Update").Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -415,12 +538,12 @@ func (suite UserControllerTestSuite) TestUpdateUserApi_FailedBindingAndNoId() {
 	assert.Equal(suite.T(), model.User{}, actualUser)
 }
 
-func (suite UserControllerTestSuite) TestUpdateUserApi_Failed() {
+func (suite *UserControllerTestSuite) TestUpdateUserApi_Failed() {
 	user := dummyUsers[0]
 
 	suite.useCaseMock.On("Update", &user).Return(model.Menu{}, errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 
@@ -437,13 +560,12 @@ func (suite UserControllerTestSuite) TestUpdateUserApi_Failed() {
 	assert.Equal(suite.T(), model.User{}, actualUser)
 }
 
-func (suite UserControllerTestSuite) TestDeleteUserApi_Success() {
+func (suite *UserControllerTestSuite) TestDeleteUserApi_Success() {
 	user := dummyUsers[0]
 
-	suite.useCaseMock.On("GetById", user.Id).Return(user, nil)
 	suite.useCaseMock.On("Delete", user.Id).Return(nil)
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/user/"+user.Id, nil)
@@ -454,13 +576,12 @@ func (suite UserControllerTestSuite) TestDeleteUserApi_Success() {
 
 }
 
-func (suite UserControllerTestSuite) TestDeleteUserApi_FailedNotFound() {
+func (suite *UserControllerTestSuite) TestDeleteUserApi_FailedNotFound() {
 	user := dummyUsers[0]
 
-	suite.useCaseMock.On("GetById", user.Id).Return(model.User{}, errors.New("not found"))
-	suite.useCaseMock.On("Delete", user.Id).Return(errors.New("failed"))
+	suite.useCaseMock.On("Delete", user.Id).Return(apperror.NotFound("user dummy id 1 not found"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/user/"+user.Id, nil)
@@ -471,13 +592,12 @@ func (suite UserControllerTestSuite) TestDeleteUserApi_FailedNotFound() {
 
 }
 
-func (suite UserControllerTestSuite) TestDeleteUserApi_Failed() {
+func (suite *UserControllerTestSuite) TestDeleteUserApi_Failed() {
 	user := dummyUsers[0]
 
-	suite.useCaseMock.On("GetById", user.Id).Return(user, nil)
 	suite.useCaseMock.On("Delete", user.Id).Return(errors.New("failed"))
 
-	controller.NewUserController(suite.useCaseMock, suite.routerMock)
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
 
 	r := httptest.NewRecorder()
 	request, _ := http.NewRequest(http.MethodDelete, "/user/"+user.Id, nil)
@@ -488,6 +608,231 @@ func (suite UserControllerTestSuite) TestDeleteUserApi_Failed() {
 
 }
 
+func (suite *UserControllerTestSuite) TestChangePasswordApi_Success() {
+	user := dummyUsers[0]
+	userToken, _ := auth.GenerateAccessToken(&user, "test token id")
+	passwordChange := model.PasswordChange{
+		OldPassword: user.Password,
+		NewPassword: "new password",
+	}
+
+	suite.useCaseMock.On("ChangePassword", user.Id, &passwordChange).Return(nil)
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(passwordChange)
+	request, _ := http.NewRequest(http.MethodPut, "/user/"+user.Id+"/password", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+userToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+}
+
+func (suite *UserControllerTestSuite) TestChangePasswordApi_WrongOldPassword() {
+	user := dummyUsers[0]
+	userToken, _ := auth.GenerateAccessToken(&user, "test token id")
+	passwordChange := model.PasswordChange{
+		OldPassword: "wrong password",
+		NewPassword: "new password",
+	}
+
+	suite.useCaseMock.On("ChangePassword", user.Id, &passwordChange).Return(usecase.ErrWrongPassword)
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(passwordChange)
+	request, _ := http.NewRequest(http.MethodPut, "/user/"+user.Id+"/password", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+userToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	var errorResponse ErrorResponse
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &errorResponse)
+
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), http.StatusBadRequest, r.Code)
+	assert.Equal(suite.T(), usecase.ErrWrongPassword.Error(), errorResponse.Error)
+}
+
+func (suite *UserControllerTestSuite) TestChangePasswordApi_ForbiddenOtherUser() {
+	user := dummyUsers[0]
+	otherToken, _ := auth.GenerateAccessToken(&dummyUsers[1], "test token id")
+	passwordChange := model.PasswordChange{
+		OldPassword: user.Password,
+		NewPassword: "new password",
+	}
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	reqBody, _ := json.Marshal(passwordChange)
+	request, _ := http.NewRequest(http.MethodPut, "/user/"+user.Id+"/password", bytes.NewBuffer(reqBody))
+	request.Header.Add("Authorization", "Bearer "+otherToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "ChangePassword", user.Id, &passwordChange)
+}
+
+func (suite *UserControllerTestSuite) TestGetAllUserApi_ForbiddenRole() {
+	cashierToken, _ := auth.GenerateAccessToken(&model.User{
+		Username: "cashier",
+		Role:     model.RoleCashier,
+	}, "test token id")
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Add("Authorization", "Bearer "+cashierToken)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusForbidden, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestLoginApi_Success() {
+	user := dummyUsers[0]
+	tokenPair := model.TokenPair{
+		AccessToken:  "access token",
+		RefreshToken: "refresh token",
+		ExpiresIn:    900,
+	}
+
+	suite.useCaseMock.On("GetByCredentials", user.Username, user.Password).Return(user, nil)
+	suite.tokenUsecaseMock.On("Issue", &user).Return(tokenPair, nil)
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+	body, _ := json.Marshal(model.Credential{
+		Username: user.Username,
+		Password: user.Password,
+	})
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+	suite.routerMock.ServeHTTP(r, request)
+
+	var actualTokenPair model.TokenPair
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actualTokenPair)
+
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), tokenPair, actualTokenPair)
+}
+
+func (suite *UserControllerTestSuite) TestRefreshTokenApi_Success() {
+	tokenPair := model.TokenPair{
+		AccessToken:  "new access token",
+		RefreshToken: "new refresh token",
+		ExpiresIn:    900,
+	}
+
+	suite.tokenUsecaseMock.On("Refresh", "refresh token").Return(tokenPair, nil)
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+	body, _ := json.Marshal(model.RefreshRequest{RefreshToken: "refresh token"})
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+	suite.routerMock.ServeHTTP(r, request)
+
+	var actualTokenPair model.TokenPair
+	jsonerr := json.Unmarshal(r.Body.Bytes(), &actualTokenPair)
+
+	assert.Nil(suite.T(), jsonerr)
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	assert.Equal(suite.T(), tokenPair, actualTokenPair)
+}
+
+func (suite *UserControllerTestSuite) TestRefreshTokenApi_Invalid() {
+	suite.tokenUsecaseMock.On("Refresh", "revoked token").Return(model.TokenPair{}, usecase.ErrInvalidRefreshToken)
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+	body, _ := json.Marshal(model.RefreshRequest{RefreshToken: "revoked token"})
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, r.Code)
+}
+
+func (suite *UserControllerTestSuite) TestLogoutApi_Success() {
+	suite.tokenUsecaseMock.On("Logout", "test token id", mock.AnythingOfType("time.Time")).Return(nil)
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.tokenUsecaseMock.AssertCalled(suite.T(), "Logout", "test token id", mock.AnythingOfType("time.Time"))
+}
+
+func (suite *UserControllerTestSuite) TestRevokedTokenApi_Unauthorized() {
+	revokedMock := new(TokenUsecaseMock)
+	revokedMock.On("IsRevoked", "test token id").Return(true, nil)
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, middleware.NewAuthTokenMiddleware(auth, revokedMock), "./images/user")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/user", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusUnauthorized, r.Code)
+	suite.useCaseMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *UserControllerTestSuite) TestRevokeUserSessionsApi_Success() {
+	user := dummyUsers[0]
+	suite.tokenUsecaseMock.On("RevokeUserSessions", user.Id).Return(nil)
+
+	controller.NewLoginController(suite.useCaseMock, suite.tokenUsecaseMock, suite.routerMock, suite.authMiddleware)
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodDelete, "/user/"+user.Id+"/sessions", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.tokenUsecaseMock.AssertCalled(suite.T(), "RevokeUserSessions", user.Id)
+}
+
+func (suite *UserControllerTestSuite) TestRestoreUserApi_Success() {
+	user := dummyUsers[0]
+
+	suite.useCaseMock.On("Restore", user.Id).Return(user, nil)
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/user/"+user.Id+"/restore", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusOK, r.Code)
+	suite.useCaseMock.AssertExpectations(suite.T())
+}
+
+func (suite *UserControllerTestSuite) TestRestoreUserApi_NotArchived() {
+	user := dummyUsers[0]
+
+	suite.useCaseMock.On("Restore", user.Id).Return(nil, apperror.NotFound("deleted user "+user.Id+" not found"))
+
+	controller.NewUserController(suite.useCaseMock, suite.routerMock, suite.authMiddleware, "./images/user")
+
+	r := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodPost, "/user/"+user.Id+"/restore", nil)
+	request.Header.Add("Authorization", "Bearer "+token)
+	suite.routerMock.ServeHTTP(r, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, r.Code)
+}
+
 func TestUserControllerTestSuite(t *testing.T) {
 	suite.Run(t, new(UserControllerTestSuite))
 }
@@ -0,0 +1,61 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/export"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSV(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := export.NewWriter(export.FormatCSV, &out)
+	assert.Nil(t, err)
+
+	created := time.Date(2022, time.October, 1, 10, 30, 0, 0, time.Local)
+	assert.Nil(t, writer.Write("id", "created_at", "name", "qty"))
+	assert.Nil(t, writer.Write("dummy id 1", created, "nasi goreng, pedas", 2))
+	assert.Nil(t, writer.Write("dummy id 2", created, "=1+1", -1))
+	assert.Equal(t, 0, out.Len())
+	assert.Nil(t, writer.Close())
+
+	assert.Equal(t, "id,created_at,name,qty\n"+
+		"dummy id 1,2022-10-01 10:30:00,\"nasi goreng, pedas\",2\n"+
+		"dummy id 2,2022-10-01 10:30:00,'=1+1,-1\n", out.String())
+}
+
+func TestXLSX(t *testing.T) {
+	var out bytes.Buffer
+	writer, err := export.NewWriter(export.FormatXLSX, &out)
+	assert.Nil(t, err)
+
+	assert.Nil(t, writer.Write("name", "qty"))
+	assert.Nil(t, writer.Write("es teh <manis>", 3))
+	assert.Nil(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	assert.Nil(t, err)
+
+	names := map[string]*zip.File{}
+	for _, file := range archive.File {
+		names[file.Name] = file
+	}
+	assert.Contains(t, names, "[Content_Types].xml")
+	assert.Contains(t, names, "xl/workbook.xml")
+
+	file, err := names["xl/worksheets/sheet1.xml"].Open()
+	assert.Nil(t, err)
+	sheet, _ := io.ReadAll(file)
+	assert.Contains(t, string(sheet), `<row r="2"><c r="A2" t="inlineStr"><is><t xml:space="preserve">es teh &lt;manis&gt;</t></is></c><c r="B2"><v>3</v></c></row>`)
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := export.NewWriter("pdf", &bytes.Buffer{})
+
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(err))
+}
@@ -0,0 +1,176 @@
+package migration_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+	"warung-makan/migration"
+	"warung-makan/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyMigrations = []migration.Migration{
+	{Version: 1, Name: "first", Up: "CREATE TABLE first (id int)", Down: "DROP TABLE first"},
+	{Version: 2, Name: "second", Up: "CREATE TABLE second (id int)", Down: "DROP TABLE second"},
+}
+
+type MigrationTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *MigrationTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *MigrationTestSuite) expectApplied(versions ...int) {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_LOCK)).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_CREATE_TABLE)).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, dummyMigrations[version-1].Name, time.Now())
+	}
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MIGRATION_GET_ALL)).WillReturnRows(rows)
+}
+
+func (suite *MigrationTestSuite) expectStep(m migration.Migration, alreadyDone bool, up bool) {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_LOCK)).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MIGRATION_IS_APPLIED)).WithArgs(m.Version).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(alreadyDone == up))
+	if alreadyDone {
+		suite.mockSql.ExpectRollback()
+		return
+	}
+
+	if up {
+		suite.mockSql.ExpectExec(regexp.QuoteMeta(m.Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_INSERT)).WithArgs(m.Version, m.Name).WillReturnResult(sqlmock.NewResult(1, 1))
+	} else {
+		suite.mockSql.ExpectExec(regexp.QuoteMeta(m.Down)).WillReturnResult(sqlmock.NewResult(0, 0))
+		suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_DELETE)).WithArgs(m.Version).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mockSql.ExpectCommit()
+}
+
+func (suite *MigrationTestSuite) TestUp_AppliesPending() {
+	suite.expectApplied(1)
+	suite.expectStep(dummyMigrations[1], false, true)
+
+	done, err := migration.NewMigrator(suite.mockSqlxDb, dummyMigrations).Up()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []migration.Migration{dummyMigrations[1]}, done)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MigrationTestSuite) TestUp_SkipsAppliedConcurrently() {
+	suite.expectApplied()
+	suite.expectStep(dummyMigrations[0], true, true)
+	suite.expectStep(dummyMigrations[1], false, true)
+
+	done, err := migration.NewMigrator(suite.mockSqlxDb, dummyMigrations).Up()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []migration.Migration{dummyMigrations[1]}, done)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MigrationTestSuite) TestUp_Failed() {
+	m := dummyMigrations[0]
+	suite.expectApplied()
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MIGRATION_LOCK)).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MIGRATION_IS_APPLIED)).WithArgs(m.Version).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(m.Up)).WillReturnError(errors.New("failed"))
+	suite.mockSql.ExpectRollback()
+
+	done, err := migration.NewMigrator(suite.mockSqlxDb, dummyMigrations).Up()
+
+	assert.ErrorContains(suite.T(), err, "1_first")
+	assert.Empty(suite.T(), done)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MigrationTestSuite) TestDown_RevertsNewestFirst() {
+	suite.expectApplied(1, 2)
+	suite.expectStep(dummyMigrations[1], false, false)
+
+	done, err := migration.NewMigrator(suite.mockSqlxDb, dummyMigrations).Down(1)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []migration.Migration{dummyMigrations[1]}, done)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MigrationTestSuite) TestStatus_Success() {
+	suite.expectApplied(1)
+
+	status, err := migration.NewMigrator(suite.mockSqlxDb, dummyMigrations).Status()
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), status, 2)
+	assert.True(suite.T(), status[0].AppliedAt.Valid)
+	assert.False(suite.T(), status[1].AppliedAt.Valid)
+}
+
+func (suite *MigrationTestSuite) TestLoad_Success() {
+	fsys := fstest.MapFS{
+		"sql/0002_second.up.sql":   {Data: []byte("up 2")},
+		"sql/0002_second.down.sql": {Data: []byte("down 2")},
+		"sql/0001_first.up.sql":    {Data: []byte("up 1")},
+		"sql/0001_first.down.sql":  {Data: []byte("down 1")},
+		"sql/readme.md":            {Data: []byte("ignored")},
+	}
+
+	migrations, err := migration.Load(fsys, "sql")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []migration.Migration{
+		{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+	}, migrations)
+}
+
+func (suite *MigrationTestSuite) TestLoad_MissingDown() {
+	fsys := fstest.MapFS{
+		"sql/0001_first.up.sql": {Data: []byte("up 1")},
+	}
+
+	_, err := migration.Load(fsys, "sql")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MigrationTestSuite) TestEmbedded_Success() {
+	migrations, err := migration.Embedded()
+
+	assert.Nil(suite.T(), err)
+	for i, m := range migrations {
+		assert.Equal(suite.T(), i+1, m.Version)
+	}
+}
+
+func TestMigrationTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrationTestSuite))
+}
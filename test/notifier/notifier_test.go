@@ -0,0 +1,106 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/utils/notifier"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var dummyAlerts = []model.StockAlert{
+	{Kind: model.StockItemMenu, Id: "menu 1", Name: "es teh", Available: 2, ReorderLevel: 5},
+	{Kind: model.StockItemIngredient, Id: "rice", Name: "rice", Unit: "g", Available: 400, ReorderLevel: 1000},
+}
+
+func TestWebhook(t *testing.T) {
+	var received struct {
+		Alerts []model.StockAlert `json:"alerts"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	err := notifier.NewWebhook(server.URL).Notify(dummyAlerts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, dummyAlerts, received.Alerts)
+}
+
+func TestWebhook_Refused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := notifier.NewWebhook(server.URL).Notify(dummyAlerts)
+
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestAsync(t *testing.T) {
+	sent := make(chan []model.StockAlert, 1)
+	err := notifier.Async(notifierFunc(func(alerts []model.StockAlert) error {
+		sent <- alerts
+		return nil
+	})).Notify(dummyAlerts)
+
+	assert.Nil(t, err)
+	assert.Equal(t, dummyAlerts, <-sent)
+}
+
+func TestAsync_CloseSendsQueued(t *testing.T) {
+	var sent [][]model.StockAlert
+	async := notifier.Async(notifierFunc(func(alerts []model.StockAlert) error {
+		time.Sleep(10 * time.Millisecond)
+		sent = append(sent, alerts)
+		return nil
+	}))
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, async.Notify(dummyAlerts))
+	}
+
+	err := async.Close(context.Background())
+
+	assert.Nil(t, err)
+	assert.Len(t, sent, 3)
+	assert.ErrorContains(t, async.Notify(dummyAlerts), "closed")
+}
+
+func TestAsync_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	async := notifier.Async(notifierFunc(func(alerts []model.StockAlert) error {
+		<-release
+		return nil
+	}))
+
+	// the worker holds one, the queue the rest
+	var err error
+	for i := 0; i < 200 && err == nil; i++ {
+		err = async.Notify(dummyAlerts)
+	}
+	assert.ErrorContains(t, err, "queue is full")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, async.Close(ctx), context.Canceled)
+	close(release)
+}
+
+type notifierFunc func(alerts []model.StockAlert) error
+
+func (f notifierFunc) Notify(alerts []model.StockAlert) error {
+	return f(alerts)
+}
+
+func (f notifierFunc) Close(ctx context.Context) error {
+	return nil
+}
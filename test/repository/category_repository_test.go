@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var categoryColumns = []string{"id", "name", "display_order", "created_at"}
+
+var dummyCategories = []model.Category{
+	{Id: "dummy category 1", Name: "rice", DisplayOrder: 1, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+	{Id: "dummy category 2", Name: "drinks", DisplayOrder: 2, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+type CategoryRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *CategoryRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func categoryRows(categories ...model.Category) *sqlmock.Rows {
+	rows := sqlmock.NewRows(categoryColumns)
+	for _, category := range categories {
+		rows.AddRow(category.Id, category.Name, category.DisplayOrder, category.CreatedAt)
+	}
+	return rows
+}
+
+func (suite *CategoryRepositoryTestSuite) TestGetAll_DisplayOrder() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.CATEGORY_GET_ALL + " ORDER BY display_order, name, id")).WillReturnRows(categoryRows(dummyCategories...))
+
+	repo := repository.NewCategoryRepository(suite.mockSqlxDb)
+	actual, err := repo.GetAll()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyCategories, actual)
+}
+
+func (suite *CategoryRepositoryTestSuite) TestInsert_Success() {
+	dummy := dummyCategories[0]
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO category(id, name, display_order) VALUES ($1, $2, $3)")).
+		WithArgs(dummy.Id, dummy.Name, dummy.DisplayOrder).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.CATEGORY_GET_BY_ID)).WithArgs(dummy.Id).WillReturnRows(categoryRows(dummy))
+
+	repo := repository.NewCategoryRepository(suite.mockSqlxDb)
+	category := model.Category{Id: dummy.Id, Name: dummy.Name, DisplayOrder: dummy.DisplayOrder}
+	actual, err := repo.Insert(&category)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *CategoryRepositoryTestSuite) TestInsert_Duplicate() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO category")).WillReturnError(&pq.Error{Code: "23505"})
+
+	repo := repository.NewCategoryRepository(suite.mockSqlxDb)
+	category := dummyCategories[0]
+	_, err := repo.Insert(&category)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "category rice already exists")
+}
+
+func (suite *CategoryRepositoryTestSuite) TestUpdate_NotFound() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE category SET")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewCategoryRepository(suite.mockSqlxDb)
+	category := dummyCategories[0]
+	_, err := repo.Update(&category)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *CategoryRepositoryTestSuite) TestDelete_Success() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.CATEGORY_DELETE)).WithArgs("dummy category 1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewCategoryRepository(suite.mockSqlxDb)
+	err := repo.Delete("dummy category 1")
+
+	assert.Nil(suite.T(), err)
+}
+
+func TestCategoryRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(CategoryRepositoryTestSuite))
+}
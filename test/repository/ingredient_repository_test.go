@@ -0,0 +1,170 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var ingredientColumns = []string{"id", "name", "unit", "stock", "reserved", "reorder_level", "created_at"}
+
+var dummyReorderLevel = 1000
+
+var dummyIngredients = []model.Ingredient{
+	{Id: "dummy ingredient 1", Name: "egg", Unit: "pcs", Stock: 30, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+	{Id: "dummy ingredient 2", Name: "rice", Unit: "g", Stock: 5000, Reserved: 400, ReorderLevel: &dummyReorderLevel, CreatedAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+type IngredientRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *IngredientRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func ingredientRows(ingredients ...model.Ingredient) *sqlmock.Rows {
+	rows := sqlmock.NewRows(ingredientColumns)
+	for _, ingredient := range ingredients {
+		rows.AddRow(ingredient.Id, ingredient.Name, ingredient.Unit, ingredient.Stock, ingredient.Reserved, ingredient.ReorderLevel, ingredient.CreatedAt)
+	}
+	return rows
+}
+
+func (suite *IngredientRepositoryTestSuite) TestGetAll_Success() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.INGREDIENT_GET_ALL + " ORDER BY name, id")).WillReturnRows(ingredientRows(dummyIngredients...))
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	actual, err := repo.GetAll()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyIngredients, actual)
+}
+
+func (suite *IngredientRepositoryTestSuite) TestGetById_NotFound() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.INGREDIENT_GET_BY_ID)).WithArgs("dummy ingredient 9").WillReturnRows(ingredientRows())
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	_, err := repo.GetById("dummy ingredient 9")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *IngredientRepositoryTestSuite) TestInsert_RecordsOpening() {
+	dummy := dummyIngredients[0]
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO ingredient(id, name, unit, stock, reorder_level) VALUES ($1, $2, $3, $4, $5)")).
+		WithArgs(dummy.Id, dummy.Name, dummy.Unit, dummy.Stock, nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs(nil, dummy.Id, dummy.Stock, model.StockOpening, nil, nil, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	suite.mockSql.ExpectCommit()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.INGREDIENT_GET_BY_ID)).WithArgs(dummy.Id).WillReturnRows(ingredientRows(dummy))
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	actual, err := repo.Insert(&dummy)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *IngredientRepositoryTestSuite) TestInsert_DuplicateName() {
+	dummy := dummyIngredients[0]
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO ingredient")).WillReturnError(&pq.Error{Code: "23505"})
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	_, err := repo.Insert(&dummy)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *IngredientRepositoryTestSuite) TestDelete_UsedByRecipe() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.INGREDIENT_DELETE)).WithArgs("dummy ingredient 1").WillReturnError(&pq.Error{Code: "23503"})
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	err := repo.Delete("dummy ingredient 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
+func (suite *IngredientRepositoryTestSuite) TestUsage_Success() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.INGREDIENT_USAGE)).WithArgs("dummy ingredient 1").
+		WillReturnRows(sqlmock.NewRows([]string{"movements", "recipes", "purchase_lines", "stock_take_lines"}).AddRow(4, 1, 0, 2))
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	actual, err := repo.Usage("dummy ingredient 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.IngredientUsage{Movements: 4, Recipes: 1, StockTakeLines: 2}, actual)
+	assert.Equal(suite.T(), []string{"stock movements", "recipes", "stock takes"}, actual.Uses())
+}
+
+func (suite *IngredientRepositoryTestSuite) TestSetRecipe_ReplacesLines() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.RECIPE_DELETE)).WithArgs("dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 3))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO recipe(menu_id, ingredient_id, qty) VALUES ($1, $2, $3)")).
+		WithArgs("dummy menu 1", "dummy ingredient 1", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO recipe(menu_id, ingredient_id, qty) VALUES ($1, $2, $3)")).
+		WithArgs("dummy menu 1", "dummy ingredient 2", 200).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectCommit()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.RECIPE_GET_BY_MENUS)).WithArgs("{\"dummy menu 1\"}").
+		WillReturnRows(sqlmock.NewRows([]string{"menu_id", "ingredient_id", "qty", "name", "unit"}).
+			AddRow("dummy menu 1", "dummy ingredient 1", 1, "egg", "pcs").
+			AddRow("dummy menu 1", "dummy ingredient 2", 200, "rice", "g"))
+
+	repo := repository.NewIngredientRepository(suite.mockSqlxDb)
+	actual, err := repo.SetRecipe("dummy menu 1", []model.RecipeLine{
+		{IngredientId: "dummy ingredient 1", Qty: 1},
+		{IngredientId: "dummy ingredient 2", Qty: 200},
+	})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.RecipeLine{
+		{MenuId: "dummy menu 1", IngredientId: "dummy ingredient 1", Qty: 1, Name: "egg", Unit: "pcs"},
+		{MenuId: "dummy menu 1", IngredientId: "dummy ingredient 2", Qty: 200, Name: "rice", Unit: "g"},
+	}, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *IngredientRepositoryTestSuite) TestTxReserveIngredient_Short() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.INGREDIENT_RESERVE_STOCK)).WithArgs(600, "dummy ingredient 2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	ok, err := tx.ReserveIngredient("dummy ingredient 2", 600)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestIngredientRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(IngredientRepositoryTestSuite))
+}
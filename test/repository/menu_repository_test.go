@@ -5,12 +5,15 @@ import (
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 	"warung-makan/model"
 	"warung-makan/repository"
 	"warung-makan/utils"
+	"warung-makan/utils/apperror"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -72,7 +75,7 @@ func (suite *MenuRepositoryTestSuite) TestGetAllMenu_Success() {
 		rows.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
 	}
 
-	suite.mockSql.ExpectQuery(utils.MENU_GET_ALL).WillReturnRows(rows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL)).WillReturnRows(rows)
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
 	actual, err := repo.GetAll()
@@ -89,7 +92,7 @@ func (suite *MenuRepositoryTestSuite) TestGetAllMenu_Failed() {
 		rows.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
 	}
 
-	suite.mockSql.ExpectQuery(utils.MENU_GET_ALL).WillReturnError(errors.New("failed to retrieve user list"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL)).WillReturnError(errors.New("failed to retrieve user list"))
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
 	actual, err := repo.GetAll()
@@ -105,10 +108,10 @@ func (suite *MenuRepositoryTestSuite) TestGetByIdMenu_Success() {
 	row := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
 	row.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID)).WillReturnRows(row)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID + utils.NOT_DELETED)).WillReturnRows(row)
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
-	actual, err := repo.GetById(dummy.Id)
+	actual, err := repo.GetById(dummy.Id, false)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), actual)
@@ -120,15 +123,25 @@ func (suite *MenuRepositoryTestSuite) TestGetByIdMenu_Failed() {
 	row := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
 	row.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID)).WillReturnError(errors.New("failed to retrieve user"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID + utils.NOT_DELETED)).WillReturnError(errors.New("failed to retrieve user"))
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
-	actual, err := repo.GetById(dummy.Id)
+	actual, err := repo.GetById(dummy.Id, false)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), model.Menu{}, actual)
 }
 
+func (suite *MenuRepositoryTestSuite) TestGetByIdMenu_NotFound() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID + utils.NOT_DELETED)).WillReturnError(sql.ErrNoRows)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	_, err := repo.GetById("dummy id 1", false)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	assert.ErrorIs(suite.T(), err, sql.ErrNoRows)
+}
+
 func (suite *MenuRepositoryTestSuite) TestGetByNameMenu_Success() {
 	dummy := dummyMenus[0]
 	row := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
@@ -161,18 +174,40 @@ func (suite *MenuRepositoryTestSuite) TestGetByNameMenu_Failed() {
 func (suite *MenuRepositoryTestSuite) TestInsertMenu_Success() {
 	var dummy = dummyMenus[0]
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INSERT_TEST)).WithArgs(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INSERT_TEST)).WithArgs(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image, nil, nil, nil, nil, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs(dummy.Id, nil, dummy.Stock, model.StockOpening, nil, nil, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+	suite.mockSql.ExpectCommit()
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
 	actual, err := repo.Insert(&dummy)
 
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), dummy, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MenuRepositoryTestSuite) TestInsertMenu_NoStock() {
+	dummy := dummyMenus[0]
+	dummy.Stock = 0
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INSERT_TEST)).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	_, err := repo.Insert(&dummy)
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
 }
 
 func (suite *MenuRepositoryTestSuite) TestInsertMenu_Failed() {
 	var dummy = dummyMenus[0]
 
+	suite.mockSql.ExpectBegin()
 	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INSERT_TEST)).WillReturnError(errors.New("insert failed"))
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
@@ -182,15 +217,32 @@ func (suite *MenuRepositoryTestSuite) TestInsertMenu_Failed() {
 	assert.Equal(suite.T(), model.Menu{}, actual)
 }
 
+func (suite *MenuRepositoryTestSuite) TestInsertMenu_Duplicate() {
+	var dummy = dummyMenus[0]
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INSERT_TEST)).WillReturnError(&pq.Error{Code: "23505"})
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	_, err := repo.Insert(&dummy)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
 func (suite *MenuRepositoryTestSuite) TestUpdateMenu_Success() {
 	var dummy = dummyMenus[0]
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_UPDATE_TEST)).WithArgs(dummy.Name, dummy.Price, dummy.Stock, dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_UPDATE_TEST)).WithArgs(dummy.Name, dummy.Price, nil, nil, nil, nil, nil, dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+	// the stock is read back, not taken from the update
+	row := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Price, 12, dummy.Image)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID + utils.NOT_DELETED)).WithArgs(dummy.Id).WillReturnRows(row)
 
 	repo := repository.NewMenuRepository(suite.mockSqlxDb)
 	actual, err := repo.Update(&dummy)
 
 	assert.Nil(suite.T(), err)
+	dummy.Stock = 12
 	assert.Equal(suite.T(), dummy, actual)
 }
 
@@ -206,6 +258,17 @@ func (suite *MenuRepositoryTestSuite) TestUpdateMenu_Failed() {
 	assert.Equal(suite.T(), model.Menu{}, actual)
 }
 
+func (suite *MenuRepositoryTestSuite) TestUpdateMenu_NotFound() {
+	var dummy = dummyMenus[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_UPDATE_TEST)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	_, err := repo.Update(&dummy)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
 func (suite *MenuRepositoryTestSuite) TestDeleteMenu_Success() {
 	var dummy = dummyMenus[0]
 
@@ -217,6 +280,40 @@ func (suite *MenuRepositoryTestSuite) TestDeleteMenu_Success() {
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *MenuRepositoryTestSuite) TestDeleteMenu_AlreadyArchived() {
+	var dummy = dummyMenus[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_DELETE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	err := repo.Delete(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *MenuRepositoryTestSuite) TestRestoreMenu_Success() {
+	var dummy = dummyMenus[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_RESTORE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	err := repo.Restore(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *MenuRepositoryTestSuite) TestRestoreMenu_NotArchived() {
+	var dummy = dummyMenus[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_RESTORE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	err := repo.Restore(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "deleted menu "+dummy.Id+" not found")
+}
+
 func (suite *MenuRepositoryTestSuite) TestDeleteMenu_Failed() {
 	var dummy = dummyMenus[0]
 
@@ -228,6 +325,136 @@ func (suite *MenuRepositoryTestSuite) TestDeleteMenu_Failed() {
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *MenuRepositoryTestSuite) TestListMenu_Filters() {
+	minPrice, inStock := 5000, true
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
+	for _, dummy := range dummyMenus {
+		rows.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
+	}
+
+	inStockWhere := " WHERE deleted_at IS NULL AND price >= $1 AND COALESCE(" + utils.MENU_PORTIONS + ", stock - reserved) > 0"
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_COUNT + inStockWhere)).
+		WithArgs(minPrice).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL+inStockWhere+" ORDER BY price DESC, id DESC LIMIT $2 OFFSET $3")).
+		WithArgs(minPrice, 2, 1).
+		WillReturnRows(rows)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	actual, page, err := repo.List(model.MenuFilter{
+		ListQuery: model.ListQuery{Page: 2, Limit: 1, Sort: "price", Order: "desc"},
+		MinPrice:  &minPrice,
+		InStock:   &inStock,
+	})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyMenus[:1], actual)
+	assert.Equal(suite.T(), 5, page.Total)
+	assert.Equal(suite.T(), 2, page.Page)
+	assert.NotEmpty(suite.T(), page.NextCursor)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MenuRepositoryTestSuite) TestListMenu_CategoryTagAvailable() {
+	dummy := dummyMenus[0]
+	category, from, until := "rice", "06:00", "10:30"
+	dummy.CategoryId, dummy.Tags = &category, []string{"spicy", "breakfast"}
+	dummy.AvailableFrom, dummy.AvailableUntil = &from, &until
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image", "category_id", "tags", "available_from", "available_until"}).
+		AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image, category, "{spicy,breakfast}", from, until)
+
+	where := " WHERE deleted_at IS NULL AND category_id = $1 AND $2 = ANY(tags) AND (available_from IS NULL" +
+		" OR (available_from < available_until AND available_from <= CAST($3 AS time) AND CAST($4 AS time) < available_until)" +
+		" OR (available_from > available_until AND (available_from <= CAST($5 AS time) OR CAST($6 AS time) < available_until)))"
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_COUNT+where)).
+		WithArgs(category, "spicy", "07:15", "07:15", "07:15", "07:15").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL + where + " ORDER BY id ASC, id ASC LIMIT $7 OFFSET $8")).
+		WillReturnRows(rows)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	actual, _, err := repo.List(model.MenuFilter{Category: category, Tag: "spicy", AvailableAt: "07:15"})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Menu{dummy}, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MenuRepositoryTestSuite) TestListMenu_Cursor() {
+	firstRows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
+	for _, dummy := range dummyMenus {
+		firstRows.AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
+	}
+	last := dummyMenus[1]
+	nextRows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"}).
+		AddRow(last.Id, last.Name, last.Price, last.Stock, last.Image)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_COUNT)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL + " WHERE deleted_at IS NULL ORDER BY name ASC, id ASC LIMIT $1 OFFSET $2")).WillReturnRows(firstRows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_COUNT)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL+" WHERE deleted_at IS NULL AND (name, id) > (CAST($1 AS text), $2) ORDER BY name ASC, id ASC LIMIT $3")).
+		WithArgs(dummyMenus[0].Name, dummyMenus[0].Id, 2).
+		WillReturnRows(nextRows)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	_, first, err := repo.List(model.MenuFilter{ListQuery: model.ListQuery{Limit: 1, Sort: "name"}})
+	assert.Nil(suite.T(), err)
+
+	actual, page, err := repo.List(model.MenuFilter{ListQuery: model.ListQuery{Limit: 1, Cursor: first.NextCursor}})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Menu{last}, actual)
+	assert.Equal(suite.T(), "name", page.Sort)
+	assert.Empty(suite.T(), page.NextCursor)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MenuRepositoryTestSuite) TestListMenu_IncludeDeleted() {
+	dummy := dummyMenus[0]
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"}).
+		AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_COUNT)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_ALL + " ORDER BY name ASC, id ASC LIMIT $1 OFFSET $2")).WillReturnRows(rows)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	actual, _, err := repo.List(model.MenuFilter{ListQuery: model.ListQuery{Sort: "name"}, IncludeDeleted: true})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Menu{dummy}, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *MenuRepositoryTestSuite) TestGetByIdMenu_IncludeDeleted() {
+	dummy := dummyMenus[0]
+	deletedAt := time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)
+	dummy.DeletedAt = &deletedAt
+	row := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image", "deleted_at"}).
+		AddRow(dummy.Id, dummy.Name, dummy.Price, dummy.Stock, dummy.Image, deletedAt)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_GET_BY_ID) + "$").WillReturnRows(row)
+
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+	actual, err := repo.GetById(dummy.Id, true)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, actual)
+}
+
+func (suite *MenuRepositoryTestSuite) TestListMenu_InvalidQuery() {
+	repo := repository.NewMenuRepository(suite.mockSqlxDb)
+
+	for _, query := range []model.ListQuery{
+		{Sort: "image"},
+		{Order: "up"},
+		{Limit: model.MaxPageLimit + 1},
+		{Cursor: "not a cursor"},
+	} {
+		_, _, err := repo.List(model.MenuFilter{ListQuery: query})
+		assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	}
+}
+
 func TestMenuRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(MenuRepositoryTestSuite))
 }
@@ -0,0 +1,181 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var modifierGroupColumns = []string{"id", "menu_id", "name", "min_select", "max_select", "display_order"}
+var modifierOptionColumns = []string{"id", "group_id", "name", "price_delta", "display_order"}
+
+var dummyModifierGroups = []model.ModifierGroup{
+	{Id: 1, MenuId: "dummy menu 1", Name: "size", MinSelect: 1, MaxSelect: 1, Options: []model.ModifierOption{
+		{Id: 11, GroupId: 1, Name: "regular"},
+		{Id: 12, GroupId: 1, Name: "large", PriceDelta: 3000, DisplayOrder: 1},
+	}},
+	{Id: 2, MenuId: "dummy menu 1", Name: "topping", MaxSelect: 1, DisplayOrder: 1, Options: []model.ModifierOption{
+		{Id: 21, GroupId: 2, Name: "extra egg", PriceDelta: 4000},
+	}},
+}
+
+type ModifierRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *ModifierRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+// expectModifiers expects the groups and options of a menu to be read.
+func (suite *ModifierRepositoryTestSuite) expectModifiers(menuId string, groups ...model.ModifierGroup) {
+	groupRows := sqlmock.NewRows(modifierGroupColumns)
+	optionRows := sqlmock.NewRows(modifierOptionColumns)
+	for _, group := range groups {
+		groupRows.AddRow(group.Id, group.MenuId, group.Name, group.MinSelect, group.MaxSelect, group.DisplayOrder)
+		for _, option := range group.Options {
+			optionRows.AddRow(option.Id, option.GroupId, option.Name, option.PriceDelta, option.DisplayOrder)
+		}
+	}
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MODIFIER_GROUP_GET_BY_MENUS)).WithArgs(pq.Array([]string{menuId})).WillReturnRows(groupRows)
+	if len(groups) > 0 {
+		suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MODIFIER_OPTION_GET_BY_MENUS)).WithArgs(pq.Array([]string{menuId})).WillReturnRows(optionRows)
+	}
+}
+
+func (suite *ModifierRepositoryTestSuite) TestGetByMenu_Success() {
+	suite.expectModifiers("dummy menu 1", dummyModifierGroups...)
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByMenu("dummy menu 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyModifierGroups, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestGetByMenu_NoGroups() {
+	suite.expectModifiers("dummy menu 1")
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByMenu("dummy menu 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestInsert_Success() {
+	dummy := dummyModifierGroups[0]
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO modifier_group")).
+		WithArgs(dummy.MenuId, dummy.Name, dummy.MinSelect, dummy.MaxSelect, dummy.DisplayOrder).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(dummy.Id))
+	for _, option := range dummy.Options {
+		suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO modifier_option")).
+			WithArgs(dummy.Id, option.Name, option.PriceDelta, option.DisplayOrder).
+			WillReturnResult(sqlmock.NewResult(option.Id, 1))
+	}
+	suite.mockSql.ExpectCommit()
+	suite.expectModifiers(dummy.MenuId, dummy)
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	group := model.ModifierGroup{MenuId: dummy.MenuId, Name: dummy.Name, MinSelect: 1, MaxSelect: 1, Options: []model.ModifierOption{
+		{Name: "regular"},
+		{Name: "large", PriceDelta: 3000, DisplayOrder: 1},
+	}}
+	actual, err := repo.Insert(&group)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestInsert_DuplicateName() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO modifier_group")).WillReturnError(&pq.Error{Code: "23505"})
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	group := dummyModifierGroups[0]
+	_, err := repo.Insert(&group)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestUpdate_ReplacesOptions() {
+	dummy := dummyModifierGroups[0]
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE modifier_group SET")).
+		WithArgs(dummy.Name, dummy.MinSelect, dummy.MaxSelect, dummy.DisplayOrder, dummy.Id, dummy.MenuId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MODIFIER_OPTION_DELETE_OTHER)).
+		WithArgs(dummy.Id, pq.Array([]int64{11})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE modifier_option SET")).
+		WithArgs("regular", 0, 0, int64(11), dummy.Id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO modifier_option")).
+		WithArgs(dummy.Id, "large", 3000, 1).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	suite.mockSql.ExpectCommit()
+	suite.expectModifiers(dummy.MenuId, dummy)
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	group := model.ModifierGroup{Id: 1, MenuId: dummy.MenuId, Name: dummy.Name, MinSelect: 1, MaxSelect: 1, Options: []model.ModifierOption{
+		{Id: 11, Name: "regular"},
+		{Name: "large", PriceDelta: 3000, DisplayOrder: 1},
+	}}
+	actual, err := repo.Update(&group)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestUpdate_NotFound() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE modifier_group SET")).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	group := dummyModifierGroups[0]
+	_, err := repo.Update(&group)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *ModifierRepositoryTestSuite) TestDelete_NotFound() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MODIFIER_GROUP_DELETE)).WithArgs(int64(9), "dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewModifierRepository(suite.mockSqlxDb)
+	err := repo.Delete("dummy menu 1", 9)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func TestModifierRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(ModifierRepositoryTestSuite))
+}
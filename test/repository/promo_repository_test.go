@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var promoColumns = []string{"code", "discount_type", "discount_value", "starts_at", "ends_at", "usage_limit", "used", "active", "created_at"}
+
+var dummyPromo = model.Promo{
+	Code:      "HEMAT10",
+	Discount:  model.Discount{Type: model.DiscountPercent, Value: 10},
+	StartsAt:  time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC),
+	Active:    true,
+	CreatedAt: time.Date(2022, time.September, 30, 0, 0, 0, 0, time.UTC),
+}
+
+type PromoRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *PromoRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func promoRow(promo model.Promo) *sqlmock.Rows {
+	return sqlmock.NewRows(promoColumns).AddRow(promo.Code, promo.Type, promo.Value, promo.StartsAt, nil, nil, promo.Used, promo.Active, promo.CreatedAt)
+}
+
+func (suite *PromoRepositoryTestSuite) TestGetByCode_Success() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PROMO_GET_BY_CODE)).WithArgs("HEMAT10").WillReturnRows(promoRow(dummyPromo))
+
+	repo := repository.NewPromoRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByCode("HEMAT10")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPromo, actual)
+}
+
+func (suite *PromoRepositoryTestSuite) TestGetByCode_NotFound() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PROMO_GET_BY_CODE)).WithArgs("NOPE").WillReturnError(sql.ErrNoRows)
+
+	repo := repository.NewPromoRepository(suite.mockSqlxDb)
+	_, err := repo.GetByCode("NOPE")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *PromoRepositoryTestSuite) TestInsert_Success() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO promo(code, discount_type, discount_value, starts_at, ends_at, usage_limit, active) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs("HEMAT10", model.DiscountPercent, 10, dummyPromo.StartsAt, sqlmock.AnyArg(), sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PROMO_GET_BY_CODE)).WithArgs("HEMAT10").WillReturnRows(promoRow(dummyPromo))
+
+	repo := repository.NewPromoRepository(suite.mockSqlxDb)
+	promo := dummyPromo
+	actual, err := repo.Insert(&promo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPromo, actual)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PromoRepositoryTestSuite) TestInsert_Duplicate() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO promo")).WillReturnError(&pq.Error{Code: "23505"})
+
+	repo := repository.NewPromoRepository(suite.mockSqlxDb)
+	promo := dummyPromo
+	_, err := repo.Insert(&promo)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "promo HEMAT10 already exists")
+}
+
+func (suite *PromoRepositoryTestSuite) TestUpdate_NotFound() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE promo SET")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewPromoRepository(suite.mockSqlxDb)
+	promo := dummyPromo
+	_, err := repo.Update(&promo)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func TestPromoRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PromoRepositoryTestSuite))
+}
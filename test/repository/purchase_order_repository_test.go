@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var purchaseOrderColumns = []string{"id", "supplier_id", "supplier_name", "status", "note", "user_id", "expected_cost", "received_cost", "created_at", "ordered_at", "received_at"}
+
+var purchaseLineColumns = []string{"id", "purchase_order_id", "menu_id", "ingredient_id", "name", "qty", "unit_cost", "received_qty", "received_cost"}
+
+type PurchaseOrderRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestGetById_WithLines() {
+	created := time.Date(2022, time.October, 1, 8, 0, 0, 0, time.UTC)
+	ingredientId, menuId := "dummy ingredient 1", "dummy menu 1"
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_ORDER_GET_BY_ID)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows(purchaseOrderColumns).
+			AddRow("dummy po 1", "dummy supplier 1", "pasar induk", model.PurchaseOrdered, nil, nil, 190000, 0, created, created, nil))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_LINE_GET_BY_ORDER)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows(purchaseLineColumns).
+			AddRow(1, "dummy po 1", nil, ingredientId, "rice", 5000, 14, 0, 0).
+			AddRow(2, "dummy po 1", menuId, nil, "es teh", 20, 6000, 0, 0))
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	actual, err := repo.GetById("dummy po 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 190000, actual.ExpectedCost)
+	assert.Equal(suite.T(), &created, actual.OrderedAt)
+	assert.Equal(suite.T(), []model.PurchaseOrderLine{
+		{Id: 1, PurchaseOrderId: "dummy po 1", IngredientId: &ingredientId, Name: "rice", Qty: 5000, UnitCost: 14},
+		{Id: 2, PurchaseOrderId: "dummy po 1", MenuId: &menuId, Name: "es teh", Qty: 20, UnitCost: 6000},
+	}, actual.Lines)
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestUpdate_NotDraft() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("UPDATE purchase_order SET supplier_id")).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	_, err := repo.Update(&model.PurchaseOrder{Id: "dummy po 1", SupplierId: "dummy supplier 1"})
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestReceive_IncreasesStock() {
+	created := time.Date(2022, time.October, 2, 9, 0, 0, 0, time.UTC)
+	note, unitCost := "first delivery", 13
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_ORDER_LOCK_STATUS)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.PurchaseOrdered))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_LINE_RECEIVE)).WithArgs(3000, 13, int64(1), "dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"menu_id", "ingredient_id"}).AddRow(nil, "dummy ingredient 1"))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.INGREDIENT_INCREASE_STOCK)).WithArgs(3000, "dummy ingredient 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs(nil, "dummy ingredient 1", 3000, model.StockPurchase, note, nil, "user 1", "dummy po 1", unitCost, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, created))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.PURCHASE_ORDER_SET_RECEIVED)).WithArgs("dummy po 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	err := repo.Receive("dummy po 1", []model.ReceiveLine{{LineId: 1, Qty: 3000, UnitCost: &unitCost}}, &note, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestReceive_MoreThanLeft() {
+	unitCost := 14
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_ORDER_LOCK_STATUS)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.PurchasePartiallyReceived))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_LINE_RECEIVE)).WithArgs(3000, 14, int64(1), "dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"menu_id", "ingredient_id"}))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	err := repo.Receive("dummy po 1", []model.ReceiveLine{{LineId: 1, Qty: 3000, UnitCost: &unitCost}}, nil, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestReceive_ArchivedMenu() {
+	unitCost := 6000
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_ORDER_LOCK_STATUS)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.PurchaseOrdered))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_LINE_RECEIVE)).WithArgs(20, 6000, int64(2), "dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"menu_id", "ingredient_id"}).AddRow("dummy menu 1", nil))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_RECEIVE_STOCK)).WithArgs(20, "dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	err := repo.Receive("dummy po 1", []model.ReceiveLine{{LineId: 2, Qty: 20, UnitCost: &unitCost}}, nil, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "line 2 of purchase order dummy po 1 cannot be received, menu dummy menu 1 is archived")
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *PurchaseOrderRepositoryTestSuite) TestReceive_AlreadyReceived() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PURCHASE_ORDER_LOCK_STATUS)).WithArgs("dummy po 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.PurchaseReceived))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewPurchaseOrderRepository(suite.mockSqlxDb)
+	err := repo.Receive("dummy po 1", nil, nil, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func TestPurchaseOrderRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(PurchaseOrderRepositoryTestSuite))
+}
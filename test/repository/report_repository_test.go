@@ -0,0 +1,168 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var reportFrom = time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)
+var reportTo = time.Date(2022, time.October, 3, 0, 0, 0, 0, time.UTC)
+
+type ReportRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *ReportRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *ReportRepositoryTestSuite) TestSummary_Success() {
+	rows := sqlmock.NewRows([]string{"transactions", "gross_revenue", "average_ticket"}).AddRow(4, 100000, 25000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_SUMMARY)).WithArgs(reportFrom, reportTo).WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.Summary(reportFrom, reportTo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.SalesSummary{Transactions: 4, GrossRevenue: 100000, AverageTicket: 25000}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestSummary_Failed() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_SUMMARY)).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	_, err := repo.Summary(reportFrom, reportTo)
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ReportRepositoryTestSuite) TestByPeriod_Success() {
+	location, _ := time.LoadLocation("Asia/Jakarta")
+	rows := sqlmock.NewRows([]string{"period", "transactions", "gross_revenue", "average_ticket"}).
+		AddRow("2022-10-01", 2, 30000, 15000).
+		AddRow("2022-10-02", 1, 5000, 5000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_BY_PERIOD)).
+		WithArgs(reportFrom, reportTo, "Asia/Jakarta", "YYYY-MM-DD").
+		WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.ByPeriod(reportFrom, reportTo, model.PeriodDay, location)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.SalesPeriod{
+		{Period: "2022-10-01", SalesSummary: model.SalesSummary{Transactions: 2, GrossRevenue: 30000, AverageTicket: 15000}},
+		{Period: "2022-10-02", SalesSummary: model.SalesSummary{Transactions: 1, GrossRevenue: 5000, AverageTicket: 5000}},
+	}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestMenuSales_ByRevenue() {
+	rows := sqlmock.NewRows([]string{"menu_id", "name", "qty", "revenue", "transactions"}).
+		AddRow("menu 1", "nasi goreng", 3, 45000, 2)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_MENU_SALES_BY_REVENUE)).
+		WithArgs(reportFrom, reportTo, 5).
+		WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.MenuSales(reportFrom, reportTo, model.MenuSalesByRevenue, 5)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.MenuSales{{MenuId: "menu 1", Name: "nasi goreng", Qty: 3, Revenue: 45000, Transactions: 2}}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestSummary_PartiallyRefunded() {
+	// one sale of 45000 of which one of three portions, 15000, was refunded
+	rows := sqlmock.NewRows([]string{"transactions", "subtotal", "gross_revenue", "refunded", "net_revenue", "average_ticket"}).AddRow(1, 45000, 45000, 15000, 30000, 45000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_SUMMARY)).WithArgs(reportFrom, reportTo).WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.Summary(reportFrom, reportTo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.SalesSummary{Transactions: 1, Subtotal: 45000, GrossRevenue: 45000, Refunded: 15000, NetRevenue: 30000, AverageTicket: 45000}, actual)
+	assert.Contains(suite.T(), utils.REPORT_SUMMARY, "FROM transaction_refund WHERE kind = 'refund'")
+}
+
+func (suite *ReportRepositoryTestSuite) TestMenuSales_PartiallyRefunded() {
+	rows := sqlmock.NewRows([]string{"menu_id", "name", "qty", "refunded_qty", "revenue", "net_revenue", "transactions"}).
+		AddRow("menu 1", "nasi goreng", 3, 1, 45000, 30000, 1)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_MENU_SALES_BY_QTY)).
+		WithArgs(reportFrom, reportTo, 5).
+		WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.MenuSales(reportFrom, reportTo, model.MenuSalesByQty, 5)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.MenuSales{{MenuId: "menu 1", Name: "nasi goreng", Qty: 3, RefundedQty: 1, Revenue: 45000, NetRevenue: 30000, Transactions: 1}}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestPaymentSales_Success() {
+	rows := sqlmock.NewRows([]string{"method", "transactions", "revenue", "refunded", "net_revenue"}).
+		AddRow(model.PaymentCash, 3, 60000, 5000, 55000).
+		AddRow(model.PaymentUnrecorded, 1, 15000, 0, 15000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_PAYMENT_SALES)).WithArgs(reportFrom, reportTo).WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.PaymentSales(reportFrom, reportTo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.PaymentSales{
+		{Method: model.PaymentCash, Transactions: 3, Revenue: 60000, Refunded: 5000, NetRevenue: 55000},
+		{Method: model.PaymentUnrecorded, Transactions: 1, Revenue: 15000, NetRevenue: 15000},
+	}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestSupplierSpending_Success() {
+	rows := sqlmock.NewRows([]string{"supplier_id", "name", "purchase_orders", "spent"}).
+		AddRow("supplier 1", "pasar induk", 2, 450000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_SUPPLIER_SPENDING)).WithArgs(reportFrom, reportTo).WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.SupplierSpending(reportFrom, reportTo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.SupplierSpending{
+		{SupplierId: "supplier 1", Name: "pasar induk", PurchaseOrders: 2, Spent: 450000},
+	}, actual)
+}
+
+func (suite *ReportRepositoryTestSuite) TestShrinkageByItem_Success() {
+	rows := sqlmock.NewRows([]string{"kind", "id", "name", "unit", "variance", "stock_takes", "lost", "found", "unvalued"}).
+		AddRow(model.StockItemIngredient, "ingredient 1", "rice", "g", -2000, 2, 28000, 0, 0).
+		AddRow(model.StockItemMenu, "menu 1", "es teh", "", 1, 1, 0, 0, 1)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REPORT_SHRINKAGE_BY_ITEM)).WithArgs(reportFrom, reportTo).WillReturnRows(rows)
+
+	repo := repository.NewReportRepository(suite.mockSqlxDb)
+	actual, err := repo.ShrinkageByItem(reportFrom, reportTo)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.ShrinkageItem{
+		{Kind: model.StockItemIngredient, Id: "ingredient 1", Name: "rice", Unit: "g", Variance: -2000, ShrinkageSummary: model.ShrinkageSummary{StockTakes: 2, Lost: 28000}},
+		{Kind: model.StockItemMenu, Id: "menu 1", Name: "es teh", Variance: 1, ShrinkageSummary: model.ShrinkageSummary{StockTakes: 1, Unvalued: 1}},
+	}, actual)
+}
+
+func TestReportRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(ReportRepositoryTestSuite))
+}
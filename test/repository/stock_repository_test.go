@@ -0,0 +1,149 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var stockMovementColumns = []string{"id", "menu_id", "ingredient_id", "qty", "reason", "note", "transaction_id", "user_id", "purchase_order_id", "unit_cost", "stock_take_id", "created_at"}
+
+type StockRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *StockRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *StockRepositoryTestSuite) TestGetByItem_Menu() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	menuId, note, transactionId, userId := "dummy menu 1", "dropped a tray", "dummy transaction 1", "user 1"
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_MOVEMENT_GET_BY_MENU)).WithArgs("dummy menu 1").
+		WillReturnRows(sqlmock.NewRows(stockMovementColumns).
+			AddRow(3, menuId, nil, -2, model.StockWaste, note, nil, userId, nil, nil, nil, created).
+			AddRow(2, menuId, nil, -1, model.StockSale, nil, transactionId, nil, nil, nil, nil, created).
+			AddRow(1, menuId, nil, 10, model.StockOpening, nil, nil, nil, nil, nil, nil, created))
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByItem(model.StockItem{Kind: model.StockItemMenu, Id: menuId})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.StockMovement{
+		{Id: 3, MenuId: &menuId, Qty: -2, Reason: model.StockWaste, Note: &note, UserId: &userId, CreatedAt: created},
+		{Id: 2, MenuId: &menuId, Qty: -1, Reason: model.StockSale, TransactionId: &transactionId, CreatedAt: created},
+		{Id: 1, MenuId: &menuId, Qty: 10, Reason: model.StockOpening, CreatedAt: created},
+	}, actual)
+}
+
+func (suite *StockRepositoryTestSuite) TestGetByItem_Ingredient() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	ingredientId, purchaseOrderId, unitCost := "dummy ingredient 1", "dummy purchase order 1", 14
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_MOVEMENT_GET_BY_INGREDIENT)).WithArgs(ingredientId).
+		WillReturnRows(sqlmock.NewRows(stockMovementColumns).
+			AddRow(6, nil, ingredientId, 5000, model.StockPurchase, nil, nil, nil, purchaseOrderId, unitCost, nil, created).
+			AddRow(5, nil, ingredientId, 1000, model.StockOpening, nil, nil, nil, nil, nil, nil, created))
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByItem(model.StockItem{Kind: model.StockItemIngredient, Id: ingredientId})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.StockMovement{
+		{Id: 6, IngredientId: &ingredientId, Qty: 5000, Reason: model.StockPurchase, PurchaseOrderId: &purchaseOrderId, UnitCost: &unitCost, CreatedAt: created},
+		{Id: 5, IngredientId: &ingredientId, Qty: 1000, Reason: model.StockOpening, CreatedAt: created},
+	}, actual)
+}
+
+func (suite *StockRepositoryTestSuite) TestMove_Success() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	userId := "user 1"
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_MOVE_STOCK)).WithArgs(5, "dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs("dummy menu 1", nil, 5, model.StockRestock, nil, nil, userId, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(4, created))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	movement := model.StockItem{Kind: model.StockItemMenu, Id: "dummy menu 1"}.Movement(5, model.StockRestock)
+	movement.UserId = &userId
+	ok, err := repo.Move(&movement)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), int64(4), movement.Id)
+	assert.Equal(suite.T(), created, movement.CreatedAt)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockRepositoryTestSuite) TestMove_BelowReserved() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_MOVE_STOCK)).WithArgs(-5, "dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	movement := model.StockItem{Kind: model.StockItemMenu, Id: "dummy menu 1"}.Movement(-5, model.StockWaste)
+	ok, err := repo.Move(&movement)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockRepositoryTestSuite) TestMove_Ingredient() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.INGREDIENT_MOVE_STOCK)).WithArgs(-250, "dummy ingredient 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs(nil, "dummy ingredient 1", -250, model.StockWaste, nil, nil, nil, nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(6, created))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	movement := model.StockItem{Kind: model.StockItemIngredient, Id: "dummy ingredient 1"}.Movement(-250, model.StockWaste)
+	ok, err := repo.Move(&movement)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+	assert.Equal(suite.T(), int64(6), movement.Id)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockRepositoryTestSuite) TestAlerts_Success() {
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_ALERT_GET_ALL)).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "id", "name", "unit", "available", "reorder_level"}).
+			AddRow(model.StockItemMenu, "dummy menu 1", "es teh", "", 3, 5).
+			AddRow(model.StockItemIngredient, "dummy ingredient 1", "rice", "g", 400, 1000))
+
+	repo := repository.NewStockRepository(suite.mockSqlxDb)
+	actual, err := repo.Alerts()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.StockAlert{
+		{Kind: model.StockItemMenu, Id: "dummy menu 1", Name: "es teh", Available: 3, ReorderLevel: 5},
+		{Kind: model.StockItemIngredient, Id: "dummy ingredient 1", Name: "rice", Unit: "g", Available: 400, ReorderLevel: 1000},
+	}, actual)
+}
+
+func TestStockRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(StockRepositoryTestSuite))
+}
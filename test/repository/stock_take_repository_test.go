@@ -0,0 +1,127 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var stockTakeLineColumns = []string{"id", "stock_take_id", "menu_id", "ingredient_id", "name", "unit", "expected", "counted", "variance", "unit_cost", "variance_value", "user_id", "counted_at"}
+
+type StockTakeRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *StockTakeRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *StockTakeRepositoryTestSuite) TestCount_Success() {
+	menuId, ingredientId, counted := "dummy menu 1", "dummy ingredient 1", 1800
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LOCK_STATUS)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StockTakeCounting))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.STOCK_TAKE_LINE_COUNT_INGREDIENT)).WithArgs("dummy take 1", ingredientId, counted, "user 1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.STOCK_TAKE_LINE_COUNT_MENU)).WithArgs("dummy take 1", menuId, 0, "user 1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	zero := 0
+	repo := repository.NewStockTakeRepository(suite.mockSqlxDb)
+	err := repo.Count("dummy take 1", []model.StockCount{
+		{IngredientId: &ingredientId, Counted: &counted},
+		{MenuId: &menuId, Counted: &zero},
+	}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockTakeRepositoryTestSuite) TestCount_Approved() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LOCK_STATUS)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StockTakeApproved))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewStockTakeRepository(suite.mockSqlxDb)
+	err := repo.Count("dummy take 1", nil, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockTakeRepositoryTestSuite) TestApprove_AdjustsStock() {
+	counted := time.Date(2022, time.October, 31, 21, 0, 0, 0, time.UTC)
+	note, unitCost := "stock take dummy take 1", 14
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LOCK_STATUS)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StockTakeCounting))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LINE_GET_BY_TAKE)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows(stockTakeLineColumns).
+			AddRow(1, "dummy take 1", "dummy menu 1", nil, "es teh", "", 5, 5, 0, nil, nil, "user 1", counted).
+			AddRow(2, "dummy take 1", nil, "dummy ingredient 1", "rice", "g", 2000, 1800, -200, unitCost, -2800, "user 1", counted))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.INGREDIENT_MOVE_STOCK)).WithArgs(-200, "dummy ingredient 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement")).
+		WithArgs(nil, "dummy ingredient 1", -200, model.StockAdjustment, note, nil, "user 2", nil, unitCost, "dummy take 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(10, counted))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.STOCK_TAKE_APPROVE)).WithArgs("dummy take 1", "user 2").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewStockTakeRepository(suite.mockSqlxDb)
+	err := repo.Approve("dummy take 1", "user 2")
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockTakeRepositoryTestSuite) TestApprove_StockHeld() {
+	counted := time.Date(2022, time.October, 31, 21, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LOCK_STATUS)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(model.StockTakeCounting))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.STOCK_TAKE_LINE_GET_BY_TAKE)).WithArgs("dummy take 1").
+		WillReturnRows(sqlmock.NewRows(stockTakeLineColumns).
+			AddRow(1, "dummy take 1", "dummy menu 1", nil, "es teh", "", 5, 2, -3, nil, nil, "user 1", counted))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_MOVE_STOCK)).WithArgs(-3, "dummy menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewStockTakeRepository(suite.mockSqlxDb)
+	err := repo.Approve("dummy take 1", "user 2")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *StockTakeRepositoryTestSuite) TestCancel_NotCounting() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.STOCK_TAKE_CANCEL)).WithArgs("dummy take 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewStockTakeRepository(suite.mockSqlxDb)
+	err := repo.Cancel("dummy take 1")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func TestStockTakeRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(StockTakeRepositoryTestSuite))
+}
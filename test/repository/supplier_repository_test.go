@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var supplierColumns = []string{"id", "name", "phone", "email", "address", "created_at"}
+
+type SupplierRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *SupplierRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *SupplierRepositoryTestSuite) TestGetAll_Success() {
+	created := time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)
+	phone := "0812"
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.SUPPLIER_GET_ALL + " ORDER BY name, id")).
+		WillReturnRows(sqlmock.NewRows(supplierColumns).AddRow("dummy supplier 1", "pasar induk", phone, nil, nil, created))
+
+	repo := repository.NewSupplierRepository(suite.mockSqlxDb)
+	actual, err := repo.GetAll()
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Supplier{{Id: "dummy supplier 1", Name: "pasar induk", Phone: &phone, CreatedAt: created}}, actual)
+}
+
+func (suite *SupplierRepositoryTestSuite) TestDelete_HasPurchaseOrders() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.SUPPLIER_DELETE)).WithArgs("dummy supplier 1").WillReturnError(&pq.Error{Code: "23503"})
+
+	repo := repository.NewSupplierRepository(suite.mockSqlxDb)
+	err := repo.Delete("dummy supplier 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
+func TestSupplierRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(SupplierRepositoryTestSuite))
+}
@@ -0,0 +1,162 @@
+package repository_test
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyRefreshTokens = []model.RefreshToken{
+	{
+		Id:        "dummy refresh id 1",
+		UserId:    "dummy user id 1",
+		TokenHash: "dummy hash 1",
+		AccessJti: "dummy jti 1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	},
+}
+
+type TokenRepositoryTestSuite struct {
+	suite.Suite
+	mockDb     *sql.DB
+	mockSql    sqlmock.Sqlmock
+	mockSqlxDb *sqlx.DB
+}
+
+func (suite *TokenRepositoryTestSuite) SetupTest() {
+	db, sql, err := sqlmock.New()
+	if err != nil {
+		panic(err)
+	}
+
+	suite.mockDb = db
+	suite.mockSql = sql
+	suite.mockSqlxDb = sqlx.NewDb(suite.mockDb, "postgres")
+}
+
+func (suite *TokenRepositoryTestSuite) TestGetRefreshTokenByHash_Success() {
+	dummy := dummyRefreshTokens[0]
+	row := sqlmock.NewRows([]string{"id", "user_id", "token_hash", "access_jti", "expires_at", "revoked_at"})
+	row.AddRow(dummy.Id, dummy.UserId, dummy.TokenHash, dummy.AccessJti, dummy.ExpiresAt, nil)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REFRESH_TOKEN_GET_BY_HASH)).WithArgs(dummy.TokenHash).WillReturnRows(row)
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	actual, err := repo.GetRefreshTokenByHash(dummy.TokenHash)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy.Id, actual.Id)
+	assert.False(suite.T(), actual.RevokedAt.Valid)
+}
+
+func (suite *TokenRepositoryTestSuite) TestGetRefreshTokenByHash_Failed() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REFRESH_TOKEN_GET_BY_HASH)).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	actual, err := repo.GetRefreshTokenByHash(dummy.TokenHash)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.RefreshToken{}, actual)
+}
+
+func (suite *TokenRepositoryTestSuite) TestInsertRefreshToken_Success() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.REFRESH_TOKEN_INSERT_TEST)).WithArgs(dummy.Id, dummy.UserId, dummy.TokenHash, dummy.AccessJti, dummy.ExpiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	err := repo.InsertRefreshToken(&dummy)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TokenRepositoryTestSuite) TestRevokeRefreshToken_Success() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.REFRESH_TOKEN_REVOKE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	revoked, err := repo.RevokeRefreshToken(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), revoked)
+}
+
+func (suite *TokenRepositoryTestSuite) TestRevokeRefreshToken_AlreadyRevoked() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.REFRESH_TOKEN_REVOKE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	revoked, err := repo.RevokeRefreshToken(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), revoked)
+}
+
+func (suite *TokenRepositoryTestSuite) TestRevokeUserRefreshTokens_Success() {
+	dummy := dummyRefreshTokens[0]
+	rows := sqlmock.NewRows([]string{"access_jti"})
+	rows.AddRow(dummy.AccessJti)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REFRESH_TOKEN_REVOKE_BY_USER)).WithArgs(dummy.UserId).WillReturnRows(rows)
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	actual, err := repo.RevokeUserRefreshTokens(dummy.UserId)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []string{dummy.AccessJti}, actual)
+}
+
+func (suite *TokenRepositoryTestSuite) TestRevokeAccessToken_Success() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.REVOKED_TOKEN_INSERT)).WithArgs(dummy.AccessJti, dummy.ExpiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	err := repo.RevokeAccessToken(dummy.AccessJti, dummy.ExpiresAt)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TokenRepositoryTestSuite) TestIsAccessTokenRevoked_Success() {
+	dummy := dummyRefreshTokens[0]
+	row := sqlmock.NewRows([]string{"exists"})
+	row.AddRow(true)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REVOKED_TOKEN_EXISTS)).WithArgs(dummy.AccessJti).WillReturnRows(row)
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	revoked, err := repo.IsAccessTokenRevoked(dummy.AccessJti)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), revoked)
+}
+
+func (suite *TokenRepositoryTestSuite) TestIsAccessTokenRevoked_Failed() {
+	dummy := dummyRefreshTokens[0]
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.REVOKED_TOKEN_EXISTS)).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewTokenRepository(suite.mockSqlxDb)
+	revoked, err := repo.IsAccessTokenRevoked(dummy.AccessJti)
+
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), revoked)
+}
+
+func TestTokenRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenRepositoryTestSuite))
+}
@@ -89,7 +89,7 @@ func (suite *TransactionDetailRepositoryTestSuite) TestGetByTransactionId_Failed
 func (suite *TransactionDetailRepositoryTestSuite) TestInsert_Success() {
 	var dummy = dummyDetail[0]
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_INSERT_TEST)).WithArgs(dummy.TransactionId, dummy.MenuId, dummy.Qty, dummy.Subtotal).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_INSERT_TEST)).WithArgs(dummy.TransactionId, dummy.MenuId, dummy.MenuName, dummy.UnitPrice, []byte("[]"), []byte("[]"), dummy.Qty, dummy.Subtotal, dummy.DiscountAmount).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	repo := repository.NewTransactionDetailRepository(suite.mockSqlxDb)
 	actual, err := repo.Insert(&dummy)
@@ -100,7 +100,7 @@ func (suite *TransactionDetailRepositoryTestSuite) TestInsert_Success() {
 func (suite *TransactionDetailRepositoryTestSuite) TestInsert_Failed() {
 	var dummy = dummyDetail[0]
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_INSERT_TEST)).WithArgs(dummy.TransactionId, dummy.MenuId, dummy.Qty, dummy.Subtotal).WillReturnError(errors.New("failed"))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_INSERT_TEST)).WithArgs(dummy.TransactionId, dummy.MenuId, dummy.MenuName, dummy.UnitPrice, []byte("[]"), []byte("[]"), dummy.Qty, dummy.Subtotal, dummy.DiscountAmount).WillReturnError(errors.New("failed"))
 
 	repo := repository.NewTransactionDetailRepository(suite.mockSqlxDb)
 	actual, err := repo.Insert(&dummy)
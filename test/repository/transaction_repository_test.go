@@ -9,6 +9,7 @@ import (
 	"warung-makan/model"
 	"warung-makan/repository"
 	"warung-makan/utils"
+	"warung-makan/utils/apperror"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -68,7 +69,7 @@ func (suite *TransactionRepositoryTestSuite) TestGetAll_Success() {
 		rows.AddRow(dummy.Id, dummy.TotalPrice, dummy.Created_at, dummy.Updated_at.Time)
 	}
 
-	suite.mockSql.ExpectQuery(utils.TRANSACTION_GET_ALL).WillReturnRows(rows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_GET_ALL)).WillReturnRows(rows)
 
 	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
 	actual, err := repo.GetAllTest()
@@ -84,7 +85,7 @@ func (suite *TransactionRepositoryTestSuite) TestGetAll_Failed() {
 		rows.AddRow(dummy.Id, dummy.TotalPrice, dummy.Created_at, dummy.Updated_at.Time)
 	}
 
-	suite.mockSql.ExpectQuery(utils.TRANSACTION_GET_ALL).WillReturnError(errors.New("failed"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_GET_ALL)).WillReturnError(errors.New("failed"))
 
 	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
 	actual, err := repo.GetAll()
@@ -93,6 +94,96 @@ func (suite *TransactionRepositoryTestSuite) TestGetAll_Failed() {
 	assert.Nil(suite.T(), actual)
 }
 
+func (suite *TransactionRepositoryTestSuite) TestGetAll_ItemsFailed() {
+	dummy := dummyTransaction[0]
+	rows := sqlmock.NewRows([]string{"id", "total_price", "created_at", "updated_at"})
+	rows.AddRow(dummy.Id, dummy.TotalPrice, dummy.Created_at, dummy.Updated_at.Time)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_GET_ALL)).WillReturnRows(rows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_GET_BY_TRANSACTIONS)).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	actual, err := repo.GetAll()
+
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), actual)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestList_LoadsItemsOnce() {
+	rows := sqlmock.NewRows([]string{"id", "total_price", "created_at", "updated_at"}).
+		AddRow("dummy id 1", 25000, "2022-10-02T10:00:00Z", nil).
+		AddRow("dummy id 2", 10000, "2022-10-01T10:00:00Z", nil)
+	items := sqlmock.NewRows([]string{"transaction_id", "menu_id", "qty", "subtotal"}).
+		AddRow("dummy id 1", "menu 1", 2, 20000).
+		AddRow("dummy id 1", "menu 2", 1, 5000).
+		AddRow("dummy id 2", "menu 1", 1, 10000)
+	minTotal := 1000
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_COUNT + " WHERE total_price >= $1")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_GET_ALL+" WHERE total_price >= $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3")).
+		WithArgs(minTotal, model.DefaultPageLimit+1, 0).
+		WillReturnRows(rows)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_GET_BY_TRANSACTIONS)).
+		WithArgs("{\"dummy id 1\",\"dummy id 2\"}").
+		WillReturnRows(items)
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	actual, page, err := repo.List(model.TransactionFilter{MinTotal: &minTotal})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, page.Total)
+	assert.Equal(suite.T(), "created_at", page.Sort)
+	assert.Equal(suite.T(), 2, len(actual[0].Items))
+	assert.Equal(suite.T(), 1, len(actual[1].Items))
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestExport_GroupsItems() {
+	from := time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "total_price", "created_at", "menu_id", "menu_name", "qty", "subtotal"}).
+		AddRow("dummy id 1", 25000, "2022-10-01T10:00:00Z", "menu 1", "nasi goreng", 2, 20000).
+		AddRow("dummy id 1", 25000, "2022-10-01T10:00:00Z", "menu 2", "es teh", 1, 5000).
+		AddRow("dummy id 2", 10000, "2022-10-02T10:00:00Z", "menu 1", "nasi goreng", 1, 10000)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_EXPORT + " WHERE t.created_at >= $1" + utils.TRANSACTION_EXPORT_ORDER)).
+		WithArgs(from).
+		WillReturnRows(rows)
+
+	var actual []model.Transaction
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	err := repo.Export(model.TransactionFilter{From: &from}, func(transaction model.Transaction) error {
+		actual = append(actual, transaction)
+		return nil
+	})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2, len(actual))
+	assert.Equal(suite.T(), []model.TransactionDetail{
+		{TransactionId: "dummy id 1", MenuId: "menu 1", MenuName: "nasi goreng", Qty: 2, Subtotal: 20000},
+		{TransactionId: "dummy id 1", MenuId: "menu 2", MenuName: "es teh", Qty: 1, Subtotal: 5000},
+	}, actual[0].Items)
+	assert.Equal(suite.T(), 10000, actual[1].TotalPrice)
+	assert.Equal(suite.T(), 1, len(actual[1].Items))
+}
+
+func (suite *TransactionRepositoryTestSuite) TestExport_StopsOnWriteError() {
+	rows := sqlmock.NewRows([]string{"id", "total_price", "created_at", "menu_id", "menu_name", "qty", "subtotal"}).
+		AddRow("dummy id 1", 25000, "2022-10-01T10:00:00Z", "menu 1", "nasi goreng", 2, 20000).
+		AddRow("dummy id 2", 10000, "2022-10-02T10:00:00Z", "menu 1", "nasi goreng", 1, 10000)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_EXPORT + utils.TRANSACTION_EXPORT_ORDER)).WillReturnRows(rows)
+
+	calls := 0
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	err := repo.Export(model.TransactionFilter{}, func(transaction model.Transaction) error {
+		calls++
+		return errors.New("client gone")
+	})
+
+	assert.EqualError(suite.T(), err, "client gone")
+	assert.Equal(suite.T(), 1, calls)
+}
+
 func (suite *TransactionRepositoryTestSuite) TestGetById_Success() {
 	dummy := dummyTransaction[0]
 	row := sqlmock.NewRows([]string{"id", "total_price", "created_at", "updated_at"})
@@ -145,6 +236,238 @@ func (suite *TransactionRepositoryTestSuite) TestInsert_Failed() {
 	assert.Equal(suite.T(), model.TransactionTest{}, actual)
 }
 
+func (suite *TransactionRepositoryTestSuite) TestTxLockMenus_Success() {
+	rows := sqlmock.NewRows([]string{"id", "name", "price", "stock", "image"})
+	rows.AddRow("menu 1", "ketoprak", 12500, 5, "menu 1.jpg")
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.MENU_LOCK_BY_IDS)).WithArgs("{\"menu 1\",\"menu 2\"}").WillReturnRows(rows)
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, err := repo.Begin()
+	assert.Nil(suite.T(), err)
+
+	menus, err := tx.LockMenus([]string{"menu 1", "menu 2"})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Menu{{Id: "menu 1", Name: "ketoprak", Price: 12500, Stock: 5, Image: "menu 1.jpg"}}, menus)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxDecreaseStock_Success() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_DECREASE_STOCK)).WithArgs(3, "menu 1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	ok, err := tx.DecreaseStock("menu 1", 3)
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), ok)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxDecreaseStock_NotEnough() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_DECREASE_STOCK)).WithArgs(3, "menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	ok, err := tx.DecreaseStock("menu 1", 3)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxInsertPayments_Failed() {
+	tendered, change := 50000, 7500
+	payments := []model.Payment{
+		{TransactionId: "dummy id 1", Method: model.PaymentCash, Amount: 42500, Tendered: &tendered, Change: &change},
+		{TransactionId: "dummy id 1", Method: model.PaymentEWallet, Amount: 1000},
+	}
+	insert := "INSERT INTO payment(transaction_id, method, amount, tendered, change) VALUES ($1, $2, $3, $4, $5)"
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("dummy id 1", model.PaymentCash, 42500, 50000, 7500).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(insert)).WithArgs("dummy id 1", model.PaymentEWallet, 1000, nil, nil).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	err := tx.InsertPayments(payments)
+
+	assert.EqualError(suite.T(), err, "e_wallet payment of transaction dummy id 1: failed")
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxReserveStock_NotEnough() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_RESERVE_STOCK)).WithArgs(3, "menu 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	ok, err := tx.ReserveStock("menu 1", 3)
+
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), ok)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxDeleteItem_NotFound() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_DELETE)).WithArgs(int64(9), "dummy id 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	err := tx.DeleteItem("dummy id 1", 9)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxInsert_Success() {
+	transaction := model.Transaction{
+		Id:         "dummy id 1",
+		Subtotal:   25000,
+		TotalPrice: 25000,
+		Status:     model.StatusPaid,
+		Items: []model.TransactionDetail{
+			{TransactionId: "dummy id 1", MenuId: "menu 1", MenuName: "nasi goreng", UnitPrice: 12500, Qty: 2, Subtotal: 25000},
+		},
+	}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO transaction(id, subtotal, discount_amount, service_charge, tax, total_price, promo_code, status) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)")).
+		WithArgs(transaction.Id, 25000, 0, 0, 0, transaction.TotalPrice, "", model.StatusPaid).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_INSERT_TEST)).WithArgs("dummy id 1", "menu 1", "nasi goreng", 12500, []byte("[]"), []byte("[]"), 2, 25000, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	err := tx.Insert(&transaction)
+	assert.Nil(suite.T(), err)
+
+	assert.Nil(suite.T(), tx.Commit())
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxInsert_Failed() {
+	transaction := model.Transaction{Id: "dummy id 1", TotalPrice: 25000, Status: model.StatusPaid}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectExec("INSERT INTO transaction").WillReturnError(errors.New("failed"))
+	suite.mockSql.ExpectRollback()
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	err := tx.Insert(&transaction)
+	assert.Error(suite.T(), err)
+
+	assert.Nil(suite.T(), tx.Rollback())
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxLockTransaction_NotFound() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_LOCK)).WithArgs("dummy id 9").WillReturnError(sql.ErrNoRows)
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	_, err := tx.LockTransaction("dummy id 9")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxRefund_Success() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	refund := model.Refund{
+		Id:            "refund 1",
+		TransactionId: "dummy id 1",
+		Kind:          model.RefundKindRefund,
+		Reason:        "cold food",
+		UserId:        "user 1",
+		Amount:        10000,
+		CreatedAt:     created,
+		Items:         []model.RefundItem{{RefundId: "refund 1", DetailId: 1, MenuId: "menu 1", Qty: 1, Amount: 10000}},
+	}
+
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_REFUNDED_QTY)).WithArgs("dummy id 1").
+		WillReturnRows(sqlmock.NewRows([]string{"detail_id", "qty"}).AddRow(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO transaction_refund(id, transaction_id, kind, reason, user_id, amount, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs("refund 1", "dummy id 1", model.RefundKindRefund, "cold food", "user 1", 10000, created).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO transaction_refund_item(refund_id, detail_id, menu_id, qty, amount) VALUES ($1, $2, $3, $4, $5)")).
+		WithArgs("refund 1", int64(1), "menu 1", 1, 10000).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.MENU_INCREASE_STOCK)).WithArgs(1, "menu 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta("INSERT INTO stock_movement(menu_id, ingredient_id, qty, reason, note, transaction_id, user_id, purchase_order_id, unit_cost, stock_take_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at")).
+		WithArgs("menu 1", nil, 1, model.StockRefund, nil, "dummy id 1", "user 1", nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, created))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.TRANSACTION_SET_STATUS)).WithArgs(model.StatusPartiallyRefunded, "dummy id 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+	refunded, err := tx.RefundedQty("dummy id 1")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), map[int64]int{1: 1}, refunded)
+
+	assert.Nil(suite.T(), tx.InsertRefund(&refund))
+	assert.Nil(suite.T(), tx.IncreaseStock("menu 1", 1))
+	transactionId, userId := "dummy id 1", "user 1"
+	movement := model.StockItem{Kind: model.StockItemMenu, Id: "menu 1"}.Movement(1, model.StockRefund)
+	movement.TransactionId, movement.UserId = &transactionId, &userId
+	movements := []model.StockMovement{movement}
+	assert.Nil(suite.T(), tx.InsertMovements(movements))
+	assert.Equal(suite.T(), int64(7), movements[0].Id)
+	assert.Nil(suite.T(), tx.SetStatus("dummy id 1", model.StatusPartiallyRefunded))
+	assert.Nil(suite.T(), tx.Commit())
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
+func (suite *TransactionRepositoryTestSuite) TestGetById_WithRefunds() {
+	created := time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_GET_BY_ID)).WithArgs("dummy id 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total_price", "status", "created_at", "updated_at"}).
+			AddRow("dummy id 1", 20000, model.StatusPartiallyRefunded, "2022-10-01T09:00:00Z", nil))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_DETAIL_GET_BY_ID_TRANSACTION)).WithArgs("dummy id 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "menu_id", "menu_name", "unit_price", "qty", "subtotal"}).
+			AddRow(1, "dummy id 1", "menu 1", "nasi goreng", 10000, 2, 20000))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.PAYMENT_GET_BY_TRANSACTION)).WithArgs("dummy id 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "method", "amount", "tendered", "change"}).
+			AddRow(1, "dummy id 1", model.PaymentQRIS, 5000, nil, nil).
+			AddRow(2, "dummy id 1", model.PaymentCash, 15000, 20000, 5000))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_REFUND_GET_BY_TRANSACTION)).WithArgs("dummy id 1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "kind", "reason", "user_id", "amount", "created_at"}).
+			AddRow("refund 1", "dummy id 1", model.RefundKindRefund, "cold food", "user 1", 10000, created))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.TRANSACTION_REFUND_ITEM_GET_BY_REFUNDS)).WithArgs("{\"refund 1\"}").
+		WillReturnRows(sqlmock.NewRows([]string{"refund_id", "detail_id", "menu_id", "qty", "amount"}).
+			AddRow("refund 1", 1, "menu 1", 1, 10000))
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	actual, err := repo.GetById("dummy id 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.StatusPartiallyRefunded, actual.Status)
+	assert.Equal(suite.T(), int64(1), actual.Items[0].Id)
+	assert.Equal(suite.T(), "nasi goreng", actual.Items[0].MenuName)
+	assert.Equal(suite.T(), 10000, actual.Items[0].UnitPrice)
+	tendered, change := 20000, 5000
+	assert.Equal(suite.T(), []model.Payment{
+		{Id: 1, TransactionId: "dummy id 1", Method: model.PaymentQRIS, Amount: 5000},
+		{Id: 2, TransactionId: "dummy id 1", Method: model.PaymentCash, Amount: 15000, Tendered: &tendered, Change: &change},
+	}, actual.Payments)
+	assert.Equal(suite.T(), 1, len(actual.Refunds))
+	assert.Equal(suite.T(), []model.RefundItem{{RefundId: "refund 1", DetailId: 1, MenuId: "menu 1", Qty: 1, Amount: 10000}}, actual.Refunds[0].Items)
+}
+
+func (suite *TransactionRepositoryTestSuite) TestTxRollbackAfterCommit() {
+	suite.mockSql.ExpectBegin()
+	suite.mockSql.ExpectCommit()
+
+	repo := repository.NewTransactionRepository(suite.mockSqlxDb)
+	tx, _ := repo.Begin()
+
+	assert.Nil(suite.T(), tx.Commit())
+	assert.Nil(suite.T(), tx.Rollback())
+	assert.Nil(suite.T(), suite.mockSql.ExpectationsWereMet())
+}
+
 func TestTransactionRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(TransactionRepositoryTestSuite))
 }
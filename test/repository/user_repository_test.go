@@ -2,12 +2,14 @@ package repository_test
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"regexp"
 	"testing"
 	"warung-makan/model"
 	"warung-makan/repository"
 	"warung-makan/utils"
+	"warung-makan/utils/apperror"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -20,16 +22,28 @@ var dummyUsers = []model.User{
 		Id:       "dummy id 1",
 		Name:     "dummy name 1",
 		Username: "dummy username 1",
+		Role:     model.RoleOwner,
 		Image:    "dummy image path 1",
 	},
 	{
 		Id:       "dummy id 2",
 		Name:     "dummy name 2",
 		Username: "dummy username 2",
+		Role:     model.RoleCashier,
 		Image:    "dummy image path 2",
 	},
 }
 
+// matches a bcrypt hash of the given plain password
+type hashedPassword struct {
+	plain string
+}
+
+func (h hashedPassword) Match(v driver.Value) bool {
+	hashed, ok := v.(string)
+	return ok && hashed != h.plain && utils.ComparePassword(hashed, h.plain)
+}
+
 type UserRepositoryTestSuite struct {
 	suite.Suite
 	mockDb     *sql.DB
@@ -65,9 +79,9 @@ func (suite *UserRepositoryTestSuite) TearDownTest() {
 }
 
 func (suite *UserRepositoryTestSuite) TestGetAllUser_Success() {
-	rows := sqlmock.NewRows([]string{"id", "name", "username", "image"})
+	rows := sqlmock.NewRows([]string{"id", "name", "username", "role", "image"})
 	for _, dummy := range dummyUsers {
-		rows.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+		rows.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 	}
 
 	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_ALL)).WillReturnRows(rows)
@@ -81,9 +95,9 @@ func (suite *UserRepositoryTestSuite) TestGetAllUser_Success() {
 }
 
 func (suite *UserRepositoryTestSuite) TestGetAllUser_Failed() {
-	rows := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "image failed"})
+	rows := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "role failed", "image failed"})
 	for _, dummy := range dummyUsers {
-		rows.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+		rows.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 	}
 
 	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_ALL)).WillReturnError(errors.New("failed to retrieve user list"))
@@ -98,13 +112,13 @@ func (suite *UserRepositoryTestSuite) TestGetAllUser_Failed() {
 
 func (suite *UserRepositoryTestSuite) TestGetByIdUser_Success() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id", "name", "username", "image"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+	row := sqlmock.NewRows([]string{"id", "name", "username", "role", "image"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_ID)).WillReturnRows(row)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_ID + utils.NOT_DELETED)).WillReturnRows(row)
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
-	actual, err := repo.GetById(dummy.Id)
+	actual, err := repo.GetById(dummy.Id, false)
 
 	assert.Nil(suite.T(), err)
 	assert.NotNil(suite.T(), actual)
@@ -113,13 +127,13 @@ func (suite *UserRepositoryTestSuite) TestGetByIdUser_Success() {
 
 func (suite *UserRepositoryTestSuite) TestGetByIdUser_Failed() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "image failed"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+	row := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "role failed", "image failed"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_ID)).WillReturnError(errors.New("failed to retrieve user"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_ID + utils.NOT_DELETED)).WillReturnError(errors.New("failed to retrieve user"))
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
-	actual, err := repo.GetById(dummy.Id)
+	actual, err := repo.GetById(dummy.Id, false)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), model.User{}, actual)
@@ -127,8 +141,8 @@ func (suite *UserRepositoryTestSuite) TestGetByIdUser_Failed() {
 
 func (suite *UserRepositoryTestSuite) TestGetByNameUser_Success() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id", "name", "username", "image"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+	row := sqlmock.NewRows([]string{"id", "name", "username", "role", "image"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 
 	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_NAME)).WillReturnRows(row)
 
@@ -142,8 +156,8 @@ func (suite *UserRepositoryTestSuite) TestGetByNameUser_Success() {
 
 func (suite *UserRepositoryTestSuite) TestGetByNameUser_Failed() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "image failed"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+	row := sqlmock.NewRows([]string{"id failed", "name failed", "username failed", "role failed", "image failed"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Role, dummy.Image)
 
 	suite.mockSql.ExpectQuery(utils.USER_GET_BY_NAME).WillReturnError(errors.New("failed to retrieve user"))
 
@@ -154,46 +168,73 @@ func (suite *UserRepositoryTestSuite) TestGetByNameUser_Failed() {
 	assert.Equal(suite.T(), 0, len(actual))
 }
 
-func (suite *UserRepositoryTestSuite) TestGetByCredentialUser_Success() {
+func (suite *UserRepositoryTestSuite) TestGetByUsernameUser_Success() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id", "name", "username", "image"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
+	dummy.Password = "dummy hashed password"
+	row := sqlmock.NewRows([]string{"id", "name", "username", "password", "role", "image"})
+	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Password, dummy.Role, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_CREDENTIALS)).WillReturnRows(row)
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_USERNAME)).WithArgs(dummy.Username).WillReturnRows(row)
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
-	actual, err := repo.GetByCredentials(dummy.Username, dummy.Password)
+	actual, err := repo.GetByUsername(dummy.Username)
 
 	assert.Nil(suite.T(), err)
-	assert.NotNil(suite.T(), actual)
 	assert.Equal(suite.T(), dummy, actual)
 }
 
-func (suite *UserRepositoryTestSuite) TestGetByCredentialUser_Failed() {
+func (suite *UserRepositoryTestSuite) TestGetByUsernameUser_Failed() {
+	dummy := dummyUsers[0]
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_USERNAME)).WillReturnError(errors.New("failed"))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	actual, err := repo.GetByUsername(dummy.Username)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.User{}, actual)
+}
+
+func (suite *UserRepositoryTestSuite) TestGetPasswordByIdUser_Success() {
+	dummy := dummyUsers[0]
+	dummy.Password = "dummy hashed password"
+	row := sqlmock.NewRows([]string{"password"})
+	row.AddRow(dummy.Password)
+
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_PASSWORD)).WithArgs(dummy.Id).WillReturnRows(row)
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	actual, err := repo.GetPasswordById(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy.Password, actual)
+}
+
+func (suite *UserRepositoryTestSuite) TestGetPasswordByIdUser_Failed() {
 	dummy := dummyUsers[0]
-	row := sqlmock.NewRows([]string{"id", "name", "username", "image"})
-	row.AddRow(dummy.Id, dummy.Name, dummy.Username, dummy.Image)
 
-	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_BY_CREDENTIALS)).WillReturnError(errors.New("failed"))
+	suite.mockSql.ExpectQuery(regexp.QuoteMeta(utils.USER_GET_PASSWORD)).WillReturnError(errors.New("failed"))
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
-	actual, err := repo.GetByCredentials(dummy.Username, dummy.Password)
+	actual, err := repo.GetPasswordById(dummy.Id)
 
 	assert.Error(suite.T(), err)
-	assert.NotEqual(suite.T(), dummy, actual)
+	assert.Equal(suite.T(), "", actual)
 }
 
 func (suite *UserRepositoryTestSuite) TestInsertUser_Success() {
 	var dummy = dummyUsers[0]
+	dummy.Password = "dummy password"
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_INSERT_TEST)).WithArgs(dummy.Id, dummy.Name, dummy.Username, dummy.Password, dummy.Image).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_INSERT_TEST)).WithArgs(dummy.Id, dummy.Name, dummy.Username, hashedPassword{dummy.Password}, dummy.Role, dummy.Image).WillReturnResult(sqlmock.NewResult(1, 1))
 	// return
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
 	actual, err := repo.Insert(&dummy)
 
 	assert.Nil(suite.T(), err)
-	assert.Equal(suite.T(), dummy, actual)
+	assert.Equal(suite.T(), dummy.Id, actual.Id)
+	assert.Equal(suite.T(), "", actual.Password)
 }
 
 func (suite *UserRepositoryTestSuite) TestInsertUser_Failed() {
@@ -210,14 +251,38 @@ func (suite *UserRepositoryTestSuite) TestInsertUser_Failed() {
 
 func (suite *UserRepositoryTestSuite) TestUpdateUser_Success() {
 	var dummy = dummyUsers[0]
+	dummy.Password = "dummy password"
 
-	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_UPDATE_TEST)).WithArgs(dummy.Name, dummy.Username, dummy.Password, dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_UPDATE_TEST)).WithArgs(dummy.Name, dummy.Username, hashedPassword{dummy.Password}, dummy.Role, dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	repo := repository.NewUserRepository(suite.mockSqlxDb)
 	actual, err := repo.Update(&dummy)
 
 	assert.Nil(suite.T(), err)
-	assert.Equal(suite.T(), dummy, actual)
+	assert.Equal(suite.T(), dummy.Name, actual.Name)
+	assert.Equal(suite.T(), "", actual.Password)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdatePasswordUser_Success() {
+	var dummy = dummyUsers[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_UPDATE_PASSWORD)).WithArgs(hashedPassword{"new password"}, dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.UpdatePassword(dummy.Id, "new password")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserRepositoryTestSuite) TestUpdatePasswordUser_Failed() {
+	var dummy = dummyUsers[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_UPDATE_PASSWORD)).WillReturnError(errors.New("update failed"))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.UpdatePassword(dummy.Id, "new password")
+
+	assert.NotNil(suite.T(), err)
 }
 
 func (suite *UserRepositoryTestSuite) TestUpdateUser_Failed() {
@@ -232,6 +297,26 @@ func (suite *UserRepositoryTestSuite) TestUpdateUser_Failed() {
 	assert.Equal(suite.T(), model.User{}, actual)
 }
 
+func (suite *UserRepositoryTestSuite) TestSetRoleUser_Success() {
+	var dummy = dummyUsers[1]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_SET_ROLE)).WithArgs(model.RoleOwner, dummy.Username).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.SetRole(dummy.Username, model.RoleOwner)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserRepositoryTestSuite) TestSetRoleUser_NotFound() {
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_SET_ROLE)).WithArgs(model.RoleOwner, "nobody").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.SetRole("nobody", model.RoleOwner)
+
+	assert.EqualError(suite.T(), err, "user nobody not found")
+}
+
 func (suite *UserRepositoryTestSuite) TestDeleteUser_Success() {
 	var dummy = dummyUsers[0]
 
@@ -243,6 +328,40 @@ func (suite *UserRepositoryTestSuite) TestDeleteUser_Success() {
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *UserRepositoryTestSuite) TestDeleteUser_AlreadyArchived() {
+	var dummy = dummyUsers[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_DELETE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.Delete(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *UserRepositoryTestSuite) TestRestoreUser_Success() {
+	var dummy = dummyUsers[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_RESTORE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.Restore(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserRepositoryTestSuite) TestRestoreUser_NotArchived() {
+	var dummy = dummyUsers[0]
+
+	suite.mockSql.ExpectExec(regexp.QuoteMeta(utils.USER_RESTORE)).WithArgs(dummy.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := repository.NewUserRepository(suite.mockSqlxDb)
+	err := repo.Restore(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "deleted user "+dummy.Id+" not found")
+}
+
 func (suite *UserRepositoryTestSuite) TestDeleteUser_Failed() {
 	var dummy = dummyUsers[0]
 
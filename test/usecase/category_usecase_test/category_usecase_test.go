@@ -0,0 +1,107 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyCategory = model.Category{
+	Id:           "dummy category 1",
+	Name:         "rice",
+	DisplayOrder: 1,
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+type CategoryUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *repoMock
+}
+
+func (r *repoMock) GetAll() ([]model.Category, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Category), nil
+}
+
+func (r *repoMock) GetById(id string) (model.Category, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *repoMock) Insert(category *model.Category) (model.Category, error) {
+	args := r.Called(category)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *repoMock) Update(category *model.Category) (model.Category, error) {
+	args := r.Called(category)
+	if args.Get(1) != nil {
+		return model.Category{}, args.Error(1)
+	}
+	return args.Get(0).(model.Category), nil
+}
+
+func (r *repoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (suite *CategoryUsecaseTestSuite) TestCategoryInsert_Success() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.Category")).Return(dummyCategory, nil)
+
+	categoryUsecaseTest := usecase.NewCategoryUsecase(suite.repoMock)
+	category := model.Category{Name: " rice ", DisplayOrder: 1}
+	_, err := categoryUsecaseTest.Insert(&category)
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Category)
+	assert.Equal(suite.T(), "rice", inserted.Name)
+	assert.NotEmpty(suite.T(), inserted.Id)
+}
+
+func (suite *CategoryUsecaseTestSuite) TestCategoryInsert_NoName() {
+	categoryUsecaseTest := usecase.NewCategoryUsecase(suite.repoMock)
+	category := model.Category{Name: "  "}
+	_, err := categoryUsecaseTest.Insert(&category)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{"name": "name is required"}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *CategoryUsecaseTestSuite) TestCategoryUpdate_KeepsId() {
+	suite.repoMock.On("Update", mock.AnythingOfType("*model.Category")).Return(dummyCategory, nil)
+
+	categoryUsecaseTest := usecase.NewCategoryUsecase(suite.repoMock)
+	category := dummyCategory
+	_, err := categoryUsecaseTest.Update(&category)
+
+	assert.Nil(suite.T(), err)
+	updated := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Category)
+	assert.Equal(suite.T(), dummyCategory.Id, updated.Id)
+}
+
+func (suite *CategoryUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+}
+
+func TestCategoryUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(CategoryUsecaseTestSuite))
+}
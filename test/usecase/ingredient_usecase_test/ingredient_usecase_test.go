@@ -0,0 +1,313 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyIngredient = model.Ingredient{Id: "dummy ingredient 1", Name: "rice", Unit: "g", Stock: 5000}
+
+var dummyMenu = model.Menu{Id: "dummy menu 1", Name: "nasi goreng", Price: 10000}
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) GetAll() ([]model.Ingredient, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (r *repoMock) GetById(id string) (model.Ingredient, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *repoMock) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *repoMock) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *repoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *repoMock) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *repoMock) SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error) {
+	args := r.Called(menuId, lines)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *repoMock) Usage(id string) (model.IngredientUsage, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.IngredientUsage{}, args.Error(1)
+	}
+	return args.Get(0).(model.IngredientUsage), nil
+}
+
+type menuRepoMock struct {
+	mock.Mock
+}
+
+func (r *menuRepoMock) GetAll() ([]model.Menu, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *menuRepoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) GetByName(name string) ([]model.Menu, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) Insert(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Update(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *menuRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type IngredientUsecaseTestSuite struct {
+	suite.Suite
+	repoMock     *repoMock
+	menuRepoMock *menuRepoMock
+}
+
+func (suite *IngredientUsecaseTestSuite) TestInsert_Success() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.Ingredient")).Return(dummyIngredient, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	actual, err := ingredientUsecaseTest.Insert(&model.Ingredient{Name: " rice ", Unit: "g", Stock: 5000, Reserved: 7})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyIngredient, actual)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Ingredient)
+	assert.NotEmpty(suite.T(), inserted.Id)
+	assert.Equal(suite.T(), "rice", inserted.Name)
+	assert.Equal(suite.T(), 0, inserted.Reserved)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestInsert_Invalid() {
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.Insert(&model.Ingredient{Name: "  ", Unit: "kg"})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"name": "name is required",
+		"unit": "unit must be g, ml or pcs",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestInsert_NegativeStock() {
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.Insert(&model.Ingredient{Name: "rice", Unit: "g", Stock: -1})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestUpdate_NegativeReorderLevel() {
+	level := -1
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.Update(&model.Ingredient{Id: "dummy ingredient 1", Name: "rice", Unit: "g", ReorderLevel: &level})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{"reorder_level": "reorder_level must not be negative"}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestDelete_Reserved() {
+	reserved := dummyIngredient
+	reserved.Reserved = 400
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(reserved, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	err := ingredientUsecaseTest.Delete("dummy ingredient 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Delete", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestDelete_HasMovements() {
+	unused := dummyIngredient
+	unused.Stock = 0
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(unused, nil)
+	suite.repoMock.On("Usage", "dummy ingredient 1").Return(model.IngredientUsage{Movements: 2, StockTakeLines: 1}, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	err := ingredientUsecaseTest.Delete("dummy ingredient 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "ingredient dummy ingredient 1 cannot be deleted, it has stock movements, stock takes")
+	suite.repoMock.AssertNotCalled(suite.T(), "Delete", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestDelete_Unused() {
+	unused := dummyIngredient
+	unused.Stock = 0
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(unused, nil)
+	suite.repoMock.On("Usage", "dummy ingredient 1").Return(model.IngredientUsage{}, nil)
+	suite.repoMock.On("Delete", "dummy ingredient 1").Return(nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	err := ingredientUsecaseTest.Delete("dummy ingredient 1")
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestUpdate_UnitOfUsedIngredient() {
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(dummyIngredient, nil)
+	suite.repoMock.On("Usage", "dummy ingredient 1").Return(model.IngredientUsage{Movements: 1, Recipes: 3}, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.Update(&model.Ingredient{Id: "dummy ingredient 1", Name: "rice", Unit: "pcs"})
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "unit of ingredient dummy ingredient 1 cannot change from g to pcs, it has stock, stock movements, recipes in g")
+	suite.repoMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestUpdate_UnitOfUnusedIngredient() {
+	unused := dummyIngredient
+	unused.Stock = 0
+	renamed := model.Ingredient{Id: "dummy ingredient 1", Name: "rice", Unit: "pcs"}
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(unused, nil)
+	suite.repoMock.On("Usage", "dummy ingredient 1").Return(model.IngredientUsage{}, nil)
+	suite.repoMock.On("Update", &renamed).Return(renamed, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	actual, err := ingredientUsecaseTest.Update(&renamed)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), "pcs", actual.Unit)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestSetRecipe_Success() {
+	lines := []model.RecipeLine{{IngredientId: "dummy ingredient 1", Qty: 200}}
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(dummyIngredient, nil)
+	suite.repoMock.On("SetRecipe", "dummy menu 1", lines).Return(lines, nil)
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	actual, err := ingredientUsecaseTest.SetRecipe("dummy menu 1", model.Recipe{Ingredients: lines})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), lines, actual)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestSetRecipe_Invalid() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("GetById", "dummy ingredient 1").Return(dummyIngredient, nil)
+	suite.repoMock.On("GetById", "dummy ingredient 9").Return(nil, apperror.NotFound("ingredient dummy ingredient 9 not found"))
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.SetRecipe("dummy menu 1", model.Recipe{Ingredients: []model.RecipeLine{
+		{IngredientId: "dummy ingredient 1", Qty: 200},
+		{IngredientId: "dummy ingredient 1", Qty: 100},
+		{IngredientId: "dummy ingredient 9", Qty: 1},
+		{IngredientId: "dummy ingredient 2", Qty: 0},
+	}})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"ingredients[1]": "ingredient dummy ingredient 1 is already in the recipe",
+		"ingredients[2]": "ingredient dummy ingredient 9 not found",
+		"ingredients[3]": "qty must be at least 1",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "SetRecipe", mock.Anything, mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) TestSetRecipe_ArchivedMenu() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(nil, apperror.NotFound("menu dummy menu 1 not found"))
+
+	ingredientUsecaseTest := usecase.NewIngredientUsecase(suite.repoMock, suite.menuRepoMock)
+	_, err := ingredientUsecaseTest.SetRecipe("dummy menu 1", model.Recipe{})
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "SetRecipe", mock.Anything, mock.Anything)
+}
+
+func (suite *IngredientUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+	suite.menuRepoMock = new(menuRepoMock)
+}
+
+func TestIngredientUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(IngredientUsecaseTestSuite))
+}
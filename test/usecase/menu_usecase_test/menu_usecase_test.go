@@ -3,8 +3,10 @@ package usecase_test
 import (
 	"errors"
 	"testing"
+	"time"
 	"warung-makan/model"
 	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +20,7 @@ var dummyMenus = []model.Menu{
 		Price: 113,
 		Stock: 999,
 		Image: "dummy image path 1",
+		Tags:  []string{"spicy"},
 	},
 	{
 		Id:    "dummy id 2",
@@ -25,6 +28,7 @@ var dummyMenus = []model.Menu{
 		Price: 123,
 		Stock: 999,
 		Image: "dummy image path 2",
+		Tags:  []string{},
 	},
 }
 
@@ -45,8 +49,16 @@ func (r *repoMock) GetAll() ([]model.Menu, error) {
 	return args.Get(0).([]model.Menu), nil
 }
 
-func (r *repoMock) GetById(id string) (model.Menu, error) {
-	args := r.Called(id)
+func (r *repoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *repoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
 	if args.Get(1) != nil {
 		return model.Menu{}, args.Error(1)
 	}
@@ -77,6 +89,11 @@ func (r *repoMock) Update(newUser *model.Menu) (model.Menu, error) {
 	return args.Get(0).(model.Menu), nil
 }
 
+func (r *repoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
 func (r *repoMock) Delete(id string) error {
 	args := r.Called(id)
 	if args.Get(0) != nil {
@@ -88,7 +105,7 @@ func (r *repoMock) Delete(id string) error {
 func (suite *MenuUsecaseTestSuite) TestMenuGetAll_Success() {
 	suite.repoMock.On("GetAll").Return(dummyMenus, nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menus, err := MenuUsecaseTest.GetAll()
 
 	assert.Nil(suite.T(), err)
@@ -98,7 +115,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuGetAll_Success() {
 func (suite *MenuUsecaseTestSuite) TestMenuGetAll_Failed() {
 	suite.repoMock.On("GetAll").Return(nil, errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menus, err := MenuUsecaseTest.GetAll()
 
 	assert.Error(suite.T(), err)
@@ -107,10 +124,10 @@ func (suite *MenuUsecaseTestSuite) TestMenuGetAll_Failed() {
 
 func (suite *MenuUsecaseTestSuite) TestMenuGetById_Success() {
 	dummy := dummyMenus[0]
-	suite.repoMock.On("GetById", dummy.Id).Return(dummy, nil)
+	suite.repoMock.On("GetById", dummy.Id, false).Return(dummy, nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
-	menu, err := MenuUsecaseTest.GetById(dummy.Id)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	menu, err := MenuUsecaseTest.GetById(dummy.Id, false)
 
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), dummy, menu)
@@ -118,10 +135,10 @@ func (suite *MenuUsecaseTestSuite) TestMenuGetById_Success() {
 
 func (suite *MenuUsecaseTestSuite) TestMenuGetById_Failed() {
 	dummy := dummyMenus[0]
-	suite.repoMock.On("GetById", dummy.Id).Return(model.Menu{}, errors.New("failed"))
+	suite.repoMock.On("GetById", dummy.Id, false).Return(model.Menu{}, errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
-	menu, err := MenuUsecaseTest.GetById(dummy.Id)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	menu, err := MenuUsecaseTest.GetById(dummy.Id, false)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), model.Menu{}, menu)
@@ -131,7 +148,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuGetByName_Success() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("GetByName", dummy.Name).Return(dummyMenus, nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menus, err := MenuUsecaseTest.GetByName(dummy.Name)
 
 	assert.Nil(suite.T(), err)
@@ -142,7 +159,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuGetByName_Failed() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("GetByName", dummy.Name).Return(nil, errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menus, err := MenuUsecaseTest.GetByName(dummy.Name)
 
 	assert.Error(suite.T(), err)
@@ -153,7 +170,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuInsert_Success() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Insert", &dummy).Return(dummy, nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menu, err := MenuUsecaseTest.Insert(&dummy)
 
 	assert.Nil(suite.T(), err)
@@ -165,7 +182,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuInsert_Failed() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Insert", &dummy).Return(model.Menu{}, errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menu, err := MenuUsecaseTest.Insert(&dummy)
 
 	assert.Error(suite.T(), err)
@@ -173,11 +190,65 @@ func (suite *MenuUsecaseTestSuite) TestMenuInsert_Failed() {
 
 }
 
+func (suite *MenuUsecaseTestSuite) TestMenuInsert_Normalized() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.Menu")).Return(dummyMenus[0], nil)
+
+	category, from, until := " ", "6:00", "22:30"
+	menu := dummyMenus[1]
+	menu.CategoryId = &category
+	menu.Tags = []string{" Spicy", "spicy", "", "Vegetarian"}
+	menu.AvailableFrom, menu.AvailableUntil = &from, &until
+
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	_, err := MenuUsecaseTest.Insert(&menu)
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Menu)
+	assert.Nil(suite.T(), inserted.CategoryId)
+	assert.Equal(suite.T(), []string{"spicy", "vegetarian"}, []string(inserted.Tags))
+	assert.Equal(suite.T(), "06:00", *inserted.AvailableFrom)
+	assert.Equal(suite.T(), "22:30", *inserted.AvailableUntil)
+}
+
+func (suite *MenuUsecaseTestSuite) TestMenuInsert_InvalidWindow() {
+	from, until := "7 am", "10:00"
+	menu := dummyMenus[1]
+	menu.AvailableFrom, menu.AvailableUntil = &from, &until
+
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	_, err := MenuUsecaseTest.Insert(&menu)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"available_from": "available_from must be a time of day as 15:04",
+	}, apperror.DetailsOf(err))
+
+	menu.AvailableFrom = nil
+	_, err = MenuUsecaseTest.Insert(&menu)
+
+	assert.Equal(suite.T(), map[string]string{
+		"available_from": "available_from and available_until go together",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *MenuUsecaseTestSuite) TestMenuList_AvailableNow() {
+	suite.repoMock.On("List", mock.AnythingOfType("model.MenuFilter")).Return(dummyMenus, model.PageInfo{}, nil)
+
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	_, _, err := MenuUsecaseTest.List(model.MenuFilter{Tag: " Spicy ", AvailableNow: true})
+
+	assert.Nil(suite.T(), err)
+	filter := suite.repoMock.Calls[0].Arguments.Get(0).(model.MenuFilter)
+	assert.Equal(suite.T(), "spicy", filter.Tag)
+	assert.Regexp(suite.T(), `^\d{2}:\d{2}$`, filter.AvailableAt)
+}
+
 func (suite *MenuUsecaseTestSuite) TestMenuUpdate_Success() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Update", &dummy).Return(dummy, nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menu, err := MenuUsecaseTest.Update(&dummy)
 
 	assert.Nil(suite.T(), err)
@@ -189,7 +260,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuUpdate_Failed() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Update", &dummy).Return(model.Menu{}, errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	menu, err := MenuUsecaseTest.Update(&dummy)
 
 	assert.Error(suite.T(), err)
@@ -201,7 +272,7 @@ func (suite *MenuUsecaseTestSuite) TestMenuDelete_Success() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Delete", dummy.Id).Return(nil)
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	err := MenuUsecaseTest.Delete(dummy.Id)
 
 	assert.Nil(suite.T(), err)
@@ -211,12 +282,35 @@ func (suite *MenuUsecaseTestSuite) TestMenuDelete_Failed() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("Delete", dummy.Id).Return(errors.New("failed"))
 
-	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock)
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
 	err := MenuUsecaseTest.Delete(dummy.Id)
 
 	assert.Error(suite.T(), err)
 }
 
+func (suite *MenuUsecaseTestSuite) TestMenuRestore_Success() {
+	dummy := dummyMenus[0]
+	suite.repoMock.On("Restore", dummy.Id).Return(nil)
+	suite.repoMock.On("GetById", dummy.Id, false).Return(dummy, nil)
+
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	restored, err := MenuUsecaseTest.Restore(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, restored)
+}
+
+func (suite *MenuUsecaseTestSuite) TestMenuRestore_NotArchived() {
+	dummy := dummyMenus[0]
+	suite.repoMock.On("Restore", dummy.Id).Return(apperror.NotFound("deleted menu " + dummy.Id + " not found"))
+
+	MenuUsecaseTest := usecase.NewMenuUsecase(suite.repoMock, time.UTC)
+	_, err := MenuUsecaseTest.Restore(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "GetById", dummy.Id, false)
+}
+
 func (suite *MenuUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(repoMock)
 }
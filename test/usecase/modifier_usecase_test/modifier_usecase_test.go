@@ -0,0 +1,208 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyMenu = model.Menu{Id: "dummy menu 1", Name: "nasi goreng", Price: 10000}
+
+var dummyGroup = model.ModifierGroup{
+	Id:        1,
+	MenuId:    "dummy menu 1",
+	Name:      "size",
+	MinSelect: 1,
+	MaxSelect: 1,
+	Options: []model.ModifierOption{
+		{Id: 11, GroupId: 1, Name: "regular"},
+		{Id: 12, GroupId: 1, Name: "large", PriceDelta: 3000},
+	},
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) GetByMenu(menuId string) ([]model.ModifierGroup, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ModifierGroup), nil
+}
+
+func (r *repoMock) Insert(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	args := r.Called(group)
+	if args.Get(1) != nil {
+		return model.ModifierGroup{}, args.Error(1)
+	}
+	return args.Get(0).(model.ModifierGroup), nil
+}
+
+func (r *repoMock) Update(group *model.ModifierGroup) (model.ModifierGroup, error) {
+	args := r.Called(group)
+	if args.Get(1) != nil {
+		return model.ModifierGroup{}, args.Error(1)
+	}
+	return args.Get(0).(model.ModifierGroup), nil
+}
+
+func (r *repoMock) Delete(menuId string, groupId int64) error {
+	args := r.Called(menuId, groupId)
+	return args.Error(0)
+}
+
+type menuRepoMock struct {
+	mock.Mock
+}
+
+func (r *menuRepoMock) GetAll() ([]model.Menu, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *menuRepoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) GetByName(name string) ([]model.Menu, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) Insert(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Update(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *menuRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type ModifierUsecaseTestSuite struct {
+	suite.Suite
+	repoMock     *repoMock
+	menuRepoMock *menuRepoMock
+}
+
+func (suite *ModifierUsecaseTestSuite) TestModifierInsert_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.ModifierGroup")).Return(dummyGroup, nil)
+
+	modifierUsecaseTest := usecase.NewModifierUsecase(suite.repoMock, suite.menuRepoMock)
+	group := model.ModifierGroup{MenuId: "dummy menu 1", Name: " size ", MinSelect: 1, Options: []model.ModifierOption{
+		{Id: 5, Name: "regular"},
+		{Name: " large ", PriceDelta: 3000},
+	}}
+	actual, err := modifierUsecaseTest.Insert(&group)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyGroup, actual)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.ModifierGroup)
+	assert.Equal(suite.T(), "size", inserted.Name)
+	assert.Equal(suite.T(), 1, inserted.MaxSelect)
+	assert.Equal(suite.T(), int64(0), inserted.Options[0].Id)
+	assert.Equal(suite.T(), "large", inserted.Options[1].Name)
+}
+
+func (suite *ModifierUsecaseTestSuite) TestModifierInsert_Invalid() {
+	modifierUsecaseTest := usecase.NewModifierUsecase(suite.repoMock, suite.menuRepoMock)
+	group := model.ModifierGroup{MenuId: "dummy menu 1", Name: "topping", MinSelect: 1, MaxSelect: 3, Options: []model.ModifierOption{
+		{Name: "egg"},
+		{Name: "Egg"},
+	}}
+	_, err := modifierUsecaseTest.Insert(&group)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"max_select":      "max_select must be at most the 2 options",
+		"options[1].name": "Egg is already an option of the group",
+	}, apperror.DetailsOf(err))
+	suite.menuRepoMock.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *ModifierUsecaseTestSuite) TestModifierInsert_ArchivedMenu() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(nil, apperror.NotFound("menu dummy menu 1 not found"))
+
+	modifierUsecaseTest := usecase.NewModifierUsecase(suite.repoMock, suite.menuRepoMock)
+	group := dummyGroup
+	_, err := modifierUsecaseTest.Insert(&group)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *ModifierUsecaseTestSuite) TestModifierUpdate_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Update", mock.AnythingOfType("*model.ModifierGroup")).Return(dummyGroup, nil)
+
+	modifierUsecaseTest := usecase.NewModifierUsecase(suite.repoMock, suite.menuRepoMock)
+	group := dummyGroup
+	_, err := modifierUsecaseTest.Update(&group)
+
+	assert.Nil(suite.T(), err)
+	updated := suite.repoMock.Calls[0].Arguments.Get(0).(*model.ModifierGroup)
+	assert.Equal(suite.T(), int64(11), updated.Options[0].Id)
+}
+
+func (suite *ModifierUsecaseTestSuite) TestModifierGetByMenu_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("GetByMenu", "dummy menu 1").Return([]model.ModifierGroup{dummyGroup}, nil)
+
+	modifierUsecaseTest := usecase.NewModifierUsecase(suite.repoMock, suite.menuRepoMock)
+	actual, err := modifierUsecaseTest.GetByMenu("dummy menu 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.ModifierGroup{dummyGroup}, actual)
+}
+
+func (suite *ModifierUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+	suite.menuRepoMock = new(menuRepoMock)
+}
+
+func TestModifierUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ModifierUsecaseTestSuite))
+}
@@ -0,0 +1,130 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyPromo = model.Promo{
+	Code:     "HEMAT10",
+	Discount: model.Discount{Type: model.DiscountPercent, Value: 10},
+	StartsAt: time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC),
+	Active:   true,
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+type PromoUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *repoMock
+}
+
+func (r *repoMock) GetAll() ([]model.Promo, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Promo), nil
+}
+
+func (r *repoMock) GetByCode(code string) (model.Promo, error) {
+	args := r.Called(code)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (r *repoMock) Insert(promo *model.Promo) (model.Promo, error) {
+	args := r.Called(promo)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (r *repoMock) Update(promo *model.Promo) (model.Promo, error) {
+	args := r.Called(promo)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (suite *PromoUsecaseTestSuite) TestPromoGetByCode_Normalized() {
+	suite.repoMock.On("GetByCode", "HEMAT10").Return(dummyPromo, nil)
+
+	promoUsecaseTest := usecase.NewPromoUsecase(suite.repoMock)
+	promo, err := promoUsecaseTest.GetByCode(" hemat10")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyPromo, promo)
+}
+
+func (suite *PromoUsecaseTestSuite) TestPromoInsert_Success() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.Promo")).Return(dummyPromo, nil)
+
+	promoUsecaseTest := usecase.NewPromoUsecase(suite.repoMock)
+	promo := model.Promo{Code: "hemat10", Discount: model.Discount{Type: model.DiscountPercent, Value: 10}}
+	_, err := promoUsecaseTest.Insert(&promo)
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Promo)
+	assert.Equal(suite.T(), "HEMAT10", inserted.Code)
+	assert.True(suite.T(), inserted.Active)
+	assert.False(suite.T(), inserted.StartsAt.IsZero())
+}
+
+func (suite *PromoUsecaseTestSuite) TestPromoInsert_Invalid() {
+	ends := dummyPromo.StartsAt.Add(-time.Hour)
+	limit := 0
+
+	promoUsecaseTest := usecase.NewPromoUsecase(suite.repoMock)
+	promo := model.Promo{
+		Code:       "hemat 10",
+		Discount:   model.Discount{Type: model.DiscountFixed},
+		StartsAt:   dummyPromo.StartsAt,
+		EndsAt:     &ends,
+		UsageLimit: &limit,
+	}
+	_, err := promoUsecaseTest.Insert(&promo)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"code":        "code must be 1 to 40 letters, digits, - or _",
+		"discount":    "value must be at least 1",
+		"ends_at":     "ends_at must be after starts_at",
+		"usage_limit": "usage_limit must be at least 1, not 0",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *PromoUsecaseTestSuite) TestPromoUpdate_KeepsActive() {
+	suite.repoMock.On("Update", mock.AnythingOfType("*model.Promo")).Return(dummyPromo, nil)
+
+	promoUsecaseTest := usecase.NewPromoUsecase(suite.repoMock)
+	promo := dummyPromo
+	promo.Active = false
+	_, err := promoUsecaseTest.Update(&promo)
+
+	assert.Nil(suite.T(), err)
+	updated := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Promo)
+	assert.False(suite.T(), updated.Active)
+}
+
+func (suite *PromoUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+}
+
+func TestPromoUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(PromoUsecaseTestSuite))
+}
@@ -0,0 +1,399 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var riceId, eggId, menuId = "dummy ingredient 1", "dummy ingredient 2", "dummy menu 1"
+
+var dummySupplier = model.Supplier{Id: "dummy supplier 1", Name: "pasar induk"}
+
+func orderedPurchase() model.PurchaseOrder {
+	return model.PurchaseOrder{
+		Id:         "dummy po 1",
+		SupplierId: dummySupplier.Id,
+		Status:     model.PurchaseOrdered,
+		Lines: []model.PurchaseOrderLine{
+			{Id: 1, IngredientId: &riceId, Name: "rice", Qty: 5000, UnitCost: 14},
+			{Id: 2, IngredientId: &eggId, Name: "egg", Qty: 30, UnitCost: 2000, ReceivedQty: 10},
+		},
+	}
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PurchaseOrder), nil
+}
+
+func (r *repoMock) GetById(id string) (model.PurchaseOrder, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *repoMock) Insert(order *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	args := r.Called(order)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *repoMock) Update(order *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	args := r.Called(order)
+	if args.Get(1) != nil {
+		return model.PurchaseOrder{}, args.Error(1)
+	}
+	return args.Get(0).(model.PurchaseOrder), nil
+}
+
+func (r *repoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *repoMock) SetOrdered(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *repoMock) Receive(id string, lines []model.ReceiveLine, note *string, userId string) error {
+	args := r.Called(id, lines, note, userId)
+	return args.Error(0)
+}
+
+type supplierRepoMock struct {
+	mock.Mock
+}
+
+func (r *supplierRepoMock) GetAll() ([]model.Supplier, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Supplier), nil
+}
+
+func (r *supplierRepoMock) GetById(id string) (model.Supplier, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *supplierRepoMock) Insert(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *supplierRepoMock) Update(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *supplierRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type menuRepoMock struct {
+	mock.Mock
+}
+
+func (r *menuRepoMock) GetAll() ([]model.Menu, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *menuRepoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) GetByName(name string) ([]model.Menu, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) Insert(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Update(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *menuRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type ingredientRepoMock struct {
+	mock.Mock
+}
+
+func (r *ingredientRepoMock) GetAll() ([]model.Ingredient, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) GetById(id string) (model.Ingredient, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *ingredientRepoMock) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error) {
+	args := r.Called(menuId, lines)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) Usage(id string) (model.IngredientUsage, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.IngredientUsage{}, args.Error(1)
+	}
+	return args.Get(0).(model.IngredientUsage), nil
+}
+
+type PurchaseOrderUsecaseTestSuite struct {
+	suite.Suite
+	repoMock           *repoMock
+	supplierRepoMock   *supplierRepoMock
+	menuRepoMock       *menuRepoMock
+	ingredientRepoMock *ingredientRepoMock
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) usecase() usecase.PurchaseOrderUsecase {
+	return usecase.NewPurchaseOrderUsecase(suite.repoMock, suite.supplierRepoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestInsert_Success() {
+	suite.supplierRepoMock.On("GetById", dummySupplier.Id).Return(dummySupplier, nil)
+	suite.ingredientRepoMock.On("GetById", riceId).Return(model.Ingredient{Id: riceId}, nil)
+	suite.menuRepoMock.On("GetById", menuId, false).Return(model.Menu{Id: menuId}, nil)
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.PurchaseOrder")).Return(orderedPurchase(), nil)
+
+	blank := "  "
+	_, err := suite.usecase().Insert(&model.PurchaseOrder{
+		SupplierId: dummySupplier.Id,
+		Note:       &blank,
+		Lines: []model.PurchaseOrderLine{
+			{IngredientId: &riceId, Qty: 5000, UnitCost: 14},
+			{MenuId: &menuId, Qty: 20, UnitCost: 6000},
+		},
+	}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.PurchaseOrder)
+	assert.NotEmpty(suite.T(), inserted.Id)
+	assert.Equal(suite.T(), "user 1", *inserted.UserId)
+	assert.Nil(suite.T(), inserted.Note)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestInsert_Invalid() {
+	suite.supplierRepoMock.On("GetById", "dummy supplier 9").Return(nil, apperror.NotFound("supplier dummy supplier 9 not found"))
+	suite.ingredientRepoMock.On("GetById", riceId).Return(model.Ingredient{Id: riceId}, nil)
+	suite.ingredientRepoMock.On("GetById", eggId).Return(nil, apperror.NotFound("ingredient "+eggId+" not found"))
+
+	_, err := suite.usecase().Insert(&model.PurchaseOrder{
+		SupplierId: "dummy supplier 9",
+		Lines: []model.PurchaseOrderLine{
+			{IngredientId: &riceId, Qty: 5000, UnitCost: 14},
+			{IngredientId: &riceId, Qty: 1000, UnitCost: 14},
+			{IngredientId: &eggId, Qty: 30, UnitCost: 2000},
+			{IngredientId: &riceId, MenuId: &menuId, Qty: 1},
+			{MenuId: &menuId, Qty: 0},
+			{MenuId: &menuId, Qty: 1, UnitCost: -1},
+		},
+	}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"supplier_id": "supplier dummy supplier 9 not found",
+		"lines[1]":    "ingredient dummy ingredient 1 is already in the purchase order",
+		"lines[2]":    "ingredient dummy ingredient 2 not found",
+		"lines[3]":    "a line is of either a menu_id or an ingredient_id",
+		"lines[4]":    "qty must be at least 1",
+		"lines[5]":    "unit_cost must not be negative",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestUpdate_NotDraft() {
+	suite.repoMock.On("GetById", "dummy po 1").Return(orderedPurchase(), nil)
+
+	_, err := suite.usecase().Update(&model.PurchaseOrder{Id: "dummy po 1", SupplierId: dummySupplier.Id})
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestOrder_Success() {
+	draft := orderedPurchase()
+	draft.Status = model.PurchaseDraft
+	suite.repoMock.On("GetById", "dummy po 1").Return(draft, nil).Once()
+	suite.repoMock.On("SetOrdered", "dummy po 1").Return(nil)
+	suite.repoMock.On("GetById", "dummy po 1").Return(orderedPurchase(), nil).Once()
+
+	actual, err := suite.usecase().Order("dummy po 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.PurchaseOrdered, actual.Status)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestReceive_DefaultsToExpectedCost() {
+	actualCost := 1800
+	suite.repoMock.On("GetById", "dummy po 1").Return(orderedPurchase(), nil)
+	suite.repoMock.On("Receive", "dummy po 1", mock.Anything, mock.Anything, "user 1").Return(nil)
+
+	_, err := suite.usecase().Receive("dummy po 1", model.ReceiveRequest{
+		Lines: []model.ReceiveLine{{LineId: 1, Qty: 5000}, {LineId: 2, Qty: 20, UnitCost: &actualCost}},
+		Note:  " first delivery ",
+	}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	lines := suite.repoMock.Calls[1].Arguments.Get(1).([]model.ReceiveLine)
+	note := suite.repoMock.Calls[1].Arguments.Get(2).(*string)
+	assert.Equal(suite.T(), 14, *lines[0].UnitCost)
+	assert.Equal(suite.T(), 1800, *lines[1].UnitCost)
+	assert.Equal(suite.T(), "first delivery", *note)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestReceive_Invalid() {
+	suite.repoMock.On("GetById", "dummy po 1").Return(orderedPurchase(), nil)
+
+	_, err := suite.usecase().Receive("dummy po 1", model.ReceiveRequest{Lines: []model.ReceiveLine{
+		{LineId: 2, Qty: 21},
+		{LineId: 9, Qty: 1},
+		{LineId: 1, Qty: 0},
+		{LineId: 2, Qty: 1},
+	}}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"lines[0]": "20 of egg are left to receive",
+		"lines[1]": "line 9 is not in the purchase order",
+		"lines[2]": "qty must be at least 1",
+		"lines[3]": "line 2 is already received",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Receive", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestReceive_Draft() {
+	draft := orderedPurchase()
+	draft.Status = model.PurchaseDraft
+	suite.repoMock.On("GetById", "dummy po 1").Return(draft, nil)
+
+	_, err := suite.usecase().Receive("dummy po 1", model.ReceiveRequest{Lines: []model.ReceiveLine{{LineId: 1, Qty: 1}}}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) TestList_InvalidStatus() {
+	_, err := suite.usecase().List(model.PurchaseFilter{Status: "lost"})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *PurchaseOrderUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+	suite.supplierRepoMock = new(supplierRepoMock)
+	suite.menuRepoMock = new(menuRepoMock)
+	suite.ingredientRepoMock = new(ingredientRepoMock)
+}
+
+func TestPurchaseOrderUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(PurchaseOrderUsecaseTestSuite))
+}
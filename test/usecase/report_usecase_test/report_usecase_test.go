@@ -0,0 +1,244 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var location, _ = time.LoadLocation("Asia/Jakarta")
+
+var dummyMenuSales = []model.MenuSales{
+	{MenuId: "menu 1", Name: "nasi goreng", Qty: 3, Revenue: 45000, Transactions: 2},
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+type ReportUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *repoMock
+}
+
+var dummyPaymentSales = []model.PaymentSales{
+	{Method: model.PaymentCash, Transactions: 2, Revenue: 20000},
+	{Method: model.PaymentQRIS, Transactions: 1, Revenue: 10000},
+}
+
+func (r *repoMock) Summary(from, to time.Time) (model.SalesSummary, error) {
+	args := r.Called(from, to)
+	if args.Get(1) != nil {
+		return model.SalesSummary{}, args.Error(1)
+	}
+	return args.Get(0).(model.SalesSummary), nil
+}
+
+func (r *repoMock) ByPeriod(from, to time.Time, period string, location *time.Location) ([]model.SalesPeriod, error) {
+	args := r.Called(from, to, period, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SalesPeriod), nil
+}
+
+func (r *repoMock) MenuSales(from, to time.Time, sort string, limit int) ([]model.MenuSales, error) {
+	args := r.Called(from, to, sort, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.MenuSales), nil
+}
+
+func (r *repoMock) PaymentSales(from, to time.Time) ([]model.PaymentSales, error) {
+	args := r.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PaymentSales), nil
+}
+
+func (r *repoMock) SupplierSpending(from, to time.Time) ([]model.SupplierSpending, error) {
+	args := r.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.SupplierSpending), nil
+}
+
+func (r *repoMock) ShrinkageByPeriod(from, to time.Time, period string, location *time.Location) ([]model.ShrinkagePeriod, error) {
+	args := r.Called(from, to, period, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ShrinkagePeriod), nil
+}
+
+func (r *repoMock) ShrinkageByItem(from, to time.Time) ([]model.ShrinkageItem, error) {
+	args := r.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ShrinkageItem), nil
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, location)
+	return &t
+}
+
+func (suite *ReportUsecaseTestSuite) TestDaily_FillsEmptyDays() {
+	from, to := date(2022, time.October, 1), date(2022, time.October, 4)
+	summary := model.SalesSummary{Transactions: 2, GrossRevenue: 30000, AverageTicket: 15000}
+	suite.repoMock.On("Summary", *from, *to).Return(summary, nil)
+	suite.repoMock.On("ByPeriod", *from, *to, model.PeriodDay, location).Return([]model.SalesPeriod{
+		{Period: "2022-10-02", SalesSummary: summary},
+	}, nil)
+	suite.repoMock.On("MenuSales", *from, *to, model.MenuSalesByQty, model.TopMenuCount).Return(dummyMenuSales, nil)
+	suite.repoMock.On("PaymentSales", *from, *to).Return(dummyPaymentSales, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Daily(model.ReportFilter{From: from, To: to})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), summary, report.Summary)
+	assert.Equal(suite.T(), []model.SalesPeriod{
+		{Period: "2022-10-01"},
+		{Period: "2022-10-02", SalesSummary: summary},
+		{Period: "2022-10-03"},
+	}, report.Periods)
+	assert.Equal(suite.T(), dummyMenuSales, report.Menus)
+}
+
+func (suite *ReportUsecaseTestSuite) TestDaily_DefaultsToToday() {
+	suite.repoMock.On("Summary", mock.Anything, mock.Anything).Return(model.SalesSummary{}, nil)
+	suite.repoMock.On("ByPeriod", mock.Anything, mock.Anything, model.PeriodDay, location).Return([]model.SalesPeriod{}, nil)
+	suite.repoMock.On("MenuSales", mock.Anything, mock.Anything, model.MenuSalesByQty, model.TopMenuCount).Return([]model.MenuSales{}, nil)
+	suite.repoMock.On("PaymentSales", mock.Anything, mock.Anything).Return(dummyPaymentSales, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Daily(model.ReportFilter{})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(report.Periods))
+	assert.Equal(suite.T(), time.Now().In(location).Format("2006-01-02"), report.Periods[0].Period)
+	assert.Equal(suite.T(), report.From.AddDate(0, 0, 1), report.To)
+}
+
+func (suite *ReportUsecaseTestSuite) TestDaily_InvalidRange() {
+	_, err := usecase.NewReportUsecase(suite.repoMock, location).Daily(model.ReportFilter{
+		From: date(2022, time.October, 4),
+		To:   date(2022, time.October, 1),
+	})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Summary", mock.Anything, mock.Anything)
+}
+
+func (suite *ReportUsecaseTestSuite) TestDaily_RangeTooLong() {
+	_, err := usecase.NewReportUsecase(suite.repoMock, location).Daily(model.ReportFilter{
+		From: date(2020, time.January, 1),
+		To:   date(2022, time.January, 1),
+	})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+}
+
+func (suite *ReportUsecaseTestSuite) TestMonthly_DefaultsToThisYear() {
+	suite.repoMock.On("Summary", mock.Anything, mock.Anything).Return(model.SalesSummary{}, nil)
+	suite.repoMock.On("ByPeriod", mock.Anything, mock.Anything, model.PeriodMonth, location).Return([]model.SalesPeriod{}, nil)
+	suite.repoMock.On("MenuSales", mock.Anything, mock.Anything, model.MenuSalesByQty, model.TopMenuCount).Return([]model.MenuSales{}, nil)
+	suite.repoMock.On("PaymentSales", mock.Anything, mock.Anything).Return(dummyPaymentSales, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Monthly(model.ReportFilter{})
+
+	now := time.Now().In(location)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), *date(now.Year(), time.January, 1), report.From)
+	assert.Equal(suite.T(), int(now.Month()), len(report.Periods))
+	assert.Equal(suite.T(), now.Format("2006-01"), report.Periods[len(report.Periods)-1].Period)
+}
+
+func (suite *ReportUsecaseTestSuite) TestMenu_Success() {
+	from, to := date(2022, time.October, 1), date(2022, time.October, 2)
+	suite.repoMock.On("Summary", *from, *to).Return(model.SalesSummary{}, nil)
+	suite.repoMock.On("MenuSales", *from, *to, model.MenuSalesByRevenue, 3).Return(dummyMenuSales, nil)
+	suite.repoMock.On("PaymentSales", *from, *to).Return(dummyPaymentSales, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Menu(model.ReportFilter{From: from, To: to, Sort: model.MenuSalesByRevenue, Limit: 3})
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), report.Periods)
+	assert.Equal(suite.T(), dummyMenuSales, report.Menus)
+	assert.Equal(suite.T(), dummyPaymentSales, report.Payments)
+}
+
+func (suite *ReportUsecaseTestSuite) TestMenu_InvalidSort() {
+	_, err := usecase.NewReportUsecase(suite.repoMock, location).Menu(model.ReportFilter{Sort: "name"})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+}
+
+func (suite *ReportUsecaseTestSuite) TestMenu_Failed() {
+	suite.repoMock.On("Summary", mock.Anything, mock.Anything).Return(nil, errors.New("failed"))
+
+	_, err := usecase.NewReportUsecase(suite.repoMock, location).Menu(model.ReportFilter{})
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ReportUsecaseTestSuite) TestSuppliers_Totals() {
+	from, to := date(2022, time.October, 1), date(2022, time.October, 31)
+	spending := []model.SupplierSpending{
+		{SupplierId: "supplier 1", Name: "pasar induk", PurchaseOrders: 2, Spent: 450000},
+		{SupplierId: "supplier 2", Name: "toko beras", PurchaseOrders: 1, Spent: 120000},
+	}
+	suite.repoMock.On("SupplierSpending", *from, *to).Return(spending, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Suppliers(model.ReportFilter{From: from, To: to})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 570000, report.Total)
+	assert.Equal(suite.T(), spending, report.Suppliers)
+}
+
+func (suite *ReportUsecaseTestSuite) TestShrinkage_FillsEmptyMonths() {
+	from, to := date(2022, time.August, 1), date(2022, time.November, 1)
+	october := model.ShrinkageSummary{StockTakes: 2, Lost: 28000, Found: 4000, Unvalued: 1}
+	items := []model.ShrinkageItem{{Kind: model.StockItemIngredient, Id: "ingredient 1", Name: "rice", Unit: "g", Variance: -2000, ShrinkageSummary: october}}
+	suite.repoMock.On("ShrinkageByPeriod", *from, *to, model.PeriodMonth, location).Return([]model.ShrinkagePeriod{
+		{Period: "2022-10", ShrinkageSummary: october},
+	}, nil)
+	suite.repoMock.On("ShrinkageByItem", *from, *to).Return(items, nil)
+
+	report, err := usecase.NewReportUsecase(suite.repoMock, location).Shrinkage(model.ReportFilter{From: from, To: to, Period: model.PeriodMonth})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), october, report.Summary)
+	assert.Equal(suite.T(), []model.ShrinkagePeriod{
+		{Period: "2022-08"},
+		{Period: "2022-09"},
+		{Period: "2022-10", ShrinkageSummary: october},
+	}, report.Periods)
+	assert.Equal(suite.T(), items, report.Items)
+}
+
+func (suite *ReportUsecaseTestSuite) TestShrinkage_InvalidPeriod() {
+	_, err := usecase.NewReportUsecase(suite.repoMock, location).Shrinkage(model.ReportFilter{Period: "week"})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "ShrinkageByPeriod", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *ReportUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+}
+
+func TestReportUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(ReportUsecaseTestSuite))
+}
@@ -0,0 +1,320 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var riceId, menuId = "dummy ingredient 1", "dummy menu 1"
+
+func countingTake() model.StockTake {
+	return model.StockTake{Id: "dummy take 1", Status: model.StockTakeCounting, Lines: []model.StockTakeLine{}}
+}
+
+func counted(n int) *int {
+	return &n
+}
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) List(status string) ([]model.StockTake, error) {
+	args := r.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockTake), nil
+}
+
+func (r *repoMock) GetById(id string) (model.StockTake, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *repoMock) Insert(stockTake *model.StockTake) (model.StockTake, error) {
+	args := r.Called(stockTake)
+	if args.Get(1) != nil {
+		return model.StockTake{}, args.Error(1)
+	}
+	return args.Get(0).(model.StockTake), nil
+}
+
+func (r *repoMock) Count(id string, counts []model.StockCount, userId string) error {
+	args := r.Called(id, counts, userId)
+	return args.Error(0)
+}
+
+func (r *repoMock) Approve(id string, userId string) error {
+	args := r.Called(id, userId)
+	return args.Error(0)
+}
+
+func (r *repoMock) Cancel(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type menuRepoMock struct {
+	mock.Mock
+}
+
+func (r *menuRepoMock) GetAll() ([]model.Menu, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *menuRepoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) GetByName(name string) ([]model.Menu, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) Insert(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Update(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *menuRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type ingredientRepoMock struct {
+	mock.Mock
+}
+
+func (r *ingredientRepoMock) GetAll() ([]model.Ingredient, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) GetById(id string) (model.Ingredient, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *ingredientRepoMock) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error) {
+	args := r.Called(menuId, lines)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) Usage(id string) (model.IngredientUsage, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.IngredientUsage{}, args.Error(1)
+	}
+	return args.Get(0).(model.IngredientUsage), nil
+}
+
+type StockTakeUsecaseTestSuite struct {
+	suite.Suite
+	repoMock           *repoMock
+	menuRepoMock       *menuRepoMock
+	ingredientRepoMock *ingredientRepoMock
+}
+
+func (suite *StockTakeUsecaseTestSuite) usecase() usecase.StockTakeUsecase {
+	return usecase.NewStockTakeUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestInsert_Success() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.StockTake")).Return(countingTake(), nil)
+
+	note := " closing "
+	_, err := suite.usecase().Insert(&model.StockTake{Note: &note}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.StockTake)
+	assert.NotEmpty(suite.T(), inserted.Id)
+	assert.Equal(suite.T(), "user 1", *inserted.UserId)
+	assert.Equal(suite.T(), "closing", *inserted.Note)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestCount_Success() {
+	countedAt := time.Date(2022, time.October, 31, 21, 0, 0, 0, time.UTC)
+	after := countingTake()
+	after.Lines = []model.StockTakeLine{{Id: 1, IngredientId: &riceId, Name: "rice", Expected: 2000, Counted: 1800, Variance: -200, CountedAt: countedAt}}
+	counts := []model.StockCount{{IngredientId: &riceId, Counted: counted(1800)}}
+	suite.repoMock.On("GetById", "dummy take 1").Return(countingTake(), nil).Once()
+	suite.ingredientRepoMock.On("GetById", riceId).Return(model.Ingredient{Id: riceId}, nil)
+	suite.repoMock.On("Count", "dummy take 1", counts, "user 1").Return(nil)
+	suite.repoMock.On("GetById", "dummy take 1").Return(after, nil).Once()
+
+	actual, err := suite.usecase().Count("dummy take 1", model.StockCountRequest{Counts: counts}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), after, actual)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestCount_Invalid() {
+	suite.repoMock.On("GetById", "dummy take 1").Return(countingTake(), nil)
+	suite.ingredientRepoMock.On("GetById", riceId).Return(model.Ingredient{Id: riceId}, nil)
+	suite.menuRepoMock.On("GetById", menuId, false).Return(nil, apperror.NotFound("menu "+menuId+" not found"))
+
+	_, err := suite.usecase().Count("dummy take 1", model.StockCountRequest{Counts: []model.StockCount{
+		{IngredientId: &riceId, Counted: counted(1800)},
+		{IngredientId: &riceId, Counted: counted(1700)},
+		{MenuId: &menuId, Counted: counted(3)},
+		{MenuId: &menuId, IngredientId: &riceId, Counted: counted(1)},
+		{IngredientId: &riceId, Counted: counted(-1)},
+		{IngredientId: &riceId},
+	}}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"counts[1]": "ingredient dummy ingredient 1 is already counted",
+		"counts[2]": "menu dummy menu 1 not found",
+		"counts[3]": "a count is of either a menu_id or an ingredient_id",
+		"counts[4]": "counted must not be negative",
+		"counts[5]": "counted is required",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Count", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestCount_Approved() {
+	approved := countingTake()
+	approved.Status = model.StockTakeApproved
+	suite.repoMock.On("GetById", "dummy take 1").Return(approved, nil)
+
+	_, err := suite.usecase().Count("dummy take 1", model.StockCountRequest{Counts: []model.StockCount{{IngredientId: &riceId, Counted: counted(1)}}}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestApprove_NothingCounted() {
+	suite.repoMock.On("GetById", "dummy take 1").Return(countingTake(), nil)
+
+	_, err := suite.usecase().Approve("dummy take 1", "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Approve", mock.Anything, mock.Anything)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestApprove_Success() {
+	take := countingTake()
+	take.Lines = []model.StockTakeLine{{Id: 1, IngredientId: &riceId, Expected: 2000, Counted: 1800, Variance: -200}}
+	approved := take
+	approved.Status = model.StockTakeApproved
+	suite.repoMock.On("GetById", "dummy take 1").Return(take, nil).Once()
+	suite.repoMock.On("Approve", "dummy take 1", "user 1").Return(nil)
+	suite.repoMock.On("GetById", "dummy take 1").Return(approved, nil).Once()
+
+	actual, err := suite.usecase().Approve("dummy take 1", "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.StockTakeApproved, actual.Status)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestCancel_NotCounting() {
+	cancelled := countingTake()
+	cancelled.Status = model.StockTakeCancelled
+	suite.repoMock.On("GetById", "dummy take 1").Return(cancelled, nil)
+
+	_, err := suite.usecase().Cancel("dummy take 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Cancel", mock.Anything)
+}
+
+func (suite *StockTakeUsecaseTestSuite) TestList_InvalidStatus() {
+	_, err := suite.usecase().List("lost")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "List", mock.Anything)
+}
+
+func (suite *StockTakeUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+	suite.menuRepoMock = new(menuRepoMock)
+	suite.ingredientRepoMock = new(ingredientRepoMock)
+}
+
+func TestStockTakeUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(StockTakeUsecaseTestSuite))
+}
@@ -0,0 +1,311 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummyMenu = model.Menu{Id: "dummy menu 1", Name: "nasi goreng", Price: 10000, Stock: 10, Reserved: 4}
+
+var dummyIngredient = model.Ingredient{Id: "dummy ingredient 1", Name: "rice", Unit: "g", Stock: 1000, Reserved: 300}
+
+var (
+	menuItem       = model.StockItem{Kind: model.StockItemMenu, Id: "dummy menu 1"}
+	ingredientItem = model.StockItem{Kind: model.StockItemIngredient, Id: "dummy ingredient 1"}
+)
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) GetByItem(item model.StockItem) ([]model.StockMovement, error) {
+	args := r.Called(item)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockMovement), nil
+}
+
+func (r *repoMock) Move(movement *model.StockMovement) (bool, error) {
+	args := r.Called(movement)
+	return args.Bool(0), args.Error(1)
+}
+
+func (r *repoMock) Alerts() ([]model.StockAlert, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.StockAlert), nil
+}
+
+type menuRepoMock struct {
+	mock.Mock
+}
+
+func (r *menuRepoMock) GetAll() ([]model.Menu, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Menu), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *menuRepoMock) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) GetByName(name string) ([]model.Menu, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (r *menuRepoMock) Insert(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Update(menu *model.Menu) (model.Menu, error) {
+	args := r.Called(menu)
+	if args.Get(1) != nil {
+		return model.Menu{}, args.Error(1)
+	}
+	return args.Get(0).(model.Menu), nil
+}
+
+func (r *menuRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *menuRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type ingredientRepoMock struct {
+	mock.Mock
+}
+
+func (r *ingredientRepoMock) GetAll() ([]model.Ingredient, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) GetById(id string) (model.Ingredient, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Insert(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Update(ingredient *model.Ingredient) (model.Ingredient, error) {
+	args := r.Called(ingredient)
+	if args.Get(1) != nil {
+		return model.Ingredient{}, args.Error(1)
+	}
+	return args.Get(0).(model.Ingredient), nil
+}
+
+func (r *ingredientRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *ingredientRepoMock) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	args := r.Called(menuId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) SetRecipe(menuId string, lines []model.RecipeLine) ([]model.RecipeLine, error) {
+	args := r.Called(menuId, lines)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (r *ingredientRepoMock) Usage(id string) (model.IngredientUsage, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.IngredientUsage{}, args.Error(1)
+	}
+	return args.Get(0).(model.IngredientUsage), nil
+}
+
+type StockUsecaseTestSuite struct {
+	suite.Suite
+	repoMock           *repoMock
+	menuRepoMock       *menuRepoMock
+	ingredientRepoMock *ingredientRepoMock
+}
+
+func (suite *StockUsecaseTestSuite) TestRestock_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(true, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	movement, err := stockUsecaseTest.Restock(menuItem, model.StockRequest{Qty: 5, Note: " morning delivery "}, "user 1")
+
+	menuId, note, userId := "dummy menu 1", "morning delivery", "user 1"
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.StockMovement{MenuId: &menuId, Qty: 5, Reason: model.StockRestock, Note: &note, UserId: &userId}, movement)
+}
+
+func (suite *StockUsecaseTestSuite) TestRestock_InvalidQty() {
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.Restock(menuItem, model.StockRequest{Qty: -5}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Move", mock.Anything)
+}
+
+func (suite *StockUsecaseTestSuite) TestRestock_ArchivedMenu() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(nil, apperror.NotFound("menu dummy menu 1 not found"))
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.Restock(menuItem, model.StockRequest{Qty: 5}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Move", mock.Anything)
+}
+
+func (suite *StockUsecaseTestSuite) TestWaste_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(true, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	movement, err := stockUsecaseTest.Waste(menuItem, model.StockRequest{Qty: 2}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), -2, movement.Qty)
+	assert.Equal(suite.T(), model.StockWaste, movement.Reason)
+	assert.Nil(suite.T(), movement.Note)
+}
+
+func (suite *StockUsecaseTestSuite) TestWaste_Reserved() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(false, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.Waste(menuItem, model.StockRequest{Qty: 7}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "menu dummy menu 1 has 6 in stock that is not reserved, 7 cannot be taken")
+}
+
+func (suite *StockUsecaseTestSuite) TestAdjust_NeedsNote() {
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.Adjust(menuItem, model.StockRequest{Qty: -1, Note: "  "}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{"note": "an adjustment needs a note"}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Move", mock.Anything)
+}
+
+func (suite *StockUsecaseTestSuite) TestAdjust_Success() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", false).Return(dummyMenu, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(true, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	movement, err := stockUsecaseTest.Adjust(menuItem, model.StockRequest{Qty: -1, Note: "miscounted"}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), -1, movement.Qty)
+	assert.Equal(suite.T(), model.StockAdjustment, movement.Reason)
+}
+
+func (suite *StockUsecaseTestSuite) TestGetByItem_IncludesArchived() {
+	suite.menuRepoMock.On("GetById", "dummy menu 1", true).Return(dummyMenu, nil)
+	suite.repoMock.On("GetByItem", menuItem).Return([]model.StockMovement{}, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	actual, err := stockUsecaseTest.GetByItem(menuItem)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.StockMovement{}, actual)
+}
+
+func (suite *StockUsecaseTestSuite) TestGetByItem_UnknownIngredient() {
+	suite.ingredientRepoMock.On("GetById", "dummy ingredient 1").Return(nil, apperror.NotFound("ingredient dummy ingredient 1 not found"))
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.GetByItem(ingredientItem)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "GetByItem", mock.Anything)
+}
+
+func (suite *StockUsecaseTestSuite) TestRestock_Ingredient() {
+	suite.ingredientRepoMock.On("GetById", "dummy ingredient 1").Return(dummyIngredient, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(true, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	movement, err := stockUsecaseTest.Restock(ingredientItem, model.StockRequest{Qty: 5000}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), movement.MenuId)
+	assert.Equal(suite.T(), "dummy ingredient 1", *movement.IngredientId)
+	assert.Equal(suite.T(), 5000, movement.Qty)
+	suite.menuRepoMock.AssertNotCalled(suite.T(), "GetById", mock.Anything, mock.Anything)
+}
+
+func (suite *StockUsecaseTestSuite) TestWaste_IngredientReserved() {
+	suite.ingredientRepoMock.On("GetById", "dummy ingredient 1").Return(dummyIngredient, nil)
+	suite.repoMock.On("Move", mock.AnythingOfType("*model.StockMovement")).Return(false, nil)
+
+	stockUsecaseTest := usecase.NewStockUsecase(suite.repoMock, suite.menuRepoMock, suite.ingredientRepoMock)
+	_, err := stockUsecaseTest.Waste(ingredientItem, model.StockRequest{Qty: 800}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "ingredient dummy ingredient 1 has 700 in stock that is not reserved, 800 cannot be taken")
+}
+
+func (suite *StockUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+	suite.menuRepoMock = new(menuRepoMock)
+	suite.ingredientRepoMock = new(ingredientRepoMock)
+}
+
+func TestStockUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(StockUsecaseTestSuite))
+}
@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"testing"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var dummySupplier = model.Supplier{Id: "dummy supplier 1", Name: "pasar induk"}
+
+type repoMock struct {
+	mock.Mock
+}
+
+func (r *repoMock) GetAll() ([]model.Supplier, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Supplier), nil
+}
+
+func (r *repoMock) GetById(id string) (model.Supplier, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *repoMock) Insert(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *repoMock) Update(supplier *model.Supplier) (model.Supplier, error) {
+	args := r.Called(supplier)
+	if args.Get(1) != nil {
+		return model.Supplier{}, args.Error(1)
+	}
+	return args.Get(0).(model.Supplier), nil
+}
+
+func (r *repoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+type SupplierUsecaseTestSuite struct {
+	suite.Suite
+	repoMock *repoMock
+}
+
+func (suite *SupplierUsecaseTestSuite) TestInsert_Success() {
+	suite.repoMock.On("Insert", mock.AnythingOfType("*model.Supplier")).Return(dummySupplier, nil)
+
+	phone, email := " 0812 ", ""
+	actual, err := usecase.NewSupplierUsecase(suite.repoMock).Insert(&model.Supplier{Name: " pasar induk ", Phone: &phone, Email: &email})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummySupplier, actual)
+	inserted := suite.repoMock.Calls[0].Arguments.Get(0).(*model.Supplier)
+	assert.NotEmpty(suite.T(), inserted.Id)
+	assert.Equal(suite.T(), "pasar induk", inserted.Name)
+	assert.Equal(suite.T(), "0812", *inserted.Phone)
+	assert.Nil(suite.T(), inserted.Email)
+}
+
+func (suite *SupplierUsecaseTestSuite) TestUpdate_Invalid() {
+	email := "pasar induk"
+	_, err := usecase.NewSupplierUsecase(suite.repoMock).Update(&model.Supplier{Id: dummySupplier.Id, Name: " ", Email: &email})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"name":  "name is required",
+		"email": "email must be an email address",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *SupplierUsecaseTestSuite) SetupTest() {
+	suite.repoMock = new(repoMock)
+}
+
+func TestSupplierUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(SupplierUsecaseTestSuite))
+}
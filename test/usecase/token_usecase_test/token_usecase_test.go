@@ -0,0 +1,302 @@
+package usecase_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"warung-makan/config"
+	"warung-makan/model"
+	"warung-makan/usecase"
+	"warung-makan/utils/authenticator"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var tokenConfig = config.TokenConfig{
+	ApplicationName:      "warung_makan_test",
+	JwtSignatureKey:      "test secret",
+	JwtSigningMethod:     jwt.SigningMethodHS256,
+	AccessTokenLifetime:  time.Minute * 15,
+	RefreshTokenLifetime: time.Hour,
+}
+
+var dummyUser = model.User{
+	Id:       "dummy user id 1",
+	Name:     "dummy name 1",
+	Username: "dummy username 1",
+	Role:     model.RoleCashier,
+}
+
+type tokenRepoMock struct {
+	mock.Mock
+}
+
+type userRepoMock struct {
+	mock.Mock
+}
+
+type TokenUsecaseTestSuite struct {
+	suite.Suite
+	tokenRepoMock *tokenRepoMock
+	userRepoMock  *userRepoMock
+	accessToken   authenticator.AccessToken
+}
+
+func (r *tokenRepoMock) GetRefreshTokenByHash(hash string) (model.RefreshToken, error) {
+	args := r.Called(hash)
+	if args.Get(1) != nil {
+		return model.RefreshToken{}, args.Error(1)
+	}
+	return args.Get(0).(model.RefreshToken), nil
+}
+
+func (r *tokenRepoMock) InsertRefreshToken(refreshToken *model.RefreshToken) error {
+	args := r.Called(refreshToken)
+	return args.Error(0)
+}
+
+func (r *tokenRepoMock) RevokeRefreshToken(id string) (bool, error) {
+	args := r.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (r *tokenRepoMock) RevokeRefreshTokenByAccessJti(jti string) error {
+	args := r.Called(jti)
+	return args.Error(0)
+}
+
+func (r *tokenRepoMock) RevokeUserRefreshTokens(userId string) ([]string, error) {
+	args := r.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), nil
+}
+
+func (r *tokenRepoMock) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	args := r.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (r *tokenRepoMock) IsAccessTokenRevoked(jti string) (bool, error) {
+	args := r.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (r *userRepoMock) GetAll() ([]model.User, error) {
+	args := r.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.User), nil
+}
+
+func (r *userRepoMock) List(filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *userRepoMock) GetById(id string, includeDeleted bool) (model.User, error) {
+	args := r.Called(id, includeDeleted)
+	if args.Get(1) != nil {
+		return model.User{}, args.Error(1)
+	}
+	return args.Get(0).(model.User), nil
+}
+
+func (r *userRepoMock) GetByName(name string) ([]model.User, error) {
+	args := r.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.User), nil
+}
+
+func (r *userRepoMock) GetByUsername(username string) (model.User, error) {
+	args := r.Called(username)
+	if args.Get(1) != nil {
+		return model.User{}, args.Error(1)
+	}
+	return args.Get(0).(model.User), nil
+}
+
+func (r *userRepoMock) GetPasswordById(id string) (string, error) {
+	args := r.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (r *userRepoMock) Insert(user *model.User) (model.User, error) {
+	args := r.Called(user)
+	if args.Get(1) != nil {
+		return model.User{}, args.Error(1)
+	}
+	return args.Get(0).(model.User), nil
+}
+
+func (r *userRepoMock) Update(user *model.User) (model.User, error) {
+	args := r.Called(user)
+	if args.Get(1) != nil {
+		return model.User{}, args.Error(1)
+	}
+	return args.Get(0).(model.User), nil
+}
+
+func (r *userRepoMock) UpdatePassword(id, password string) error {
+	args := r.Called(id, password)
+	return args.Error(0)
+}
+
+func (r *userRepoMock) SetRole(username, role string) error {
+	args := r.Called(username, role)
+	return args.Error(0)
+}
+
+func (r *userRepoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (r *userRepoMock) Delete(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
+func (suite *TokenUsecaseTestSuite) newUsecase() usecase.TokenUsecase {
+	return usecase.NewTokenUsecase(suite.tokenRepoMock, suite.userRepoMock, suite.accessToken, tokenConfig)
+}
+
+func (suite *TokenUsecaseTestSuite) TestIssue_Success() {
+	suite.tokenRepoMock.On("InsertRefreshToken", mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	tokenPair, err := suite.newUsecase().Issue(&dummyUser)
+
+	assert.Nil(suite.T(), err)
+	assert.NotEmpty(suite.T(), tokenPair.RefreshToken)
+	assert.Equal(suite.T(), 900, tokenPair.ExpiresIn)
+
+	claims, err := suite.accessToken.VerifyToken(tokenPair.AccessToken)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyUser.Id, claims["user_id"])
+	assert.Equal(suite.T(), dummyUser.Role, claims["role"])
+
+	stored := suite.tokenRepoMock.Calls[0].Arguments.Get(0).(*model.RefreshToken)
+	assert.Equal(suite.T(), claims["jti"], stored.AccessJti)
+	assert.Equal(suite.T(), authenticator.HashRefreshToken(tokenPair.RefreshToken), stored.TokenHash)
+}
+
+func (suite *TokenUsecaseTestSuite) TestIssue_Failed() {
+	suite.tokenRepoMock.On("InsertRefreshToken", mock.AnythingOfType("*model.RefreshToken")).Return(errors.New("failed"))
+
+	tokenPair, err := suite.newUsecase().Issue(&dummyUser)
+
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), model.TokenPair{}, tokenPair)
+}
+
+func (suite *TokenUsecaseTestSuite) TestRefresh_Success() {
+	stored := model.RefreshToken{
+		Id:        "dummy refresh id",
+		UserId:    dummyUser.Id,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	suite.tokenRepoMock.On("GetRefreshTokenByHash", authenticator.HashRefreshToken("refresh token")).Return(stored, nil)
+	suite.tokenRepoMock.On("RevokeRefreshToken", stored.Id).Return(true, nil)
+	suite.tokenRepoMock.On("InsertRefreshToken", mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+	suite.userRepoMock.On("GetById", dummyUser.Id, false).Return(dummyUser, nil)
+
+	tokenPair, err := suite.newUsecase().Refresh("refresh token")
+
+	assert.Nil(suite.T(), err)
+	assert.NotEqual(suite.T(), "refresh token", tokenPair.RefreshToken)
+	suite.tokenRepoMock.AssertCalled(suite.T(), "RevokeRefreshToken", stored.Id)
+}
+
+func (suite *TokenUsecaseTestSuite) TestRefresh_Revoked() {
+	stored := model.RefreshToken{
+		Id:        "dummy refresh id",
+		UserId:    dummyUser.Id,
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	suite.tokenRepoMock.On("GetRefreshTokenByHash", authenticator.HashRefreshToken("refresh token")).Return(stored, nil)
+
+	_, err := suite.newUsecase().Refresh("refresh token")
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidRefreshToken)
+	suite.tokenRepoMock.AssertNotCalled(suite.T(), "RevokeRefreshToken", stored.Id)
+}
+
+func (suite *TokenUsecaseTestSuite) TestRefresh_Expired() {
+	stored := model.RefreshToken{
+		Id:        "dummy refresh id",
+		UserId:    dummyUser.Id,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+	suite.tokenRepoMock.On("GetRefreshTokenByHash", authenticator.HashRefreshToken("refresh token")).Return(stored, nil)
+
+	_, err := suite.newUsecase().Refresh("refresh token")
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidRefreshToken)
+}
+
+func (suite *TokenUsecaseTestSuite) TestRefresh_UsedConcurrently() {
+	stored := model.RefreshToken{
+		Id:        "dummy refresh id",
+		UserId:    dummyUser.Id,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	suite.tokenRepoMock.On("GetRefreshTokenByHash", authenticator.HashRefreshToken("refresh token")).Return(stored, nil)
+	suite.tokenRepoMock.On("RevokeRefreshToken", stored.Id).Return(false, nil)
+
+	_, err := suite.newUsecase().Refresh("refresh token")
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidRefreshToken)
+	suite.tokenRepoMock.AssertNotCalled(suite.T(), "InsertRefreshToken", mock.Anything)
+}
+
+func (suite *TokenUsecaseTestSuite) TestLogout_Success() {
+	expiresAt := time.Now().Add(time.Minute)
+	suite.tokenRepoMock.On("RevokeAccessToken", "dummy jti", expiresAt).Return(nil)
+	suite.tokenRepoMock.On("RevokeRefreshTokenByAccessJti", "dummy jti").Return(nil)
+
+	err := suite.newUsecase().Logout("dummy jti", expiresAt)
+
+	assert.Nil(suite.T(), err)
+	suite.tokenRepoMock.AssertExpectations(suite.T())
+}
+
+func (suite *TokenUsecaseTestSuite) TestRevokeUserSessions_Success() {
+	suite.tokenRepoMock.On("RevokeUserRefreshTokens", dummyUser.Id).Return([]string{"jti 1", "jti 2"}, nil)
+	suite.tokenRepoMock.On("RevokeAccessToken", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := suite.newUsecase().RevokeUserSessions(dummyUser.Id)
+
+	assert.Nil(suite.T(), err)
+	suite.tokenRepoMock.AssertNumberOfCalls(suite.T(), "RevokeAccessToken", 2)
+}
+
+func (suite *TokenUsecaseTestSuite) TestIsRevoked_Success() {
+	suite.tokenRepoMock.On("IsAccessTokenRevoked", "dummy jti").Return(true, nil)
+
+	revoked, err := suite.newUsecase().IsRevoked("dummy jti")
+
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), revoked)
+}
+
+func (suite *TokenUsecaseTestSuite) SetupTest() {
+	suite.tokenRepoMock = new(tokenRepoMock)
+	suite.userRepoMock = new(userRepoMock)
+	suite.accessToken = authenticator.NewAccessToken(tokenConfig)
+}
+
+func TestTokenUsecaseTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenUsecaseTestSuite))
+}
@@ -1,12 +1,16 @@
 package usecase_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"testing"
 	"time"
+	"warung-makan/config"
 	"warung-makan/model"
+	"warung-makan/repository"
 	"warung-makan/usecase"
+	"warung-makan/utils/apperror"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -28,13 +32,70 @@ var dummyMenus = []model.Transaction{
 	},
 }
 
+// a paid transaction, menu 1 is on two items
+var dummyPaidTransaction = model.Transaction{
+	Id:         "dummy id 1",
+	TotalPrice: 42500,
+	Status:     model.StatusPaid,
+	Items: []model.TransactionDetail{
+		{Id: 1, TransactionId: "dummy id 1", MenuId: "menu 1", Qty: 2, Subtotal: 20000},
+		{Id: 2, TransactionId: "dummy id 1", MenuId: "menu 2", Qty: 1, Subtotal: 12500},
+		{Id: 3, TransactionId: "dummy id 1", MenuId: "menu 1", Qty: 1, Subtotal: 10000},
+	},
+}
+
+var dummyOrderMenus = []model.Menu{
+	{Id: "menu 1", Name: "nasi goreng", Price: 10000, Stock: 5},
+	{Id: "menu 2", Name: "es teh", Price: 12500, Stock: 5},
+}
+
+// menu 1 is ordered on two lines
+var dummyOrderItems = []model.TransactionDetail{
+	{MenuId: "menu 1", Qty: 2},
+	{MenuId: "menu 2", Qty: 1},
+	{MenuId: "menu 1", Qty: 1},
+}
+
+// pays the 42500 of dummyOrderItems exactly
+var dummyCash = []model.Payment{{Method: model.PaymentCash, Amount: 42500}}
+
+// saleMovement is a movement of stock by transaction, userId is left out
+// when empty.
+func saleMovement(kind, id string, qty int, reason, transactionId, userId string) model.StockMovement {
+	movement := model.StockItem{Kind: kind, Id: id}.Movement(qty, reason)
+	movement.TransactionId = &transactionId
+	if userId != "" {
+		movement.UserId = &userId
+	}
+	return movement
+}
+
 type repoMock struct {
 	mock.Mock
 }
 
+type txMock struct {
+	mock.Mock
+}
+
+type notifierMock struct {
+	mock.Mock
+}
+
+func (n *notifierMock) Notify(alerts []model.StockAlert) error {
+	args := n.Called(alerts)
+	return args.Error(0)
+}
+
+func (n *notifierMock) Close(ctx context.Context) error {
+	return nil
+}
+
 type TransactionUsecaseTestSuite struct {
 	suite.Suite
-	repoMock *repoMock
+	repoMock     *repoMock
+	txMock       *txMock
+	notifierMock *notifierMock
 }
 
 func (r *repoMock) GetAll() ([]model.Transaction, error) {
@@ -45,6 +106,28 @@ func (r *repoMock) GetAll() ([]model.Transaction, error) {
 	return args.Get(0).([]model.Transaction), nil
 }
 
+func (r *repoMock) List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.Transaction), args.Get(1).(model.PageInfo), nil
+}
+
+// Export passes the transactions given to Return to each, then returns the
+// error.
+func (r *repoMock) Export(filter model.TransactionFilter, each func(model.Transaction) error) error {
+	args := r.Called(filter)
+	if transactions, ok := args.Get(0).([]model.Transaction); ok {
+		for _, transaction := range transactions {
+			if err := each(transaction); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
 func (r *repoMock) GetById(id string) (model.Transaction, error) {
 	args := r.Called(id)
 	if args.Get(1) != nil {
@@ -53,14 +136,180 @@ func (r *repoMock) GetById(id string) (model.Transaction, error) {
 	return args.Get(0).(model.Transaction), nil
 }
 
-func (r *repoMock) Insert(menu *model.Transaction) (model.Transaction, error) {
-	args := r.Called(menu)
+func (r *repoMock) Begin() (repository.TransactionTx, error) {
+	args := r.Called()
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(repository.TransactionTx), nil
+}
+
+func (t *txMock) LockMenus(ids []string) ([]model.Menu, error) {
+	args := t.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Menu), nil
+}
+
+func (t *txMock) ModifierGroups(menuIds []string) ([]model.ModifierGroup, error) {
+	args := t.Called(menuIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ModifierGroup), nil
+}
+
+func (t *txMock) Recipes(menuIds []string) ([]model.RecipeLine, error) {
+	args := t.Called(menuIds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.RecipeLine), nil
+}
+
+func (t *txMock) LockIngredients(ids []string) ([]model.Ingredient, error) {
+	args := t.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Ingredient), nil
+}
+
+func (t *txMock) DecreaseIngredient(ingredientId string, qty int) (bool, error) {
+	args := t.Called(ingredientId, qty)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *txMock) ReserveIngredient(ingredientId string, qty int) (bool, error) {
+	args := t.Called(ingredientId, qty)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *txMock) ReleaseIngredient(ingredientId string, qty int) error {
+	args := t.Called(ingredientId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) CommitIngredient(ingredientId string, qty int) error {
+	args := t.Called(ingredientId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) IncreaseIngredient(ingredientId string, qty int) error {
+	args := t.Called(ingredientId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) InsertMovements(movements []model.StockMovement) error {
+	args := t.Called(movements)
+	return args.Error(0)
+}
+
+func (t *txMock) DecreaseStock(menuId string, qty int) (bool, error) {
+	args := t.Called(menuId, qty)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *txMock) ReserveStock(menuId string, qty int) (bool, error) {
+	args := t.Called(menuId, qty)
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *txMock) ReleaseStock(menuId string, qty int) error {
+	args := t.Called(menuId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) CommitStock(menuId string, qty int) error {
+	args := t.Called(menuId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) InsertItems(items []model.TransactionDetail) error {
+	args := t.Called(items)
+	return args.Error(0)
+}
+
+func (t *txMock) InsertPayments(payments []model.Payment) error {
+	args := t.Called(payments)
+	return args.Error(0)
+}
+
+func (t *txMock) DeleteItem(transactionId string, itemId int64) error {
+	args := t.Called(transactionId, itemId)
+	return args.Error(0)
+}
+
+func (t *txMock) SetPrices(transaction *model.Transaction) error {
+	args := t.Called(transaction)
+	return args.Error(0)
+}
+
+func (t *txMock) LockPromo(code string) (model.Promo, error) {
+	args := t.Called(code)
+	if args.Get(1) != nil {
+		return model.Promo{}, args.Error(1)
+	}
+	return args.Get(0).(model.Promo), nil
+}
+
+func (t *txMock) UsePromo(code string) error {
+	args := t.Called(code)
+	return args.Error(0)
+}
+
+func (t *txMock) ReleasePromo(code string) error {
+	args := t.Called(code)
+	return args.Error(0)
+}
+
+func (t *txMock) Insert(transaction *model.Transaction) error {
+	args := t.Called(transaction)
+	return args.Error(0)
+}
+
+func (t *txMock) LockTransaction(id string) (model.Transaction, error) {
+	args := t.Called(id)
 	if args.Get(1) != nil {
 		return model.Transaction{}, args.Error(1)
 	}
 	return args.Get(0).(model.Transaction), nil
 }
 
+func (t *txMock) RefundedQty(transactionId string) (map[int64]int, error) {
+	args := t.Called(transactionId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int64]int), nil
+}
+
+func (t *txMock) InsertRefund(refund *model.Refund) error {
+	args := t.Called(refund)
+	return args.Error(0)
+}
+
+func (t *txMock) IncreaseStock(menuId string, qty int) error {
+	args := t.Called(menuId, qty)
+	return args.Error(0)
+}
+
+func (t *txMock) SetStatus(transactionId string, status string) error {
+	args := t.Called(transactionId, status)
+	return args.Error(0)
+}
+
+func (t *txMock) Commit() error {
+	args := t.Called()
+	return args.Error(0)
+}
+
+func (t *txMock) Rollback() error {
+	args := t.Called()
+	return args.Error(0)
+}
+
 func (r *repoMock) GetAllTest() ([]model.Transaction, error) {
 	args := r.Called()
 	if args.Get(0) == nil {
@@ -88,7 +337,7 @@ func (r *repoMock) InsertTest(menu *model.TransactionTest) (model.TransactionTes
 func (suite *TransactionUsecaseTestSuite) TestTransactionGetAll_Success() {
 	suite.repoMock.On("GetAll").Return(dummyMenus, nil)
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
 	menus, err := TransactionUsecaseTest.GetAll()
 
 	assert.Nil(suite.T(), err)
@@ -98,7 +347,7 @@ func (suite *TransactionUsecaseTestSuite) TestTransactionGetAll_Success() {
 func (suite *TransactionUsecaseTestSuite) TestTransactionGetAll_Failed() {
 	suite.repoMock.On("GetAll").Return(nil, errors.New("failed"))
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
 	menus, err := TransactionUsecaseTest.GetAll()
 
 	assert.Error(suite.T(), err)
@@ -109,7 +358,7 @@ func (suite *TransactionUsecaseTestSuite) TestTransactionGetById_Success() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("GetById", dummy.Id).Return(dummy, nil)
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
 	menu, err := TransactionUsecaseTest.GetById(dummy.Id)
 
 	assert.Nil(suite.T(), err)
@@ -120,7 +369,7 @@ func (suite *TransactionUsecaseTestSuite) TestTransactionGetById_Failed() {
 	dummy := dummyMenus[0]
 	suite.repoMock.On("GetById", dummy.Id).Return(model.Transaction{}, errors.New("failed"))
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
 	menu, err := TransactionUsecaseTest.GetById(dummy.Id)
 
 	assert.Error(suite.T(), err)
@@ -128,31 +377,1040 @@ func (suite *TransactionUsecaseTestSuite) TestTransactionGetById_Failed() {
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Success() {
-	dummy := dummyMenus[0]
-	suite.repoMock.On("Insert", &dummy).Return(dummy, nil)
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", "menu 1", 3).Return(true, nil)
+	suite.txMock.On("DecreaseStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
-	menu, err := TransactionUsecaseTest.Insert(&dummy)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
 
 	assert.Nil(suite.T(), err)
-	assert.Equal(suite.T(), dummy, menu)
+	assert.NotEmpty(suite.T(), transaction.Id)
+	assert.Equal(suite.T(), 3*10000+12500, transaction.TotalPrice)
+	assert.Equal(suite.T(), 2*10000, transaction.Items[0].Subtotal)
+	assert.Equal(suite.T(), "es teh", transaction.Items[1].MenuName)
+	assert.Equal(suite.T(), 12500, transaction.Items[1].UnitPrice)
+	assert.Equal(suite.T(), transaction.Id, transaction.Items[2].TransactionId)
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemMenu, "menu 1", -3, model.StockSale, transaction.Id, ""),
+		saleMovement(model.StockItemMenu, "menu 2", -1, model.StockSale, transaction.Id, ""),
+	})
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+	suite.notifierMock.AssertNotCalled(suite.T(), "Notify", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_AlertsLowStock() {
+	menu1Level, menu2Level := 3, 6
+	menus := []model.Menu{dummyOrderMenus[0], dummyOrderMenus[1]}
+	menus[0].ReorderLevel = &menu1Level
+	// menu 2 is already under its level, it is not alerted again
+	menus[1].ReorderLevel = &menu2Level
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(menus, nil)
+	suite.txMock.On("DecreaseStock", mock.Anything, mock.Anything).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	assert.Nil(suite.T(), err)
+	suite.notifierMock.AssertCalled(suite.T(), "Notify", []model.StockAlert{
+		{Kind: model.StockItemMenu, Id: "menu 1", Name: "nasi goreng", Available: 2, ReorderLevel: 3},
+	})
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_InsufficientStock() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return([]model.Menu{
+		{Id: "menu 1", Price: 10000, Stock: 2},
+		{Id: "menu 2", Price: 12500, Stock: 0},
+	}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	none := 0
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 1, MenuId: "menu 2", Qty: 1, Reason: model.RejectInsufficientStock, Available: &none},
+		{Index: 2, MenuId: "menu 1", Qty: 1, Reason: model.RejectInsufficientStock, Available: &none},
+	}, apperror.DetailsOf(err))
+	assert.Equal(suite.T(), model.Transaction{}, transaction)
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+	suite.txMock.AssertCalled(suite.T(), "Rollback")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_StockTakenConcurrently() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", "menu 1", 3).Return(false, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_UnknownMenu() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus[:1], nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 1, MenuId: "menu 2", Qty: 1, Reason: model.RejectMenuNotFound},
+	}, apperror.DetailsOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_InvalidQty() {
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: []model.TransactionDetail{{MenuId: "menu 1", Qty: -1}}, Payments: dummyCash}, false)
 
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 0, MenuId: "menu 1", Qty: -1, Reason: model.RejectInvalidQty},
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_NoItems() {
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{}, false)
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidOrder)
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Partial() {
+	items := []model.TransactionDetail{
+		{MenuId: "menu 1", Qty: 2},
+		{MenuId: "menu x", Qty: 1},
+		{MenuId: "menu 2", Qty: 0},
+		{MenuId: "menu 1", Qty: 9},
+	}
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu x"}).Return(dummyOrderMenus[:1], nil)
+	suite.txMock.On("DecreaseStock", "menu 1", 2).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	tendered := 50000
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: items, Payments: []model.Payment{{Method: model.PaymentCash, Tendered: &tendered}}}, true)
+
+	left := 3
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(transaction.Items))
+	assert.Equal(suite.T(), 2*10000, transaction.TotalPrice)
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 1, MenuId: "menu x", Qty: 1, Reason: model.RejectMenuNotFound},
+		{Index: 2, MenuId: "menu 2", Qty: 0, Reason: model.RejectInvalidQty},
+		{Index: 3, MenuId: "menu 1", Qty: 9, Reason: model.RejectInsufficientStock, Available: &left},
+	}, transaction.RejectedItems)
+	change := 30000
+	assert.Equal(suite.T(), []model.Payment{
+		{TransactionId: transaction.Id, Method: model.PaymentCash, Amount: 20000, Tendered: &tendered, Change: &change},
+	}, transaction.Payments)
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_PartialNothingLeft() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu x"}).Return([]model.Menu{}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: []model.TransactionDetail{{MenuId: "menu x", Qty: 1}}, Payments: dummyCash}, true)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_SplitPayment() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	tendered := 50000
+	payments := []model.Payment{
+		{Method: model.PaymentCash, Tendered: &tendered},
+		{Method: model.PaymentQRIS, Amount: 12500},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: payments}, false)
+
+	change := 20000
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.Payment{
+		{TransactionId: transaction.Id, Method: model.PaymentCash, Amount: 30000, Tendered: &tendered, Change: &change},
+		{TransactionId: transaction.Id, Method: model.PaymentQRIS, Amount: 12500},
+	}, transaction.Payments)
+	inserted := suite.txMock.Calls[5].Arguments.Get(0).(*model.Transaction)
+	assert.Equal(suite.T(), transaction.Payments, inserted.Payments)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_PaymentsShort() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	payments := []model.Payment{
+		{Method: model.PaymentBankTransfer, Amount: 30000},
+		{Method: model.PaymentEWallet, Amount: 10000},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: payments}, false)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "payments of 40000 do not cover the total of 42500")
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_WithoutPayments() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems}, false)
+
+	assert.EqualError(suite.T(), err, "payments are required, the total is 42500")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_InvalidPayments() {
+	tendered := 5000
+	payments := []model.Payment{
+		{Method: "card", Amount: 10000},
+		{Method: model.PaymentQRIS, Amount: 10000, Tendered: &tendered},
+		{Method: model.PaymentCash, Amount: 10000, Tendered: &tendered},
+		{Method: model.PaymentCash},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: payments}, false)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"payments[0]": "method must be cash, qris, bank_transfer or e_wallet",
+		"payments[1]": "only cash payments have tendered",
+		"payments[2]": "tendered must be at least the amount",
+		"payments[3]": "a cash payment needs amount or tendered",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
 }
 
 func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Failed() {
-	dummy := dummyMenus[0]
-	suite.repoMock.On("Insert", &dummy).Return(model.Transaction{}, errors.New("failed"))
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(errors.New("failed"))
+	suite.txMock.On("Rollback").Return(nil)
 
-	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock)
-	menu, err := TransactionUsecaseTest.Insert(&dummy)
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
 
 	assert.Error(suite.T(), err)
-	assert.Equal(suite.T(), model.Transaction{}, menu)
+	assert.Equal(suite.T(), model.Transaction{}, transaction)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+	suite.txMock.AssertCalled(suite.T(), "Rollback")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionVoid_Success() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(dummyPaidTransaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 3).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 2", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusVoided).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	refund, err := TransactionUsecaseTest.Void("dummy id 1", model.RefundRequest{Reason: " wrong table "}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.RefundKindVoid, refund.Kind)
+	assert.Equal(suite.T(), "wrong table", refund.Reason)
+	assert.Equal(suite.T(), "user 1", refund.UserId)
+	assert.Equal(suite.T(), 42500, refund.Amount)
+	assert.Equal(suite.T(), 3, len(refund.Items))
+	transactionId, userId := "dummy id 1", "user 1"
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemMenu, "menu 1", 3, model.StockVoid, transactionId, userId),
+		saleMovement(model.StockItemMenu, "menu 2", 1, model.StockVoid, transactionId, userId),
+	})
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionVoid_ReleasesPromo() {
+	transaction := dummyPaidTransaction
+	transaction.PromoCode = "HEMAT10"
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusVoided).Return(nil)
+	suite.txMock.On("ReleasePromo", "HEMAT10").Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
 
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Void("dummy id 1", model.RefundRequest{Reason: "wrong table"}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertCalled(suite.T(), "ReleasePromo", "HEMAT10")
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionVoid_AlreadyRefunded() {
+	transaction := dummyPaidTransaction
+	transaction.Status = model.StatusPartiallyRefunded
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Void("dummy id 1", model.RefundRequest{Reason: "wrong table"}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "IncreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionVoid_WithItems() {
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Void("dummy id 1", model.RefundRequest{Reason: "wrong table", Items: []model.RefundLine{{DetailId: 1, Qty: 1}}}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_Partial() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(dummyPaidTransaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPartiallyRefunded).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	refund, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{
+		Reason: "cold food",
+		Items:  []model.RefundLine{{DetailId: 1, Qty: 1}},
+	}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), []model.RefundItem{{RefundId: refund.Id, DetailId: 1, MenuId: "menu 1", Qty: 1, Amount: 10000}}, refund.Items)
+	assert.Equal(suite.T(), 10000, refund.Amount)
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_PartialKeepsPromo() {
+	transaction := dummyPaidTransaction
+	transaction.PromoCode = "HEMAT10"
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPartiallyRefunded).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{
+		Reason: "cold food",
+		Items:  []model.RefundLine{{DetailId: 1, Qty: 1}},
+	}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "ReleasePromo", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_RestIsFullyRefunded() {
+	transaction := dummyPaidTransaction
+	transaction.Status = model.StatusPartiallyRefunded
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{1: 2, 2: 1}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusRefunded).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	refund, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{Reason: "closing early"}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 1, len(refund.Items))
+	assert.Equal(suite.T(), int64(3), refund.Items[0].DetailId)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_FullReleasesPromo() {
+	transaction := dummyPaidTransaction
+	transaction.Status = model.StatusPartiallyRefunded
+	transaction.PromoCode = "HEMAT10"
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{1: 2, 2: 1}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusRefunded).Return(nil)
+	suite.txMock.On("ReleasePromo", "HEMAT10").Return(errors.New("failed"))
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{Reason: "closing early"}, "user 1")
+
+	assert.Error(suite.T(), err)
+	suite.txMock.AssertCalled(suite.T(), "ReleasePromo", "HEMAT10")
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_TooMuch() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(dummyPaidTransaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{1: 1}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{
+		Reason: "cold food",
+		Items:  []model.RefundLine{{DetailId: 1, Qty: 2}, {DetailId: 9, Qty: 1}},
+	}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"items[0]": "only 1 of item 1 can be refunded",
+		"items[1]": "item 9 is not part of the transaction",
+	}, apperror.DetailsOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "InsertRefund", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_Voided() {
+	transaction := dummyPaidTransaction
+	transaction.Status = model.StatusVoided
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{Reason: "cold food"}, "user 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Pricing() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	items := []model.TransactionDetail{
+		{MenuId: "menu 1", Qty: 2, Discount: &model.Discount{Type: model.DiscountPercent, Value: 10}},
+		{MenuId: "menu 2", Qty: 1},
+		{MenuId: "menu 1", Qty: 1},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{ServiceRate: 500, TaxRate: 1000}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{
+		Items:    items,
+		Discount: &model.Discount{Type: model.DiscountFixed, Value: 500},
+		Payments: []model.Payment{{Method: model.PaymentQRIS, Amount: 46200}},
+	}, false)
+
+	// 42500 less 2000 and 500 is 40000, 5% service is 2000, 10% tax on 42000 is 4200
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 2000, transaction.Items[0].DiscountAmount)
+	assert.Equal(suite.T(), 42500, transaction.Subtotal)
+	assert.Equal(suite.T(), 2500, transaction.DiscountAmount)
+	assert.Equal(suite.T(), 2000, transaction.ServiceCharge)
+	assert.Equal(suite.T(), 4200, transaction.Tax)
+	assert.Equal(suite.T(), 46200, transaction.TotalPrice)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_PromoUsedUp() {
+	limit := 5
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("LockPromo", "HEMAT10").Return(model.Promo{
+		Code:       "HEMAT10",
+		Discount:   model.Discount{Type: model.DiscountPercent, Value: 10},
+		StartsAt:   time.Now().Add(-time.Hour),
+		UsageLimit: &limit,
+		Used:       5,
+		Active:     true,
+	}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, PromoCode: " hemat10 ", Payments: dummyCash}, false)
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "promo HEMAT10 is used up")
+	suite.txMock.AssertNotCalled(suite.T(), "UsePromo", mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_PromoWithDiscount() {
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{
+		Items:     dummyOrderItems,
+		Discount:  &model.Discount{Type: model.DiscountPercent, Value: 120},
+		PromoCode: "HEMAT10",
+		Payments:  dummyCash,
+	}, false)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), map[string]string{
+		"discount":   "a percent discount is at most 100",
+		"promo_code": "a promo code cannot be combined with an order discount",
+	}, apperror.DetailsOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_Promo() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusServed), nil)
+	suite.txMock.On("CommitStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(nil)
+	suite.txMock.On("LockPromo", "HEMAT").Return(model.Promo{
+		Code:     "HEMAT",
+		Discount: model.Discount{Type: model.DiscountFixed, Value: 2500},
+		StartsAt: time.Now().Add(-time.Hour),
+		Active:   true,
+	}, nil)
+	suite.txMock.On("UsePromo", "HEMAT").Return(nil)
+	suite.txMock.On("SetPrices", mock.MatchedBy(func(order *model.Transaction) bool {
+		return order.PromoCode == "HEMAT" && order.DiscountAmount == 2500 && order.TotalPrice == 40000
+	})).Return(nil)
+	suite.txMock.On("InsertPayments", mock.Anything).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPaid).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{
+		PromoCode: "hemat",
+		Payments:  []model.Payment{{Method: model.PaymentQRIS, Amount: 40000}},
+	})
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertExpectations(suite.T())
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_SharesOrderDiscount() {
+	transaction := dummyPaidTransaction
+	transaction.Subtotal = 42500
+	transaction.DiscountAmount = 4250
+	transaction.TotalPrice = 38250
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(transaction, nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{1: 1}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("IncreaseStock", "menu 1", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPartiallyRefunded).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	refund, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{
+		Reason: "cold food",
+		Items:  []model.RefundLine{{DetailId: 1, Qty: 1}},
+	}, "user 1")
+
+	// item 1 carries 18000 of the 38250, 9000 a plate
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 9000, refund.Amount)
+}
+
+// menu 1 needs a size and may take toppings
+var dummyModifierGroups = []model.ModifierGroup{
+	{Id: 1, MenuId: "menu 1", Name: "size", MinSelect: 1, MaxSelect: 1, Options: []model.ModifierOption{
+		{Id: 11, GroupId: 1, Name: "regular"},
+		{Id: 12, GroupId: 1, Name: "large", PriceDelta: 3000},
+	}},
+	{Id: 2, MenuId: "menu 1", Name: "topping", MinSelect: 0, MaxSelect: 2, Options: []model.ModifierOption{
+		{Id: 21, GroupId: 2, Name: "extra egg", PriceDelta: 4000},
+	}},
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Modifiers() {
+	suite.txMock = new(txMock)
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1"}).Return(dummyOrderMenus[:1], nil)
+	suite.txMock.On("ModifierGroups", []string{"menu 1"}).Return(dummyModifierGroups, nil)
+	suite.txMock.On("Recipes", []string{"menu 1"}).Return(nil, nil)
+	suite.txMock.On("DecreaseStock", "menu 1", 2).Return(true, nil)
+	suite.txMock.On("InsertMovements", mock.Anything).Return(nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	items := []model.TransactionDetail{
+		{MenuId: "menu 1", Qty: 2, Modifiers: model.LineModifiers{{OptionId: 12}, {OptionId: 21}}},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	payments := []model.Payment{{Method: model.PaymentCash, Amount: 34000}}
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: items, Payments: payments}, false)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 17000, transaction.Items[0].UnitPrice)
+	assert.Equal(suite.T(), 2*17000, transaction.TotalPrice)
+	assert.Equal(suite.T(), model.LineModifiers{
+		{OptionId: 12, Group: "size", Name: "large", PriceDelta: 3000},
+		{OptionId: 21, Group: "topping", Name: "extra egg", PriceDelta: 4000},
+	}, transaction.Items[0].Modifiers)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_InvalidModifiers() {
+	suite.txMock = new(txMock)
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1"}).Return(dummyOrderMenus[:1], nil)
+	suite.txMock.On("ModifierGroups", []string{"menu 1"}).Return(dummyModifierGroups, nil)
+	suite.txMock.On("Recipes", []string{"menu 1"}).Return(nil, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	items := []model.TransactionDetail{
+		{MenuId: "menu 1", Qty: 1, Modifiers: model.LineModifiers{{OptionId: 21}}},
+		{MenuId: "menu 1", Qty: 1, Modifiers: model.LineModifiers{{OptionId: 11}, {OptionId: 99}}},
+	}
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: items, Payments: dummyCash}, false)
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 0, MenuId: "menu 1", Qty: 1, Reason: model.RejectInvalidModifiers, Problem: "size needs at least 1 choice(s)"},
+		{Index: 1, MenuId: "menu 1", Qty: 1, Reason: model.RejectInvalidModifiers, Problem: "option 99 is not a modifier of menu menu 1"},
+	}, apperror.DetailsOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "Insert", mock.Anything)
+}
+
+// menu 1 is made of an egg and rice, menu 2 sells from its own stock
+var dummyRecipes = []model.RecipeLine{
+	{MenuId: "menu 1", IngredientId: "egg", Qty: 1},
+	{MenuId: "menu 1", IngredientId: "rice", Qty: 200},
+}
+
+// the rice is enough for 3 portions of menu 1
+var dummyIngredients = []model.Ingredient{
+	{Id: "egg", Name: "egg", Unit: "pcs", Stock: 10},
+	{Id: "rice", Name: "rice", Unit: "g", Stock: 700, Reserved: 100},
+}
+
+var dummyLineIngredients = model.LineIngredients{{IngredientId: "egg", Qty: 1}, {IngredientId: "rice", Qty: 200}}
+
+// recipeOrder is an order with the given status whose menu 1 lines were
+// added with dummyRecipes
+func recipeOrder(status string) model.Transaction {
+	order := order(status)
+	order.Items = append([]model.TransactionDetail(nil), order.Items...)
+	for i := range order.Items {
+		if order.Items[i].MenuId == "menu 1" {
+			order.Items[i].Ingredients = dummyLineIngredients
+		}
+	}
+	return order
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_Recipe() {
+	suite.txMock = new(txMock)
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("ModifierGroups", mock.Anything).Return(nil, nil)
+	suite.txMock.On("Recipes", []string{"menu 1", "menu 2"}).Return(dummyRecipes, nil)
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return(dummyIngredients, nil)
+	suite.txMock.On("DecreaseStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("DecreaseIngredient", "egg", 3).Return(true, nil)
+	suite.txMock.On("DecreaseIngredient", "rice", 600).Return(true, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("InsertMovements", mock.Anything).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	transaction, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummyLineIngredients, transaction.Items[0].Ingredients)
+	assert.Nil(suite.T(), transaction.Items[1].Ingredients)
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseStock", "menu 1", mock.Anything)
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemMenu, "menu 2", -1, model.StockSale, transaction.Id, ""),
+		saleMovement(model.StockItemIngredient, "egg", -3, model.StockSale, transaction.Id, ""),
+		saleMovement(model.StockItemIngredient, "rice", -600, model.StockSale, transaction.Id, ""),
+	})
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionInsert_RecipeShort() {
+	suite.txMock = new(txMock)
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("ModifierGroups", mock.Anything).Return(nil, nil)
+	suite.txMock.On("Recipes", []string{"menu 1", "menu 2"}).Return(dummyRecipes, nil)
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return([]model.Ingredient{
+		dummyIngredients[0],
+		{Id: "rice", Name: "rice", Unit: "g", Stock: 700, Reserved: 300},
+	}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Insert(&model.Transaction{Items: dummyOrderItems, Payments: dummyCash}, false)
+
+	none := 0
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 2, MenuId: "menu 1", Qty: 1, Reason: model.RejectInsufficientStock, Available: &none},
+	}, apperror.DetailsOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseIngredient", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderSend_ReservesIngredients() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(recipeOrder(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return(dummyIngredients, nil)
+	suite.txMock.On("ReserveStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("ReserveIngredient", "egg", 3).Return(true, nil)
+	suite.txMock.On("ReserveIngredient", "rice", 600).Return(true, nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusSentToKitchen).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Send("dummy id 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "Recipes", mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "ReserveStock", "menu 1", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderSend_AlertsLowIngredient() {
+	riceLevel := 500
+	ingredients := []model.Ingredient{dummyIngredients[0], dummyIngredients[1]}
+	ingredients[1].ReorderLevel = &riceLevel
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(recipeOrder(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return(ingredients, nil)
+	suite.txMock.On("ReserveStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("ReserveIngredient", mock.Anything, mock.Anything).Return(true, nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusSentToKitchen).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Send("dummy id 1")
+
+	assert.Nil(suite.T(), err)
+	suite.notifierMock.AssertCalled(suite.T(), "Notify", []model.StockAlert{
+		{Kind: model.StockItemIngredient, Id: "rice", Name: "rice", Unit: "g", Available: 0, ReorderLevel: 500},
+	})
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_CommitsReservedIngredients() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(recipeOrder(model.StatusServed), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	// the egg is no longer kept
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return(dummyIngredients[1:], nil)
+	suite.txMock.On("CommitStock", "menu 2", 1).Return(nil)
+	suite.txMock.On("CommitIngredient", "rice", 600).Return(nil)
+	suite.txMock.On("SetPrices", mock.MatchedBy(totalOf(42500))).Return(nil)
+	suite.txMock.On("InsertPayments", mock.Anything).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPaid).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{Payments: []model.Payment{{Method: model.PaymentQRIS, Amount: 42500}}})
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "CommitIngredient", "egg", mock.Anything)
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemMenu, "menu 2", -1, model.StockSale, "dummy id 1", ""),
+		saleMovement(model.StockItemIngredient, "rice", -600, model.StockSale, "dummy id 1", ""),
+	})
+}
+
+func (suite *TransactionUsecaseTestSuite) TestTransactionRefund_PutsIngredientsBack() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(recipeOrder(model.StatusPaid), nil)
+	suite.txMock.On("RefundedQty", "dummy id 1").Return(map[int64]int{}, nil)
+	suite.txMock.On("InsertRefund", mock.AnythingOfType("*model.Refund")).Return(nil)
+	suite.txMock.On("LockMenus", []string{"menu 1"}).Return(dummyOrderMenus[:1], nil)
+	suite.txMock.On("LockIngredients", []string{"egg", "rice"}).Return(dummyIngredients, nil)
+	suite.txMock.On("IncreaseIngredient", "egg", 1).Return(nil)
+	suite.txMock.On("IncreaseIngredient", "rice", 200).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPartiallyRefunded).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Refund("dummy id 1", model.RefundRequest{Reason: "cold food", Items: []model.RefundLine{{DetailId: 3, Qty: 1}}}, "user 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "IncreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemIngredient, "egg", 1, model.StockRefund, "dummy id 1", "user 1"),
+		saleMovement(model.StockItemIngredient, "rice", 200, model.StockRefund, "dummy id 1", "user 1"),
+	})
+}
+
+// totalOf matches a transaction priced at total.
+func totalOf(total int) func(*model.Transaction) bool {
+	return func(transaction *model.Transaction) bool {
+		return transaction.TotalPrice == total
+	}
+}
+
+// order returns dummyPaidTransaction as an order with the given status
+func order(status string) model.Transaction {
+	order := dummyPaidTransaction
+	order.Status = status
+	return order
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderOpen_Success() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("Insert", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("LockTransaction", mock.AnythingOfType("string")).Return(order(model.StatusOpen), nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Open(dummyOrderItems)
+
+	assert.Nil(suite.T(), err)
+	inserted := suite.txMock.Calls[3].Arguments.Get(0).(*model.Transaction)
+	assert.Equal(suite.T(), model.StatusOpen, inserted.Status)
+	assert.Equal(suite.T(), 3*10000+12500, inserted.TotalPrice)
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "ReserveStock", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderOpen_InvalidDiscount() {
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Open([]model.TransactionDetail{
+		{MenuId: "menu 1", Qty: 2, Discount: &model.Discount{Type: model.DiscountPercent, Value: 150}},
+		{MenuId: "menu 2", Qty: 1, Discount: &model.Discount{Type: "free", Value: 1000}},
+	})
+
+	assert.Equal(suite.T(), apperror.KindValidation, apperror.KindOf(err))
+	assert.Contains(suite.T(), apperror.DetailsOf(err), "items[0].discount")
+	assert.Contains(suite.T(), apperror.DetailsOf(err), "items[1].discount")
+	suite.repoMock.AssertNotCalled(suite.T(), "Begin")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderAddItems_Success() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 2"}).Return(dummyOrderMenus[1:], nil)
+	suite.txMock.On("InsertItems", []model.TransactionDetail{{TransactionId: "dummy id 1", MenuId: "menu 2", MenuName: "es teh", UnitPrice: 12500, Qty: 2, Subtotal: 25000}}).Return(nil)
+	suite.txMock.On("SetPrices", mock.MatchedBy(totalOf(42500+25000))).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.AddItems("dummy id 1", []model.TransactionDetail{{MenuId: "menu 2", Qty: 2}})
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderAddItems_AlreadySent() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusSentToKitchen), nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.AddItems("dummy id 1", []model.TransactionDetail{{MenuId: "menu 2", Qty: 2}})
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	assert.EqualError(suite.T(), err, "transaction dummy id 1 is sent_to_kitchen, it must be open")
+	suite.txMock.AssertNotCalled(suite.T(), "InsertItems", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderRemoveItem_Success() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("DeleteItem", "dummy id 1", int64(2)).Return(nil)
+	suite.txMock.On("SetPrices", mock.MatchedBy(totalOf(42500-12500))).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.RemoveItem("dummy id 1", 2)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderRemoveItem_NotFound() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.RemoveItem("dummy id 1", 9)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderSend_ReservesStock() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("ReserveStock", "menu 1", 3).Return(true, nil)
+	suite.txMock.On("ReserveStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusSentToKitchen).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Send("dummy id 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "DecreaseStock", mock.Anything, mock.Anything)
+	suite.txMock.AssertCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderSend_ReservedByOthers() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return([]model.Menu{
+		{Id: "menu 1", Price: 10000, Stock: 5, Reserved: 3},
+		{Id: "menu 2", Price: 12500, Stock: 5},
+	}, nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Send("dummy id 1")
+
+	assert.Equal(suite.T(), apperror.KindConflict, apperror.KindOf(err))
+	none := 0
+	assert.Equal(suite.T(), []model.RejectedItem{
+		{Index: 2, MenuId: "menu 1", Qty: 1, Reason: model.RejectInsufficientStock, Available: &none},
+	}, apperror.DetailsOf(err))
+	suite.txMock.AssertNotCalled(suite.T(), "ReserveStock", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_CommitsReservedStock() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusServed), nil)
+	suite.txMock.On("CommitStock", "menu 1", 3).Return(nil)
+	suite.txMock.On("CommitStock", "menu 2", 1).Return(nil)
+	suite.txMock.On("SetPrices", mock.MatchedBy(totalOf(42500))).Return(nil)
+	suite.txMock.On("InsertPayments", []model.Payment{{TransactionId: "dummy id 1", Method: model.PaymentQRIS, Amount: 42500}}).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPaid).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	order, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{Payments: []model.Payment{{Method: model.PaymentQRIS, Amount: 42500}}})
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.PaymentQRIS, order.Payments[0].Method)
+	transactionId := "dummy id 1"
+	suite.txMock.AssertCalled(suite.T(), "InsertMovements", []model.StockMovement{
+		saleMovement(model.StockItemMenu, "menu 1", -3, model.StockSale, transactionId, ""),
+		saleMovement(model.StockItemMenu, "menu 2", -1, model.StockSale, transactionId, ""),
+	})
+	suite.txMock.AssertNotCalled(suite.T(), "LockMenus", mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_OpenTakesStock() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusOpen), nil)
+	suite.txMock.On("LockMenus", []string{"menu 1", "menu 2"}).Return(dummyOrderMenus, nil)
+	suite.txMock.On("DecreaseStock", "menu 1", 3).Return(true, nil)
+	suite.txMock.On("DecreaseStock", "menu 2", 1).Return(true, nil)
+	suite.txMock.On("SetPrices", mock.AnythingOfType("*model.Transaction")).Return(nil)
+	suite.txMock.On("InsertPayments", mock.Anything).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusPaid).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{Payments: dummyCash})
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertNotCalled(suite.T(), "CommitStock", mock.Anything, mock.Anything)
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_AlreadyPaid() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusPaid), nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{Payments: dummyCash})
+
+	assert.EqualError(suite.T(), err, "transaction dummy id 1 is paid, it must be open, sent_to_kitchen or served")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderPay_TooMuch() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusServed), nil)
+	suite.txMock.On("CommitStock", mock.AnythingOfType("string"), mock.AnythingOfType("int")).Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Pay("dummy id 1", model.PaymentRequest{Payments: []model.Payment{{Method: model.PaymentEWallet, Amount: 50000}}})
+
+	assert.EqualError(suite.T(), err, "payments of 50000 are more than the total of 42500")
+	suite.txMock.AssertNotCalled(suite.T(), "InsertPayments", mock.Anything)
+	suite.txMock.AssertNotCalled(suite.T(), "Commit")
+}
+
+func (suite *TransactionUsecaseTestSuite) TestOrderCancel_ReleasesStock() {
+	suite.repoMock.On("Begin").Return(suite.txMock, nil)
+	suite.txMock.On("LockTransaction", "dummy id 1").Return(order(model.StatusSentToKitchen), nil)
+	suite.txMock.On("ReleaseStock", "menu 1", 3).Return(nil)
+	suite.txMock.On("ReleaseStock", "menu 2", 1).Return(nil)
+	suite.txMock.On("SetStatus", "dummy id 1", model.StatusCancelled).Return(nil)
+	suite.txMock.On("Commit").Return(nil)
+	suite.txMock.On("Rollback").Return(nil)
+
+	TransactionUsecaseTest := usecase.NewTransactionUsecase(suite.repoMock, config.PricingConfig{}, suite.notifierMock)
+	_, err := TransactionUsecaseTest.Cancel("dummy id 1")
+
+	assert.Nil(suite.T(), err)
+	suite.txMock.AssertCalled(suite.T(), "Commit")
 }
 
 func (suite *TransactionUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(repoMock)
+	suite.txMock = new(txMock)
+	suite.txMock.On("ModifierGroups", mock.Anything).Return(nil, nil).Maybe()
+	suite.txMock.On("InsertMovements", mock.Anything).Return(nil).Maybe()
+	suite.txMock.On("Recipes", mock.Anything).Return(nil, nil).Maybe()
+	suite.notifierMock = new(notifierMock)
+	suite.notifierMock.On("Notify", mock.Anything).Return(nil).Maybe()
 }
 
 func TestTransactionUsecaseTestSuite(t *testing.T) {
@@ -3,8 +3,11 @@ package usecase_test
 import (
 	"errors"
 	"testing"
+	"time"
 	"warung-makan/model"
 	"warung-makan/usecase"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -17,6 +20,7 @@ var dummyUsers = []model.User{
 		Name:     "dummy name 1",
 		Username: "dummy username 1",
 		Password: "dummy password 1",
+		Role:     model.RoleCashier,
 		Image:    "dummy image path 1",
 	},
 	{
@@ -24,6 +28,7 @@ var dummyUsers = []model.User{
 		Name:     "dummy name 2",
 		Username: "dummy username 2",
 		Password: "dummy password 2",
+		Role:     model.RoleKitchen,
 		Image:    "dummy image path 2",
 	},
 }
@@ -32,9 +37,39 @@ type repoMock struct {
 	mock.Mock
 }
 
+type tokenUsecaseMock struct {
+	mock.Mock
+}
+
 type UserUsecaseTestSuite struct {
 	suite.Suite
-	repoMock *repoMock
+	repoMock         *repoMock
+	tokenUsecaseMock *tokenUsecaseMock
+}
+
+func (r *tokenUsecaseMock) Issue(user *model.User) (model.TokenPair, error) {
+	args := r.Called(user)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (r *tokenUsecaseMock) Refresh(refreshToken string) (model.TokenPair, error) {
+	args := r.Called(refreshToken)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (r *tokenUsecaseMock) Logout(jti string, expiresAt time.Time) error {
+	args := r.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (r *tokenUsecaseMock) RevokeUserSessions(userId string) error {
+	args := r.Called(userId)
+	return args.Error(0)
+}
+
+func (r *tokenUsecaseMock) IsRevoked(jti string) (bool, error) {
+	args := r.Called(jti)
+	return args.Bool(0), args.Error(1)
 }
 
 func (r *repoMock) GetAll() ([]model.User, error) {
@@ -45,8 +80,16 @@ func (r *repoMock) GetAll() ([]model.User, error) {
 	return args.Get(0).([]model.User), nil
 }
 
-func (r *repoMock) GetById(id string) (model.User, error) {
-	args := r.Called(id)
+func (r *repoMock) List(filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := r.Called(filter)
+	if args.Get(0) == nil {
+		return nil, model.PageInfo{}, args.Error(2)
+	}
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), nil
+}
+
+func (r *repoMock) GetById(id string, includeDeleted bool) (model.User, error) {
+	args := r.Called(id, includeDeleted)
 	if args.Get(1) != nil {
 		return model.User{}, args.Error(1)
 	}
@@ -61,14 +104,22 @@ func (r *repoMock) GetByName(name string) ([]model.User, error) {
 	return args.Get(0).([]model.User), nil
 }
 
-func (r *repoMock) GetByCredentials(username, password string) (model.User, error) {
-	args := r.Called(username, password)
+func (r *repoMock) GetByUsername(username string) (model.User, error) {
+	args := r.Called(username)
 	if args.Get(1) != nil {
 		return model.User{}, args.Error(1)
 	}
 	return args.Get(0).(model.User), nil
 }
 
+func (r *repoMock) GetPasswordById(id string) (string, error) {
+	args := r.Called(id)
+	if args.Get(1) != nil {
+		return "", args.Error(1)
+	}
+	return args.String(0), nil
+}
+
 func (r *repoMock) Insert(user *model.User) (model.User, error) {
 	args := r.Called(user)
 	if args.Get(1) != nil {
@@ -85,6 +136,24 @@ func (r *repoMock) Update(newUser *model.User) (model.User, error) {
 	return args.Get(0).(model.User), nil
 }
 
+func (r *repoMock) UpdatePassword(id, password string) error {
+	args := r.Called(id, password)
+	if args.Get(0) != nil {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (r *repoMock) SetRole(username, role string) error {
+	args := r.Called(username, role)
+	return args.Error(0)
+}
+
+func (r *repoMock) Restore(id string) error {
+	args := r.Called(id)
+	return args.Error(0)
+}
+
 func (r *repoMock) Delete(id string) error {
 	args := r.Called(id)
 	if args.Get(0) != nil {
@@ -96,7 +165,7 @@ func (r *repoMock) Delete(id string) error {
 func (suite *UserUsecaseTestSuite) TestUserGetAll_Success() {
 	suite.repoMock.On("GetAll").Return(dummyUsers, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	users, err := UserUsecaseTest.GetAll()
 
 	assert.Nil(suite.T(), err)
@@ -106,7 +175,7 @@ func (suite *UserUsecaseTestSuite) TestUserGetAll_Success() {
 func (suite *UserUsecaseTestSuite) TestUserGetAll_Failed() {
 	suite.repoMock.On("GetAll").Return(nil, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	users, err := UserUsecaseTest.GetAll()
 
 	assert.Error(suite.T(), err)
@@ -115,10 +184,10 @@ func (suite *UserUsecaseTestSuite) TestUserGetAll_Failed() {
 
 func (suite *UserUsecaseTestSuite) TestUserGetById_Success() {
 	dummy := dummyUsers[0]
-	suite.repoMock.On("GetById", dummy.Id).Return(dummy, nil)
+	suite.repoMock.On("GetById", dummy.Id, false).Return(dummy, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
-	user, err := UserUsecaseTest.GetById(dummy.Id)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	user, err := UserUsecaseTest.GetById(dummy.Id, false)
 
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), dummy, user)
@@ -126,10 +195,10 @@ func (suite *UserUsecaseTestSuite) TestUserGetById_Success() {
 
 func (suite *UserUsecaseTestSuite) TestUserGetById_Failed() {
 	dummy := dummyUsers[0]
-	suite.repoMock.On("GetById", dummy.Id).Return(model.User{}, errors.New("failed"))
+	suite.repoMock.On("GetById", dummy.Id, false).Return(model.User{}, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
-	user, err := UserUsecaseTest.GetById(dummy.Id)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	user, err := UserUsecaseTest.GetById(dummy.Id, false)
 
 	assert.Error(suite.T(), err)
 	assert.Equal(suite.T(), model.User{}, user)
@@ -139,7 +208,7 @@ func (suite *UserUsecaseTestSuite) TestUserGetByName_Success() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("GetByName", dummy.Name).Return(dummyUsers, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	users, err := UserUsecaseTest.GetByName(dummy.Name)
 
 	assert.Nil(suite.T(), err)
@@ -150,7 +219,7 @@ func (suite *UserUsecaseTestSuite) TestUserGetByName_Failed() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("GetByName", dummy.Name).Return(nil, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	users, err := UserUsecaseTest.GetByName(dummy.Name)
 
 	assert.Error(suite.T(), err)
@@ -159,31 +228,102 @@ func (suite *UserUsecaseTestSuite) TestUserGetByName_Failed() {
 
 func (suite *UserUsecaseTestSuite) TestUserGetByCredentials_Success() {
 	dummy := dummyUsers[0]
-	suite.repoMock.On("GetByCredentials", dummy.Username, dummy.Password).Return(dummy, nil)
+	stored := dummy
+	stored.Password, _ = utils.HashPassword(dummy.Password)
+	suite.repoMock.On("GetByUsername", dummy.Username).Return(stored, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.GetByCredentials(dummy.Username, dummy.Password)
 
 	assert.Nil(suite.T(), err)
-	assert.Equal(suite.T(), dummy, user)
+	assert.Equal(suite.T(), dummy.Id, user.Id)
+	assert.Equal(suite.T(), "", user.Password)
+	suite.repoMock.AssertNotCalled(suite.T(), "UpdatePassword", dummy.Id, dummy.Password)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserGetByCredentials_UpgradePlainPassword() {
+	dummy := dummyUsers[0]
+	suite.repoMock.On("GetByUsername", dummy.Username).Return(dummy, nil)
+	suite.repoMock.On("UpdatePassword", dummy.Id, dummy.Password).Return(nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	user, err := UserUsecaseTest.GetByCredentials(dummy.Username, dummy.Password)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy.Id, user.Id)
+	suite.repoMock.AssertCalled(suite.T(), "UpdatePassword", dummy.Id, dummy.Password)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserGetByCredentials_WrongPassword() {
+	dummy := dummyUsers[0]
+	stored := dummy
+	stored.Password, _ = utils.HashPassword(dummy.Password)
+	suite.repoMock.On("GetByUsername", dummy.Username).Return(stored, nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	user, err := UserUsecaseTest.GetByCredentials(dummy.Username, "wrong password")
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidCredentials)
+	assert.Equal(suite.T(), model.User{}, user)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserGetByCredentials_UnknownUsername() {
+	suite.repoMock.On("GetByUsername", "nobody").Return(model.User{}, apperror.NotFound("user not found"))
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	user, err := UserUsecaseTest.GetByCredentials("nobody", "password")
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrInvalidCredentials)
+	assert.Equal(suite.T(), model.User{}, user)
 }
 
 func (suite *UserUsecaseTestSuite) TestUserGetByCredentials_Failed() {
 	dummy := dummyUsers[0]
-	suite.repoMock.On("GetByCredentials", dummy.Username, dummy.Password).Return(model.User{}, errors.New("failed"))
+	suite.repoMock.On("GetByUsername", dummy.Username).Return(model.User{}, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.GetByCredentials(dummy.Username, dummy.Password)
 
-	assert.Error(suite.T(), err)
+	assert.EqualError(suite.T(), err, "failed")
+	assert.NotErrorIs(suite.T(), err, usecase.ErrInvalidCredentials)
 	assert.Equal(suite.T(), model.User{}, user)
 }
 
+func (suite *UserUsecaseTestSuite) TestUserChangePassword_Success() {
+	dummy := dummyUsers[0]
+	stored, _ := utils.HashPassword(dummy.Password)
+	suite.repoMock.On("GetPasswordById", dummy.Id).Return(stored, nil)
+	suite.repoMock.On("UpdatePassword", dummy.Id, "new password").Return(nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	err := UserUsecaseTest.ChangePassword(dummy.Id, &model.PasswordChange{
+		OldPassword: dummy.Password,
+		NewPassword: "new password",
+	})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserChangePassword_WrongOldPassword() {
+	dummy := dummyUsers[0]
+	stored, _ := utils.HashPassword(dummy.Password)
+	suite.repoMock.On("GetPasswordById", dummy.Id).Return(stored, nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	err := UserUsecaseTest.ChangePassword(dummy.Id, &model.PasswordChange{
+		OldPassword: "wrong password",
+		NewPassword: "new password",
+	})
+
+	assert.ErrorIs(suite.T(), err, usecase.ErrWrongPassword)
+	suite.repoMock.AssertNotCalled(suite.T(), "UpdatePassword", dummy.Id, "new password")
+}
+
 func (suite *UserUsecaseTestSuite) TestUserInsert_Success() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Insert", &dummy).Return(dummy, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.Insert(&dummy)
 
 	assert.Nil(suite.T(), err)
@@ -191,11 +331,25 @@ func (suite *UserUsecaseTestSuite) TestUserInsert_Success() {
 
 }
 
+func (suite *UserUsecaseTestSuite) TestUserInsert_DefaultRole() {
+	dummy := dummyUsers[0]
+	dummy.Role = ""
+	suite.repoMock.On("Insert", mock.MatchedBy(func(user *model.User) bool {
+		return user.Role == model.RoleViewer
+	})).Return(dummy, nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	_, err := UserUsecaseTest.Insert(&dummy)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), model.RoleViewer, dummy.Role)
+}
+
 func (suite *UserUsecaseTestSuite) TestUserInsert_Failed() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Insert", &dummy).Return(model.User{}, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.Insert(&dummy)
 
 	assert.Error(suite.T(), err)
@@ -207,7 +361,7 @@ func (suite *UserUsecaseTestSuite) TestUserUpdate_Success() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Update", &dummy).Return(dummy, nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.Update(&dummy)
 
 	assert.Nil(suite.T(), err)
@@ -219,7 +373,7 @@ func (suite *UserUsecaseTestSuite) TestUserUpdate_Failed() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Update", &dummy).Return(model.User{}, errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	user, err := UserUsecaseTest.Update(&dummy)
 
 	assert.Error(suite.T(), err)
@@ -227,28 +381,85 @@ func (suite *UserUsecaseTestSuite) TestUserUpdate_Failed() {
 
 }
 
+func (suite *UserUsecaseTestSuite) TestUserPromoteOwner_Success() {
+	dummy := dummyUsers[0]
+	suite.repoMock.On("SetRole", dummy.Username, model.RoleOwner).Return(nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	err := UserUsecaseTest.PromoteOwner(dummy.Username)
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserPromoteOwner_NotFound() {
+	suite.repoMock.On("SetRole", "nobody", model.RoleOwner).Return(apperror.NotFound("user nobody not found"))
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	err := UserUsecaseTest.PromoteOwner("nobody")
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+}
+
 func (suite *UserUsecaseTestSuite) TestUserDelete_Success() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Delete", dummy.Id).Return(nil)
+	suite.tokenUsecaseMock.On("RevokeUserSessions", dummy.Id).Return(nil)
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	err := UserUsecaseTest.Delete(dummy.Id)
 
 	assert.Nil(suite.T(), err)
+	suite.tokenUsecaseMock.AssertCalled(suite.T(), "RevokeUserSessions", dummy.Id)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserDelete_RevokeFailed() {
+	dummy := dummyUsers[0]
+	suite.repoMock.On("Delete", dummy.Id).Return(nil)
+	suite.tokenUsecaseMock.On("RevokeUserSessions", dummy.Id).Return(errors.New("failed"))
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	err := UserUsecaseTest.Delete(dummy.Id)
+
+	assert.Error(suite.T(), err)
 }
 
 func (suite *UserUsecaseTestSuite) TestUserDelete_Failed() {
 	dummy := dummyUsers[0]
 	suite.repoMock.On("Delete", dummy.Id).Return(errors.New("failed"))
 
-	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock)
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
 	err := UserUsecaseTest.Delete(dummy.Id)
 
 	assert.Error(suite.T(), err)
+	suite.tokenUsecaseMock.AssertNotCalled(suite.T(), "RevokeUserSessions", dummy.Id)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserRestore_Success() {
+	dummy := dummyUsers[0]
+	suite.repoMock.On("Restore", dummy.Id).Return(nil)
+	suite.repoMock.On("GetById", dummy.Id, false).Return(dummy, nil)
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	restored, err := UserUsecaseTest.Restore(dummy.Id)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), dummy, restored)
+}
+
+func (suite *UserUsecaseTestSuite) TestUserRestore_NotArchived() {
+	dummy := dummyUsers[0]
+	suite.repoMock.On("Restore", dummy.Id).Return(apperror.NotFound("deleted user " + dummy.Id + " not found"))
+
+	UserUsecaseTest := usecase.NewUserUsecase(suite.repoMock, suite.tokenUsecaseMock)
+	_, err := UserUsecaseTest.Restore(dummy.Id)
+
+	assert.Equal(suite.T(), apperror.KindNotFound, apperror.KindOf(err))
+	suite.repoMock.AssertNotCalled(suite.T(), "GetById", dummy.Id, false)
 }
 
 func (suite *UserUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(repoMock)
+	suite.tokenUsecaseMock = new(tokenUsecaseMock)
 }
 
 func TestUserUsecaseTestSuite(t *testing.T) {
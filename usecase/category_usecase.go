@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+type categoryUsecase struct {
+	categoryRepository repository.CategoryRepository
+}
+
+type CategoryUsecase interface {
+	GetAll() ([]model.Category, error)
+	GetById(id string) (model.Category, error)
+	Insert(category *model.Category) (model.Category, error)
+	Update(category *model.Category) (model.Category, error)
+	// Delete removes a category, its menus are left without one.
+	Delete(id string) error
+}
+
+func (p *categoryUsecase) GetAll() ([]model.Category, error) {
+	return p.categoryRepository.GetAll()
+}
+
+func (p *categoryUsecase) GetById(id string) (model.Category, error) {
+	return p.categoryRepository.GetById(id)
+}
+
+func (p *categoryUsecase) Insert(newCategory *model.Category) (model.Category, error) {
+	if err := checkCategory(newCategory); err != nil {
+		return model.Category{}, err
+	}
+	newCategory.Id = utils.GenerateId()
+	return p.categoryRepository.Insert(newCategory)
+}
+
+func (p *categoryUsecase) Update(newCategory *model.Category) (model.Category, error) {
+	if err := checkCategory(newCategory); err != nil {
+		return model.Category{}, err
+	}
+	return p.categoryRepository.Update(newCategory)
+}
+
+func (p *categoryUsecase) Delete(id string) error {
+	return p.categoryRepository.Delete(id)
+}
+
+func checkCategory(category *model.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return apperror.Validation("invalid category", map[string]string{"name": "name is required"})
+	}
+	return nil
+}
+
+func NewCategoryUsecase(categoryRepository repository.CategoryRepository) CategoryUsecase {
+	usecase := new(categoryUsecase)
+	usecase.categoryRepository = categoryRepository
+	return usecase
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+type ingredientUsecase struct {
+	ingredientRepository repository.IngredientRepository
+	menuRepository       repository.MenuRepository
+}
+
+type IngredientUsecase interface {
+	GetAll() ([]model.Ingredient, error)
+	GetById(id string) (model.Ingredient, error)
+	// Insert adds an ingredient, its stock is recorded as the opening
+	// movement.
+	Insert(ingredient *model.Ingredient) (model.Ingredient, error)
+	// Update changes the name, the unit and the reorder level of an
+	// ingredient, not its stock. The unit only changes while nothing is
+	// counted in it.
+	Update(ingredient *model.Ingredient) (model.Ingredient, error)
+	// Delete removes an ingredient that has no stock, movements, recipes,
+	// purchase orders or stock takes and that no order holds.
+	Delete(id string) error
+	GetRecipe(menuId string) ([]model.RecipeLine, error)
+	// SetRecipe replaces the recipe of a menu, a menu without one sells from
+	// its own stock.
+	SetRecipe(menuId string, recipe model.Recipe) ([]model.RecipeLine, error)
+}
+
+func (p *ingredientUsecase) GetAll() ([]model.Ingredient, error) {
+	return p.ingredientRepository.GetAll()
+}
+
+func (p *ingredientUsecase) GetById(id string) (model.Ingredient, error) {
+	return p.ingredientRepository.GetById(id)
+}
+
+func (p *ingredientUsecase) Insert(newIngredient *model.Ingredient) (model.Ingredient, error) {
+	if err := checkIngredient(newIngredient); err != nil {
+		return model.Ingredient{}, err
+	}
+	if newIngredient.Stock < 0 {
+		return model.Ingredient{}, apperror.Validation("invalid ingredient", map[string]string{"stock": "stock must not be negative"})
+	}
+	newIngredient.Id = utils.GenerateId()
+	newIngredient.Reserved = 0
+	return p.ingredientRepository.Insert(newIngredient)
+}
+
+func (p *ingredientUsecase) Update(newIngredient *model.Ingredient) (model.Ingredient, error) {
+	if err := checkIngredient(newIngredient); err != nil {
+		return model.Ingredient{}, err
+	}
+
+	ingredient, err := p.ingredientRepository.GetById(newIngredient.Id)
+	if err != nil {
+		return model.Ingredient{}, err
+	}
+	if ingredient.Unit != newIngredient.Unit {
+		// nothing converts what is already counted in the old unit
+		uses, err := p.uses(ingredient)
+		if err != nil {
+			return model.Ingredient{}, err
+		}
+		if len(uses) > 0 {
+			return model.Ingredient{}, apperror.Conflict(fmt.Sprintf("unit of ingredient %s cannot change from %s to %s, it has %s in %s", ingredient.Id, ingredient.Unit, newIngredient.Unit, strings.Join(uses, ", "), ingredient.Unit), nil)
+		}
+	}
+	return p.ingredientRepository.Update(newIngredient)
+}
+
+func (p *ingredientUsecase) Delete(id string) error {
+	ingredient, err := p.ingredientRepository.GetById(id)
+	if err != nil {
+		return err
+	}
+	if ingredient.Reserved > 0 {
+		return apperror.Conflict(fmt.Sprintf("ingredient %s has %d reserved by orders", id, ingredient.Reserved), nil)
+	}
+	uses, err := p.uses(ingredient)
+	if err != nil {
+		return err
+	}
+	if len(uses) > 0 {
+		return apperror.Conflict(fmt.Sprintf("ingredient %s cannot be deleted, it has %s", id, strings.Join(uses, ", ")), nil)
+	}
+	return p.ingredientRepository.Delete(id)
+}
+
+// uses names what is counted in the unit of an ingredient, its stock first.
+func (p *ingredientUsecase) uses(ingredient model.Ingredient) ([]string, error) {
+	usage, err := p.ingredientRepository.Usage(ingredient.Id)
+	if err != nil {
+		return nil, err
+	}
+	uses := usage.Uses()
+	if ingredient.Stock != 0 {
+		uses = append([]string{"stock"}, uses...)
+	}
+	return uses, nil
+}
+
+func (p *ingredientUsecase) GetRecipe(menuId string) ([]model.RecipeLine, error) {
+	if _, err := p.menuRepository.GetById(menuId, false); err != nil {
+		return nil, err
+	}
+	return p.ingredientRepository.GetRecipe(menuId)
+}
+
+func (p *ingredientUsecase) SetRecipe(menuId string, recipe model.Recipe) ([]model.RecipeLine, error) {
+	if _, err := p.menuRepository.GetById(menuId, false); err != nil {
+		return nil, err
+	}
+
+	invalid := map[string]string{}
+	seen := map[string]bool{}
+	for i, line := range recipe.Ingredients {
+		key := fmt.Sprintf("ingredients[%d]", i)
+		switch {
+		case line.Qty < 1:
+			invalid[key] = "qty must be at least 1"
+		case seen[line.IngredientId]:
+			invalid[key] = "ingredient " + line.IngredientId + " is already in the recipe"
+		default:
+			if _, err := p.ingredientRepository.GetById(line.IngredientId); apperror.KindOf(err) == apperror.KindNotFound {
+				invalid[key] = "ingredient " + line.IngredientId + " not found"
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		seen[line.IngredientId] = true
+	}
+	if len(invalid) > 0 {
+		return nil, apperror.Validation("invalid recipe", invalid)
+	}
+	return p.ingredientRepository.SetRecipe(menuId, recipe.Ingredients)
+}
+
+// checkIngredient normalizes the name of an ingredient and validates it
+// with its unit and its reorder level.
+func checkIngredient(ingredient *model.Ingredient) error {
+	ingredient.Name = strings.TrimSpace(ingredient.Name)
+
+	invalid := map[string]string{}
+	if ingredient.Name == "" {
+		invalid["name"] = "name is required"
+	}
+	if !contains(model.IngredientUnits, ingredient.Unit) {
+		invalid["unit"] = "unit must be " + joinOr(model.IngredientUnits)
+	}
+	if ingredient.ReorderLevel != nil && *ingredient.ReorderLevel < 0 {
+		invalid["reorder_level"] = "reorder_level must not be negative"
+	}
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid ingredient", invalid)
+	}
+	return nil
+}
+
+func NewIngredientUsecase(ingredientRepository repository.IngredientRepository, menuRepository repository.MenuRepository) IngredientUsecase {
+	usecase := new(ingredientUsecase)
+	usecase.ingredientRepository = ingredientRepository
+	usecase.menuRepository = menuRepository
+	return usecase
+}
@@ -1,22 +1,37 @@
 package usecase
 
 import (
+	"fmt"
+	"strings"
+	"time"
 	"warung-makan/model"
 	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+const (
+	clockLayout  = "15:04"
+	maxTagLength = 30
 )
 
 type menuUsecase struct {
 	menuRepository repository.MenuRepository
+	location       *time.Location
 }
 
 type MenuUsecase interface {
 	GetAll() ([]model.Menu, error)
+	// List reads AvailableNow as the time of day where the shop is.
+	List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error)
 	// GetAllPaginated(page int, rows int) ([]model.Menu, error)
-	GetById(id string) (model.Menu, error)
+	GetById(id string, includeDeleted bool) (model.Menu, error)
 	GetByName(name string) ([]model.Menu, error)
 	Insert(menu *model.Menu) (model.Menu, error)
 	Update(menu *model.Menu) (model.Menu, error)
+	// Delete archives a menu, it stays on the transactions that sold it.
 	Delete(id string) error
+	// Restore brings back an archived menu and returns it.
+	Restore(id string) (model.Menu, error)
 }
 
 func (p *menuUsecase) GetAll() ([]model.Menu, error) {
@@ -24,12 +39,20 @@ func (p *menuUsecase) GetAll() ([]model.Menu, error) {
 
 }
 
+func (p *menuUsecase) List(filter model.MenuFilter) ([]model.Menu, model.PageInfo, error) {
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	if filter.AvailableNow {
+		filter.AvailableAt = time.Now().In(p.location).Format(clockLayout)
+	}
+	return p.menuRepository.List(filter)
+}
+
 // func (p *menuUsecase) GetAllPaginated(page int, rows int) ([]model.Menu, error) {
 // 	return p.menuRepository.GetAllPaginated(page, rows)
 // }
 
-func (p *menuUsecase) GetById(id string) (model.Menu, error) {
-	return p.menuRepository.GetById(id)
+func (p *menuUsecase) GetById(id string, includeDeleted bool) (model.Menu, error) {
+	return p.menuRepository.GetById(id, includeDeleted)
 }
 
 func (p *menuUsecase) GetByName(name string) ([]model.Menu, error) {
@@ -37,19 +60,94 @@ func (p *menuUsecase) GetByName(name string) ([]model.Menu, error) {
 }
 
 func (p *menuUsecase) Insert(newMenu *model.Menu) (model.Menu, error) {
+	if err := checkMenu(newMenu); err != nil {
+		return model.Menu{}, err
+	}
 	return p.menuRepository.Insert(newMenu)
 }
 
 func (p *menuUsecase) Update(newMenu *model.Menu) (model.Menu, error) {
+	if err := checkMenu(newMenu); err != nil {
+		return model.Menu{}, err
+	}
 	return p.menuRepository.Update(newMenu)
 }
 
+// checkMenu normalizes the category, tags and serving window of a menu and
+// validates them with the reorder level. tags are kept lower case and once.
+func checkMenu(menu *model.Menu) error {
+	invalid := map[string]string{}
+
+	if menu.CategoryId != nil && strings.TrimSpace(*menu.CategoryId) == "" {
+		menu.CategoryId = nil
+	}
+
+	tags := make([]string, 0, len(menu.Tags))
+	seen := map[string]bool{}
+	for _, tag := range menu.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			invalid["tags"] = fmt.Sprintf("tags must be at most %d characters, %q is not", maxTagLength, tag)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	menu.Tags = tags
+
+	from, fromErr := normalizeClock(menu.AvailableFrom)
+	until, untilErr := normalizeClock(menu.AvailableUntil)
+	switch {
+	case fromErr != nil:
+		invalid["available_from"] = "available_from must be a time of day as 15:04"
+	case untilErr != nil:
+		invalid["available_until"] = "available_until must be a time of day as 15:04"
+	case (from == nil) != (until == nil):
+		invalid["available_from"] = "available_from and available_until go together"
+	case from != nil && *from == *until:
+		invalid["available_until"] = "available_until must differ from available_from"
+	}
+	menu.AvailableFrom, menu.AvailableUntil = from, until
+
+	if menu.ReorderLevel != nil && *menu.ReorderLevel < 0 {
+		invalid["reorder_level"] = "reorder_level must not be negative"
+	}
+
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid menu", invalid)
+	}
+	return nil
+}
+
+// normalizeClock reads a time of day, an empty one is no time.
+func normalizeClock(clock *string) (*string, error) {
+	if clock == nil || strings.TrimSpace(*clock) == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(clockLayout, strings.TrimSpace(*clock))
+	if err != nil {
+		return nil, err
+	}
+	normalized := t.Format(clockLayout)
+	return &normalized, nil
+}
+
 func (p *menuUsecase) Delete(id string) error {
 	return p.menuRepository.Delete(id)
 }
 
-func NewMenuUsecase(menuRepository repository.MenuRepository) MenuUsecase {
+func (p *menuUsecase) Restore(id string) (model.Menu, error) {
+	if err := p.menuRepository.Restore(id); err != nil {
+		return model.Menu{}, err
+	}
+	return p.menuRepository.GetById(id, false)
+}
+
+func NewMenuUsecase(menuRepository repository.MenuRepository, location *time.Location) MenuUsecase {
 	usecase := new(menuUsecase)
 	usecase.menuRepository = menuRepository
+	usecase.location = location
 	return usecase
 }
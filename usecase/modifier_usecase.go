@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+type modifierUsecase struct {
+	modifierRepository repository.ModifierRepository
+	menuRepository     repository.MenuRepository
+}
+
+type ModifierUsecase interface {
+	GetByMenu(menuId string) ([]model.ModifierGroup, error)
+	// Insert adds a group to a menu that is not archived, a group without
+	// MaxSelect allows one choice.
+	Insert(group *model.ModifierGroup) (model.ModifierGroup, error)
+	// Update replaces a group and its options, options sent without an id
+	// are added and the ones left out are removed.
+	Update(group *model.ModifierGroup) (model.ModifierGroup, error)
+	Delete(menuId string, groupId int64) error
+}
+
+func (p *modifierUsecase) GetByMenu(menuId string) ([]model.ModifierGroup, error) {
+	if _, err := p.menuRepository.GetById(menuId, false); err != nil {
+		return nil, err
+	}
+	return p.modifierRepository.GetByMenu(menuId)
+}
+
+func (p *modifierUsecase) Insert(newGroup *model.ModifierGroup) (model.ModifierGroup, error) {
+	if err := checkModifierGroup(newGroup); err != nil {
+		return model.ModifierGroup{}, err
+	}
+	if _, err := p.menuRepository.GetById(newGroup.MenuId, false); err != nil {
+		return model.ModifierGroup{}, err
+	}
+	for i := range newGroup.Options {
+		newGroup.Options[i].Id = 0
+	}
+	return p.modifierRepository.Insert(newGroup)
+}
+
+func (p *modifierUsecase) Update(newGroup *model.ModifierGroup) (model.ModifierGroup, error) {
+	if err := checkModifierGroup(newGroup); err != nil {
+		return model.ModifierGroup{}, err
+	}
+	if _, err := p.menuRepository.GetById(newGroup.MenuId, false); err != nil {
+		return model.ModifierGroup{}, err
+	}
+	return p.modifierRepository.Update(newGroup)
+}
+
+func (p *modifierUsecase) Delete(menuId string, groupId int64) error {
+	return p.modifierRepository.Delete(menuId, groupId)
+}
+
+// checkModifierGroup normalizes the names and the choice limits of a group
+// and validates them.
+func checkModifierGroup(group *model.ModifierGroup) error {
+	group.Name = strings.TrimSpace(group.Name)
+	if group.MaxSelect == 0 {
+		group.MaxSelect = 1
+	}
+
+	invalid := map[string]string{}
+	if group.Name == "" {
+		invalid["name"] = "name is required"
+	}
+	switch {
+	case group.MinSelect < 0:
+		invalid["min_select"] = "min_select must be at least 0"
+	case group.MaxSelect < group.MinSelect:
+		invalid["max_select"] = "max_select must be at least min_select"
+	case group.MaxSelect > len(group.Options):
+		invalid["max_select"] = fmt.Sprintf("max_select must be at most the %d options", len(group.Options))
+	}
+	if len(group.Options) == 0 {
+		invalid["options"] = "a group needs at least one option"
+	}
+
+	seen := map[string]bool{}
+	for i := range group.Options {
+		option := &group.Options[i]
+		option.Name = strings.TrimSpace(option.Name)
+		key := fmt.Sprintf("options[%d].name", i)
+		switch {
+		case option.Name == "":
+			invalid[key] = "name is required"
+		case seen[strings.ToLower(option.Name)]:
+			invalid[key] = option.Name + " is already an option of the group"
+		}
+		seen[strings.ToLower(option.Name)] = true
+	}
+
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid modifier group", invalid)
+	}
+	return nil
+}
+
+func NewModifierUsecase(modifierRepository repository.ModifierRepository, menuRepository repository.MenuRepository) ModifierUsecase {
+	usecase := new(modifierUsecase)
+	usecase.modifierRepository = modifierRepository
+	usecase.menuRepository = menuRepository
+	return usecase
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,40}$`)
+
+type promoUsecase struct {
+	promoRepository repository.PromoRepository
+}
+
+type PromoUsecase interface {
+	GetAll() ([]model.Promo, error)
+	GetByCode(code string) (model.Promo, error)
+	// Insert creates an active promo, it starts now when no start is given.
+	Insert(promo *model.Promo) (model.Promo, error)
+	// Update replaces the discount, window, limit and active flag of a
+	// promo, its use count is kept.
+	Update(promo *model.Promo) (model.Promo, error)
+}
+
+func (p *promoUsecase) GetAll() ([]model.Promo, error) {
+	return p.promoRepository.GetAll()
+}
+
+func (p *promoUsecase) GetByCode(code string) (model.Promo, error) {
+	return p.promoRepository.GetByCode(normalizePromoCode(code))
+}
+
+func (p *promoUsecase) Insert(newPromo *model.Promo) (model.Promo, error) {
+	newPromo.Active = true
+	if err := checkPromo(newPromo); err != nil {
+		return model.Promo{}, err
+	}
+	return p.promoRepository.Insert(newPromo)
+}
+
+func (p *promoUsecase) Update(newPromo *model.Promo) (model.Promo, error) {
+	if err := checkPromo(newPromo); err != nil {
+		return model.Promo{}, err
+	}
+	return p.promoRepository.Update(newPromo)
+}
+
+// checkPromo normalizes the code and start of a promo and validates it.
+func checkPromo(promo *model.Promo) error {
+	promo.Code = normalizePromoCode(promo.Code)
+	if promo.StartsAt.IsZero() {
+		promo.StartsAt = time.Now()
+	}
+
+	invalid := map[string]string{}
+	if !promoCodePattern.MatchString(promo.Code) {
+		invalid["code"] = "code must be 1 to 40 letters, digits, - or _"
+	}
+	if problem := checkDiscount(&promo.Discount); problem != "" {
+		invalid["discount"] = problem
+	}
+	if promo.EndsAt != nil && !promo.EndsAt.After(promo.StartsAt) {
+		invalid["ends_at"] = "ends_at must be after starts_at"
+	}
+	if promo.UsageLimit != nil && *promo.UsageLimit < 1 {
+		invalid["usage_limit"] = fmt.Sprintf("usage_limit must be at least 1, not %d", *promo.UsageLimit)
+	}
+
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid promo", invalid)
+	}
+	return nil
+}
+
+func NewPromoUsecase(promoRepository repository.PromoRepository) PromoUsecase {
+	usecase := new(promoUsecase)
+	usecase.promoRepository = promoRepository
+	return usecase
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+var purchaseStatuses = []string{model.PurchaseDraft, model.PurchaseOrdered, model.PurchasePartiallyReceived, model.PurchaseReceived}
+
+type purchaseOrderUsecase struct {
+	purchaseOrderRepository repository.PurchaseOrderRepository
+	supplierRepository      repository.SupplierRepository
+	menuRepository          repository.MenuRepository
+	ingredientRepository    repository.IngredientRepository
+}
+
+type PurchaseOrderUsecase interface {
+	List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error)
+	GetById(id string) (model.PurchaseOrder, error)
+	// Insert starts a draft, only SupplierId, Note and the item, Qty and
+	// UnitCost of the lines are read.
+	Insert(order *model.PurchaseOrder, userId string) (model.PurchaseOrder, error)
+	// Update replaces the supplier, the note and the lines of a draft.
+	Update(order *model.PurchaseOrder) (model.PurchaseOrder, error)
+	// Delete drops a draft.
+	Delete(id string) error
+	// Order marks a draft as sent to its supplier, it can be received then.
+	Order(id string) (model.PurchaseOrder, error)
+	// Receive adds what came in to the stock of the items, at the unit cost
+	// it was bought for.
+	Receive(id string, request model.ReceiveRequest, userId string) (model.PurchaseOrder, error)
+}
+
+func (p *purchaseOrderUsecase) List(filter model.PurchaseFilter) ([]model.PurchaseOrder, error) {
+	if filter.Status != "" && !contains(purchaseStatuses, filter.Status) {
+		return nil, apperror.Validation("status must be "+joinOr(purchaseStatuses), nil)
+	}
+	return p.purchaseOrderRepository.List(filter)
+}
+
+func (p *purchaseOrderUsecase) GetById(id string) (model.PurchaseOrder, error) {
+	return p.purchaseOrderRepository.GetById(id)
+}
+
+func (p *purchaseOrderUsecase) Insert(newOrder *model.PurchaseOrder, userId string) (model.PurchaseOrder, error) {
+	if err := p.checkOrder(newOrder); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	newOrder.Id = utils.GenerateId()
+	newOrder.UserId = &userId
+	return p.purchaseOrderRepository.Insert(newOrder)
+}
+
+func (p *purchaseOrderUsecase) Update(newOrder *model.PurchaseOrder) (model.PurchaseOrder, error) {
+	if _, err := p.draft(newOrder.Id); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if err := p.checkOrder(newOrder); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	return p.purchaseOrderRepository.Update(newOrder)
+}
+
+func (p *purchaseOrderUsecase) Delete(id string) error {
+	if _, err := p.draft(id); err != nil {
+		return err
+	}
+	return p.purchaseOrderRepository.Delete(id)
+}
+
+func (p *purchaseOrderUsecase) Order(id string) (model.PurchaseOrder, error) {
+	if _, err := p.draft(id); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if err := p.purchaseOrderRepository.SetOrdered(id); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	return p.purchaseOrderRepository.GetById(id)
+}
+
+func (p *purchaseOrderUsecase) Receive(id string, request model.ReceiveRequest, userId string) (model.PurchaseOrder, error) {
+	order, err := p.purchaseOrderRepository.GetById(id)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if order.Status != model.PurchaseOrdered && order.Status != model.PurchasePartiallyReceived {
+		return model.PurchaseOrder{}, apperror.Conflict(fmt.Sprintf("purchase order %s is %s, it must be ordered or partially_received", id, order.Status), nil)
+	}
+	if len(request.Lines) == 0 {
+		return model.PurchaseOrder{}, apperror.Validation("invalid receipt", map[string]string{"lines": "at least one line must be received"})
+	}
+
+	lines := map[int64]model.PurchaseOrderLine{}
+	for _, line := range order.Lines {
+		lines[line.Id] = line
+	}
+	invalid := map[string]string{}
+	seen := map[int64]bool{}
+	received := make([]model.ReceiveLine, len(request.Lines))
+	for i, each := range request.Lines {
+		key := fmt.Sprintf("lines[%d]", i)
+		line, ok := lines[each.LineId]
+		switch {
+		case !ok:
+			invalid[key] = fmt.Sprintf("line %d is not in the purchase order", each.LineId)
+		case seen[each.LineId]:
+			invalid[key] = fmt.Sprintf("line %d is already received", each.LineId)
+		case each.Qty < 1:
+			invalid[key] = "qty must be at least 1"
+		case each.Qty > line.Qty-line.ReceivedQty:
+			invalid[key] = fmt.Sprintf("%d of %s are left to receive", line.Qty-line.ReceivedQty, line.Name)
+		case each.UnitCost != nil && *each.UnitCost < 0:
+			invalid[key] = "unit_cost must not be negative"
+		}
+		seen[each.LineId] = true
+
+		if each.UnitCost == nil {
+			unitCost := line.UnitCost
+			each.UnitCost = &unitCost
+		}
+		received[i] = each
+	}
+	if len(invalid) > 0 {
+		return model.PurchaseOrder{}, apperror.Validation("invalid receipt", invalid)
+	}
+
+	var note *string
+	if trimmed := strings.TrimSpace(request.Note); trimmed != "" {
+		note = &trimmed
+	}
+	if err := p.purchaseOrderRepository.Receive(id, received, note, userId); err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	return p.purchaseOrderRepository.GetById(id)
+}
+
+// draft reads a purchase order that must still be a draft.
+func (p *purchaseOrderUsecase) draft(id string) (model.PurchaseOrder, error) {
+	order, err := p.purchaseOrderRepository.GetById(id)
+	if err != nil {
+		return model.PurchaseOrder{}, err
+	}
+	if order.Status != model.PurchaseDraft {
+		return model.PurchaseOrder{}, apperror.Conflict(fmt.Sprintf("purchase order %s is %s, only a draft can be changed", id, order.Status), nil)
+	}
+	return order, nil
+}
+
+// checkOrder validates the supplier and the lines of a purchase order, every
+// line is of one menu or ingredient that is not on another line.
+func (p *purchaseOrderUsecase) checkOrder(order *model.PurchaseOrder) error {
+	invalid := map[string]string{}
+	if _, err := p.supplierRepository.GetById(order.SupplierId); apperror.KindOf(err) == apperror.KindNotFound {
+		invalid["supplier_id"] = "supplier " + order.SupplierId + " not found"
+	} else if err != nil {
+		return err
+	}
+	if order.Note != nil && strings.TrimSpace(*order.Note) == "" {
+		order.Note = nil
+	}
+	if len(order.Lines) == 0 {
+		invalid["lines"] = "a purchase order needs at least one line"
+	}
+
+	seen := map[model.StockItem]bool{}
+	for i, line := range order.Lines {
+		key := fmt.Sprintf("lines[%d]", i)
+		item := line.Item()
+		switch {
+		case (line.MenuId == nil) == (line.IngredientId == nil):
+			invalid[key] = "a line is of either a menu_id or an ingredient_id"
+		case line.Qty < 1:
+			invalid[key] = "qty must be at least 1"
+		case line.UnitCost < 0:
+			invalid[key] = "unit_cost must not be negative"
+		case seen[item]:
+			invalid[key] = item.String() + " is already in the purchase order"
+		default:
+			found, err := stockItemExists(p.menuRepository, p.ingredientRepository, item)
+			if err != nil {
+				return err
+			}
+			if !found {
+				invalid[key] = item.String() + " not found"
+			}
+		}
+		seen[item] = true
+	}
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid purchase order", invalid)
+	}
+	return nil
+}
+
+func NewPurchaseOrderUsecase(purchaseOrderRepository repository.PurchaseOrderRepository, supplierRepository repository.SupplierRepository, menuRepository repository.MenuRepository, ingredientRepository repository.IngredientRepository) PurchaseOrderUsecase {
+	usecase := new(purchaseOrderUsecase)
+	usecase.purchaseOrderRepository = purchaseOrderRepository
+	usecase.supplierRepository = supplierRepository
+	usecase.menuRepository = menuRepository
+	usecase.ingredientRepository = ingredientRepository
+	return usecase
+}
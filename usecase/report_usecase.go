@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+const (
+	maxDailyReportDays     = 366
+	maxMonthlyReportMonths = 120
+	defaultMenuReportLimit = 10
+)
+
+type reportUsecase struct {
+	reportRepository repository.ReportRepository
+	location         *time.Location
+}
+
+type ReportUsecase interface {
+	// Daily is the sales of every day in the range, today when no range is
+	// given.
+	Daily(filter model.ReportFilter) (model.SalesReport, error)
+	// Monthly is the sales of every month in the range, this year when no
+	// range is given.
+	Monthly(filter model.ReportFilter) (model.SalesReport, error)
+	// Menu ranks the menus by qty or revenue, today when no range is given.
+	Menu(filter model.ReportFilter) (model.SalesReport, error)
+	// Suppliers is what was received from every supplier, today when no
+	// range is given.
+	Suppliers(filter model.ReportFilter) (model.SpendingReport, error)
+	// Shrinkage is what the stock takes approved in the range found missing
+	// or over, per day or month and per item, today when no range is given.
+	Shrinkage(filter model.ReportFilter) (model.ShrinkageReport, error)
+}
+
+func (p *reportUsecase) Daily(filter model.ReportFilter) (model.SalesReport, error) {
+	from, to, err := p.dayRange(filter)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	if to.Sub(from) > maxDailyReportDays*24*time.Hour {
+		return model.SalesReport{}, apperror.Validation(fmt.Sprintf("a daily report covers at most %d days", maxDailyReportDays), nil)
+	}
+
+	return p.periodReport(from, to, model.PeriodDay)
+}
+
+func (p *reportUsecase) Monthly(filter model.ReportFilter) (model.SalesReport, error) {
+	now := time.Now().In(p.location)
+	to := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, p.location)
+	if filter.To != nil {
+		to = *filter.To
+	}
+	from := time.Date(to.Add(-time.Nanosecond).Year(), time.January, 1, 0, 0, 0, 0, p.location)
+	if filter.From != nil {
+		from = *filter.From
+	}
+	if err := checkRange(from, to); err != nil {
+		return model.SalesReport{}, err
+	}
+	if to.After(from.AddDate(0, maxMonthlyReportMonths, 0)) {
+		return model.SalesReport{}, apperror.Validation(fmt.Sprintf("a monthly report covers at most %d months", maxMonthlyReportMonths), nil)
+	}
+
+	return p.periodReport(from, to, model.PeriodMonth)
+}
+
+func (p *reportUsecase) Menu(filter model.ReportFilter) (model.SalesReport, error) {
+	from, to, err := p.dayRange(filter)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = model.MenuSalesByQty
+	}
+	if filter.Sort != model.MenuSalesByQty && filter.Sort != model.MenuSalesByRevenue {
+		return model.SalesReport{}, apperror.Validation("sort must be qty or revenue", nil)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultMenuReportLimit
+	}
+	if filter.Limit < 1 || filter.Limit > model.MaxPageLimit {
+		return model.SalesReport{}, apperror.Validation(fmt.Sprintf("limit must be between 1 and %d", model.MaxPageLimit), nil)
+	}
+
+	summary, err := p.reportRepository.Summary(from, to)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	menus, err := p.reportRepository.MenuSales(from, to, filter.Sort, filter.Limit)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	payments, err := p.reportRepository.PaymentSales(from, to)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	return model.SalesReport{From: from, To: to, Summary: summary, Menus: menus, Payments: payments}, nil
+}
+
+func (p *reportUsecase) Suppliers(filter model.ReportFilter) (model.SpendingReport, error) {
+	from, to, err := p.dayRange(filter)
+	if err != nil {
+		return model.SpendingReport{}, err
+	}
+
+	suppliers, err := p.reportRepository.SupplierSpending(from, to)
+	if err != nil {
+		return model.SpendingReport{}, err
+	}
+	report := model.SpendingReport{From: from, To: to, Suppliers: suppliers}
+	for _, each := range suppliers {
+		report.Total += each.Spent
+	}
+	return report, nil
+}
+
+func (p *reportUsecase) Shrinkage(filter model.ReportFilter) (model.ShrinkageReport, error) {
+	from, to, err := p.dayRange(filter)
+	if err != nil {
+		return model.ShrinkageReport{}, err
+	}
+
+	switch filter.Period {
+	case "", model.PeriodDay:
+		filter.Period = model.PeriodDay
+		if to.Sub(from) > maxDailyReportDays*24*time.Hour {
+			return model.ShrinkageReport{}, apperror.Validation(fmt.Sprintf("a daily report covers at most %d days", maxDailyReportDays), nil)
+		}
+	case model.PeriodMonth:
+		if to.After(from.AddDate(0, maxMonthlyReportMonths, 0)) {
+			return model.ShrinkageReport{}, apperror.Validation(fmt.Sprintf("a monthly report covers at most %d months", maxMonthlyReportMonths), nil)
+		}
+	default:
+		return model.ShrinkageReport{}, apperror.Validation("period must be day or month", nil)
+	}
+
+	shrinkage, err := p.reportRepository.ShrinkageByPeriod(from, to, filter.Period, p.location)
+	if err != nil {
+		return model.ShrinkageReport{}, err
+	}
+	items, err := p.reportRepository.ShrinkageByItem(from, to)
+	if err != nil {
+		return model.ShrinkageReport{}, err
+	}
+
+	byPeriod := map[string]model.ShrinkagePeriod{}
+	for _, each := range shrinkage {
+		byPeriod[each.Period] = each
+	}
+	report := model.ShrinkageReport{From: from, To: to, Items: items}
+	for _, key := range p.periodKeys(from, to, filter.Period) {
+		each, ok := byPeriod[key]
+		if !ok {
+			each = model.ShrinkagePeriod{Period: key}
+		}
+		report.Summary.StockTakes += each.StockTakes
+		report.Summary.Lost += each.Lost
+		report.Summary.Found += each.Found
+		report.Summary.Unvalued += each.Unvalued
+		report.Periods = append(report.Periods, each)
+	}
+	return report, nil
+}
+
+func (p *reportUsecase) periodReport(from, to time.Time, period string) (model.SalesReport, error) {
+	summary, err := p.reportRepository.Summary(from, to)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	sales, err := p.reportRepository.ByPeriod(from, to, period, p.location)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	menus, err := p.reportRepository.MenuSales(from, to, model.MenuSalesByQty, model.TopMenuCount)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+	payments, err := p.reportRepository.PaymentSales(from, to)
+	if err != nil {
+		return model.SalesReport{}, err
+	}
+
+	return model.SalesReport{
+		From:     from,
+		To:       to,
+		Summary:  summary,
+		Periods:  p.fillPeriods(from, to, period, sales),
+		Menus:    menus,
+		Payments: payments,
+	}, nil
+}
+
+// fillPeriods lists every period of the range, with zeroes for the ones
+// without sales, so a chart of the report has no gaps.
+func (p *reportUsecase) fillPeriods(from, to time.Time, period string, sales []model.SalesPeriod) []model.SalesPeriod {
+	byPeriod := map[string]model.SalesPeriod{}
+	for _, each := range sales {
+		byPeriod[each.Period] = each
+	}
+
+	var periods []model.SalesPeriod
+	for _, key := range p.periodKeys(from, to, period) {
+		each, ok := byPeriod[key]
+		if !ok {
+			each = model.SalesPeriod{Period: key}
+		}
+		periods = append(periods, each)
+	}
+	return periods
+}
+
+// periodKeys lists the days (2006-01-02) or the months (2006-01) the range
+// touches.
+func (p *reportUsecase) periodKeys(from, to time.Time, period string) []string {
+	layout, next := "2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	start := from.In(p.location)
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, p.location)
+	if period == model.PeriodMonth {
+		layout, next = "2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, p.location)
+	}
+
+	var keys []string
+	for t := start; t.Before(to); t = next(t) {
+		keys = append(keys, t.Format(layout))
+	}
+	return keys
+}
+
+// dayRange defaults to today. with only one end given the range is the one
+// day next to it.
+func (p *reportUsecase) dayRange(filter model.ReportFilter) (time.Time, time.Time, error) {
+	now := time.Now().In(p.location)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.location)
+	to := from.AddDate(0, 0, 1)
+
+	switch {
+	case filter.From != nil && filter.To != nil:
+		from, to = *filter.From, *filter.To
+	case filter.From != nil:
+		from = *filter.From
+		to = from.AddDate(0, 0, 1)
+	case filter.To != nil:
+		to = *filter.To
+		from = to.AddDate(0, 0, -1)
+	}
+
+	return from, to, checkRange(from, to)
+}
+
+func checkRange(from, to time.Time) error {
+	if !from.Before(to) {
+		return apperror.Validation("from must be before to", nil)
+	}
+	return nil
+}
+
+func NewReportUsecase(reportRepository repository.ReportRepository, location *time.Location) ReportUsecase {
+	usecase := new(reportUsecase)
+	usecase.reportRepository = reportRepository
+	usecase.location = location
+	return usecase
+}
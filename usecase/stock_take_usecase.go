@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+var stockTakeStatuses = []string{model.StockTakeCounting, model.StockTakeApproved, model.StockTakeCancelled}
+
+type stockTakeUsecase struct {
+	stockTakeRepository  repository.StockTakeRepository
+	menuRepository       repository.MenuRepository
+	ingredientRepository repository.IngredientRepository
+}
+
+type StockTakeUsecase interface {
+	List(status string) ([]model.StockTake, error)
+	GetById(id string) (model.StockTake, error)
+	// Insert starts a stock take, only its Note is read.
+	Insert(stockTake *model.StockTake, userId string) (model.StockTake, error)
+	// Count saves what was counted of menus and ingredients, against the
+	// stock they have now.
+	Count(id string, request model.StockCountRequest, userId string) (model.StockTake, error)
+	// Approve adjusts the stock of the counted items by their variances.
+	Approve(id string, userId string) (model.StockTake, error)
+	// Cancel drops a stock take without touching the stock.
+	Cancel(id string) (model.StockTake, error)
+}
+
+func (p *stockTakeUsecase) List(status string) ([]model.StockTake, error) {
+	if status != "" && !contains(stockTakeStatuses, status) {
+		return nil, apperror.Validation("status must be "+joinOr(stockTakeStatuses), nil)
+	}
+	return p.stockTakeRepository.List(status)
+}
+
+func (p *stockTakeUsecase) GetById(id string) (model.StockTake, error) {
+	return p.stockTakeRepository.GetById(id)
+}
+
+func (p *stockTakeUsecase) Insert(newStockTake *model.StockTake, userId string) (model.StockTake, error) {
+	newStockTake.Id = utils.GenerateId()
+	newStockTake.Note = trimmedOrNil(newStockTake.Note)
+	newStockTake.UserId = &userId
+	return p.stockTakeRepository.Insert(newStockTake)
+}
+
+func (p *stockTakeUsecase) Count(id string, request model.StockCountRequest, userId string) (model.StockTake, error) {
+	if _, err := p.counting(id); err != nil {
+		return model.StockTake{}, err
+	}
+	if len(request.Counts) == 0 {
+		return model.StockTake{}, apperror.Validation("invalid counts", map[string]string{"counts": "at least one item must be counted"})
+	}
+
+	invalid := map[string]string{}
+	seen := map[model.StockItem]bool{}
+	for i, count := range request.Counts {
+		key := fmt.Sprintf("counts[%d]", i)
+		item := count.Item()
+		switch {
+		case (count.MenuId == nil) == (count.IngredientId == nil):
+			invalid[key] = "a count is of either a menu_id or an ingredient_id"
+		case count.Counted == nil:
+			invalid[key] = "counted is required"
+		case *count.Counted < 0:
+			invalid[key] = "counted must not be negative"
+		case seen[item]:
+			invalid[key] = item.String() + " is already counted"
+		default:
+			found, err := stockItemExists(p.menuRepository, p.ingredientRepository, item)
+			if err != nil {
+				return model.StockTake{}, err
+			}
+			if !found {
+				invalid[key] = item.String() + " not found"
+			}
+		}
+		seen[item] = true
+	}
+	if len(invalid) > 0 {
+		return model.StockTake{}, apperror.Validation("invalid counts", invalid)
+	}
+
+	if err := p.stockTakeRepository.Count(id, request.Counts, userId); err != nil {
+		return model.StockTake{}, err
+	}
+	return p.stockTakeRepository.GetById(id)
+}
+
+func (p *stockTakeUsecase) Approve(id string, userId string) (model.StockTake, error) {
+	stockTake, err := p.counting(id)
+	if err != nil {
+		return model.StockTake{}, err
+	}
+	if len(stockTake.Lines) == 0 {
+		return model.StockTake{}, apperror.Validation(fmt.Sprintf("stock take %s has nothing counted", id), nil)
+	}
+
+	if err := p.stockTakeRepository.Approve(id, userId); err != nil {
+		return model.StockTake{}, err
+	}
+	return p.stockTakeRepository.GetById(id)
+}
+
+func (p *stockTakeUsecase) Cancel(id string) (model.StockTake, error) {
+	if _, err := p.counting(id); err != nil {
+		return model.StockTake{}, err
+	}
+	if err := p.stockTakeRepository.Cancel(id); err != nil {
+		return model.StockTake{}, err
+	}
+	return p.stockTakeRepository.GetById(id)
+}
+
+// counting reads a stock take that must still be counting.
+func (p *stockTakeUsecase) counting(id string) (model.StockTake, error) {
+	stockTake, err := p.stockTakeRepository.GetById(id)
+	if err != nil {
+		return model.StockTake{}, err
+	}
+	if stockTake.Status != model.StockTakeCounting {
+		return model.StockTake{}, apperror.Conflict(fmt.Sprintf("stock take %s is %s, it must be counting", id, stockTake.Status), nil)
+	}
+	return stockTake, nil
+}
+
+func NewStockTakeUsecase(stockTakeRepository repository.StockTakeRepository, menuRepository repository.MenuRepository, ingredientRepository repository.IngredientRepository) StockTakeUsecase {
+	usecase := new(stockTakeUsecase)
+	usecase.stockTakeRepository = stockTakeRepository
+	usecase.menuRepository = menuRepository
+	usecase.ingredientRepository = ingredientRepository
+	return usecase
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+type stockUsecase struct {
+	stockRepository      repository.StockRepository
+	menuRepository       repository.MenuRepository
+	ingredientRepository repository.IngredientRepository
+}
+
+type StockUsecase interface {
+	GetByItem(item model.StockItem) ([]model.StockMovement, error)
+	// Restock adds the qty of the request to the stock of a menu or an
+	// ingredient.
+	Restock(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error)
+	// Waste takes the qty of the request out of the stock of a menu or an
+	// ingredient, stock reserved by orders cannot be wasted.
+	Waste(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error)
+	// Adjust corrects the stock of a menu or an ingredient by a signed qty,
+	// the note tells why.
+	Adjust(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error)
+	// Alerts lists the menus and the ingredients under their reorder level.
+	Alerts() ([]model.StockAlert, error)
+}
+
+func (p *stockUsecase) GetByItem(item model.StockItem) ([]model.StockMovement, error) {
+	if item.Kind == model.StockItemIngredient {
+		if _, err := p.ingredientRepository.GetById(item.Id); err != nil {
+			return nil, err
+		}
+	} else if _, err := p.menuRepository.GetById(item.Id, true); err != nil {
+		return nil, err
+	}
+	return p.stockRepository.GetByItem(item)
+}
+
+func (p *stockUsecase) Restock(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	if request.Qty < 1 {
+		return model.StockMovement{}, apperror.Validation("qty must be at least 1", nil)
+	}
+	return p.move(item, request.Qty, model.StockRestock, request.Note, userId)
+}
+
+func (p *stockUsecase) Waste(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	if request.Qty < 1 {
+		return model.StockMovement{}, apperror.Validation("qty must be at least 1", nil)
+	}
+	return p.move(item, -request.Qty, model.StockWaste, request.Note, userId)
+}
+
+func (p *stockUsecase) Adjust(item model.StockItem, request model.StockRequest, userId string) (model.StockMovement, error) {
+	invalid := map[string]string{}
+	if request.Qty == 0 {
+		invalid["qty"] = "qty must not be 0"
+	}
+	if strings.TrimSpace(request.Note) == "" {
+		invalid["note"] = "an adjustment needs a note"
+	}
+	if len(invalid) > 0 {
+		return model.StockMovement{}, apperror.Validation("invalid adjustment", invalid)
+	}
+	return p.move(item, request.Qty, model.StockAdjustment, request.Note, userId)
+}
+
+func (p *stockUsecase) Alerts() ([]model.StockAlert, error) {
+	return p.stockRepository.Alerts()
+}
+
+// move changes the stock of an ingredient or a menu that is not archived
+// and records who did it and why.
+func (p *stockUsecase) move(item model.StockItem, qty int, reason string, note string, userId string) (model.StockMovement, error) {
+	available, err := p.available(item)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+
+	movement := item.Movement(qty, reason)
+	movement.UserId = &userId
+	if note = strings.TrimSpace(note); note != "" {
+		movement.Note = &note
+	}
+	ok, err := p.stockRepository.Move(&movement)
+	if err != nil {
+		return model.StockMovement{}, err
+	}
+	if !ok && qty > 0 {
+		// archived or deleted after it was read
+		return model.StockMovement{}, apperror.NotFound(item.String() + " not found")
+	}
+	if !ok {
+		return model.StockMovement{}, apperror.Conflict(fmt.Sprintf("%s has %d in stock that is not reserved, %d cannot be taken", item, available, -qty), nil)
+	}
+	return movement, nil
+}
+
+// available is the stock of an item that is not reserved.
+func (p *stockUsecase) available(item model.StockItem) (int, error) {
+	if item.Kind == model.StockItemIngredient {
+		ingredient, err := p.ingredientRepository.GetById(item.Id)
+		return ingredient.Available(), err
+	}
+	menu, err := p.menuRepository.GetById(item.Id, false)
+	return menu.Stock - menu.Reserved, err
+}
+
+// stockItemExists tells if an item is a menu that is not archived or an
+// ingredient.
+func stockItemExists(menuRepository repository.MenuRepository, ingredientRepository repository.IngredientRepository, item model.StockItem) (bool, error) {
+	var err error
+	if item.Kind == model.StockItemIngredient {
+		_, err = ingredientRepository.GetById(item.Id)
+	} else {
+		_, err = menuRepository.GetById(item.Id, false)
+	}
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func NewStockUsecase(stockRepository repository.StockRepository, menuRepository repository.MenuRepository, ingredientRepository repository.IngredientRepository) StockUsecase {
+	usecase := new(stockUsecase)
+	usecase.stockRepository = stockRepository
+	usecase.menuRepository = menuRepository
+	usecase.ingredientRepository = ingredientRepository
+	return usecase
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"strings"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+type supplierUsecase struct {
+	supplierRepository repository.SupplierRepository
+}
+
+type SupplierUsecase interface {
+	GetAll() ([]model.Supplier, error)
+	GetById(id string) (model.Supplier, error)
+	Insert(supplier *model.Supplier) (model.Supplier, error)
+	Update(supplier *model.Supplier) (model.Supplier, error)
+	// Delete removes a supplier that no purchase order is of.
+	Delete(id string) error
+}
+
+func (p *supplierUsecase) GetAll() ([]model.Supplier, error) {
+	return p.supplierRepository.GetAll()
+}
+
+func (p *supplierUsecase) GetById(id string) (model.Supplier, error) {
+	return p.supplierRepository.GetById(id)
+}
+
+func (p *supplierUsecase) Insert(newSupplier *model.Supplier) (model.Supplier, error) {
+	if err := checkSupplier(newSupplier); err != nil {
+		return model.Supplier{}, err
+	}
+	newSupplier.Id = utils.GenerateId()
+	return p.supplierRepository.Insert(newSupplier)
+}
+
+func (p *supplierUsecase) Update(newSupplier *model.Supplier) (model.Supplier, error) {
+	if err := checkSupplier(newSupplier); err != nil {
+		return model.Supplier{}, err
+	}
+	return p.supplierRepository.Update(newSupplier)
+}
+
+func (p *supplierUsecase) Delete(id string) error {
+	return p.supplierRepository.Delete(id)
+}
+
+// checkSupplier trims the fields of a supplier, empty contact fields are
+// left out.
+func checkSupplier(supplier *model.Supplier) error {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.Phone = trimmedOrNil(supplier.Phone)
+	supplier.Email = trimmedOrNil(supplier.Email)
+	supplier.Address = trimmedOrNil(supplier.Address)
+
+	invalid := map[string]string{}
+	if supplier.Name == "" {
+		invalid["name"] = "name is required"
+	}
+	if supplier.Email != nil && !strings.Contains(*supplier.Email, "@") {
+		invalid["email"] = "email must be an email address"
+	}
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid supplier", invalid)
+	}
+	return nil
+}
+
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func NewSupplierUsecase(supplierRepository repository.SupplierRepository) SupplierUsecase {
+	usecase := new(supplierUsecase)
+	usecase.supplierRepository = supplierRepository
+	return usecase
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+	"warung-makan/config"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/authenticator"
+)
+
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid, expired or revoked")
+
+type tokenUsecase struct {
+	tokenRepository repository.TokenRepository
+	userRepository  repository.UserRepository
+	accessToken     authenticator.AccessToken
+	config          config.TokenConfig
+}
+
+type TokenUsecase interface {
+	Issue(user *model.User) (model.TokenPair, error)
+	Refresh(refreshToken string) (model.TokenPair, error)
+	Logout(jti string, expiresAt time.Time) error
+	RevokeUserSessions(userId string) error
+	IsRevoked(jti string) (bool, error)
+}
+
+func (p *tokenUsecase) Issue(user *model.User) (model.TokenPair, error) {
+	jti := utils.GenerateId()
+	accessToken, err := p.accessToken.GenerateAccessToken(user, jti)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	refreshToken, hash, err := authenticator.NewRefreshToken()
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	err = p.tokenRepository.InsertRefreshToken(&model.RefreshToken{
+		Id:        utils.GenerateId(),
+		UserId:    user.Id,
+		TokenHash: hash,
+		AccessJti: jti,
+		ExpiresAt: time.Now().Add(p.config.RefreshTokenLifetime),
+	})
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(p.config.AccessTokenLifetime.Seconds()),
+	}, nil
+}
+
+// the used refresh token is revoked and a new pair is issued (rotation)
+func (p *tokenUsecase) Refresh(refreshToken string) (model.TokenPair, error) {
+	stored, err := p.tokenRepository.GetRefreshTokenByHash(authenticator.HashRefreshToken(refreshToken))
+	if err != nil {
+		return model.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt.Valid || time.Now().After(stored.ExpiresAt) {
+		return model.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	revoked, err := p.tokenRepository.RevokeRefreshToken(stored.Id)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if !revoked {
+		return model.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	// reload the user so role changes are picked up
+	user, err := p.userRepository.GetById(stored.UserId, false)
+	if err != nil {
+		return model.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	return p.Issue(&user)
+}
+
+func (p *tokenUsecase) Logout(jti string, expiresAt time.Time) error {
+	err := p.tokenRepository.RevokeAccessToken(jti, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return p.tokenRepository.RevokeRefreshTokenByAccessJti(jti)
+}
+
+func (p *tokenUsecase) RevokeUserSessions(userId string) error {
+	accessJtis, err := p.tokenRepository.RevokeUserRefreshTokens(userId)
+	if err != nil {
+		return err
+	}
+
+	// the access token of a session may have been issued right now
+	expiresAt := time.Now().Add(p.config.AccessTokenLifetime)
+	for _, jti := range accessJtis {
+		err = p.tokenRepository.RevokeAccessToken(jti, expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *tokenUsecase) IsRevoked(jti string) (bool, error) {
+	return p.tokenRepository.IsAccessTokenRevoked(jti)
+}
+
+func NewTokenUsecase(tokenRepository repository.TokenRepository, userRepository repository.UserRepository, accessToken authenticator.AccessToken, config config.TokenConfig) TokenUsecase {
+	usecase := new(tokenUsecase)
+	usecase.tokenRepository = tokenRepository
+	usecase.userRepository = userRepository
+	usecase.accessToken = accessToken
+	usecase.config = config
+	return usecase
+}
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+// orders that still hold reserved stock
+var reservedStatuses = []string{model.StatusSentToKitchen, model.StatusServed}
+
+func (p *transactionUsecase) Open(items []model.TransactionDetail) (model.Transaction, error) {
+	if err := checkDiscounts(items, nil, ""); err != nil {
+		return model.Transaction{}, err
+	}
+
+	tx, err := p.transactionRepository.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	order := model.Transaction{Id: utils.GenerateId(), Status: model.StatusOpen}
+	order.Items, err = newOrderItems(tx, order.Id, items)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	p.price(&order, nil)
+
+	if err := tx.Insert(&order); err != nil {
+		return model.Transaction{}, err
+	}
+	if order, err = tx.LockTransaction(order.Id); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return order, nil
+}
+
+func (p *transactionUsecase) AddItems(id string, items []model.TransactionDetail) (model.Transaction, error) {
+	if len(items) == 0 {
+		return model.Transaction{}, fmt.Errorf("%w: no items", ErrInvalidOrder)
+	}
+	if err := checkDiscounts(items, nil, ""); err != nil {
+		return model.Transaction{}, err
+	}
+
+	return p.changeOrder(id, []string{model.StatusOpen}, func(tx repository.TransactionTx, order model.Transaction) error {
+		added, err := newOrderItems(tx, id, items)
+		if err != nil {
+			return err
+		}
+		if err := tx.InsertItems(added); err != nil {
+			return err
+		}
+		order.Items = append(order.Items, added...)
+		p.price(&order, nil)
+		return tx.SetPrices(&order)
+	})
+}
+
+func (p *transactionUsecase) RemoveItem(id string, itemId int64) (model.Transaction, error) {
+	return p.changeOrder(id, []string{model.StatusOpen}, func(tx repository.TransactionTx, order model.Transaction) error {
+		for i, item := range order.Items {
+			if item.Id == itemId {
+				if err := tx.DeleteItem(id, itemId); err != nil {
+					return err
+				}
+				order.Items = append(order.Items[:i:i], order.Items[i+1:]...)
+				p.price(&order, nil)
+				return tx.SetPrices(&order)
+			}
+		}
+		return apperror.NotFound(fmt.Sprintf("item %d of transaction %s not found", itemId, id))
+	})
+}
+
+func (p *transactionUsecase) Send(id string) (model.Transaction, error) {
+	var alerts []model.StockAlert
+	order, err := p.changeOrder(id, []string{model.StatusOpen}, func(tx repository.TransactionTx, order model.Transaction) error {
+		var err error
+		if _, alerts, err = takeOrderStock(tx, order, tx.ReserveStock, tx.ReserveIngredient); err != nil {
+			return err
+		}
+		return tx.SetStatus(id, model.StatusSentToKitchen)
+	})
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	p.notify(alerts)
+	return order, nil
+}
+
+func (p *transactionUsecase) Serve(id string) (model.Transaction, error) {
+	return p.changeOrder(id, []string{model.StatusSentToKitchen}, func(tx repository.TransactionTx, order model.Transaction) error {
+		return tx.SetStatus(id, model.StatusServed)
+	})
+}
+
+func (p *transactionUsecase) Pay(id string, request model.PaymentRequest) (model.Transaction, error) {
+	promoCode := normalizePromoCode(request.PromoCode)
+	if err := checkDiscounts(nil, request.Discount, promoCode); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := checkPayments(request.Payments); err != nil {
+		return model.Transaction{}, err
+	}
+
+	var settled []model.Payment
+	var alerts []model.StockAlert
+	from := append([]string{model.StatusOpen}, reservedStatuses...)
+	order, err := p.changeOrder(id, from, func(tx repository.TransactionTx, order model.Transaction) error {
+		var needs stockNeeds
+		var err error
+		if order.Status == model.StatusOpen {
+			needs, alerts, err = takeOrderStock(tx, order, tx.DecreaseStock, tx.DecreaseIngredient)
+		} else if needs, err = orderNeeds(tx, order.Items); err == nil {
+			err = takeNeeds(needs, always(tx.CommitStock), always(tx.CommitIngredient))
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.InsertMovements(stockMovements(model.StockSale, id, "", needs, -1)); err != nil {
+			return err
+		}
+
+		// the promo is locked after the menus, like a sale locks them
+		discount, err := orderDiscount(tx, promoCode, request.Discount)
+		if err != nil {
+			return err
+		}
+		order.PromoCode = promoCode
+		p.price(&order, discount)
+		if settled, err = settle(id, order.TotalPrice, request.Payments); err != nil {
+			return err
+		}
+
+		if err := tx.SetPrices(&order); err != nil {
+			return err
+		}
+		if err := tx.InsertPayments(settled); err != nil {
+			return err
+		}
+		return tx.SetStatus(id, model.StatusPaid)
+	})
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	p.notify(alerts)
+	order.Payments = settled
+	return order, nil
+}
+
+func (p *transactionUsecase) Cancel(id string) (model.Transaction, error) {
+	from := append([]string{model.StatusOpen}, reservedStatuses...)
+	return p.changeOrder(id, from, func(tx repository.TransactionTx, order model.Transaction) error {
+		if order.Status != model.StatusOpen {
+			needs, err := orderNeeds(tx, order.Items)
+			if err != nil {
+				return err
+			}
+			if err := takeNeeds(needs, always(tx.ReleaseStock), always(tx.ReleaseIngredient)); err != nil {
+				return err
+			}
+		}
+		return tx.SetStatus(id, model.StatusCancelled)
+	})
+}
+
+// changeOrder locks an order in one of the from statuses, changes it and
+// returns it as it is after the change.
+func (p *transactionUsecase) changeOrder(id string, from []string, change func(tx repository.TransactionTx, order model.Transaction) error) (model.Transaction, error) {
+	tx, err := p.transactionRepository.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	order, err := tx.LockTransaction(id)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if !contains(from, order.Status) {
+		return model.Transaction{}, apperror.Conflict(fmt.Sprintf("transaction %s is %s, it must be %s", id, order.Status, joinOr(from)), nil)
+	}
+
+	if err := change(tx, order); err != nil {
+		return model.Transaction{}, err
+	}
+	if order, err = tx.LockTransaction(id); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+	return order, nil
+}
+
+// newOrderItems prices new lines of an order, stock is not checked until
+// the order is sent or paid. the lines keep the recipes of their menus as
+// they are now.
+func newOrderItems(tx repository.TransactionTx, orderId string, lines []model.TransactionDetail) ([]model.TransactionDetail, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	catalog := orderCatalog{menus: map[string]model.Menu{}, modifiers: map[string][]model.ModifierGroup{}}
+	if menuIds := orderedMenuIds(lines); len(menuIds) > 0 {
+		var err error
+		if catalog, err = loadCatalog(tx, menuIds, false); err != nil {
+			return nil, err
+		}
+	}
+
+	accepted, _, rejected := allocate(lines, catalog, false)
+	if len(rejected) > 0 {
+		return nil, rejectedOrder(rejected)
+	}
+	return priceItems(orderId, accepted, catalog.menus), nil
+}
+
+// takeOrderStock checks the available stock for every item of an order and
+// takes it with takeMenu and takeIngredient. it returns the stock taken and
+// the items it puts under their reorder level.
+func takeOrderStock(tx repository.TransactionTx, order model.Transaction, takeMenu func(menuId string, qty int) (bool, error), takeIngredient func(ingredientId string, qty int) (bool, error)) (stockNeeds, []model.StockAlert, error) {
+	if len(order.Items) == 0 {
+		return stockNeeds{}, nil, fmt.Errorf("%w: no items", ErrInvalidOrder)
+	}
+
+	menus, err := lockMenus(tx, orderedMenuIds(order.Items))
+	if err != nil {
+		return stockNeeds{}, nil, err
+	}
+	ingredients, err := lockIngredients(tx, lineIngredientIds(order.Items))
+	if err != nil {
+		return stockNeeds{}, nil, err
+	}
+	// the modifiers were checked and the recipes kept when the items were
+	// added
+	_, needs, rejected := allocate(order.Items, orderCatalog{menus: menus, ingredients: ingredients}, true)
+	if len(rejected) > 0 {
+		return stockNeeds{}, nil, rejectedOrder(rejected)
+	}
+	if err := takeNeeds(needs, takeMenu, takeIngredient); err != nil {
+		return stockNeeds{}, nil, err
+	}
+	return needs, stockAlerts(menus, ingredients, needs), nil
+}
+
+// orderNeeds is the stock items took when they were sold or sent. the
+// ingredients they use are locked after their menus and the ones that no
+// longer exist are left out.
+func orderNeeds(tx repository.TransactionTx, items []model.TransactionDetail) (stockNeeds, error) {
+	ingredients := map[string]model.Ingredient{}
+	if ingredientIds := lineIngredientIds(items); len(ingredientIds) > 0 {
+		if _, err := tx.LockMenus(orderedMenuIds(items)); err != nil {
+			return stockNeeds{}, err
+		}
+		var err error
+		if ingredients, err = lockIngredients(tx, ingredientIds); err != nil {
+			return stockNeeds{}, err
+		}
+	}
+
+	needs := newStockNeeds()
+	for _, each := range items {
+		needs.add(each, each.Qty, ingredients)
+	}
+	return needs, nil
+}
+
+func lineIngredientIds(items []model.TransactionDetail) []string {
+	var ids []string
+	for _, item := range items {
+		for _, each := range item.Ingredients {
+			ids = append(ids, each.IngredientId)
+		}
+	}
+	return ids
+}
+
+// always turns a stock change that cannot fall short into one for
+// takeStock.
+func always(change func(menuId string, qty int) error) func(string, int) (bool, error) {
+	return func(menuId string, qty int) (bool, error) {
+		return true, change(menuId, qty)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, each := range values {
+		if each == value {
+			return true
+		}
+	}
+	return false
+}
+
+func joinOr(values []string) string {
+	joined := ""
+	for i, each := range values {
+		switch {
+		case i == 0:
+		case i == len(values)-1:
+			joined += " or "
+		default:
+			joined += ", "
+		}
+		joined += each
+	}
+	return joined
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"fmt"
+	"warung-makan/model"
+	"warung-makan/utils/apperror"
+)
+
+// checkPayments validates the payments of a sale on their own, before its
+// total is known.
+func checkPayments(payments []model.Payment) error {
+	invalid := map[string]string{}
+	withoutAmount := 0
+	for i, each := range payments {
+		key := fmt.Sprintf("payments[%d]", i)
+		cash := each.Method == model.PaymentCash
+		switch {
+		case !contains(model.PaymentMethods, each.Method):
+			invalid[key] = "method must be " + joinOr(model.PaymentMethods)
+		case each.Amount < 0:
+			invalid[key] = "amount must not be negative"
+		case !cash && each.Tendered != nil:
+			invalid[key] = "only cash payments have tendered"
+		case !cash && each.Amount == 0:
+			invalid[key] = "amount must be at least 1"
+		case each.Tendered == nil && each.Amount == 0:
+			invalid[key] = "a cash payment needs amount or tendered"
+		case each.Tendered != nil && *each.Tendered < each.Amount:
+			invalid[key] = "tendered must be at least the amount"
+		case each.Amount == 0:
+			withoutAmount++
+			if withoutAmount > 1 {
+				invalid[key] = "only one cash payment can leave out the amount"
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid payments", invalid)
+	}
+	return nil
+}
+
+// settle fills in the payments of a sale: the amount of the cash payment
+// that left it out and the change of cash. the payments must add up to the
+// total, cash handed over above it is change.
+func settle(transactionId string, total int, payments []model.Payment) ([]model.Payment, error) {
+	if len(payments) == 0 && total > 0 {
+		return nil, apperror.Validation(fmt.Sprintf("payments are required, the total is %d", total), nil)
+	}
+
+	paid := 0
+	for _, each := range payments {
+		paid += each.Amount
+	}
+
+	settled := make([]model.Payment, len(payments))
+	for i, each := range payments {
+		each.Id = 0
+		each.TransactionId = transactionId
+		if each.Method == model.PaymentCash {
+			if each.Amount == 0 {
+				if total-paid < 1 {
+					return nil, apperror.Validation(fmt.Sprintf("nothing is left to pay for payments[%d]", i), nil)
+				}
+				each.Amount = *each.Tendered
+				if each.Amount > total-paid {
+					each.Amount = total - paid
+				}
+				paid += each.Amount
+			}
+
+			tendered := each.Amount
+			if each.Tendered != nil {
+				tendered = *each.Tendered
+			}
+			change := tendered - each.Amount
+			each.Tendered, each.Change = &tendered, &change
+		}
+		settled[i] = each
+	}
+
+	switch {
+	case paid < total:
+		return nil, apperror.Validation(fmt.Sprintf("payments of %d do not cover the total of %d", paid, total), nil)
+	case paid > total:
+		return nil, apperror.Validation(fmt.Sprintf("payments of %d are more than the total of %d", paid, total), nil)
+	}
+	return settled, nil
+}
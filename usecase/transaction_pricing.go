@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"warung-makan/model"
+	"warung-makan/repository"
+	"warung-makan/utils/apperror"
+)
+
+// checkDiscounts validates the line discounts and the order discount or
+// promo code of a sale before anything is priced.
+func checkDiscounts(lines []model.TransactionDetail, discount *model.Discount, promoCode string) error {
+	invalid := map[string]string{}
+	for i, line := range lines {
+		if problem := checkDiscount(line.Discount); problem != "" {
+			invalid[fmt.Sprintf("items[%d].discount", i)] = problem
+		}
+	}
+	if problem := checkDiscount(discount); problem != "" {
+		invalid["discount"] = problem
+	}
+	if discount != nil && promoCode != "" {
+		invalid["promo_code"] = "a promo code cannot be combined with an order discount"
+	}
+
+	if len(invalid) > 0 {
+		return apperror.Validation("invalid discounts", invalid)
+	}
+	return nil
+}
+
+func checkDiscount(discount *model.Discount) string {
+	switch {
+	case discount == nil:
+		return ""
+	case discount.Type != model.DiscountPercent && discount.Type != model.DiscountFixed:
+		return "type must be percent or fixed"
+	case discount.Value < 1:
+		return "value must be at least 1"
+	case discount.Type == model.DiscountPercent && discount.Value > 100:
+		return "a percent discount is at most 100"
+	}
+	return ""
+}
+
+// discountOf is what discount takes off amount, never more than amount.
+func discountOf(discount *model.Discount, amount int) int {
+	switch {
+	case discount == nil:
+		return 0
+	case discount.Type == model.DiscountPercent:
+		return rateOf(amount, discount.Value*100)
+	case discount.Value > amount:
+		return amount
+	}
+	return discount.Value
+}
+
+// rateOf is rate basis points of amount, rounded half up.
+func rateOf(amount, rate int) int {
+	return (amount*rate + 5000) / 10000
+}
+
+// price fills in the price breakdown of a transaction from the subtotals
+// and line discounts of its items. the order discount is taken off after
+// the line discounts, the service charge is added to what is left and the
+// tax to both.
+func (p *transactionUsecase) price(transaction *model.Transaction, discount *model.Discount) {
+	subtotal, lineDiscounts := 0, 0
+	for _, item := range transaction.Items {
+		subtotal += item.Subtotal
+		lineDiscounts += item.DiscountAmount
+	}
+	orderDiscount := discountOf(discount, subtotal-lineDiscounts)
+	net := subtotal - lineDiscounts - orderDiscount
+
+	transaction.Subtotal = subtotal
+	transaction.DiscountAmount = lineDiscounts + orderDiscount
+	transaction.ServiceCharge = rateOf(net, p.pricing.ServiceRate)
+	transaction.Tax = rateOf(net+transaction.ServiceCharge, p.pricing.TaxRate)
+	transaction.TotalPrice = net + transaction.ServiceCharge + transaction.Tax
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// orderDiscount is the order discount of a sale, the one of its promo when
+// it has a promo code. the promo is counted as used.
+func orderDiscount(tx repository.TransactionTx, promoCode string, discount *model.Discount) (*model.Discount, error) {
+	if promoCode == "" {
+		return discount, nil
+	}
+
+	promo, err := tx.LockPromo(promoCode)
+	if err != nil {
+		return nil, err
+	}
+	if err := promoUsable(promo, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := tx.UsePromo(promoCode); err != nil {
+		return nil, err
+	}
+	return &promo.Discount, nil
+}
+
+func promoUsable(promo model.Promo, now time.Time) error {
+	switch {
+	case !promo.Active:
+		return apperror.Conflict("promo "+promo.Code+" is not active", nil)
+	case now.Before(promo.StartsAt):
+		return apperror.Conflict("promo "+promo.Code+" starts at "+promo.StartsAt.Format(time.RFC3339), nil)
+	case promo.EndsAt != nil && !now.Before(*promo.EndsAt):
+		return apperror.Conflict("promo "+promo.Code+" has ended", nil)
+	case promo.UsageLimit != nil && promo.Used >= *promo.UsageLimit:
+		return apperror.Conflict("promo "+promo.Code+" is used up", nil)
+	}
+	return nil
+}
+
+// lineShares splits the total of a transaction over its items by what they
+// cost after their line discount, so every item carries its part of the
+// order discount, service charge and tax. the shares add up to the total,
+// the rupiah left by rounding go to the largest remainders.
+func lineShares(transaction model.Transaction) map[int64]int {
+	weight := 0
+	for _, item := range transaction.Items {
+		weight += item.Subtotal - item.DiscountAmount
+	}
+
+	shares := map[int64]int{}
+	if weight == 0 {
+		return shares
+	}
+
+	remainders := make([]int, len(transaction.Items))
+	left := transaction.TotalPrice
+	for i, item := range transaction.Items {
+		part := transaction.TotalPrice * (item.Subtotal - item.DiscountAmount)
+		shares[item.Id] = part / weight
+		remainders[i] = part % weight
+		left -= shares[item.Id]
+	}
+
+	order := make([]int, len(transaction.Items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, i := range order[:left] {
+		shares[transaction.Items[i].Id]++
+	}
+	return shares
+}
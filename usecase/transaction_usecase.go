@@ -1,20 +1,77 @@
 package usecase
 
 import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"warung-makan/config"
 	"warung-makan/model"
 	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+	"warung-makan/utils/notifier"
 )
 
+var ErrInvalidOrder = apperror.Validation("invalid order", nil)
+
+// rejectedOrder is returned when a strict order has invalid lines, or when
+// nothing is left of a partial order. it is a conflict when the only
+// problem is stock, the rejected lines are the details.
+func rejectedOrder(rejected []model.RejectedItem) error {
+	message := fmt.Sprintf("%d of the order lines cannot be sold", len(rejected))
+	for _, each := range rejected {
+		if each.Reason != model.RejectInsufficientStock {
+			return apperror.Validation(message, rejected)
+		}
+	}
+	return apperror.Conflict(message, rejected)
+}
+
 type transactionUsecase struct {
 	transactionRepository repository.TransactionRepository
+	pricing               config.PricingConfig
+	notifier              notifier.Notifier
 }
 
 type TransactionUsecase interface {
 	GetAll() ([]model.Transaction, error)
+	List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error)
+	Export(filter model.TransactionFilter, each func(model.Transaction) error) error
 	// GetAllPaginated(page int, rows int) ([]model.Transaction, error)
 	GetById(id string) (model.Transaction, error)
 
-	Insert(transaction *model.Transaction) (model.Transaction, error)
+	// Insert prices the items from the menus and takes their stock in one db
+	// transaction. only MenuId and Qty of the items are read. an order with
+	// an invalid line is rejected, unless partial is set, then the invalid
+	// lines are dropped and returned in RejectedItems. the order discount or
+	// promo, the service charge and the tax are priced on the lines that are
+	// sold, the payments must cover the total. the menus and ingredients
+	// the sale puts under their reorder level are alerted after the commit.
+	Insert(transaction *model.Transaction, partial bool) (model.Transaction, error)
+	// Open starts an order that is paid later, items can be added and
+	// removed until it is sent to the kitchen. it may start without items.
+	Open(items []model.TransactionDetail) (model.Transaction, error)
+	AddItems(id string, items []model.TransactionDetail) (model.Transaction, error)
+	RemoveItem(id string, itemId int64) (model.Transaction, error)
+	// Send reserves the stock of an open order for the kitchen, like a sale
+	// it alerts the stock it puts under the reorder level.
+	Send(id string) (model.Transaction, error)
+	Serve(id string) (model.Transaction, error)
+	// Pay prices an order with its order discount or promo, records the
+	// payments and takes its stock. an order that was not sent takes it from
+	// the available stock, like Insert.
+	Pay(id string, request model.PaymentRequest) (model.Transaction, error)
+	// Cancel drops an order that is not paid and releases its stock.
+	Cancel(id string) (model.Transaction, error)
+	// Void cancels a paid transaction that has no refunds, every item goes
+	// back to stock.
+	Void(id string, request model.RefundRequest, userId string) (model.Refund, error)
+	// Refund gives back the requested items, or all that are left when none
+	// are requested, and puts their quantity back to stock.
+	Refund(id string, request model.RefundRequest, userId string) (model.Refund, error)
 	// Update(transaction *model.Transaction) (model.Transaction, error)
 	// Delete(id string) error
 }
@@ -23,6 +80,14 @@ func (p *transactionUsecase) GetAll() ([]model.Transaction, error) {
 	return p.transactionRepository.GetAll()
 }
 
+func (p *transactionUsecase) List(filter model.TransactionFilter) ([]model.Transaction, model.PageInfo, error) {
+	return p.transactionRepository.List(filter)
+}
+
+func (p *transactionUsecase) Export(filter model.TransactionFilter, each func(model.Transaction) error) error {
+	return p.transactionRepository.Export(filter, each)
+}
+
 // func (p *transactionUsecase) GetAllPaginated(page int, rows int) ([]model.Transaction, error) {
 // 	return p.transactionRepository.GetAllPaginated(page, rows)
 // }
@@ -31,8 +96,620 @@ func (p *transactionUsecase) GetById(id string) (model.Transaction, error) {
 	return p.transactionRepository.GetById(id)
 }
 
-func (p *transactionUsecase) Insert(newTransaction *model.Transaction) (model.Transaction, error) {
-	return p.transactionRepository.Insert(newTransaction)
+func (p *transactionUsecase) Insert(newTransaction *model.Transaction, partial bool) (model.Transaction, error) {
+	if len(newTransaction.Items) == 0 {
+		return model.Transaction{}, fmt.Errorf("%w: no items", ErrInvalidOrder)
+	}
+	newTransaction.PromoCode = normalizePromoCode(newTransaction.PromoCode)
+	if err := checkDiscounts(newTransaction.Items, newTransaction.Discount, newTransaction.PromoCode); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := checkPayments(newTransaction.Payments); err != nil {
+		return model.Transaction{}, err
+	}
+
+	menuIds := orderedMenuIds(newTransaction.Items)
+	if len(menuIds) == 0 {
+		_, _, rejected := allocate(newTransaction.Items, orderCatalog{}, false)
+		return model.Transaction{}, rejectedOrder(rejected)
+	}
+
+	tx, err := p.transactionRepository.Begin()
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	catalog, err := loadCatalog(tx, menuIds, true)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	accepted, needs, rejected := allocate(newTransaction.Items, catalog, true)
+	if len(rejected) > 0 && (!partial || len(accepted) == 0) {
+		return model.Transaction{}, rejectedOrder(rejected)
+	}
+
+	transaction := *newTransaction
+	transaction.Id = utils.GenerateId()
+	transaction.Items = priceItems(transaction.Id, accepted, catalog.menus)
+	transaction.Status = model.StatusPaid
+	transaction.RejectedItems = rejected
+
+	discount, err := orderDiscount(tx, transaction.PromoCode, transaction.Discount)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	p.price(&transaction, discount)
+
+	transaction.Payments, err = settle(transaction.Id, transaction.TotalPrice, newTransaction.Payments)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if err := takeNeeds(needs, tx.DecreaseStock, tx.DecreaseIngredient); err != nil {
+		return model.Transaction{}, err
+	}
+
+	if err := tx.Insert(&transaction); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := tx.InsertMovements(stockMovements(model.StockSale, transaction.Id, "", needs, -1)); err != nil {
+		return model.Transaction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Transaction{}, err
+	}
+
+	p.notify(stockAlerts(catalog.menus, catalog.ingredients, needs))
+	return transaction, nil
+}
+
+// orderedMenuIds are the menus of the lines with a valid qty, sorted so
+// their rows are always locked in the same order.
+func orderedMenuIds(lines []model.TransactionDetail) []string {
+	var menuIds []string
+	for _, each := range lines {
+		if each.Qty >= 1 {
+			menuIds = append(menuIds, each.MenuId)
+		}
+	}
+	return uniqueSorted(menuIds)
+}
+
+func lockMenus(tx repository.TransactionTx, menuIds []string) (map[string]model.Menu, error) {
+	locked, err := tx.LockMenus(menuIds)
+	if err != nil {
+		return nil, err
+	}
+
+	menus := map[string]model.Menu{}
+	for _, menu := range locked {
+		menus[menu.Id] = menu
+	}
+	return menus, nil
+}
+
+// menuModifiers are the modifier groups of menus, keyed by menu id.
+func menuModifiers(tx repository.TransactionTx, menuIds []string) (map[string][]model.ModifierGroup, error) {
+	groups, err := tx.ModifierGroups(menuIds)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiers := map[string][]model.ModifierGroup{}
+	for _, group := range groups {
+		modifiers[group.MenuId] = append(modifiers[group.MenuId], group)
+	}
+	return modifiers, nil
+}
+
+// menuRecipes are the ingredients of one portion of menus, keyed by menu
+// id. menus without a recipe are left out.
+func menuRecipes(tx repository.TransactionTx, menuIds []string) (map[string]model.LineIngredients, error) {
+	lines, err := tx.Recipes(menuIds)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := map[string]model.LineIngredients{}
+	for _, line := range lines {
+		recipes[line.MenuId] = append(recipes[line.MenuId], model.LineIngredient{IngredientId: line.IngredientId, Qty: line.Qty})
+	}
+	return recipes, nil
+}
+
+// lockIngredients locks the ingredients after the menus, sorted like them.
+func lockIngredients(tx repository.TransactionTx, ids []string) (map[string]model.Ingredient, error) {
+	ingredients := map[string]model.Ingredient{}
+	if len(ids) == 0 {
+		return ingredients, nil
+	}
+
+	locked, err := tx.LockIngredients(uniqueSorted(ids))
+	if err != nil {
+		return nil, err
+	}
+	for _, ingredient := range locked {
+		ingredients[ingredient.Id] = ingredient
+	}
+	return ingredients, nil
+}
+
+// orderCatalog is what the lines of an order are checked against. without
+// modifiers the chosen options are not checked, without recipes the lines
+// keep the ingredients they were added with.
+type orderCatalog struct {
+	menus       map[string]model.Menu
+	modifiers   map[string][]model.ModifierGroup
+	recipes     map[string]model.LineIngredients
+	ingredients map[string]model.Ingredient
+}
+
+// loadCatalog locks the menus and reads their modifiers and recipes. with
+// withStock the ingredients of the recipes are locked too.
+func loadCatalog(tx repository.TransactionTx, menuIds []string, withStock bool) (orderCatalog, error) {
+	var catalog orderCatalog
+	var err error
+	if catalog.menus, err = lockMenus(tx, menuIds); err != nil {
+		return orderCatalog{}, err
+	}
+	if catalog.modifiers, err = menuModifiers(tx, menuIds); err != nil {
+		return orderCatalog{}, err
+	}
+	if catalog.recipes, err = menuRecipes(tx, menuIds); err != nil {
+		return orderCatalog{}, err
+	}
+	if !withStock {
+		return catalog, nil
+	}
+
+	var ingredientIds []string
+	for _, recipe := range catalog.recipes {
+		for _, each := range recipe {
+			ingredientIds = append(ingredientIds, each.IngredientId)
+		}
+	}
+	if catalog.ingredients, err = lockIngredients(tx, ingredientIds); err != nil {
+		return orderCatalog{}, err
+	}
+	return catalog, nil
+}
+
+// stockNeeds is how much stock of menus and of ingredients lines take, by
+// id. a line with ingredients takes them instead of the stock of its menu.
+type stockNeeds struct {
+	menus       map[string]int
+	ingredients map[string]int
+}
+
+func newStockNeeds() stockNeeds {
+	return stockNeeds{menus: map[string]int{}, ingredients: map[string]int{}}
+}
+
+// add counts qty portions of a line. ingredients that are not in known are
+// no longer kept and left out.
+func (n stockNeeds) add(line model.TransactionDetail, qty int, known map[string]model.Ingredient) {
+	if len(line.Ingredients) == 0 {
+		n.menus[line.MenuId] += qty
+		return
+	}
+	for _, each := range line.Ingredients {
+		if _, ok := known[each.IngredientId]; ok {
+			n.ingredients[each.IngredientId] += each.Qty * qty
+		}
+	}
+}
+
+// portions is how many portions of a line the ingredients have left after
+// what is already needed.
+func (n stockNeeds) portions(line model.TransactionDetail, known map[string]model.Ingredient) int {
+	portions := -1
+	for _, each := range line.Ingredients {
+		ingredient, ok := known[each.IngredientId]
+		if !ok {
+			continue
+		}
+		left := (ingredient.Available() - n.ingredients[each.IngredientId]) / each.Qty
+		if portions < 0 || left < portions {
+			portions = left
+		}
+	}
+	if portions < 0 {
+		// none of its ingredients are kept any more
+		return math.MaxInt32
+	}
+	return portions
+}
+
+// allocate walks the lines in order, a menu or an ingredient used by more
+// than one line shares its available stock. it returns the lines that can
+// be sold, the stock they need and the lines that cannot be sold. without
+// withStock only the qty and the menu of the lines are checked. the
+// accepted lines carry their modifiers as sold and their ingredients as
+// the recipe is, when the catalog has them.
+func allocate(lines []model.TransactionDetail, catalog orderCatalog, withStock bool) ([]model.TransactionDetail, stockNeeds, []model.RejectedItem) {
+	var accepted []model.TransactionDetail
+	var rejected []model.RejectedItem
+	needs := newStockNeeds()
+
+	reject := func(index int, line model.TransactionDetail, reason string, available *int, problem string) {
+		rejected = append(rejected, model.RejectedItem{
+			Index:     index,
+			MenuId:    line.MenuId,
+			Qty:       line.Qty,
+			Reason:    reason,
+			Available: available,
+			Problem:   problem,
+		})
+	}
+
+	for i, each := range lines {
+		menu, ok := catalog.menus[each.MenuId]
+		problem := ""
+		if ok && catalog.modifiers != nil {
+			each.Modifiers, problem = chooseModifiers(menu, each.Modifiers, catalog.modifiers[each.MenuId])
+		}
+		if ok && catalog.recipes != nil {
+			each.Ingredients = catalog.recipes[each.MenuId]
+		}
+
+		available := menu.Available() - needs.menus[each.MenuId]
+		if len(each.Ingredients) > 0 {
+			available = needs.portions(each, catalog.ingredients)
+		}
+		switch {
+		case each.Qty < 1:
+			reject(i, each, model.RejectInvalidQty, nil, "")
+		case !ok:
+			reject(i, each, model.RejectMenuNotFound, nil, "")
+		case problem != "":
+			reject(i, each, model.RejectInvalidModifiers, nil, problem)
+		case withStock && each.Qty > available:
+			reject(i, each, model.RejectInsufficientStock, &available, "")
+		default:
+			needs.add(each, each.Qty, catalog.ingredients)
+			accepted = append(accepted, each)
+		}
+	}
+	return accepted, needs, rejected
+}
+
+// chooseModifiers checks the options chosen on a line of menu against its
+// modifier groups. it returns them as sold, in the order of the groups, or
+// what is wrong with them.
+func chooseModifiers(menu model.Menu, chosen model.LineModifiers, groups []model.ModifierGroup) (model.LineModifiers, string) {
+	wanted := map[int64]bool{}
+	for _, each := range chosen {
+		if wanted[each.OptionId] {
+			return nil, fmt.Sprintf("option %d is chosen twice", each.OptionId)
+		}
+		wanted[each.OptionId] = true
+	}
+
+	var sold model.LineModifiers
+	for _, group := range groups {
+		count := 0
+		for _, option := range group.Options {
+			if !wanted[option.Id] {
+				continue
+			}
+			delete(wanted, option.Id)
+			count++
+			sold = append(sold, model.LineModifier{
+				OptionId:   option.Id,
+				Group:      group.Name,
+				Name:       option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+		}
+		if count < group.MinSelect {
+			return nil, fmt.Sprintf("%s needs at least %d choice(s)", group.Name, group.MinSelect)
+		}
+		if count > group.MaxSelect {
+			return nil, fmt.Sprintf("%s allows at most %d choice(s)", group.Name, group.MaxSelect)
+		}
+	}
+	for _, each := range chosen {
+		if wanted[each.OptionId] {
+			return nil, fmt.Sprintf("option %d is not a modifier of menu %s", each.OptionId, menu.Id)
+		}
+	}
+	if menu.Price+sold.PriceDelta() < 0 {
+		return nil, "the modifiers take the price below 0"
+	}
+	return sold, ""
+}
+
+// takeStock takes the qty of every menu or ingredient with take, in id
+// order like they were locked. take returns false when the stock is no
+// longer there.
+func takeStock(kind string, take func(id string, qty int) (bool, error), taken map[string]int) error {
+	for _, id := range sortedKeys(taken) {
+		ok, err := take(id, taken[id])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperror.Conflict("stock of "+kind+" "+id+" changed, try again", nil)
+		}
+	}
+	return nil
+}
+
+// takeNeeds takes the stock of the menus and then of the ingredients.
+func takeNeeds(needs stockNeeds, takeMenu func(menuId string, qty int) (bool, error), takeIngredient func(ingredientId string, qty int) (bool, error)) error {
+	if err := takeStock(model.StockItemMenu, takeMenu, needs.menus); err != nil {
+		return err
+	}
+	return takeStock(model.StockItemIngredient, takeIngredient, needs.ingredients)
+}
+
+// stockMovements record the stock taken from or put back to every menu and
+// ingredient by a transaction, in id order. sign is -1 for stock that goes
+// out.
+func stockMovements(reason string, transactionId string, userId string, needs stockNeeds, sign int) []model.StockMovement {
+	var movements []model.StockMovement
+	record := func(kind string, qty map[string]int) {
+		for _, id := range sortedKeys(qty) {
+			movement := model.StockItem{Kind: kind, Id: id}.Movement(sign*qty[id], reason)
+			movement.TransactionId = &transactionId
+			if userId != "" {
+				movement.UserId = &userId
+			}
+			movements = append(movements, movement)
+		}
+	}
+	record(model.StockItemMenu, needs.menus)
+	record(model.StockItemIngredient, needs.ingredients)
+	return movements
+}
+
+// stockAlerts are the menus and the ingredients that taking needs puts
+// under their reorder level, from the stock they had when they were
+// locked. the ones that were already under it are not alerted again.
+func stockAlerts(menus map[string]model.Menu, ingredients map[string]model.Ingredient, needs stockNeeds) []model.StockAlert {
+	var alerts []model.StockAlert
+	check := func(alert model.StockAlert, level *int, taken int) {
+		if level == nil || taken <= 0 {
+			return
+		}
+		before := alert.Available
+		alert.Available -= taken
+		alert.ReorderLevel = *level
+		if before >= *level && alert.Available < *level {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for _, id := range sortedKeys(needs.menus) {
+		menu := menus[id]
+		check(model.StockAlert{Kind: model.StockItemMenu, Id: id, Name: menu.Name, Available: menu.Stock - menu.Reserved}, menu.ReorderLevel, needs.menus[id])
+	}
+	for _, id := range sortedKeys(needs.ingredients) {
+		ingredient := ingredients[id]
+		check(model.StockAlert{Kind: model.StockItemIngredient, Id: id, Name: ingredient.Name, Unit: ingredient.Unit, Available: ingredient.Available()}, ingredient.ReorderLevel, needs.ingredients[id])
+	}
+	return alerts
+}
+
+// notify sends the alerts, a sale that is committed never fails on them.
+func (p *transactionUsecase) notify(alerts []model.StockAlert) {
+	if len(alerts) == 0 {
+		return
+	}
+	if err := p.notifier.Notify(alerts); err != nil {
+		log.Println("stock alert not sent:", err)
+	}
+}
+
+// priceItems are the lines of a transaction priced from the menus and their
+// modifiers, with their line discount and a snapshot of the menu name and
+// price.
+func priceItems(transactionId string, lines []model.TransactionDetail, menus map[string]model.Menu) []model.TransactionDetail {
+	items := make([]model.TransactionDetail, len(lines))
+	for i, each := range lines {
+		menu := menus[each.MenuId]
+		unitPrice := menu.Price + each.Modifiers.PriceDelta()
+		subtotal := unitPrice * each.Qty
+		items[i] = model.TransactionDetail{
+			TransactionId:  transactionId,
+			MenuId:         each.MenuId,
+			MenuName:       menu.Name,
+			UnitPrice:      unitPrice,
+			Modifiers:      each.Modifiers,
+			Ingredients:    each.Ingredients,
+			Qty:            each.Qty,
+			Subtotal:       subtotal,
+			Discount:       each.Discount,
+			DiscountAmount: discountOf(each.Discount, subtotal),
+		}
+	}
+	return items
+}
+
+func (p *transactionUsecase) Void(id string, request model.RefundRequest, userId string) (model.Refund, error) {
+	if len(request.Items) > 0 {
+		return model.Refund{}, apperror.Validation("a void gives back every item, use a refund for some of them", nil)
+	}
+	return p.refund(model.RefundKindVoid, id, request, userId)
+}
+
+func (p *transactionUsecase) Refund(id string, request model.RefundRequest, userId string) (model.Refund, error) {
+	return p.refund(model.RefundKindRefund, id, request, userId)
+}
+
+// refund records a void or a refund, puts the stock back, sets the status
+// of the transaction and gives back the use of its promo when nothing is
+// left in one db transaction.
+func (p *transactionUsecase) refund(kind string, id string, request model.RefundRequest, userId string) (model.Refund, error) {
+	if strings.TrimSpace(request.Reason) == "" {
+		return model.Refund{}, apperror.Validation("a reason is required", nil)
+	}
+
+	tx, err := p.transactionRepository.Begin()
+	if err != nil {
+		return model.Refund{}, err
+	}
+	defer tx.Rollback()
+
+	transaction, err := tx.LockTransaction(id)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	allowed := transaction.Status == model.StatusPaid ||
+		kind == model.RefundKindRefund && transaction.Status == model.StatusPartiallyRefunded
+	if !allowed {
+		return model.Refund{}, apperror.Conflict(fmt.Sprintf("transaction %s is %s and cannot get a %s", id, transaction.Status, kind), nil)
+	}
+
+	refunded, err := tx.RefundedQty(id)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	left := map[int64]int{}
+	items := map[int64]model.TransactionDetail{}
+	for _, item := range transaction.Items {
+		left[item.Id] = item.Qty - refunded[item.Id]
+		items[item.Id] = item
+	}
+
+	wanted, err := refundQty(request.Items, left)
+	if err != nil {
+		return model.Refund{}, err
+	}
+
+	refund := model.Refund{
+		Id:            utils.GenerateId(),
+		TransactionId: id,
+		Kind:          kind,
+		Reason:        strings.TrimSpace(request.Reason),
+		UserId:        userId,
+		CreatedAt:     time.Now(),
+	}
+	// an item gives back its share of the total, the qty refunded before is
+	// counted so the refunds of an item add up to its share
+	shares := lineShares(transaction)
+	var restock []model.TransactionDetail
+	for _, item := range transaction.Items {
+		qty := wanted[item.Id]
+		if qty == 0 {
+			continue
+		}
+		before := refunded[item.Id]
+		amount := shares[item.Id]*(before+qty)/item.Qty - shares[item.Id]*before/item.Qty
+		refund.Items = append(refund.Items, model.RefundItem{
+			RefundId: refund.Id,
+			DetailId: item.Id,
+			MenuId:   item.MenuId,
+			Qty:      qty,
+			Amount:   amount,
+		})
+		refund.Amount += amount
+		item.Qty = qty
+		restock = append(restock, item)
+		left[item.Id] -= qty
+	}
+	if len(refund.Items) == 0 {
+		return model.Refund{}, apperror.Conflict("every item of transaction "+id+" is already refunded", nil)
+	}
+
+	if err := tx.InsertRefund(&refund); err != nil {
+		return model.Refund{}, err
+	}
+
+	needs, err := orderNeeds(tx, restock)
+	if err != nil {
+		return model.Refund{}, err
+	}
+	if err := takeNeeds(needs, always(tx.IncreaseStock), always(tx.IncreaseIngredient)); err != nil {
+		return model.Refund{}, err
+	}
+	reason := model.StockRefund
+	if kind == model.RefundKindVoid {
+		reason = model.StockVoid
+	}
+	if err := tx.InsertMovements(stockMovements(reason, id, userId, needs, 1)); err != nil {
+		return model.Refund{}, err
+	}
+
+	status := model.StatusRefunded
+	for _, qty := range left {
+		if qty > 0 {
+			status = model.StatusPartiallyRefunded
+		}
+	}
+	if kind == model.RefundKindVoid {
+		status = model.StatusVoided
+	}
+	if err := tx.SetStatus(id, status); err != nil {
+		return model.Refund{}, err
+	}
+	// a sale that is given back in full did not use its promo
+	if transaction.PromoCode != "" && status != model.StatusPartiallyRefunded {
+		if err := tx.ReleasePromo(transaction.PromoCode); err != nil {
+			return model.Refund{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return model.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+// refundQty is the quantity to give back per item, all that is left when
+// no lines are given. lines for the same item are added up.
+func refundQty(lines []model.RefundLine, left map[int64]int) (map[int64]int, error) {
+	wanted := map[int64]int{}
+	if len(lines) == 0 {
+		for id, qty := range left {
+			wanted[id] = qty
+		}
+		return wanted, nil
+	}
+
+	invalid := map[string]string{}
+	for i, line := range lines {
+		key := fmt.Sprintf("items[%d]", i)
+		if _, ok := left[line.DetailId]; !ok {
+			invalid[key] = fmt.Sprintf("item %d is not part of the transaction", line.DetailId)
+			continue
+		}
+		if line.Qty < 1 {
+			invalid[key] = "qty must be at least 1"
+			continue
+		}
+		wanted[line.DetailId] += line.Qty
+		if wanted[line.DetailId] > left[line.DetailId] {
+			invalid[key] = fmt.Sprintf("only %d of item %d can be refunded", left[line.DetailId], line.DetailId)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, apperror.Validation("invalid refund", invalid)
+	}
+	return wanted, nil
+}
+
+func uniqueSorted(ids []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// sortedKeys are the ids of a map of quantities in order.
+func sortedKeys(qty map[string]int) []string {
+	ids := make([]string, 0, len(qty))
+	for id := range qty {
+		ids = append(ids, id)
+	}
+	return uniqueSorted(ids)
 }
 
 // func (p *transactionUsecase) Update(newTransaction *model.Transaction) (model.Transaction, error) {
@@ -43,8 +720,10 @@ func (p *transactionUsecase) Insert(newTransaction *model.Transaction) (model.Tr
 // 	return p.transactionRepository.Delete(id)
 // }
 
-func NewTransactionUsecase(transactionRepository repository.TransactionRepository) TransactionUsecase {
+func NewTransactionUsecase(transactionRepository repository.TransactionRepository, pricing config.PricingConfig, notifier notifier.Notifier) TransactionUsecase {
 	usecase := new(transactionUsecase)
 	usecase.transactionRepository = transactionRepository
+	usecase.pricing = pricing
+	usecase.notifier = notifier
 	return usecase
 }
@@ -1,23 +1,40 @@
 package usecase
 
 import (
+	"errors"
+	"log"
 	"warung-makan/model"
 	"warung-makan/repository"
+	"warung-makan/utils"
+	"warung-makan/utils/apperror"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrWrongPassword      = apperror.Validation("old password does not match", nil)
 )
 
 type userUsecase struct {
 	userRepository repository.UserRepository
+	tokenUsecase   TokenUsecase
 }
 
 type UserUsecase interface {
 	GetAll() ([]model.User, error)
-	GetById(id string) (model.User, error)
+	List(filter model.UserFilter) ([]model.User, model.PageInfo, error)
+	GetById(id string, includeDeleted bool) (model.User, error)
 	GetByName(name string) ([]model.User, error)
 	GetByCredentials(username, password string) (model.User, error)
 
 	Insert(user *model.User) (model.User, error)
 	Update(user *model.User) (model.User, error)
+	ChangePassword(id string, passwordChange *model.PasswordChange) error
+	// PromoteOwner makes the user with the username an owner.
+	PromoteOwner(username string) error
+	// Delete archives a user and revokes their sessions, they cannot log in
+	// until Restore.
 	Delete(id string) error
+	Restore(id string) (model.User, error)
 }
 
 func (p *userUsecase) GetAll() ([]model.User, error) {
@@ -25,8 +42,12 @@ func (p *userUsecase) GetAll() ([]model.User, error) {
 
 }
 
-func (p *userUsecase) GetById(id string) (model.User, error) {
-	return p.userRepository.GetById(id)
+func (p *userUsecase) List(filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	return p.userRepository.List(filter)
+}
+
+func (p *userUsecase) GetById(id string, includeDeleted bool) (model.User, error) {
+	return p.userRepository.GetById(id, includeDeleted)
 }
 
 func (p *userUsecase) GetByName(name string) ([]model.User, error) {
@@ -34,10 +55,34 @@ func (p *userUsecase) GetByName(name string) ([]model.User, error) {
 }
 
 func (p *userUsecase) GetByCredentials(username, password string) (model.User, error) {
-	return p.userRepository.GetByCredentials(username, password)
+	user, err := p.userRepository.GetByUsername(username)
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return model.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if !utils.ComparePassword(user.Password, password) {
+		return model.User{}, ErrInvalidCredentials
+	}
+
+	// upgrade plain text password left from before hashing was introduced
+	if !utils.IsPasswordHashed(user.Password) {
+		err = p.userRepository.UpdatePassword(user.Id, password)
+		if err != nil {
+			log.Println("cannot upgrade password of user", user.Id, err)
+		}
+	}
+
+	user.Password = ""
+	return user, nil
 }
 
 func (p *userUsecase) Insert(newUser *model.User) (model.User, error) {
+	if newUser.Role == "" {
+		newUser.Role = model.RoleViewer
+	}
 	return p.userRepository.Insert(newUser)
 }
 
@@ -45,12 +90,42 @@ func (p *userUsecase) Update(newUser *model.User) (model.User, error) {
 	return p.userRepository.Update(newUser)
 }
 
+func (p *userUsecase) ChangePassword(id string, passwordChange *model.PasswordChange) error {
+	stored, err := p.userRepository.GetPasswordById(id)
+	if err != nil {
+		return err
+	}
+
+	if !utils.ComparePassword(stored, passwordChange.OldPassword) {
+		return ErrWrongPassword
+	}
+
+	return p.userRepository.UpdatePassword(id, passwordChange.NewPassword)
+}
+
+func (p *userUsecase) PromoteOwner(username string) error {
+	return p.userRepository.SetRole(username, model.RoleOwner)
+}
+
+// the user is archived first, so a login or refresh racing the revocation
+// does not start a new session
 func (p *userUsecase) Delete(id string) error {
-	return p.userRepository.Delete(id)
+	if err := p.userRepository.Delete(id); err != nil {
+		return err
+	}
+	return p.tokenUsecase.RevokeUserSessions(id)
+}
+
+func (p *userUsecase) Restore(id string) (model.User, error) {
+	if err := p.userRepository.Restore(id); err != nil {
+		return model.User{}, err
+	}
+	return p.userRepository.GetById(id, false)
 }
 
-func NewUserUsecase(userRepository repository.UserRepository) UserUsecase {
+func NewUserUsecase(userRepository repository.UserRepository, tokenUsecase TokenUsecase) UserUsecase {
 	usecase := new(userUsecase)
 	usecase.userRepository = userRepository
+	usecase.tokenUsecase = tokenUsecase
 	return usecase
 }
@@ -0,0 +1,71 @@
+package apperror
+
+import "errors"
+
+type Kind string
+
+const (
+	KindInternal   Kind = "internal"
+	KindNotFound   Kind = "not_found"
+	KindConflict   Kind = "conflict"
+	KindValidation Kind = "validation"
+)
+
+// Error is an error with a kind that controllers turn into a status code.
+// Details is sent along in the response, for example the invalid fields.
+type Error struct {
+	Kind    Kind
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string, details interface{}) *Error {
+	return &Error{Kind: KindConflict, Message: message, Details: details}
+}
+
+func Validation(message string, details interface{}) *Error {
+	return &Error{Kind: KindValidation, Message: message, Details: details}
+}
+
+func Internal(err error, message string) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// Wrap gives err a kind and keeps it reachable for errors.Is and errors.As.
+func Wrap(kind Kind, err error, message string) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// KindOf returns the kind of the first Error in err's chain, errors without
+// one are internal.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return KindInternal
+}
+
+func DetailsOf(err error) interface{} {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Details
+	}
+	return nil
+}
@@ -14,22 +14,24 @@ type accessToken struct {
 }
 
 type AccessToken interface {
-	GenerateAccessToken(user *model.User) (string, error)
+	GenerateAccessToken(user *model.User, tokenId string) (string, error)
 	VerifyToken(tokenString string) (jwt.MapClaims, error)
 }
 
-func (at *accessToken) GenerateAccessToken(user *model.User) (string, error) {
+func (at *accessToken) GenerateAccessToken(user *model.User, tokenId string) (string, error) {
 	now := time.Now().UTC()
 	end := now.Add(at.config.AccessTokenLifetime)
 
 	claims := MyClaims{
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
 			Issuer:    at.config.ApplicationName,
 			IssuedAt:  now.Unix(),
 			ExpiresAt: end.Unix(),
 		},
 		Id:       user.Id,
 		Username: user.Username,
+		Role:     user.Role,
 	}
 
 	token := jwt.NewWithClaims(
@@ -51,11 +53,13 @@ func (at *accessToken) VerifyToken(tokenString string) (jwt.MapClaims, error) {
 
 		return []byte(at.config.JwtSignatureKey), nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return nil, err
-		// panic(err)
+		return nil, fmt.Errorf("invalid token")
 	}
 
 	return claims, nil
@@ -6,4 +6,5 @@ type MyClaims struct {
 	jwt.StandardClaims
 	Id       string `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role"`
 }
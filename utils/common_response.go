@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net/http"
+	"warung-makan/utils/apperror"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +25,14 @@ func JsonNamedDataMessageResponse(ctx *gin.Context, keyName string, data interfa
 	})
 }
 
+// JsonPageResponse sends one page of a list with its page info.
+func JsonPageResponse(ctx *gin.Context, data interface{}, page interface{}) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"meta": page,
+	})
+}
+
 func JsonSuccessMessage(ctx *gin.Context, message string) {
 	ctx.AbortWithStatusJSON(http.StatusOK, gin.H{
 		"message": message,
@@ -70,3 +79,29 @@ func JsonErrorUnauthorized(ctx *gin.Context, err error, message string) {
 		"message": message,
 	})
 }
+
+func JsonErrorForbidden(ctx *gin.Context, err error, message string) {
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":   err.Error(),
+		"message": message,
+	})
+}
+
+var appErrorStatus = map[apperror.Kind]int{
+	apperror.KindNotFound:   http.StatusNotFound,
+	apperror.KindConflict:   http.StatusConflict,
+	apperror.KindValidation: http.StatusBadRequest,
+	apperror.KindInternal:   http.StatusInternalServerError,
+}
+
+// JsonAppError picks the status code from the kind of err, see apperror.
+func JsonAppError(ctx *gin.Context, err error, message string) {
+	body := gin.H{
+		"error":   err.Error(),
+		"message": message,
+	}
+	if details := apperror.DetailsOf(err); details != nil {
+		body["details"] = details
+	}
+	ctx.AbortWithStatusJSON(appErrorStatus[apperror.KindOf(err)], body)
+}
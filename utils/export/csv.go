@@ -0,0 +1,46 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"strings"
+)
+
+type csvWriter struct {
+	out    *bufio.Writer
+	csv    *csv.Writer
+	record []string
+}
+
+func newCSVWriter(out *bufio.Writer) *csvWriter {
+	return &csvWriter{out: out, csv: csv.NewWriter(out)}
+}
+
+func (w *csvWriter) Write(row ...interface{}) error {
+	w.record = w.record[:0]
+	for _, cell := range row {
+		value, numeric := text(cell)
+		if !numeric {
+			value = escapeFormula(value)
+		}
+		w.record = append(w.record, value)
+	}
+	return w.csv.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// escapeFormula keeps spreadsheets from running text that looks like a
+// formula, a menu named "=1+1" stays text.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
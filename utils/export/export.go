@@ -0,0 +1,63 @@
+// Package export writes tables as csv or xlsx files one row at a time, so
+// a large export never has to be in memory.
+package export
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"time"
+	"warung-makan/utils/apperror"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	// how times are written in both formats, in time.Local
+	TimeLayout = "2006-01-02 15:04:05"
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Writer writes the rows of one table. cells are strings, ints or times,
+// anything else is written with its zero value. the file is only complete
+// after Close.
+type Writer interface {
+	Write(row ...interface{}) error
+	Close() error
+}
+
+// NewWriter writes the format to w. the output is buffered, nothing reaches
+// w before a few rows are written.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	buffered := bufio.NewWriter(w)
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(buffered), nil
+	case FormatXLSX:
+		return newXLSXWriter(buffered)
+	}
+	return nil, apperror.Validation("format must be csv or xlsx", nil)
+}
+
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// text is a cell as it is written to a csv file, numeric tells if it is a
+// number.
+func text(cell interface{}) (value string, numeric bool) {
+	switch v := cell.(type) {
+	case string:
+		return v, false
+	case int:
+		return strconv.Itoa(v), true
+	case time.Time:
+		return v.In(time.Local).Format(TimeLayout), false
+	}
+	return "", false
+}
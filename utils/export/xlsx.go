@@ -0,0 +1,106 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+// the parts of a workbook with a single sheet, the sheet itself is written
+// row by row.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+const (
+	sheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd = `</sheetData></worksheet>`
+)
+
+type xlsxWriter struct {
+	out   *bufio.Writer
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func newXLSXWriter(out *bufio.Writer) (*xlsxWriter, error) {
+	w := &xlsxWriter{out: out, zip: zip.NewWriter(out)}
+	for _, part := range xlsxParts {
+		file, err := w.zip.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	w.sheet = sheet
+	_, err = io.WriteString(w.sheet, sheetStart)
+	return w, err
+}
+
+func (w *xlsxWriter) Write(row ...interface{}) error {
+	w.row++
+	number := strconv.Itoa(w.row)
+	if _, err := io.WriteString(w.sheet, `<row r="`+number+`">`); err != nil {
+		return err
+	}
+
+	for i, cell := range row {
+		value, numeric := text(cell)
+		ref := column(i) + number
+		var err error
+		if numeric {
+			_, err = io.WriteString(w.sheet, `<c r="`+ref+`"><v>`+value+`</v></c>`)
+		} else {
+			_, err = io.WriteString(w.sheet, `<c r="`+ref+`" t="inlineStr"><is><t xml:space="preserve">`)
+			if err == nil {
+				err = xml.EscapeText(w.sheet, []byte(value))
+			}
+			if err == nil {
+				_, err = io.WriteString(w.sheet, `</t></is></c>`)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, sheetEnd); err != nil {
+		return err
+	}
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+	return w.out.Flush()
+}
+
+// column is the letter of the i-th column, 0 is A and 26 is AA.
+func column(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -0,0 +1,133 @@
+// Package notifier tells the owner about menus and ingredients that run
+// low, in the log, to a webhook or by email.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"warung-makan/config"
+	"warung-makan/model"
+)
+
+// how many sales' alerts wait to be sent in the background, more are
+// dropped
+const asyncQueueSize = 100
+
+// Notifier sends the stock alerts raised by one sale.
+type Notifier interface {
+	Notify(alerts []model.StockAlert) error
+	// Close waits for the alerts still to be sent until ctx is done,
+	// nothing is sent after it.
+	Close(ctx context.Context) error
+}
+
+// New builds the notifier of the config, it sends in the background so a
+// slow webhook or mail server never holds a sale.
+func New(conf config.AlertConfig) Notifier {
+	switch conf.Notifier {
+	case config.AlertWebhook:
+		return Async(NewWebhook(conf.WebhookURL))
+	case config.AlertSMTP:
+		return Async(NewSMTP(conf))
+	}
+	return NewLog()
+}
+
+type logNotifier struct{}
+
+// NewLog writes every alert to the standard logger.
+func NewLog() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(alerts []model.StockAlert) error {
+	for _, alert := range alerts {
+		log.Println("low stock:", describe(alert))
+	}
+	return nil
+}
+
+func (logNotifier) Close(ctx context.Context) error {
+	return nil
+}
+
+type asyncNotifier struct {
+	next   Notifier
+	queue  chan []model.StockAlert
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+}
+
+// Async queues the alerts and sends them one sale at a time with next in
+// a goroutine, send errors are only logged. Notify fails when the queue is
+// full instead of holding the sale.
+func Async(next Notifier) Notifier {
+	n := &asyncNotifier{
+		next:  next,
+		queue: make(chan []model.StockAlert, asyncQueueSize),
+		done:  make(chan struct{}),
+	}
+	go n.work()
+	return n
+}
+
+func (n *asyncNotifier) work() {
+	defer close(n.done)
+	for alerts := range n.queue {
+		if err := n.next.Notify(alerts); err != nil {
+			log.Println("stock alert not sent:", err)
+		}
+	}
+}
+
+func (n *asyncNotifier) Notify(alerts []model.StockAlert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return fmt.Errorf("notifier is closed, %d alert(s) dropped", len(alerts))
+	}
+	select {
+	case n.queue <- alerts:
+		return nil
+	default:
+		return fmt.Errorf("alert queue is full, %d alert(s) dropped", len(alerts))
+	}
+}
+
+func (n *asyncNotifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return n.next.Close(ctx)
+	case <-ctx.Done():
+		return fmt.Errorf("stock alerts of %d sale(s) not sent: %w", len(n.queue), ctx.Err())
+	}
+}
+
+// describe is one alert as a line of text.
+func describe(alert model.StockAlert) string {
+	unit := alert.Unit
+	if unit != "" {
+		unit = " " + unit
+	}
+	return fmt.Sprintf("%s %s (%s) has %d%s left, reorder level %d", alert.Kind, alert.Name, alert.Id, alert.Available, unit, alert.ReorderLevel)
+}
+
+// text is the alerts one per line.
+func text(alerts []model.StockAlert) string {
+	lines := make([]string, len(alerts))
+	for i, alert := range alerts {
+		lines[i] = describe(alert)
+	}
+	return strings.Join(lines, "\n")
+}
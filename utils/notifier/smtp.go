@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"warung-makan/config"
+	"warung-makan/model"
+)
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewSMTP mails the alerts from SMTPFrom to every SMTPTo, it logs in only
+// when SMTPUser is set.
+func NewSMTP(conf config.AlertConfig) Notifier {
+	n := &smtpNotifier{
+		addr: net.JoinHostPort(conf.SMTPHost, conf.SMTPPort),
+		from: conf.SMTPFrom,
+		to:   conf.SMTPTo,
+	}
+	if conf.SMTPUser != "" {
+		n.auth = smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPass, conf.SMTPHost)
+	}
+	return n
+}
+
+func (n *smtpNotifier) Notify(alerts []model.StockAlert) error {
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Low stock: %d item(s)\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		n.from, strings.Join(n.to, ", "), len(alerts), strings.ReplaceAll(text(alerts), "\n", "\r\n"))
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(message))
+}
+
+func (n *smtpNotifier) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"warung-makan/model"
+)
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook posts the alerts as json, {"alerts": [...]}, to url.
+func NewWebhook(url string) Notifier {
+	return &webhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *webhookNotifier) Notify(alerts []model.StockAlert) error {
+	body, err := json.Marshal(map[string]interface{}{"alerts": alerts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (n *webhookNotifier) Close(ctx context.Context) error {
+	return nil
+}
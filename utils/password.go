@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"crypto/subtle"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func HashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func IsPasswordHashed(stored string) bool {
+	_, err := bcrypt.Cost([]byte(stored))
+	return err == nil
+}
+
+// rows created before hashing was introduced still hold the plain password,
+// those are compared in constant time and upgraded by the caller
+func ComparePassword(stored, password string) bool {
+	if IsPasswordHashed(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
@@ -1,56 +1,218 @@
 package utils
 
 const (
-	MENU_GET_ALL           = "SELECT id, name, price, stock, image FROM menu"
+	// archived rows are left out by adding NOT_DELETED to a query that has
+	// a WHERE
+	NOT_DELETED = " AND deleted_at IS NULL"
+
+	// MENU_PORTIONS is how many portions of a menu the ingredients of its
+	// recipe make, NULL for a menu without a recipe
+	MENU_PORTIONS          = "(SELECT MIN((i.stock - i.reserved) / r.qty) FROM recipe r JOIN ingredient i ON i.id = r.ingredient_id WHERE r.menu_id = menu.id)"
+	MENU_GET_ALL           = "SELECT id, name, price, stock, reserved, image, category_id, tags, to_char(available_from, 'HH24:MI') AS available_from, to_char(available_until, 'HH24:MI') AS available_until, " + MENU_PORTIONS + " AS portions, reorder_level, deleted_at FROM menu"
 	MENU_GET_ALL_PAGINATED = MENU_GET_ALL + " limit $1 offset $2"
 	MENU_GET_BY_ID         = MENU_GET_ALL + " WHERE id = $1"
-	MENU_GET_BY_NAME       = MENU_GET_ALL + " WHERE name like $1"
+	MENU_GET_BY_NAME       = MENU_GET_ALL + " WHERE name like $1" + NOT_DELETED
+	MENU_COUNT             = "SELECT COUNT(*) FROM menu"
+
+	MENU_INSERT         = "INSERT INTO menu(id, name, price, stock, image, category_id, tags, available_from, available_until, reorder_level) VALUES (:id, :name, :price, :stock, :image, :category_id, :tags, :available_from, :available_until, :reorder_level)"
+	MENU_UPDATE         = "UPDATE menu SET name=:name, price=:price, category_id=:category_id, tags=:tags, available_from=:available_from, available_until=:available_until, reorder_level=:reorder_level where id=:id AND deleted_at IS NULL"
+	MENU_LOCK_BY_IDS    = MENU_GET_ALL + " WHERE id = ANY($1)" + NOT_DELETED + " ORDER BY id FOR UPDATE"
+	MENU_DECREASE_STOCK = "UPDATE menu SET stock=stock-$1 WHERE id=$2 AND stock-reserved >= $1"
+	MENU_INCREASE_STOCK = "UPDATE menu SET stock=stock+$1 WHERE id=$2"
+	MENU_RESERVE_STOCK  = "UPDATE menu SET reserved=reserved+$1 WHERE id=$2 AND stock-reserved >= $1"
+	MENU_RELEASE_STOCK  = "UPDATE menu SET reserved=reserved-$1 WHERE id=$2"
+	MENU_COMMIT_STOCK   = "UPDATE menu SET stock=stock-$1, reserved=reserved-$1 WHERE id=$2"
+	MENU_MOVE_STOCK     = "UPDATE menu SET stock=stock+$1 WHERE id=$2 AND deleted_at IS NULL AND stock+$1 >= reserved"
+	MENU_RECEIVE_STOCK  = "UPDATE menu SET stock=stock+$1 WHERE id=$2 AND deleted_at IS NULL"
+	MENU_DELETE         = "UPDATE menu SET deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL"
+	MENU_RESTORE        = "UPDATE menu SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL"
+
+	MENU_INSERT_TEST = "INSERT INTO menu(id, name, price, stock, image, category_id, tags, available_from, available_until, reorder_level) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	MENU_UPDATE_TEST = "UPDATE menu SET name=$1, price=$2, category_id=$3, tags=$4, available_from=$5, available_until=$6, reorder_level=$7 where id=$8 AND deleted_at IS NULL"
+
+	STOCK_MOVEMENT_GET_ALL           = "SELECT id, menu_id, ingredient_id, qty, reason, note, transaction_id, user_id, purchase_order_id, unit_cost, stock_take_id, created_at FROM stock_movement"
+	STOCK_MOVEMENT_GET_BY_MENU       = STOCK_MOVEMENT_GET_ALL + " WHERE menu_id = $1 ORDER BY created_at DESC, id DESC"
+	STOCK_MOVEMENT_GET_BY_INGREDIENT = STOCK_MOVEMENT_GET_ALL + " WHERE ingredient_id = $1 ORDER BY created_at DESC, id DESC"
+	STOCK_MOVEMENT_INSERT            = "INSERT INTO stock_movement(menu_id, ingredient_id, qty, reason, note, transaction_id, user_id, purchase_order_id, unit_cost, stock_take_id) VALUES (:menu_id, :ingredient_id, :qty, :reason, :note, :transaction_id, :user_id, :purchase_order_id, :unit_cost, :stock_take_id) RETURNING id, created_at"
+	// STOCK_ALERT_GET_ALL lists the menus without a recipe and the
+	// ingredients whose stock that is not reserved is under their reorder
+	// level
+	STOCK_ALERT_GET_ALL = "SELECT 'menu' AS kind, id, name, '' AS unit, stock - reserved AS available, reorder_level FROM menu" +
+		" WHERE stock - reserved < reorder_level" + NOT_DELETED + " AND NOT EXISTS (SELECT 1 FROM recipe r WHERE r.menu_id = menu.id)" +
+		" UNION ALL SELECT 'ingredient' AS kind, id, name, unit, stock - reserved AS available, reorder_level FROM ingredient" +
+		" WHERE stock - reserved < reorder_level ORDER BY kind DESC, name, id"
+
+	INGREDIENT_GET_ALL        = "SELECT id, name, unit, stock, reserved, reorder_level, created_at FROM ingredient"
+	INGREDIENT_GET_BY_ID      = INGREDIENT_GET_ALL + " WHERE id = $1"
+	INGREDIENT_LOCK_BY_IDS    = INGREDIENT_GET_ALL + " WHERE id = ANY($1) ORDER BY id FOR UPDATE"
+	INGREDIENT_INSERT         = "INSERT INTO ingredient(id, name, unit, stock, reorder_level) VALUES (:id, :name, :unit, :stock, :reorder_level)"
+	INGREDIENT_UPDATE         = "UPDATE ingredient SET name = :name, unit = :unit, reorder_level = :reorder_level WHERE id = :id"
+	INGREDIENT_DELETE         = "DELETE FROM ingredient WHERE id = $1"
+	INGREDIENT_USAGE          = "SELECT (SELECT COUNT(*) FROM stock_movement WHERE ingredient_id = $1) AS movements, (SELECT COUNT(*) FROM recipe WHERE ingredient_id = $1) AS recipes, (SELECT COUNT(*) FROM purchase_order_line WHERE ingredient_id = $1) AS purchase_lines, (SELECT COUNT(*) FROM stock_take_line WHERE ingredient_id = $1) AS stock_take_lines"
+	INGREDIENT_MOVE_STOCK     = "UPDATE ingredient SET stock=stock+$1 WHERE id=$2 AND stock+$1 >= reserved"
+	INGREDIENT_DECREASE_STOCK = "UPDATE ingredient SET stock=stock-$1 WHERE id=$2 AND stock-reserved >= $1"
+	INGREDIENT_INCREASE_STOCK = "UPDATE ingredient SET stock=stock+$1 WHERE id=$2"
+	INGREDIENT_RESERVE_STOCK  = "UPDATE ingredient SET reserved=reserved+$1 WHERE id=$2 AND stock-reserved >= $1"
+	INGREDIENT_RELEASE_STOCK  = "UPDATE ingredient SET reserved=reserved-$1 WHERE id=$2"
+	INGREDIENT_COMMIT_STOCK   = "UPDATE ingredient SET stock=stock-$1, reserved=reserved-$1 WHERE id=$2"
+
+	RECIPE_GET_BY_MENUS = "SELECT r.menu_id, r.ingredient_id, r.qty, i.name, i.unit FROM recipe r JOIN ingredient i ON i.id = r.ingredient_id WHERE r.menu_id = ANY($1) ORDER BY r.menu_id, i.name"
+	RECIPE_DELETE       = "DELETE FROM recipe WHERE menu_id = $1"
+	RECIPE_INSERT       = "INSERT INTO recipe(menu_id, ingredient_id, qty) VALUES (:menu_id, :ingredient_id, :qty)"
+
+	SUPPLIER_GET_ALL   = "SELECT id, name, phone, email, address, created_at FROM supplier"
+	SUPPLIER_GET_BY_ID = SUPPLIER_GET_ALL + " WHERE id = $1"
+	SUPPLIER_INSERT    = "INSERT INTO supplier(id, name, phone, email, address) VALUES (:id, :name, :phone, :email, :address)"
+	SUPPLIER_UPDATE    = "UPDATE supplier SET name = :name, phone = :phone, email = :email, address = :address WHERE id = :id"
+	SUPPLIER_DELETE    = "DELETE FROM supplier WHERE id = $1"
+
+	PURCHASE_ORDER_GET_ALL      = "SELECT po.id, po.supplier_id, s.name AS supplier_name, po.status, po.note, po.user_id, COALESCE((SELECT SUM(l.qty * l.unit_cost) FROM purchase_order_line l WHERE l.purchase_order_id = po.id), 0) AS expected_cost, COALESCE((SELECT SUM(l.received_cost) FROM purchase_order_line l WHERE l.purchase_order_id = po.id), 0) AS received_cost, po.created_at, po.ordered_at, po.received_at FROM purchase_order po JOIN supplier s ON s.id = po.supplier_id"
+	PURCHASE_ORDER_LIST         = PURCHASE_ORDER_GET_ALL + " WHERE ($1 = '' OR po.supplier_id = $1) AND ($2 = '' OR po.status = $2) ORDER BY po.created_at DESC, po.id"
+	PURCHASE_ORDER_GET_BY_ID    = PURCHASE_ORDER_GET_ALL + " WHERE po.id = $1"
+	PURCHASE_ORDER_LOCK_STATUS  = "SELECT status FROM purchase_order WHERE id = $1 FOR UPDATE"
+	PURCHASE_ORDER_INSERT       = "INSERT INTO purchase_order(id, supplier_id, note, user_id) VALUES (:id, :supplier_id, :note, :user_id)"
+	PURCHASE_ORDER_UPDATE       = "UPDATE purchase_order SET supplier_id = :supplier_id, note = :note WHERE id = :id AND status = 'draft'"
+	PURCHASE_ORDER_DELETE       = "DELETE FROM purchase_order WHERE id = $1 AND status = 'draft'"
+	PURCHASE_ORDER_SET_ORDERED  = "UPDATE purchase_order SET status = 'ordered', ordered_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'draft'"
+	PURCHASE_ORDER_LINES_OPEN   = "EXISTS (SELECT 1 FROM purchase_order_line WHERE purchase_order_id = $1 AND received_qty < qty)"
+	PURCHASE_ORDER_SET_RECEIVED = "UPDATE purchase_order SET status = CASE WHEN " + PURCHASE_ORDER_LINES_OPEN + " THEN 'partially_received' ELSE 'received' END, received_at = CASE WHEN " + PURCHASE_ORDER_LINES_OPEN + " THEN NULL ELSE CURRENT_TIMESTAMP END WHERE id = $1"
+
+	PURCHASE_LINE_GET_BY_ORDER    = "SELECT l.id, l.purchase_order_id, l.menu_id, l.ingredient_id, COALESCE(m.name, i.name) AS name, l.qty, l.unit_cost, l.received_qty, l.received_cost FROM purchase_order_line l LEFT JOIN menu m ON m.id = l.menu_id LEFT JOIN ingredient i ON i.id = l.ingredient_id WHERE l.purchase_order_id = $1 ORDER BY l.id"
+	PURCHASE_LINE_INSERT          = "INSERT INTO purchase_order_line(purchase_order_id, menu_id, ingredient_id, qty, unit_cost) VALUES (:purchase_order_id, :menu_id, :ingredient_id, :qty, :unit_cost)"
+	PURCHASE_LINE_DELETE_BY_ORDER = "DELETE FROM purchase_order_line WHERE purchase_order_id = $1"
+	PURCHASE_LINE_RECEIVE         = "UPDATE purchase_order_line SET received_qty = received_qty + $1, received_cost = received_cost + $1::bigint * $2 WHERE id = $3 AND purchase_order_id = $4 AND received_qty + $1 <= qty RETURNING menu_id, ingredient_id"
+
+	STOCK_TAKE_GET_ALL     = "SELECT st.id, st.status, st.note, st.user_id, st.approved_by, COUNT(l.id) AS lines, COALESCE(SUM(l.variance_value), 0) AS variance_value, st.created_at, st.approved_at FROM stock_take st LEFT JOIN stock_take_line l ON l.stock_take_id = st.id"
+	STOCK_TAKE_LIST        = STOCK_TAKE_GET_ALL + " WHERE ($1 = '' OR st.status = $1) GROUP BY st.id ORDER BY st.created_at DESC, st.id"
+	STOCK_TAKE_GET_BY_ID   = STOCK_TAKE_GET_ALL + " WHERE st.id = $1 GROUP BY st.id"
+	STOCK_TAKE_LOCK_STATUS = "SELECT status FROM stock_take WHERE id = $1 FOR UPDATE"
+	STOCK_TAKE_INSERT      = "INSERT INTO stock_take(id, note, user_id) VALUES (:id, :note, :user_id)"
+	STOCK_TAKE_APPROVE     = "UPDATE stock_take SET status = 'approved', approved_by = $2, approved_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'counting'"
+	STOCK_TAKE_CANCEL      = "UPDATE stock_take SET status = 'cancelled' WHERE id = $1 AND status = 'counting'"
 
-	MENU_INSERT       = "INSERT INTO menu(id, name, price, stock, image) VALUES (:id, :name, :price, :stock, :image)"
-	MENU_UPDATE       = "UPDATE menu SET name=:name, price=:price, stock=:stock where id=:id"
-	MENU_UPDATE_STOCK = "UPDATE menu SET stock=stock-:qty where id=:menu_id"
-	MENU_DELETE       = "DELETE from menu WHERE id=$1"
+	// counting an item takes its stock as expected and values the variance
+	// at the unit cost of its last purchase, NULL when it was never bought.
+	// a recount replaces the line
+	STOCK_TAKE_LINE_RECOUNT          = "DO UPDATE SET expected = EXCLUDED.expected, counted = EXCLUDED.counted, variance = EXCLUDED.variance, unit_cost = EXCLUDED.unit_cost, variance_value = EXCLUDED.variance_value, user_id = EXCLUDED.user_id, counted_at = CURRENT_TIMESTAMP"
+	STOCK_TAKE_LINE_COUNT_MENU       = "INSERT INTO stock_take_line(stock_take_id, menu_id, expected, counted, variance, unit_cost, variance_value, user_id) SELECT $1, m.id, m.stock, $3, $3 - m.stock, c.unit_cost, ($3 - m.stock)::bigint * c.unit_cost, $4 FROM menu m LEFT JOIN LATERAL (SELECT unit_cost FROM stock_movement WHERE menu_id = m.id AND reason = 'purchase' ORDER BY created_at DESC, id DESC LIMIT 1) c ON true WHERE m.id = $2 AND m.deleted_at IS NULL ON CONFLICT (stock_take_id, menu_id) " + STOCK_TAKE_LINE_RECOUNT
+	STOCK_TAKE_LINE_COUNT_INGREDIENT = "INSERT INTO stock_take_line(stock_take_id, ingredient_id, expected, counted, variance, unit_cost, variance_value, user_id) SELECT $1, i.id, i.stock, $3, $3 - i.stock, c.unit_cost, ($3 - i.stock)::bigint * c.unit_cost, $4 FROM ingredient i LEFT JOIN LATERAL (SELECT unit_cost FROM stock_movement WHERE ingredient_id = i.id AND reason = 'purchase' ORDER BY created_at DESC, id DESC LIMIT 1) c ON true WHERE i.id = $2 ON CONFLICT (stock_take_id, ingredient_id) " + STOCK_TAKE_LINE_RECOUNT
+	STOCK_TAKE_LINE_GET_BY_TAKE      = "SELECT l.id, l.stock_take_id, l.menu_id, l.ingredient_id, COALESCE(m.name, i.name) AS name, COALESCE(i.unit, '') AS unit, l.expected, l.counted, l.variance, l.unit_cost, l.variance_value, l.user_id, l.counted_at FROM stock_take_line l LEFT JOIN menu m ON m.id = l.menu_id LEFT JOIN ingredient i ON i.id = l.ingredient_id WHERE l.stock_take_id = $1 ORDER BY name, l.id"
 
-	MENU_INSERT_TEST       = "INSERT INTO menu(id, name, price, stock, image) VALUES ($1, $2, $3, $4, $5)"
-	MENU_UPDATE_TEST       = "UPDATE menu SET name=$1, price=$2, stock=$3 where id=$4"
-	MENU_UPDATE_STOCK_TEST = "UPDATE menu SET stock=stock-$1 where id=$2"
+	MODIFIER_GROUP_GET_BY_MENUS  = "SELECT id, menu_id, name, min_select, max_select, display_order FROM modifier_group WHERE menu_id = ANY($1) ORDER BY menu_id, display_order, id"
+	MODIFIER_GROUP_INSERT        = "INSERT INTO modifier_group(menu_id, name, min_select, max_select, display_order) VALUES (:menu_id, :name, :min_select, :max_select, :display_order) RETURNING id"
+	MODIFIER_GROUP_UPDATE        = "UPDATE modifier_group SET name = :name, min_select = :min_select, max_select = :max_select, display_order = :display_order WHERE id = :id AND menu_id = :menu_id"
+	MODIFIER_GROUP_DELETE        = "DELETE FROM modifier_group WHERE id = $1 AND menu_id = $2"
+	MODIFIER_OPTION_GET_BY_MENUS = "SELECT o.id, o.group_id, o.name, o.price_delta, o.display_order FROM modifier_option o JOIN modifier_group g ON g.id = o.group_id WHERE g.menu_id = ANY($1) ORDER BY o.group_id, o.display_order, o.id"
+	MODIFIER_OPTION_INSERT       = "INSERT INTO modifier_option(group_id, name, price_delta, display_order) VALUES (:group_id, :name, :price_delta, :display_order)"
+	MODIFIER_OPTION_UPDATE       = "UPDATE modifier_option SET name = :name, price_delta = :price_delta, display_order = :display_order WHERE id = :id AND group_id = :group_id"
+	MODIFIER_OPTION_DELETE_OTHER = "DELETE FROM modifier_option WHERE group_id = $1 AND NOT (id = ANY($2))"
+
+	CATEGORY_GET_ALL   = "SELECT id, name, display_order, created_at FROM category"
+	CATEGORY_GET_BY_ID = CATEGORY_GET_ALL + " WHERE id = $1"
+	CATEGORY_INSERT    = "INSERT INTO category(id, name, display_order) VALUES (:id, :name, :display_order)"
+	CATEGORY_UPDATE    = "UPDATE category SET name = :name, display_order = :display_order WHERE id = :id"
+	CATEGORY_DELETE    = "DELETE FROM category WHERE id = $1"
 	// ===========================================================
 
-	USER_GET_ALL            = "SELECT id, name, username, image  FROM users"
-	USER_GET_ALL_PAGINATED  = USER_GET_ALL + " limit $1 offset $2"
-	USER_GET_BY_ID          = USER_GET_ALL + " WHERE id = $1"
-	USER_GET_BY_NAME        = USER_GET_ALL + " WHERE name like $1"
-	USER_GET_BY_CREDENTIALS = USER_GET_ALL + " WHERE username=$1 AND password=$2"
+	USER_GET_ALL           = "SELECT id, name, username, role, image, deleted_at FROM users"
+	USER_GET_ALL_PAGINATED = USER_GET_ALL + " limit $1 offset $2"
+	USER_GET_BY_ID         = USER_GET_ALL + " WHERE id = $1"
+	USER_GET_BY_NAME       = USER_GET_ALL + " WHERE name like $1" + NOT_DELETED
+	USER_COUNT             = "SELECT COUNT(*) FROM users"
+	USER_GET_BY_USERNAME   = "SELECT id, name, username, password, role, image FROM users WHERE username=$1" + NOT_DELETED
+	USER_GET_PASSWORD      = "SELECT password FROM users WHERE id=$1"
 
-	USER_INSERT = "INSERT INTO users(id, name, username, password, image) VALUES (:id, :name, :username, :password, :image)"
-	USER_UPDATE = "UPDATE users SET name=:name, username=:username, password=:password where id=:id"
-	USER_DELETE = "DELETE from users WHERE id=$1"
+	USER_INSERT          = "INSERT INTO users(id, name, username, password, role, image) VALUES (:id, :name, :username, :password, :role, :image)"
+	USER_UPDATE          = "UPDATE users SET name=:name, username=:username, password=:password, role=COALESCE(NULLIF(:role, ''), role) where id=:id AND deleted_at IS NULL"
+	USER_UPDATE_PASSWORD = "UPDATE users SET password=$1 where id=$2"
+	USER_SET_ROLE        = "UPDATE users SET role=$1 WHERE username=$2 AND deleted_at IS NULL"
+	USER_DELETE          = "UPDATE users SET deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL"
+	USER_RESTORE         = "UPDATE users SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL"
 
-	USER_INSERT_TEST = "INSERT INTO users(id, name, username, password, image) VALUES ($1, $2, $3, $4, $5)"
-	USER_UPDATE_TEST = "UPDATE users SET name=$1, username=$2, password=$3 where id=$4"
+	USER_INSERT_TEST = "INSERT INTO users(id, name, username, password, role, image) VALUES ($1, $2, $3, $4, $5, $6)"
+	USER_UPDATE_TEST = "UPDATE users SET name=$1, username=$2, password=$3, role=COALESCE(NULLIF($4, ''), role) where id=$5 AND deleted_at IS NULL"
 	// ===========================================================
 
-	TRANSACTION_GET_ALL           = "SELECT id, total_price, created_at, updated_at FROM transaction "
+	REFRESH_TOKEN_GET_BY_HASH          = "SELECT id, user_id, token_hash, access_jti, expires_at, revoked_at FROM refresh_token WHERE token_hash=$1"
+	REFRESH_TOKEN_INSERT               = "INSERT INTO refresh_token(id, user_id, token_hash, access_jti, expires_at) VALUES (:id, :user_id, :token_hash, :access_jti, :expires_at)"
+	REFRESH_TOKEN_REVOKE               = "UPDATE refresh_token SET revoked_at=CURRENT_TIMESTAMP WHERE id=$1 AND revoked_at IS NULL"
+	REFRESH_TOKEN_REVOKE_BY_ACCESS_JTI = "UPDATE refresh_token SET revoked_at=CURRENT_TIMESTAMP WHERE access_jti=$1 AND revoked_at IS NULL"
+	REFRESH_TOKEN_REVOKE_BY_USER       = "UPDATE refresh_token SET revoked_at=CURRENT_TIMESTAMP WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING access_jti"
+	REVOKED_TOKEN_INSERT               = "INSERT INTO revoked_token(jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING"
+	REVOKED_TOKEN_EXISTS               = "SELECT EXISTS(SELECT 1 FROM revoked_token WHERE jti=$1)"
+
+	REFRESH_TOKEN_INSERT_TEST = "INSERT INTO refresh_token(id, user_id, token_hash, access_jti, expires_at) VALUES ($1, $2, $3, $4, $5)"
+	// ===========================================================
+
+	TRANSACTION_GET_ALL           = "SELECT id, subtotal, discount_amount, service_charge, tax, total_price, COALESCE(promo_code, '') AS promo_code, status, created_at, updated_at FROM transaction "
 	TRANSACTION_GET_ALL_PAGINATED = TRANSACTION_GET_ALL + " limit $1 offset $2"
 	TRANSACTION_GET_BY_ID         = TRANSACTION_GET_ALL + " WHERE id = $1"
 	TRANSACTION_GET_LAST_ID       = "SELECT id from transaction order by id desc limit 1"
+	TRANSACTION_COUNT             = "SELECT COUNT(*) FROM transaction"
+	TRANSACTION_LOCK              = TRANSACTION_GET_BY_ID + " FOR UPDATE"
+	TRANSACTION_SET_STATUS        = "UPDATE transaction SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2"
+	TRANSACTION_EXPORT            = "SELECT t.id, t.subtotal AS transaction_subtotal, t.discount_amount AS transaction_discount, t.service_charge, t.tax, t.total_price, t.status, t.created_at, d.menu_id, d.menu_name, d.modifiers, d.unit_price, d.qty, d.subtotal, d.discount_amount FROM transaction t JOIN transaction_detail d ON d.transaction_id = t.id"
+	TRANSACTION_EXPORT_ORDER      = " ORDER BY t.created_at, t.id, d.id"
 
-	TRANSACTION_INSERT = "INSERT INTO transaction(id, total_price) VALUES (:id, :total_price)"
+	TRANSACTION_INSERT     = "INSERT INTO transaction(id, subtotal, discount_amount, service_charge, tax, total_price, promo_code, status) VALUES (:id, :subtotal, :discount_amount, :service_charge, :tax, :total_price, NULLIF(:promo_code, ''), :status)"
+	TRANSACTION_SET_PRICES = "UPDATE transaction SET subtotal = :subtotal, discount_amount = :discount_amount, service_charge = :service_charge, tax = :tax, total_price = :total_price, promo_code = NULLIF(:promo_code, ''), updated_at = CURRENT_TIMESTAMP WHERE id = :id"
 	// TRANSACTION_UPDATE = "UPDATE transaction set total_price=:total_price where id=:id"
 	// TRANSACTION_DELETE = "DELETE from transaction WHERE id=$1"
 
-	TRANSACTION_INSERT_TEST = "INSERT INTO transaction(id, total_price) VALUES ($1, $2)"
+	TRANSACTION_INSERT_TEST = "INSERT INTO transaction(id, subtotal, total_price) VALUES ($1, $2, $2)"
 	// ==============================================================
 
-	TRANSACTION_DETAIL_INSERT                = "INSERT INTO transaction_detail(transaction_id, menu_id, qty, subtotal) VALUES (:transaction_id, :menu_id, :qty, :subtotal)"
-	TRANSACTION_DETAIL_GET_ALL               = "SELECT transaction_id, menu_id, qty, subtotal from transaction_detail "
+	TRANSACTION_DETAIL_INSERT                = "INSERT INTO transaction_detail(transaction_id, menu_id, menu_name, unit_price, modifiers, ingredients, qty, subtotal, discount_amount) VALUES (:transaction_id, :menu_id, :menu_name, :unit_price, :modifiers, :ingredients, :qty, :subtotal, :discount_amount)"
+	TRANSACTION_DETAIL_GET_ALL               = "SELECT id, transaction_id, menu_id, menu_name, unit_price, modifiers, ingredients, qty, subtotal, discount_amount from transaction_detail "
 	TRANSACTION_DETAIL_GET_BY_ID_TRANSACTION = TRANSACTION_DETAIL_GET_ALL + " where transaction_id = $1"
+	TRANSACTION_DETAIL_GET_BY_TRANSACTIONS   = TRANSACTION_DETAIL_GET_ALL + " where transaction_id = ANY($1) order by transaction_id, id"
+	TRANSACTION_DETAIL_DELETE                = "DELETE FROM transaction_detail WHERE id = $1 AND transaction_id = $2"
+
+	PROMO_GET_ALL     = "SELECT code, discount_type, discount_value, starts_at, ends_at, usage_limit, used, active, created_at FROM promo"
+	PROMO_GET_BY_CODE = PROMO_GET_ALL + " WHERE code = $1"
+	PROMO_LOCK        = PROMO_GET_BY_CODE + " FOR UPDATE"
+	PROMO_INSERT      = "INSERT INTO promo(code, discount_type, discount_value, starts_at, ends_at, usage_limit, active) VALUES (:code, :discount_type, :discount_value, :starts_at, :ends_at, :usage_limit, :active)"
+	PROMO_UPDATE      = "UPDATE promo SET discount_type = :discount_type, discount_value = :discount_value, starts_at = :starts_at, ends_at = :ends_at, usage_limit = :usage_limit, active = :active WHERE code = :code"
+	PROMO_USE         = "UPDATE promo SET used = used + 1 WHERE code = $1"
+	PROMO_RELEASE     = "UPDATE promo SET used = GREATEST(used - 1, 0) WHERE code = $1"
 
-	TRANSACTION_DETAIL_INSERT_TEST = "INSERT INTO transaction_detail(transaction_id, menu_id, qty, subtotal) VALUES ($1, $2, $3, $4)"
+	PAYMENT_INSERT             = "INSERT INTO payment(transaction_id, method, amount, tendered, change) VALUES (:transaction_id, :method, :amount, :tendered, :change)"
+	PAYMENT_GET_BY_TRANSACTION = "SELECT id, transaction_id, method, amount, tendered, change FROM payment WHERE transaction_id = $1 ORDER BY id"
+
+	TRANSACTION_REFUND_INSERT              = "INSERT INTO transaction_refund(id, transaction_id, kind, reason, user_id, amount, created_at) VALUES (:id, :transaction_id, :kind, :reason, :user_id, :amount, :created_at)"
+	TRANSACTION_REFUND_ITEM_INSERT         = "INSERT INTO transaction_refund_item(refund_id, detail_id, menu_id, qty, amount) VALUES (:refund_id, :detail_id, :menu_id, :qty, :amount)"
+	TRANSACTION_REFUND_GET_BY_TRANSACTION  = "SELECT id, transaction_id, kind, reason, user_id, amount, created_at FROM transaction_refund WHERE transaction_id = $1 ORDER BY created_at, id"
+	TRANSACTION_REFUND_ITEM_GET_BY_REFUNDS = "SELECT refund_id, detail_id, menu_id, qty, amount FROM transaction_refund_item WHERE refund_id = ANY($1) ORDER BY id"
+	TRANSACTION_REFUNDED_QTY               = "SELECT i.detail_id, SUM(i.qty) AS qty FROM transaction_refund_item i JOIN transaction_refund r ON r.id = i.refund_id WHERE r.transaction_id = $1 GROUP BY i.detail_id"
+
+	TRANSACTION_DETAIL_INSERT_TEST = "INSERT INTO transaction_detail(transaction_id, menu_id, menu_name, unit_price, modifiers, ingredients, qty, subtotal, discount_amount) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 	// ==============================================================
 
-// 	GET_DAILY_REPORT   = "SELECT date, COUNT(id) as transaction, SUM(total_price) as income from transaction group by date"
-// 	GET_MONTHLY_REPORT = "SELECT date, COUNT(id) as transaction, SUM(total_price) as income from transaction where date between $1 and $2"
+	MIGRATION_CREATE_TABLE = "CREATE TABLE IF NOT EXISTS schema_migration (version integer PRIMARY KEY, name text NOT NULL, applied_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL)"
+	MIGRATION_LOCK         = "SELECT pg_advisory_xact_lock($1)"
+	MIGRATION_GET_ALL      = "SELECT version, name, applied_at FROM schema_migration ORDER BY version"
+	MIGRATION_IS_APPLIED   = "SELECT EXISTS(SELECT 1 FROM schema_migration WHERE version=$1)"
+	MIGRATION_INSERT       = "INSERT INTO schema_migration(version, name) VALUES ($1, $2)"
+	MIGRATION_DELETE       = "DELETE FROM schema_migration WHERE version=$1"
+	// ==============================================================
+
+	// refunds count against the sale they give back, voids are left out
+	REPORT_REFUNDS       = " LEFT JOIN (SELECT transaction_id, SUM(amount) AS refunded FROM transaction_refund WHERE kind = 'refund' GROUP BY transaction_id) r ON r.transaction_id = t.id"
+	REPORT_SALES_COLUMNS = "COUNT(*) AS transactions, COALESCE(SUM(t.subtotal), 0) AS subtotal, COALESCE(SUM(t.discount_amount), 0) AS discount_amount, COALESCE(SUM(t.service_charge), 0) AS service_charge, COALESCE(SUM(t.tax), 0) AS tax, COALESCE(SUM(t.total_price), 0) AS gross_revenue, COALESCE(SUM(r.refunded), 0) AS refunded, COALESCE(SUM(t.total_price - COALESCE(r.refunded, 0)), 0) AS net_revenue, COALESCE(ROUND(AVG(t.total_price)), 0)::bigint AS average_ticket"
+	REPORT_SUMMARY       = "SELECT " + REPORT_SALES_COLUMNS + " FROM transaction t" + REPORT_REFUNDS + " WHERE t.created_at >= $1 AND t.created_at < $2 AND t.status IN ('paid', 'partially_refunded', 'refunded')"
+	REPORT_BY_PERIOD     = "SELECT to_char(t.created_at AT TIME ZONE $3, $4) AS period, " + REPORT_SALES_COLUMNS + " FROM transaction t" + REPORT_REFUNDS + " WHERE t.created_at >= $1 AND t.created_at < $2 AND t.status IN ('paid', 'partially_refunded', 'refunded') GROUP BY 1 ORDER BY 1"
+	// a refunded unit gives back its share of the line revenue
+	REPORT_MENU_SALES            = "SELECT d.menu_id, COALESCE(m.name, MAX(d.menu_name)) AS name, SUM(d.qty) AS qty, COALESCE(SUM(ri.qty), 0) AS refunded_qty, SUM(d.subtotal - d.discount_amount) AS revenue, SUM(d.subtotal - d.discount_amount - ROUND((d.subtotal - d.discount_amount)::numeric * COALESCE(ri.qty, 0) / d.qty))::bigint AS net_revenue, COUNT(DISTINCT d.transaction_id) AS transactions FROM transaction_detail d JOIN transaction t ON t.id = d.transaction_id LEFT JOIN menu m ON m.id = d.menu_id LEFT JOIN (SELECT i.detail_id, SUM(i.qty) AS qty FROM transaction_refund_item i JOIN transaction_refund rf ON rf.id = i.refund_id WHERE rf.kind = 'refund' GROUP BY i.detail_id) ri ON ri.detail_id = d.id WHERE t.created_at >= $1 AND t.created_at < $2 AND t.status IN ('paid', 'partially_refunded', 'refunded') GROUP BY d.menu_id, m.name"
+	REPORT_MENU_SALES_BY_QTY     = REPORT_MENU_SALES + " ORDER BY qty DESC, revenue DESC, d.menu_id LIMIT $3"
+	REPORT_MENU_SALES_BY_REVENUE = REPORT_MENU_SALES + " ORDER BY revenue DESC, qty DESC, d.menu_id LIMIT $3"
+	// sales without payment rows are counted as unrecorded, a refund is shared
+	// by the payments of its sale
+	REPORT_PAYMENT_REFUNDED = "COALESCE(SUM(ROUND(COALESCE(p.amount, t.total_price)::numeric * COALESCE(r.refunded, 0) / NULLIF(t.total_price, 0))), 0)::bigint"
+	REPORT_PAYMENT_SALES    = "SELECT COALESCE(p.method, 'unrecorded') AS method, COUNT(DISTINCT t.id) AS transactions, SUM(COALESCE(p.amount, t.total_price)) AS revenue, " + REPORT_PAYMENT_REFUNDED + " AS refunded, SUM(COALESCE(p.amount, t.total_price)) - " + REPORT_PAYMENT_REFUNDED + " AS net_revenue FROM transaction t LEFT JOIN payment p ON p.transaction_id = t.id" + REPORT_REFUNDS + " WHERE t.created_at >= $1 AND t.created_at < $2 AND t.status IN ('paid', 'partially_refunded', 'refunded') GROUP BY 1 ORDER BY revenue DESC, method"
+
+	REPORT_SUPPLIER_SPENDING = "SELECT s.id AS supplier_id, s.name, COUNT(DISTINCT po.id) AS purchase_orders, SUM(m.qty::bigint * m.unit_cost) AS spent FROM stock_movement m JOIN purchase_order po ON po.id = m.purchase_order_id JOIN supplier s ON s.id = po.supplier_id WHERE m.reason = 'purchase' AND m.created_at >= $1 AND m.created_at < $2 GROUP BY s.id, s.name ORDER BY spent DESC, s.name"
 
-// GET_OVERALL_REPORT = "SELECT date, COUNT(id) as transaction, SUM(total_price) as income from transaction"
+	REPORT_SHRINKAGE_COLUMNS   = "COUNT(DISTINCT st.id) AS stock_takes, COALESCE(SUM(-l.variance_value) FILTER (WHERE l.variance < 0), 0) AS lost, COALESCE(SUM(l.variance_value) FILTER (WHERE l.variance > 0), 0) AS found, COUNT(*) FILTER (WHERE l.variance <> 0 AND l.unit_cost IS NULL) AS unvalued"
+	REPORT_SHRINKAGE_BY_PERIOD = "SELECT to_char(st.approved_at AT TIME ZONE $3, $4) AS period, " + REPORT_SHRINKAGE_COLUMNS + " FROM stock_take st JOIN stock_take_line l ON l.stock_take_id = st.id WHERE st.status = 'approved' AND st.approved_at >= $1 AND st.approved_at < $2 GROUP BY 1 ORDER BY 1"
+	REPORT_SHRINKAGE_BY_ITEM   = "SELECT CASE WHEN l.menu_id IS NULL THEN 'ingredient' ELSE 'menu' END AS kind, COALESCE(l.menu_id, l.ingredient_id) AS id, COALESCE(MAX(m.name), MAX(i.name)) AS name, COALESCE(MAX(i.unit), '') AS unit, SUM(l.variance) AS variance, " + REPORT_SHRINKAGE_COLUMNS + " FROM stock_take st JOIN stock_take_line l ON l.stock_take_id = st.id LEFT JOIN menu m ON m.id = l.menu_id LEFT JOIN ingredient i ON i.id = l.ingredient_id WHERE st.status = 'approved' AND st.approved_at >= $1 AND st.approved_at < $2 GROUP BY 1, 2 ORDER BY lost DESC, variance, name, id"
 )